@@ -3,11 +3,13 @@ package main
 import (
 	"GoFrioCalor/config"
 	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/models"
 	"GoFrioCalor/internal/routes"
 	"GoFrioCalor/internal/service"
 	"GoFrioCalor/internal/store"
 	"GoFrioCalor/internal/transport"
 	"context"
+	"time"
 
 	"github.com/rs/zerolog/log"
 )
@@ -21,6 +23,63 @@ func main() {
 
 	config.InitLogger(cfg.Environment)
 
+	tokenPolicy := service.TokenPolicy{
+		Length:      cfg.DeliveryTokenLength,
+		Alphabet:    cfg.DeliveryTokenAlphabet,
+		MaxAttempts: cfg.DeliveryTokenMaxAttempts,
+		GraceDays:   cfg.DeliveryTokenGraceDays,
+	}
+	if err := tokenPolicy.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("Configuración de tokens de entrega inválida")
+	}
+
+	if cfg.GeofenceMode != "flag" && cfg.GeofenceMode != "reject" {
+		log.Fatal().Str("mode", cfg.GeofenceMode).Msg("GEOFENCE_MODE inválido: use flag o reject")
+	}
+	geofencePolicy := service.GeofencePolicy{
+		RadiusMeters: float64(cfg.GeofenceRadiusMeters),
+		Reject:       cfg.GeofenceMode == "reject",
+	}
+	if err := geofencePolicy.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("Configuración de geocerca inválida")
+	}
+
+	serialPolicy := service.DispenserSerialPolicy{
+		Formats: map[models.TipoDispenser]string{
+			models.TipoDispenserPie:    cfg.DispenserSerialFormatP,
+			models.TipoDispenserMesada: cfg.DispenserSerialFormatM,
+		},
+	}
+	if err := serialPolicy.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("Formato de número de serie de dispensers inválido")
+	}
+
+	partialFollowUpPolicy := service.PartialFollowUpPolicy{
+		Days:         cfg.PartialFollowUpDays,
+		SkipWeekends: cfg.PartialFollowUpSkipWeekends,
+	}
+	if err := partialFollowUpPolicy.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("Configuración de seguimiento de entregas parciales inválida")
+	}
+
+	maintenancePolicy := service.MaintenancePolicy{
+		LeadDays:           cfg.MaintenanceLeadDays,
+		SearchDays:         cfg.MaintenanceSearchDays,
+		RouteDailyCapacity: cfg.MaintenanceRouteCapacity,
+		SkipWeekends:       true,
+	}
+	if err := maintenancePolicy.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("Configuración de mantenimiento preventivo inválida")
+	}
+
+	stockPolicy := service.StockPolicy{
+		EnforceOnBooking: cfg.StockEnforceOnBooking,
+		SuggestDays:      cfg.StockSuggestDays,
+	}
+	if err := stockPolicy.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("Configuración de stock inválida")
+	}
+
 	db, err := config.NewDatabase(cfg.GetDSN())
 	if err != nil {
 		log.Fatal().Err(err).Msg(constants.MsgErrorConnectingDB)
@@ -38,6 +97,17 @@ func main() {
 	deliveryStore := store.NewDeliveryStore(db)
 	workOrderStore := store.NewWorkOrderStore(db)
 	termsSessionStore := store.NewTermsSessionStore(db)
+	failureReasonStore := store.NewFailureReasonStore(db)
+	mobileSyncStore := store.NewMobileSyncStore(db)
+	idempotencyStore := store.NewIdempotencyStore(db)
+	deliveryAttachmentStore := store.NewDeliveryAttachmentStore(db)
+	staffStore := store.NewStaffStore(db)
+	dispenserStore := store.NewDispenserStore(db)
+	maintenanceStore := store.NewMaintenanceStore(db)
+	preparationStore := store.NewPreparationStore(db)
+	stockStore := store.NewStockStore(db)
+	truckStore := store.NewTruckStore(db)
+	routeCapacityStore := store.NewRouteCapacityStore(db)
 	auditEventStore := store.NewAuditEventStore(sqlxDB)
 
 	// RabbitMQ Configuration
@@ -84,21 +154,57 @@ func main() {
 	}
 
 	// Services
-	deliveryService := service.NewDeliveryServiceWithEmail(deliveryStore, emailService)
+	deliveryService := service.NewDeliveryServiceWithServices(deliveryStore, emailService, dispenserStore, stockStore, routeCapacityStore, tokenPolicy, stockPolicy)
 	deliveryHandler := transport.NewDeliveryHandler(deliveryService, auditService)
 
-	pdfService := service.NewPDFService(workOrderStore)
+	// Prueba de entrega: firma y fotos en blob storage local
+	blobStorage, err := service.NewLocalBlobStorage(cfg.BlobStorageDir)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize blob storage")
+	}
+	deliveryProofService := service.NewDeliveryProofService(deliveryStore, deliveryAttachmentStore, blobStorage, cfg.MaxDeliveryPhotos)
+	deliveryProofHandler := transport.NewDeliveryProofHandler(deliveryProofService, auditService)
+
+	pdfService := service.NewPDFServiceWithAttachments(workOrderStore, deliveryAttachmentStore, blobStorage)
 	workOrderHandler := transport.NewWorkOrderHandler(pdfService)
 
 	// Flujo integrado: Entregas con Términos y Condiciones
-	deliveryWithTermsService := service.NewDeliveryWithTermsService(deliveryStore, termsSessionStore, termsSessionService)
+	deliveryWithTermsService := service.NewDeliveryWithTermsService(deliveryStore, termsSessionStore, termsSessionService, tokenPolicy)
 	deliveryWithTermsHandler := transport.NewDeliveryWithTermsHandler(deliveryWithTermsService, cfg.AppBaseURL, cfg.TermsTTLHours)
 
+	// Personal: repartidores y técnicos identificados en el dispositivo
+	staffService := service.NewStaffService(staffStore, time.Duration(cfg.StaffSessionTTLHours)*time.Hour, cfg.StaffPinMaxAttempts)
+	staffHandler := transport.NewStaffHandler(staffService)
+
+	// Registro de dispensers por número de serie
+	dispenserService := service.NewDispenserService(dispenserStore, workOrderStore)
+	dispenserHandler := transport.NewDispenserHandler(dispenserService)
+
+	// Mantenimiento preventivo: planes por cuenta o dispenser y generación de services
+	maintenanceService := service.NewMaintenanceService(maintenanceStore, deliveryStore, dispenserStore, routeCapacityStore, emailService, tokenPolicy, maintenancePolicy)
+	maintenanceHandler := transport.NewMaintenanceHandler(maintenanceService)
+
+	// Preparación en taller: números de serie apartados y carga por reparto
+	tallerService := service.NewTallerService(preparationStore, deliveryStore, dispenserStore, serialPolicy)
+	tallerHandler := transport.NewTallerHandler(tallerService)
+
+	// Stock por depósito: ingresos, ajustes y libro de movimientos (las reservas las lleva el store de entregas)
+	stockService := service.NewStockService(stockStore)
+	stockHandler := transport.NewStockHandler(stockService)
+
+	// Camiones: asignación de entregas por fecha con control de capacidad y hoja de carga
+	truckService := service.NewTruckService(truckStore)
+	truckHandler := transport.NewTruckHandler(truckService)
+
+	// Capacidad diaria por reparto: reglas por día de la semana y tipo de entrega
+	routeCapacityService := service.NewRouteCapacityService(routeCapacityStore)
+	routeCapacityHandler := transport.NewRouteCapacityHandler(routeCapacityService)
+
 	// Mobile Delivery - Validación y Completar Entregas
 	var mobileDeliveryHandler *transport.MobileDeliveryHandler
 	if rabbitPublisher != nil {
 		clientLookupService := service.NewClientLookupService(cfg.ClientLookupBaseURL, cfg.ClientLookupAPIKey, cfg.ClientLookupDefaultEmail)
-		mobileDeliveryService := service.NewMobileDeliveryServiceWithServices(deliveryStore, termsSessionStore, rabbitPublisher, pdfService, emailService, clientLookupService)
+		mobileDeliveryService := service.NewMobileDeliveryServiceWithServices(deliveryStore, termsSessionStore, failureReasonStore, mobileSyncStore, dispenserStore, preparationStore, rabbitPublisher, pdfService, emailService, clientLookupService, tokenPolicy, geofencePolicy, serialPolicy, partialFollowUpPolicy)
 		mobileDeliveryHandler = transport.NewMobileDeliveryHandler(mobileDeliveryService, auditService)
 		log.Info().Msg("Mobile Delivery Service initialized with PDF and Email services")
 	}
@@ -128,10 +234,11 @@ func main() {
 		}
 	}
 
-	router := routes.SetupRouter(deliveryHandler, workOrderHandler, termsSessionHandler, deliveryWithTermsHandler, mobileDeliveryHandler, auditHandler, cfg)
+	router := routes.SetupRouter(deliveryHandler, workOrderHandler, termsSessionHandler, deliveryWithTermsHandler, mobileDeliveryHandler, auditHandler, deliveryProofHandler, staffHandler, dispenserHandler, maintenanceHandler, tallerHandler, stockHandler, truckHandler, routeCapacityHandler, staffService, idempotencyStore, cfg)
 
-	// Scheduler: cancelar deliveries pendientes cuya fecha_accion ya pasó (se ejecuta a medianoche)
-	scheduler := service.NewScheduler(deliveryStore)
+	// Scheduler: cancelar deliveries pendientes cuya fecha_accion ya pasó, purgar Idempotency-Keys
+	// vencidas y generar los services preventivos (se ejecuta a medianoche)
+	scheduler := service.NewSchedulerWithMaintenance(deliveryStore, idempotencyStore, maintenanceService)
 	scheduler.Start()
 	defer scheduler.Stop()
 
@@ -28,7 +28,7 @@ func NewDatabase(dsn string) (*gorm.DB, error) {
 	sqlDB.SetMaxOpenConns(constants.MAX_OPEN_CONNS)
 	sqlDB.SetConnMaxLifetime(time.Hour)
 
-	if err := db.AutoMigrate(&models.Delivery{}, &models.ItemDispenser{}, &models.WorkOrder{}, &models.TermsSession{}); err != nil {
+	if err := db.AutoMigrate(&models.Delivery{}, &models.ItemDispenser{}, &models.DeliveryShortfall{}, &models.WorkOrder{}, &models.TermsSession{}, &models.DeliveryStatusHistory{}, &models.DeliveryReschedule{}, &models.FailureReason{}, &models.MobileSyncAction{}, &models.IdempotencyKey{}, &models.DeliveryAttachment{}, &models.Staff{}, &models.StaffSession{}, &models.Dispenser{}, &models.DispenserMovement{}, &models.MaintenancePlan{}, &models.DeliveryPreparation{}, &models.Warehouse{}, &models.StockMovement{}, &models.StockReservation{}, &models.Truck{}, &models.RouteCapacity{}); err != nil {
 		// SQLSTATE 42701: column already exists — ocurre cuando la migración SQL
 		// ya renombró/agregó la columna antes de que AutoMigrate la detecte.
 		// Es seguro ignorar este error; la segunda ejecución siempre funciona.
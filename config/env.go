@@ -1,6 +1,7 @@
 package config
 
 import (
+	"GoFrioCalor/internal/constants"
 	"fmt"
 	"os"
 
@@ -8,27 +9,47 @@ import (
 )
 
 type Config struct {
-	DBHost                   string
-	DBPort                   string
-	DBUser                   string
-	DBPassword               string
-	DBName                   string
-	Port                     string
-	CORSOrigins              string
-	Environment              string
-	InfobipBaseURL           string
-	InfobipAPIKey            string
-	AppBaseURL               string
-	TermsTTLHours            int
-	AuthServiceURL           string
-	EmailHost                string
-	EmailPort                string
-	EmailFrom                string
-	EmailPassword            string
-	EmailTo                  string
-	ClientLookupBaseURL      string
-	ClientLookupAPIKey       string
-	ClientLookupDefaultEmail string
+	DBHost                      string
+	DBPort                      string
+	DBUser                      string
+	DBPassword                  string
+	DBName                      string
+	Port                        string
+	CORSOrigins                 string
+	Environment                 string
+	InfobipBaseURL              string
+	InfobipAPIKey               string
+	AppBaseURL                  string
+	TermsTTLHours               int
+	AuthServiceURL              string
+	EmailHost                   string
+	EmailPort                   string
+	EmailFrom                   string
+	EmailPassword               string
+	EmailTo                     string
+	ClientLookupBaseURL         string
+	ClientLookupAPIKey          string
+	ClientLookupDefaultEmail    string
+	DeliveryTokenLength         int
+	DeliveryTokenAlphabet       string
+	DeliveryTokenMaxAttempts    int
+	DeliveryTokenGraceDays      int
+	IdempotencyTTLHours         int
+	BlobStorageDir              string
+	MaxDeliveryPhotos           int
+	GeofenceRadiusMeters        int
+	GeofenceMode                string
+	StaffSessionTTLHours        int
+	StaffPinMaxAttempts         int
+	DispenserSerialFormatP      string
+	DispenserSerialFormatM      string
+	PartialFollowUpDays         int
+	PartialFollowUpSkipWeekends bool
+	MaintenanceLeadDays         int
+	MaintenanceSearchDays       int
+	MaintenanceRouteCapacity    int
+	StockEnforceOnBooking       bool
+	StockSuggestDays            int
 }
 
 func LoadConfig() (*Config, error) {
@@ -36,27 +57,47 @@ func LoadConfig() (*Config, error) {
 	_ = godotenv.Load()
 
 	config := &Config{
-		DBHost:                   os.Getenv("DB_HOST"),
-		DBPort:                   os.Getenv("DB_PORT"),
-		DBUser:                   os.Getenv("DB_USER"),
-		DBPassword:               os.Getenv("DB_PASSWORD"),
-		DBName:                   os.Getenv("DB_NAME"),
-		Port:                     os.Getenv("PORT"),
-		CORSOrigins:              os.Getenv("CORS_ORIGINS"),
-		Environment:              getEnvOrDefault("ENVIRONMENT", "development"),
-		InfobipBaseURL:           getEnvOrDefault("INFOBIP_BASE_URL", "https://api2.infobip.com"),
-		InfobipAPIKey:            os.Getenv("INFOBIP_API_KEY"),
-		AppBaseURL:               getEnvOrDefault("APP_BASE_URL", "http://localhost:5173"),
-		TermsTTLHours:            getEnvAsInt("TERMS_TTL_HOURS", 48),
-		AuthServiceURL:           getEnvOrDefault("AUTH_SERVICE_URL", "http://192.168.0.55:8087"),
-		EmailHost:                getEnvOrDefault("EMAIL_HOST", "smtp.gmail.com"),
-		EmailPort:                getEnvOrDefault("EMAIL_PORT", "587"),
-		EmailFrom:                os.Getenv("EMAIL_FROM"),
-		EmailPassword:            os.Getenv("EMAIL_PASSWORD"),
-		EmailTo:                  os.Getenv("EMAIL_TO"),
-		ClientLookupBaseURL:      getEnvOrDefault("CLIENT_LOOKUP_BASE_URL", "https://servicios.el-jumillano.com.ar:8443"),
-		ClientLookupAPIKey:       os.Getenv("CLIENT_LOOKUP_API_KEY"),
-		ClientLookupDefaultEmail: getEnvOrDefault("CLIENT_LOOKUP_DEFAULT_EMAIL", "gwinazki@el-jumillano.com.ar"),
+		DBHost:                      os.Getenv("DB_HOST"),
+		DBPort:                      os.Getenv("DB_PORT"),
+		DBUser:                      os.Getenv("DB_USER"),
+		DBPassword:                  os.Getenv("DB_PASSWORD"),
+		DBName:                      os.Getenv("DB_NAME"),
+		Port:                        os.Getenv("PORT"),
+		CORSOrigins:                 os.Getenv("CORS_ORIGINS"),
+		Environment:                 getEnvOrDefault("ENVIRONMENT", "development"),
+		InfobipBaseURL:              getEnvOrDefault("INFOBIP_BASE_URL", "https://api2.infobip.com"),
+		InfobipAPIKey:               os.Getenv("INFOBIP_API_KEY"),
+		AppBaseURL:                  getEnvOrDefault("APP_BASE_URL", "http://localhost:5173"),
+		TermsTTLHours:               getEnvAsInt("TERMS_TTL_HOURS", 48),
+		AuthServiceURL:              getEnvOrDefault("AUTH_SERVICE_URL", "http://192.168.0.55:8087"),
+		EmailHost:                   getEnvOrDefault("EMAIL_HOST", "smtp.gmail.com"),
+		EmailPort:                   getEnvOrDefault("EMAIL_PORT", "587"),
+		EmailFrom:                   os.Getenv("EMAIL_FROM"),
+		EmailPassword:               os.Getenv("EMAIL_PASSWORD"),
+		EmailTo:                     os.Getenv("EMAIL_TO"),
+		ClientLookupBaseURL:         getEnvOrDefault("CLIENT_LOOKUP_BASE_URL", "https://servicios.el-jumillano.com.ar:8443"),
+		ClientLookupAPIKey:          os.Getenv("CLIENT_LOOKUP_API_KEY"),
+		ClientLookupDefaultEmail:    getEnvOrDefault("CLIENT_LOOKUP_DEFAULT_EMAIL", "gwinazki@el-jumillano.com.ar"),
+		DeliveryTokenLength:         getEnvAsInt("DELIVERY_TOKEN_LENGTH", constants.TOKEN_DEFAULT_LENGTH),
+		DeliveryTokenAlphabet:       getEnvOrDefault("DELIVERY_TOKEN_ALPHABET", constants.TOKEN_DEFAULT_ALPHABET),
+		DeliveryTokenMaxAttempts:    getEnvAsInt("DELIVERY_TOKEN_MAX_ATTEMPTS", constants.TOKEN_DEFAULT_MAX_ATTEMPTS),
+		DeliveryTokenGraceDays:      getEnvAsInt("DELIVERY_TOKEN_GRACE_DAYS", 0),
+		IdempotencyTTLHours:         getEnvAsInt("IDEMPOTENCY_TTL_HOURS", 24),
+		BlobStorageDir:              getEnvOrDefault("BLOB_STORAGE_DIR", "storage"),
+		MaxDeliveryPhotos:           getEnvAsInt("MAX_DELIVERY_PHOTOS", constants.DEFAULT_MAX_DELIVERY_PHOTOS),
+		GeofenceRadiusMeters:        getEnvAsInt("GEOFENCE_RADIUS_METERS", constants.GEOFENCE_DEFAULT_RADIUS_METERS),
+		GeofenceMode:                getEnvOrDefault("GEOFENCE_MODE", "flag"),
+		StaffSessionTTLHours:        getEnvAsInt("STAFF_SESSION_TTL_HOURS", constants.STAFF_SESSION_DEFAULT_TTL_HOURS),
+		StaffPinMaxAttempts:         getEnvAsInt("STAFF_PIN_MAX_ATTEMPTS", constants.STAFF_PIN_DEFAULT_MAX_ATTEMPTS),
+		DispenserSerialFormatP:      getEnvOrDefault("DISPENSER_SERIAL_FORMAT_P", constants.DISPENSER_SERIAL_DEFAULT_FORMAT),
+		DispenserSerialFormatM:      getEnvOrDefault("DISPENSER_SERIAL_FORMAT_M", constants.DISPENSER_SERIAL_DEFAULT_FORMAT),
+		PartialFollowUpDays:         getEnvAsInt("PARTIAL_FOLLOW_UP_DAYS", constants.PARTIAL_FOLLOW_UP_DEFAULT_DAYS),
+		PartialFollowUpSkipWeekends: getEnvOrDefault("PARTIAL_FOLLOW_UP_SKIP_WEEKENDS", "true") == "true",
+		MaintenanceLeadDays:         getEnvAsInt("MAINTENANCE_LEAD_DAYS", constants.MAINTENANCE_DEFAULT_LEAD_DAYS),
+		MaintenanceSearchDays:       getEnvAsInt("MAINTENANCE_SEARCH_DAYS", constants.MAINTENANCE_DEFAULT_SEARCH_DAYS),
+		MaintenanceRouteCapacity:    getEnvAsInt("MAINTENANCE_ROUTE_DAILY_CAPACITY", 0),
+		StockEnforceOnBooking:       getEnvOrDefault("STOCK_ENFORCE_ON_BOOKING", "true") == "true",
+		StockSuggestDays:            getEnvAsInt("STOCK_SUGGEST_DAYS", constants.STOCK_DEFAULT_SUGGEST_DAYS),
 	}
 
 	return config, nil
@@ -5,6 +5,9 @@ const (
 	MsgDeliveryCreated          = "Entrega creada exitosamente"
 	MsgDeliveryUpdated          = "Entrega actualizada exitosamente"
 	MsgDeliveryDeleted          = "Entrega eliminada exitosamente"
+	MsgDeliveryRestored         = "Entrega restaurada exitosamente"
+	MsgIfMatchRequired          = "Se requiere el header If-Match con la versión (ETag) de la entrega"
+	MsgInvalidIfMatch           = "Header If-Match inválido"
 	MsgDeliveryCancelled        = "Entrega cancelada exitosamente"
 	MsgDeliveryAlreadyCancelled = "La entrega ya se encuentra cancelada"
 	MsgDispenserCreated         = "Dispenser creado exitosamente"
@@ -51,6 +54,8 @@ const (
 	PDFLabelToken          = "Token de Verificacion:"
 	PDFFooterImportant     = "IMPORTANTE: No realizar la devolucion del equipo sin su correspondiente comprobante, el cual es entregado en el momento por nuestro representante."
 	PDFAcceptanceNote      = "El cliente fue informado sobre los terminos y condiciones del servicio y acepto digitalmente mediante el token de verificacion."
+	PDFSectionSignature    = "FIRMA DEL CLIENTE"
+	PDFSectionPhotos       = "REGISTRO FOTOGRAFICO"
 
 	// Descripciones de tareas
 	TaskInstallation = "Se realizo la instalacion del Dispenser Frio Calor"
@@ -75,6 +80,14 @@ const (
 	ErrCreateDelivery           = "error al crear entrega: %w"
 	ErrUpdateDelivery           = "error al actualizar entrega: %w"
 	ErrDeleteDelivery           = "error al eliminar entrega con id %d: %w"
+	ErrRestoreDelivery          = "error al restaurar entrega con id %d: %w"
+	ErrDeliveryNotDeleted       = "la entrega no se encuentra eliminada"
+	ErrDeliveryVersionConflict  = "la entrega fue modificada por otro proceso"
+	ErrVersionConflictDetail    = "la entrega fue modificada por otro proceso (versión esperada: %d, versión actual: %d)"
+	ErrImportUnsupportedFormat  = "formato de archivo no soportado, use .csv o .xlsx"
+	ErrImportEmptyFile          = "el archivo no contiene filas para importar"
+	ErrImportMissingColumns     = "faltan columnas obligatorias en el archivo: %s"
+	ErrImportTooManyRows        = "el archivo supera el máximo de %d filas"
 	ErrFindAllDispensers        = "error al buscar todos los dispensers: %w"
 	ErrFindDispenserByID        = "error al buscar dispenser con id %d: %w"
 	ErrFindDispensersByDelivery = "error al buscar dispensers de la entrega %d: %w"
@@ -84,6 +97,113 @@ const (
 	ErrCreateWorkOrder          = "error al crear orden de trabajo: %w"
 	ErrCountWorkOrders          = "error al contar órdenes de trabajo: %w"
 
+	// Delivery lifecycle
+	ErrDeliveryNotFound          = "entrega no encontrada"
+	ErrInvalidStatusTransition   = "transición de estado inválida: %s → %s"
+	ErrInvalidStatusTransitionID = "transición de estado inválida"
+	ErrStatusRequiresMobileClose = "transición de estado inválida: %s se registra desde la app móvil al completar la entrega o marcar la visita fallida"
+	ErrDeliveryStatusHistory     = "error al registrar historial de estado de la entrega %d: %w"
+	MsgDeliveryStatusChanged     = "Estado de la entrega actualizado exitosamente"
+	MsgInvalidEstado             = "Estado inválido. Valores aceptados: Pendiente, Programado, EnCamino, Reprogramado, Completado, Fallido, Cancelado"
+	ErrRescheduleDateInPast      = "la nueva fecha_accion no puede ser anterior a hoy"
+	ErrRescheduleSameDate        = "la nueva fecha_accion coincide con la fecha actual de la entrega"
+	ErrUpdateBookingFields       = "campos de la reserva no editables con PUT: %s (la fecha se cambia con PATCH /deliveries/:id/reschedule)"
+	ErrUpdateBookingFieldsID     = "campos de la reserva no editables con PUT"
+	MsgDeliveryRescheduled       = "Entrega reprogramada exitosamente"
+	ErrTokenLocked               = "el token está bloqueado por exceso de intentos fallidos, solicite uno nuevo al contact center"
+	ErrTokenGeneration           = "no se pudo generar un token único para la ruta y fecha de la entrega"
+	ErrTokenRegenerateNotOpen    = "no se puede regenerar el token de una entrega en estado %s"
+	MsgTokenRegenerated          = "Token regenerado y notificado al cliente"
+	ErrClientTimestampFormat     = "%s inválido, use formato ISO 8601 (RFC3339)"
+	ErrClientTimestampFuture     = "%s inválido: no puede ser posterior a la hora del servidor"
+	ErrSyncUnknownAction         = "tipo de acción de sincronización desconocido: %s"
+	ErrSyncTooManyActions        = "el lote supera el máximo de %d acciones"
+	ErrSyncActionInProgress      = "la acción se está aplicando en otra sincronización, reintente más tarde"
+	ErrPatchInvalidBody          = "el merge patch debe ser un objeto JSON"
+	ErrPatchEmpty                = "el merge patch no contiene cambios"
+	ErrPatchUnknownField         = "campo desconocido en el merge patch: %s"
+	ErrPatchFieldNotEditable     = "campo no editable en estado %s: %s (campos editables: %s)"
+	ErrPatchInvalidValue         = "valor inválido para %s: %s"
+
+	ErrGeofenceOutside          = "la ubicación del dispositivo está a %.0f m de la dirección del cliente (máximo %.0f m)"
+	ErrGeofenceMissingLocation  = "latitude y longitude son requeridos: la dirección del cliente tiene coordenadas"
+	ErrDeviceLocationIncomplete = "latitude y longitude se deben informar juntas"
+
+	// Prueba de entrega (firma y fotos)
+	ErrAttachmentNotFound   = "adjunto no encontrado"
+	ErrProofEmpty           = "debe adjuntar la firma (campo 'signature') o al menos una foto (campo 'photos')"
+	ErrProofSignatureFormat = "la firma debe ser una imagen PNG"
+	ErrProofPhotoFormat     = "formato de foto inválido (%s): se admiten JPEG y PNG"
+	ErrProofFileTooLarge    = "el archivo %s supera el máximo de %d MB"
+	ErrProofTooManyPhotos   = "la entrega admite como máximo %d fotos (ya tiene %d)"
+	ErrProofNotAllowed      = "no se puede adjuntar prueba de entrega en estado %s"
+	ErrProofStorage         = "error guardando archivo de prueba de entrega: %w"
+	MsgProofUploaded        = "Prueba de entrega registrada"
+
+	// Validación de números de serie al completar entregas
+	ErrDispenserSerialsInvalid      = "números de serie de dispensers inválidos en %d operación(es): corrija los códigos o envíe override_serial_validation con override_reason"
+	ErrSerialOverrideReasonRequired = "override_reason es requerido cuando override_serial_validation es true"
+	ErrSerialRequired               = "falta el número de serie (%s) para la operación %s"
+	ErrSerialFormat                 = "el número de serie %s no tiene un formato válido"
+	ErrSerialInstalledElsewhere     = "el dispenser %s figura instalado en la cuenta %s"
+	ErrSerialNotInstalledHere       = "el dispenser %s no figura instalado en la cuenta %s"
+	ErrOperationTipoRequired        = "tipo (P o M) es requerido en las operaciones: la entrega incluye dispensers de ambos tipos y se realizaron menos operaciones que las pedidas"
+
+	// Personal (repartidores y técnicos)
+	ErrStaffNotFound       = "personal no encontrado"
+	ErrStaffLegajoInUse    = "ya existe personal con el legajo %s"
+	ErrStaffLoginFailed    = "legajo o PIN incorrectos"
+	ErrStaffSessionInvalid = "sesión de personal inválida o expirada"
+	ErrStaffPinLocked      = "el PIN está bloqueado por exceso de intentos fallidos, solicite a un supervisor que cargue uno nuevo"
+	MsgStaffLoggedOut      = "Sesión de personal cerrada"
+
+	// Registro de dispensers
+	ErrDispenserNotFound         = "dispenser no encontrado"
+	ErrAccountWithoutEquipment   = "no hay dispensers instalados en la cuenta %s: no se puede pedir %s"
+	ErrAccountWithoutEquipmentID = "no hay dispensers instalados en la cuenta"
+
+	// Mantenimiento preventivo
+	ErrMaintenancePlanNotFound = "plan de mantenimiento no encontrado"
+	ErrMaintenancePlanExists   = "ya existe un plan de mantenimiento para %s"
+	MsgMaintenancePlanDeleted  = "Plan de mantenimiento eliminado"
+
+	// Preparación en taller
+	ErrPreparationNotAllowed       = "no se puede preparar una entrega en estado %s"
+	ErrPreparationSerialInstalled  = "el dispenser %s está instalado en la cuenta %s: no se puede preparar"
+	ErrPreparationSerialInUse      = "el dispenser %s ya está preparado para la entrega %d"
+	ErrPreparationTooManySerials   = "se asignaron %d números de serie pero la entrega tiene %d dispensers"
+	ErrPreparationDuplicatedSerial = "el número de serie %s está repetido"
+	ErrSerialNotPrepared           = "el dispenser %s no es uno de los preparados por el taller (%s)"
+
+	// Stock por depósito
+	ErrWarehouseNotFound     = "depósito no encontrado"
+	ErrWarehouseCodeExists   = "ya existe un depósito con el código %s"
+	ErrWarehouseRouteTaken   = "el reparto %s ya es abastecido por el depósito %s"
+	ErrStockSync             = "error actualizando las reservas de stock de la entrega %d: %w"
+	ErrStockInsufficient     = "stock insuficiente en el depósito %s para el %s (%s)"
+	ErrStockInsufficientID   = "stock insuficiente en el depósito"
+	ErrStockSuggestedFecha   = "; primera fecha con stock: %s"
+	ErrStockAdjustBelowZero  = "el ajuste deja negativa la existencia de %s en el depósito %s (existencia actual: %d)"
+	ErrStockAdjustFutureDate = "solo los ingresos pueden tener fecha futura"
+	ErrStockReceiptQuantity  = "un ingreso debe tener cantidad positiva"
+
+	// Camiones y carga diaria
+	ErrTruckNotFound         = "camión no encontrado"
+	ErrTruckNroExists        = "ya existe un camión con el número %s"
+	ErrTruckHasDeliveries    = "el camión %s tiene %d entregas abiertas asignadas"
+	ErrTruckCapacityExceeded = "la carga del camión %s para el %s sería de %d dispensers y su capacidad es %d"
+	ErrTruckAssignState      = "no se puede asignar la entrega %d en estado %s"
+	ErrTruckAssignDate       = "no se puede asignar la entrega %d: es para el %s, no para el %s"
+	ErrTruckAssignNotFound   = "entrega no encontrada: %d"
+
+	// Capacidad diaria por reparto
+	ErrRouteCapacityNotFound  = "capacidad de reparto no encontrada"
+	ErrRouteCapacityExists    = "ya existe una capacidad para el reparto %s el %s%s"
+	ErrRouteCapacityFull      = "sin lugar en el reparto %s para el %s: %d de %d entregas%s ocupadas"
+	ErrRouteCapacityFullID    = "sin lugar en el reparto"
+	ErrRouteCapacitySuggested = "; primera fecha con lugar: %s"
+	ErrAvailabilityRange      = "el rango de fechas no puede superar %d días"
+
 	// Terms Session Messages
 	MsgTermsAlreadyAccepted = "Términos ya fueron aceptados previamente"
 	MsgTermsAcceptedSuccess = "Términos aceptados exitosamente"
@@ -1,15 +1,61 @@
 package constants
 
 const (
-	TOKEN_MIN      = 1000
-	TOKEN_MAX      = 9999
 	MAX_IDLE_CONNS = 10
 	MAX_OPEN_CONNS = 100
 
+	// Tokens de validación de entregas (los valores por defecto se pueden cambiar por configuración)
+	TOKEN_DEFAULT_LENGTH       = 6
+	TOKEN_DEFAULT_ALPHABET     = "0123456789"
+	TOKEN_DEFAULT_MAX_ATTEMPTS = 5
+	TOKEN_MIN_LENGTH           = 4
+	TOKEN_MAX_LENGTH           = 12
+	TOKEN_GENERATION_RETRIES   = 20
+
 	// Validaciones de dispensers
 	MIN_DISPENSERS = 1
 	MAX_DISPENSERS = 10
 
+	// Importación masiva de entregas
+	MAX_IMPORT_ROWS      = 5000
+	MAX_IMPORT_FILE_SIZE = 10 << 20 // 10 MB
+
+	// Sincronización offline de la app móvil
+	MAX_SYNC_ACTIONS             = 200
+	CLIENT_CLOCK_SKEW_MINUTES    = 5  // desfasaje admitido del reloj del dispositivo hacia el futuro
+	SYNC_PENDING_TIMEOUT_MINUTES = 10 // una acción pendiente más antigua se considera abandonada y se vuelve a aplicar
+
+	// Prueba de entrega: firma del cliente y fotos de la instalación
+	MAX_PROOF_FILE_SIZE         = 5 << 20  // 5 MB por archivo
+	MAX_PROOF_UPLOAD_SIZE       = 40 << 20 // 40 MB por solicitud
+	DEFAULT_MAX_DELIVERY_PHOTOS = 5
+
+	// Geocerca: distancia máxima entre la lectura GPS de cierre y la dirección del cliente
+	GEOFENCE_DEFAULT_RADIUS_METERS = 200
+
+	// Formato por defecto de los números de serie de dispensers (P y M), configurable por tipo
+	DISPENSER_SERIAL_DEFAULT_FORMAT = `^[A-Z0-9][A-Z0-9-]{5,29}$`
+
+	// Login de repartidores y técnicos en el dispositivo
+	STAFF_SESSION_DEFAULT_TTL_HOURS = 14 // un turno completo
+	STAFF_PIN_DEFAULT_MAX_ATTEMPTS  = 5  // PIN incorrectos seguidos antes del bloqueo
+
+	// Entrega parcial: días hasta la entrega de seguimiento por el faltante
+	PARTIAL_FOLLOW_UP_DEFAULT_DAYS = 1
+
+	// Mantenimiento preventivo: anticipación con la que se crea el Service y días hábiles en los que
+	// se busca un reparto con lugar a partir del vencimiento
+	MAINTENANCE_DEFAULT_LEAD_DAYS   = 7
+	MAINTENANCE_DEFAULT_SEARCH_DAYS = 10
+
+	// Días hacia adelante en los que se busca una fecha con stock para sugerir al reservar
+	STOCK_DEFAULT_SUGGEST_DAYS = 14
+
+	// Capacidad por reparto: días en los que se busca una fecha con lugar para sugerir al reservar y
+	// rango máximo de GET /deliveries/availability
+	ROUTE_CAPACITY_SUGGEST_DAYS = 14
+	ROUTE_AVAILABILITY_MAX_DAYS = 62
+
 	// Valores por defecto
 	DISPENSER_MARCA_PENDIENTE = "PENDIENTE"
 )
@@ -8,18 +8,35 @@ type ItemDispenserResponse struct {
 }
 
 type DeliveryResponse struct {
-	ID             int                     `json:"id,omitempty"`
-	NroCta         string                  `json:"nro_cta"`
-	NroRto         string                  `json:"nro_rto"`
-	ItemDispensers []ItemDispenserResponse `json:"item_dispensers"`
-	Cantidad       uint                    `json:"cantidad"`
-	Token          string                  `json:"token"`
-	Estado         models.EstadoEntrega    `json:"estado"`
-	TipoEntrega    models.TipoEntrega      `json:"tipo_entrega"`
-	EntregadoPor   models.EntregadoPor     `json:"entregado_por"`
-	ConversationID *string                 `json:"conversation_id,omitempty"`
-	FechaAccion    string                  `json:"fecha_accion"`
-	FechaCreacion  string                  `json:"fecha_creacion"`
+	ID                 int                        `json:"id,omitempty"`
+	NroCta             string                     `json:"nro_cta"`
+	NroRto             string                     `json:"nro_rto"`
+	ItemDispensers     []ItemDispenserResponse    `json:"item_dispensers"`
+	Cantidad           uint                       `json:"cantidad"`
+	Token              string                     `json:"token,omitempty"`
+	TokenLocked        bool                       `json:"token_locked"`
+	Estado             models.EstadoEntrega       `json:"estado"`
+	TipoEntrega        models.TipoEntrega         `json:"tipo_entrega"`
+	EntregadoPor       models.EntregadoPor        `json:"entregado_por"`
+	ConversationID     *string                    `json:"conversation_id,omitempty"`
+	FechaAccion        string                     `json:"fecha_accion"`
+	FechaCreacion      string                     `json:"fecha_creacion"`
+	RescheduleCount    int                        `json:"reschedule_count"`
+	FailureReason      string                     `json:"failure_reason,omitempty"`
+	FailureNotes       string                     `json:"failure_notes,omitempty"`
+	FailedAt           string                     `json:"failed_at,omitempty"`
+	CompletedAt        string                     `json:"completed_at,omitempty"`
+	CompletedBy        *int                       `json:"completed_by,omitempty"`
+	AddressLatitude    *float64                   `json:"address_latitude,omitempty"`
+	AddressLongitude   *float64                   `json:"address_longitude,omitempty"`
+	CompletionDistance *float64                   `json:"completion_distance,omitempty"`
+	GeofenceFlagged    bool                       `json:"geofence_flagged"`
+	RequiresFollowUp   bool                       `json:"requires_follow_up"`
+	FollowUpOfID       *int                       `json:"follow_up_of_id,omitempty"`
+	TruckID            *int                       `json:"truck_id,omitempty"`
+	Shortfalls         []models.DeliveryShortfall `json:"shortfalls,omitempty"`
+	DeletedAt          string                     `json:"deleted_at,omitempty"`
+	Version            int                        `json:"version"`
 }
 
 func ToDeliveryResponse(delivery *models.Delivery) DeliveryResponse {
@@ -31,19 +48,51 @@ func ToDeliveryResponse(delivery *models.Delivery) DeliveryResponse {
 		}
 	}
 
+	var failedAt string
+	if delivery.FailedAt != nil {
+		failedAt = delivery.FailedAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+
+	var completedAt string
+	if delivery.CompletedAt != nil {
+		completedAt = delivery.CompletedAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+
+	var deletedAt string
+	if delivery.DeletedAt.Valid {
+		deletedAt = delivery.DeletedAt.Time.Format("2006-01-02T15:04:05Z07:00")
+	}
+
 	return DeliveryResponse{
-		ID:             delivery.ID,
-		NroCta:         delivery.NroCta,
-		NroRto:         delivery.NroRto,
-		ItemDispensers: itemDispensers,
-		Cantidad:       delivery.Cantidad,
-		Token:          delivery.Token,
-		Estado:         delivery.Estado,
-		TipoEntrega:    delivery.TipoEntrega,
-		EntregadoPor:   delivery.EntregadoPor,
-		ConversationID: delivery.ConversationID,
-		FechaAccion:    delivery.FechaAccion.Format("2006-01-02T15:04:05Z07:00"),
-		FechaCreacion:  delivery.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		ID:                 delivery.ID,
+		NroCta:             delivery.NroCta,
+		NroRto:             delivery.NroRto,
+		ItemDispensers:     itemDispensers,
+		Cantidad:           delivery.Cantidad,
+		Token:              delivery.Token,
+		TokenLocked:        delivery.TokenLockedAt != nil,
+		Estado:             delivery.Estado,
+		TipoEntrega:        delivery.TipoEntrega,
+		EntregadoPor:       delivery.EntregadoPor,
+		ConversationID:     delivery.ConversationID,
+		FechaAccion:        delivery.FechaAccion.Format("2006-01-02T15:04:05Z07:00"),
+		FechaCreacion:      delivery.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		RescheduleCount:    delivery.RescheduleCount,
+		FailureReason:      delivery.FailureReason,
+		FailureNotes:       delivery.FailureNotes,
+		FailedAt:           failedAt,
+		CompletedAt:        completedAt,
+		CompletedBy:        delivery.CompletedBy,
+		AddressLatitude:    delivery.AddressLatitude,
+		AddressLongitude:   delivery.AddressLongitude,
+		CompletionDistance: delivery.CompletionDistance,
+		GeofenceFlagged:    delivery.GeofenceFlagged,
+		RequiresFollowUp:   delivery.RequiresFollowUp,
+		FollowUpOfID:       delivery.FollowUpOfID,
+		TruckID:            delivery.TruckID,
+		Shortfalls:         delivery.Shortfalls,
+		DeletedAt:          deletedAt,
+		Version:            delivery.Version,
 	}
 }
 
@@ -55,6 +104,24 @@ func ToDeliveryResponseList(deliveries []models.Delivery) []DeliveryResponse {
 	return responses
 }
 
+// ChangeDeliveryStatusRequest solicita una transición explícita de estado
+type ChangeDeliveryStatusRequest struct {
+	Estado models.EstadoEntrega `json:"estado" binding:"required,oneof=Pendiente Programado EnCamino Reprogramado Completado Fallido Cancelado"`
+	Reason string               `json:"reason" binding:"max=500"`
+}
+
+// CancelDeliveryRequest cuerpo opcional para cancelar una entrega
+type CancelDeliveryRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// RescheduleDeliveryRequest solicita cambiar la fecha_accion de una entrega
+type RescheduleDeliveryRequest struct {
+	FechaAccion     string `json:"fecha_accion" binding:"required"`
+	Reason          string `json:"reason" binding:"max=500"`
+	RegenerateToken bool   `json:"regenerate_token"`
+}
+
 // TallerPrepDeliveryItem representa un delivery con los datos relevantes para preparación de taller
 type TallerPrepDeliveryItem struct {
 	ID     int    `json:"id"`
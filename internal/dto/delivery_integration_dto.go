@@ -74,3 +74,28 @@ type InfobipPendingResponse struct {
 	Count      int                         `json:"count"`
 	Deliveries []InfobipPendingDeliveryDTO `json:"deliveries"`
 }
+
+// DeliveryImportRowError detalla los errores de validación de una fila del archivo importado
+type DeliveryImportRowError struct {
+	Row    int      `json:"row"`
+	NroCta string   `json:"nro_cta,omitempty"`
+	Errors []string `json:"errors"`
+}
+
+// DeliveryImportCreated identifica una entrega creada a partir de una fila del archivo
+type DeliveryImportCreated struct {
+	Row        int    `json:"row"`
+	DeliveryID int    `json:"delivery_id"`
+	NroCta     string `json:"nro_cta"`
+	Token      string `json:"token"`
+}
+
+// DeliveryImportResponse es el reporte de una importación masiva (dry-run o commit)
+type DeliveryImportResponse struct {
+	DryRun      bool                     `json:"dry_run"`
+	TotalRows   int                      `json:"total_rows"`
+	ValidRows   int                      `json:"valid_rows"`
+	InvalidRows int                      `json:"invalid_rows"`
+	Created     []DeliveryImportCreated  `json:"created"`
+	Errors      []DeliveryImportRowError `json:"errors"`
+}
@@ -0,0 +1,30 @@
+package dto
+
+import "GoFrioCalor/internal/models"
+
+// DispenserHistoryResponse ubicación actual de un dispenser y sus movimientos, del más antiguo al más reciente
+type DispenserHistoryResponse struct {
+	Dispenser models.Dispenser           `json:"dispenser"`
+	Total     int                        `json:"total"`
+	Movements []models.DispenserMovement `json:"movements"`
+}
+
+// AccountEquipmentItem dispenser instalado en la cuenta con su instalación y último servicio
+type AccountEquipmentItem struct {
+	Serial                string `json:"serial"`
+	Tipo                  string `json:"tipo,omitempty"`
+	Model                 string `json:"model,omitempty"`
+	InstalledAt           string `json:"installed_at,omitempty"`
+	InstallDeliveryID     *int   `json:"install_delivery_id,omitempty"`
+	InstallWorkOrder      string `json:"install_work_order,omitempty"`
+	LastServiceAt         string `json:"last_service_at,omitempty"`
+	LastServiceDeliveryID *int   `json:"last_service_delivery_id,omitempty"`
+	LastServiceWorkOrder  string `json:"last_service_work_order,omitempty"`
+}
+
+// AccountEquipmentResponse equipos instalados actualmente en una cuenta
+type AccountEquipmentResponse struct {
+	NroCta    string                 `json:"nro_cta"`
+	Total     int                    `json:"total"`
+	Equipment []AccountEquipmentItem `json:"equipment"`
+}
@@ -0,0 +1,10 @@
+package dto
+
+// MaintenancePlanRequest alta o modificación de un plan de mantenimiento preventivo. Sin serial el
+// plan cubre todos los dispensers instalados en la cuenta.
+type MaintenancePlanRequest struct {
+	NroCta       string `json:"nro_cta" binding:"required,min=1,max=50"`
+	Serial       string `json:"serial" binding:"omitempty,max=100"`
+	IntervalDays int    `json:"interval_days" binding:"required,min=7,max=730"`
+	Active       *bool  `json:"active"`
+}
@@ -1,17 +1,33 @@
 package dto
 
+import (
+	"GoFrioCalor/internal/models"
+	"encoding/json"
+)
+
 // ValidateTokenRequest - Solicitud del repartidor para validar el token del cliente
 // Requiere token + nro_cta + fecha para mayor seguridad
+// validated_at es opcional (RFC3339): la sincronización offline informa cuándo se validó en el dispositivo
+// latitude/longitude/accuracy son la lectura GPS del dispositivo (opcional, accuracy en metros)
 type ValidateTokenRequest struct {
-	Token       string `json:"token" binding:"required,min=4"`
-	NroCta      string `json:"nro_cta" binding:"required"`
-	FechaAccion string `json:"fecha_accion" binding:"required"`
+	Token       string   `json:"token" binding:"required,min=4"`
+	NroCta      string   `json:"nro_cta" binding:"required"`
+	FechaAccion string   `json:"fecha_accion" binding:"required"`
+	ValidatedAt string   `json:"validated_at,omitempty"`
+	Latitude    *float64 `json:"latitude,omitempty" binding:"omitempty,min=-90,max=90"`
+	Longitude   *float64 `json:"longitude,omitempty" binding:"omitempty,min=-180,max=180"`
+	Accuracy    *float64 `json:"accuracy,omitempty" binding:"omitempty,min=0"`
 }
 
 // ValidateTokenResponse - Respuesta de validación de token
+// RemainingAttempts solo se informa ante un token incorrecto; Locked indica que se superó el máximo
+// de intentos y el cliente debe pedir un token nuevo al contact center.
 type ValidateTokenResponse struct {
-	Valid   bool   `json:"valid"`
-	Message string `json:"message"`
+	Valid             bool   `json:"valid"`
+	Message           string `json:"message"`
+	Locked            bool   `json:"locked,omitempty"`
+	Expired           bool   `json:"expired,omitempty"`
+	RemainingAttempts *int   `json:"remaining_attempts,omitempty"`
 }
 
 // DeliveryInfoDTO - Información básica del delivery para el repartidor
@@ -34,8 +50,10 @@ type ItemDispenserInfoDTO struct {
 // Ya no se escanean dispensers individuales, solo se registran cantidades por tipo
 
 // DispenserOperation - Una operación sobre un dispenser (instalación, retiro, recambio o servicio técnico)
+// tipo (P o M) indica sobre qué dispensers pedidos se hizo la operación; permite registrar faltantes
 type DispenserOperation struct {
 	Type                   string `json:"type" binding:"required,oneof=installation retirement replacement service"`
+	Tipo                   string `json:"tipo,omitempty" binding:"omitempty,oneof=P M"`
 	InstalledDispenserCode string `json:"installed_dispenser_code,omitempty"`
 	RetiredDispenserCode   string `json:"retired_dispenser_code,omitempty"`
 	ServiceDispenserCode   string `json:"service_dispenser_code,omitempty"`
@@ -43,7 +61,10 @@ type DispenserOperation struct {
 
 // MobileCompleteDeliveryRequest - Completar la entrega desde app móvil
 // delivery_id y token son opcionales: solo requeridos para instalaciones pre-coordinadas (Infobip)
+// Si la entrega tiene token, token es obligatorio y tiene que coincidir
 // Para retiros y recambios se crea un delivery nuevo en el momento
+// completed_at es opcional (RFC3339); si no se envía se usa la hora del servidor
+// latitude/longitude/accuracy son la lectura GPS del dispositivo al cerrar (opcional, accuracy en metros)
 type MobileCompleteDeliveryRequest struct {
 	DeliveryID  int                  `json:"delivery_id"`
 	OrderNumber string               `json:"order_number" binding:"required"`
@@ -55,6 +76,27 @@ type MobileCompleteDeliveryRequest struct {
 	Locality    string               `json:"locality"`
 	Token       string               `json:"token"`
 	Operations  []DispenserOperation `json:"operations" binding:"required,min=1,dive"`
+	CompletedAt string               `json:"completed_at,omitempty"`
+	Latitude    *float64             `json:"latitude,omitempty" binding:"omitempty,min=-90,max=90"`
+	Longitude   *float64             `json:"longitude,omitempty" binding:"omitempty,min=-180,max=180"`
+	Accuracy    *float64             `json:"accuracy,omitempty" binding:"omitempty,min=0"`
+	// OverrideSerialValidation acepta números de serie que no pasan la validación; requiere OverrideReason
+	OverrideSerialValidation bool   `json:"override_serial_validation,omitempty"`
+	OverrideReason           string `json:"override_reason,omitempty" binding:"max=500"`
+	// CompletedBy y StaffLegajo identifican al Staff de la sesión del dispositivo; no se leen del body
+	CompletedBy *int   `json:"-"`
+	StaffLegajo string `json:"-"`
+}
+
+// DispenserSerialError - Error de validación del número de serie de una operación.
+// code: serial_required, serial_format, serial_installed_elsewhere o serial_not_installed_here
+type DispenserSerialError struct {
+	OperationIndex int    `json:"operation_index"`
+	Type           string `json:"type"`
+	Field          string `json:"field"`
+	Serial         string `json:"serial,omitempty"`
+	Code           string `json:"code"`
+	Message        string `json:"message"`
 }
 
 // ItemDispenserDelivered - Items de dispensers efectivamente entregados
@@ -90,12 +132,117 @@ type MobileCompleteDeliveryResponse struct {
 	OrderNumber     string                  `json:"order_number"`
 	Operations      []OperationCompletedDTO `json:"operations"`
 	WorkOrderQueued bool                    `json:"work_order_queued"`
+	CompletedAt     string                  `json:"completed_at"`
+	CompletedBy     *int                    `json:"completed_by,omitempty"`
+	GeofenceStatus  string                  `json:"geofence_status"`
+	GeofenceFlagged bool                    `json:"geofence_flagged"`
+	DistanceMeters  *float64                `json:"distance_meters,omitempty"`
+	SerialWarnings  []DispenserSerialError  `json:"serial_warnings,omitempty"` // Errores aceptados con override
+	// Entrega parcial: faltantes por tipo y entrega Pendiente creada por el resto
+	Shortfalls          []models.DeliveryShortfall `json:"shortfalls,omitempty"`
+	FollowUpDeliveryID  *int                       `json:"follow_up_delivery_id,omitempty"`
+	FollowUpFechaAccion string                     `json:"follow_up_fecha_accion,omitempty"`
+	// Dispensers instalados que no son los que preparó el taller (no bloquean el cierre)
+	PreparationWarnings []DispenserSerialError `json:"preparation_warnings,omitempty"`
 }
 
-// MobileDeliverySearchResponse - Respuesta simplificada para búsqueda de deliveries (mobile)
-type MobileDeliverySearchResponse struct {
-	ID          int    `json:"id"`
-	FechaAccion string `json:"fecha_accion"`
-	NroCta      string `json:"nro_cta"`
-	Token       string `json:"token"`
+// MobileRouteStop - Una parada de la hoja de ruta del repartidor. Nunca incluye el token de
+// validación: el repartidor se lo pide al cliente al momento de la entrega.
+// terms_status es el estado de la sesión de términos (PENDING, ACCEPTED, REJECTED, EXPIRED) o
+// NONE si la entrega no tiene sesión.
+type MobileRouteStop struct {
+	ID               int                     `json:"id"`
+	NroCta           string                  `json:"nro_cta"`
+	NroRto           string                  `json:"nro_rto"`
+	FechaAccion      string                  `json:"fecha_accion"`
+	Name             string                  `json:"name"`
+	Address          string                  `json:"address"`
+	Locality         string                  `json:"locality"`
+	AddressLatitude  *float64                `json:"address_latitude,omitempty"`
+	AddressLongitude *float64                `json:"address_longitude,omitempty"`
+	TipoEntrega      models.TipoEntrega      `json:"tipo_entrega"`
+	EntregadoPor     models.EntregadoPor     `json:"entregado_por"`
+	Estado           models.EstadoEntrega    `json:"estado"`
+	ItemDispensers   []ItemDispenserResponse `json:"item_dispensers"`
+	DispensersP      uint                    `json:"dispensers_p"`
+	DispensersM      uint                    `json:"dispensers_m"`
+	TermsStatus      string                  `json:"terms_status,omitempty"`
+	TokenLocked      bool                    `json:"token_locked"`
+}
+
+// MobileRouteManifestResponse - Hoja de ruta de un reparto para un día
+type MobileRouteManifestResponse struct {
+	NroRto      string            `json:"nro_rto"`
+	FechaAccion string            `json:"fecha_accion"`
+	TotalStops  int               `json:"total_stops"`
+	DispensersP uint              `json:"dispensers_p"`
+	DispensersM uint              `json:"dispensers_m"`
+	Stops       []MobileRouteStop `json:"stops"`
+}
+
+// MobileFailDeliveryRequest - Registrar una visita fallida desde la app móvil
+// failed_at es opcional (RFC3339); si no se envía se usa la hora del servidor
+type MobileFailDeliveryRequest struct {
+	ReasonCode string `json:"reason_code" binding:"required,max=50"`
+	Notes      string `json:"notes" binding:"max=1000"`
+	FailedAt   string `json:"failed_at,omitempty"`
+	// StaffLegajo es el legajo del Staff de la sesión del dispositivo; no se lee del body
+	StaffLegajo string `json:"-"`
+}
+
+// MobileFailDeliveryResponse - Resultado de registrar una visita fallida
+type MobileFailDeliveryResponse struct {
+	DeliveryID            int    `json:"delivery_id"`
+	Estado                string `json:"estado"`
+	ReasonCode            string `json:"reason_code"`
+	ReasonDescription     string `json:"reason_description"`
+	FailedAt              string `json:"failed_at"`
+	Policy                string `json:"policy"`
+	RequiresContactCenter bool   `json:"requires_contact_center"`
+	FollowUpDeliveryID    *int   `json:"follow_up_delivery_id,omitempty"`
+	FollowUpFechaAccion   string `json:"follow_up_fecha_accion,omitempty"`
+}
+
+// MobileSyncRequest - Lote de acciones encoladas por la app mientras estaba sin señal
+type MobileSyncRequest struct {
+	DeviceID string             `json:"device_id" binding:"max=100"`
+	Actions  []MobileSyncAction `json:"actions" binding:"required,min=1,dive"`
+	// StaffID y StaffLegajo identifican al Staff de la sesión del dispositivo; no se leen del body
+	StaffID     *int   `json:"-"`
+	StaffLegajo string `json:"-"`
+}
+
+// MobileSyncAction - Una acción offline. client_action_id es un UUID generado en el dispositivo
+// y client_timestamp (RFC3339) el momento en que ocurrió. payload tiene el mismo formato que el
+// endpoint online equivalente: validate-token, complete-delivery o deliveries/:id/fail
+// (para fail_delivery el id de la entrega va en delivery_id).
+type MobileSyncAction struct {
+	ClientActionID  string          `json:"client_action_id" binding:"required,uuid"`
+	Type            string          `json:"type" binding:"required,oneof=validate_token complete_delivery fail_delivery"`
+	ClientTimestamp string          `json:"client_timestamp" binding:"required"`
+	DeliveryID      int             `json:"delivery_id"`
+	Payload         json.RawMessage `json:"payload" binding:"required"`
+}
+
+// MobileSyncResult - Resultado de una acción del lote. status: applied, conflict, rejected,
+// error (transitorio, reintentar) o duplicate (ya procesada; original_status indica el resultado)
+type MobileSyncResult struct {
+	ClientActionID string          `json:"client_action_id"`
+	Type           string          `json:"type"`
+	Status         string          `json:"status"`
+	OriginalStatus string          `json:"original_status,omitempty"`
+	DeliveryID     int             `json:"delivery_id,omitempty"`
+	Error          string          `json:"error,omitempty"`
+	Result         json.RawMessage `json:"result,omitempty" swaggertype:"object"`
+}
+
+// MobileSyncResponse - Resultados del lote en el mismo orden en que se enviaron las acciones
+type MobileSyncResponse struct {
+	Total      int                `json:"total"`
+	Applied    int                `json:"applied"`
+	Duplicates int                `json:"duplicates"`
+	Conflicts  int                `json:"conflicts"`
+	Rejected   int                `json:"rejected"`
+	Errors     int                `json:"errors"`
+	Results    []MobileSyncResult `json:"results"`
 }
@@ -0,0 +1,43 @@
+package dto
+
+import "GoFrioCalor/internal/models"
+
+// RouteCapacityRequest alta o modificación de una regla de capacidad. weekday va de 0 (domingo) a 6
+// (sábado). Sin tipo_entrega el límite es para el total de entregas del día; max_deliveries 0
+// cierra el día.
+type RouteCapacityRequest struct {
+	NroRto        string `json:"nro_rto" binding:"required,min=1,max=50"`
+	Weekday       *int   `json:"weekday" binding:"required,min=0,max=6"`
+	TipoEntrega   string `json:"tipo_entrega" binding:"omitempty,oneof=Instalacion Retiro Recambio Service Mixto"`
+	MaxDeliveries *int   `json:"max_deliveries" binding:"required,min=0,max=1000"`
+}
+
+// TipoAvailability lugar de un día para un tipo de entrega con límite propio
+type TipoAvailability struct {
+	TipoEntrega models.TipoEntrega `json:"tipo_entrega"`
+	Limit       int                `json:"limit"`
+	Booked      int                `json:"booked"`
+	Remaining   int                `json:"remaining"`
+}
+
+// DayAvailability lugar de un día en el reparto. limit es el límite del total de entregas (null si
+// no hay) y remaining el lugar que queda para el tipo pedido, o para el total si no se pidió tipo
+// (null = sin límite).
+type DayAvailability struct {
+	Fecha     string             `json:"fecha"`
+	Weekday   int                `json:"weekday"`
+	Limit     *int               `json:"limit"`
+	Booked    int                `json:"booked"`
+	Remaining *int               `json:"remaining"`
+	Available bool               `json:"available"`
+	ByTipo    []TipoAvailability `json:"by_tipo"`
+}
+
+// RouteAvailabilityResponse lugar por día de un reparto entre from y to
+type RouteAvailabilityResponse struct {
+	NroRto      string             `json:"nro_rto"`
+	TipoEntrega models.TipoEntrega `json:"tipo_entrega,omitempty"`
+	From        string             `json:"from"`
+	To          string             `json:"to"`
+	Days        []DayAvailability  `json:"days"`
+}
@@ -0,0 +1,36 @@
+package dto
+
+import "GoFrioCalor/internal/models"
+
+// StaffRequest alta o modificación de un repartidor o técnico. El PIN es opcional en la
+// modificación: si se omite se conserva el anterior.
+type StaffRequest struct {
+	Legajo string              `json:"legajo" binding:"required,min=1,max=20"`
+	Name   string              `json:"name" binding:"required,min=3,max=200"`
+	Role   models.EntregadoPor `json:"role" binding:"required,oneof=Repartidor Tecnico"`
+	NroRto string              `json:"nro_rto" binding:"omitempty,max=50"`
+	Pin    string              `json:"pin" binding:"omitempty,numeric,min=4,max=8"`
+	Active *bool               `json:"active"`
+}
+
+// StaffLoginRequest login del repartidor o técnico en el dispositivo al iniciar el turno
+type StaffLoginRequest struct {
+	Legajo   string `json:"legajo" binding:"required,min=1,max=20"`
+	Pin      string `json:"pin" binding:"required,numeric,min=4,max=8"`
+	DeviceID string `json:"device_id" binding:"required,min=1,max=100"`
+}
+
+// StaffLoginResponse devuelve el token de sesión que el dispositivo envía en X-Staff-Session
+type StaffLoginResponse struct {
+	SessionToken string       `json:"session_token"`
+	ExpiresAt    string       `json:"expires_at"`
+	Staff        models.Staff `json:"staff"`
+}
+
+// StaffDeliveriesResponse historial diario de entregas completadas por una persona
+type StaffDeliveriesResponse struct {
+	Staff      models.Staff       `json:"staff"`
+	Fecha      string             `json:"fecha"`
+	Total      int                `json:"total"`
+	Deliveries []DeliveryResponse `json:"deliveries"`
+}
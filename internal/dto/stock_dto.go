@@ -0,0 +1,46 @@
+package dto
+
+import "GoFrioCalor/internal/models"
+
+// WarehouseRequest alta o modificación de un depósito. routes son los repartos que abastece; el
+// depósito is_default abastece a los repartos que no figuran en ningún otro.
+type WarehouseRequest struct {
+	Code      string   `json:"code" binding:"required,max=20"`
+	Name      string   `json:"name" binding:"required,max=100"`
+	Routes    []string `json:"routes" binding:"omitempty,dive,min=1,max=50"`
+	IsDefault bool     `json:"is_default"`
+	Active    *bool    `json:"active"`
+}
+
+// StockMovementRequest registra un ingreso (cantidad positiva, con fecha futura si es una recepción
+// programada) o un ajuste de inventario (positivo o negativo, con efecto inmediato)
+type StockMovementRequest struct {
+	Tipo          string `json:"tipo" binding:"required,oneof=P M"`
+	Kind          string `json:"kind" binding:"required,oneof=Ingreso Ajuste"`
+	Quantity      int    `json:"quantity" binding:"required,ne=0"`
+	EffectiveDate string `json:"effective_date" binding:"omitempty,datetime=2006-01-02"`
+	Reason        string `json:"reason" binding:"max=255"`
+}
+
+// StockLevel existencia de un tipo de dispenser en un depósito. available es lo que todavía se
+// puede reservar hoy sin dejar descubierta ninguna reserva, contando los ingresos programados.
+type StockLevel struct {
+	Tipo      models.TipoDispenser `json:"tipo"`
+	OnHand    int                  `json:"on_hand"`
+	Incoming  int                  `json:"incoming"`
+	Reserved  int                  `json:"reserved"`
+	Available int                  `json:"available"`
+}
+
+// WarehouseStockResponse existencia por tipo de un depósito
+type WarehouseStockResponse struct {
+	Warehouse models.Warehouse `json:"warehouse"`
+	Levels    []StockLevel     `json:"levels"`
+}
+
+// StockShortage un tipo de dispenser sin stock suficiente para la fecha pedida
+type StockShortage struct {
+	Tipo      models.TipoDispenser `json:"tipo"`
+	Requested uint                 `json:"requested"`
+	Available int                  `json:"available"`
+}
@@ -0,0 +1,54 @@
+package dto
+
+import (
+	"GoFrioCalor/internal/models"
+	"time"
+)
+
+// PrepareDeliveryRequest registra la preparación en taller de una entrega. Si se omite serials se
+// conservan los números de serie ya asignados; una lista vacía los libera. status por defecto es
+// Preparado.
+type PrepareDeliveryRequest struct {
+	Serials []string `json:"serials" binding:"omitempty,max=10,dive,max=100"`
+	Status  string   `json:"status" binding:"omitempty,oneof=Preparado Cargado"`
+	Notes   string   `json:"notes" binding:"max=500"`
+}
+
+// TallerBoardDelivery una entrega del tablero de taller con su avance de preparación
+type TallerBoardDelivery struct {
+	ID             int                      `json:"id"`
+	NroCta         string                   `json:"nro_cta"`
+	Name           string                   `json:"name,omitempty"`
+	Address        string                   `json:"address,omitempty"`
+	TipoEntrega    models.TipoEntrega       `json:"tipo_entrega"`
+	Estado         models.EstadoEntrega     `json:"estado"`
+	ItemDispensers []ItemDispenserResponse  `json:"item_dispensers"`
+	PrepStatus     models.EstadoPreparacion `json:"prep_status"`
+	Serials        []string                 `json:"serials,omitempty"`
+	Notes          string                   `json:"notes,omitempty"`
+	PreparedAt     *time.Time               `json:"prepared_at,omitempty"`
+	LoadedAt       *time.Time               `json:"loaded_at,omitempty"`
+}
+
+// TallerBoardRoute avance de preparación de un reparto
+type TallerBoardRoute struct {
+	NroRto          string                `json:"nro_rto"`
+	TotalDeliveries int                   `json:"total_deliveries"`
+	Pendientes      int                   `json:"pendientes"`
+	Preparadas      int                   `json:"preparadas"`
+	Cargadas        int                   `json:"cargadas"`
+	TotalDispenserP uint                  `json:"total_dispensers_P"`
+	TotalDispenserM uint                  `json:"total_dispensers_M"`
+	SerialsAssigned int                   `json:"serials_assigned"`
+	Deliveries      []TallerBoardDelivery `json:"deliveries"`
+}
+
+// TallerBoardResponse tablero de preparación del taller para una fecha, agrupado por reparto
+type TallerBoardResponse struct {
+	Fecha           string             `json:"fecha"`
+	TotalDeliveries int                `json:"total_deliveries"`
+	Pendientes      int                `json:"pendientes"`
+	Preparadas      int                `json:"preparadas"`
+	Cargadas        int                `json:"cargadas"`
+	Routes          []TallerBoardRoute `json:"routes"`
+}
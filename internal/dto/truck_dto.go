@@ -0,0 +1,42 @@
+package dto
+
+import "GoFrioCalor/internal/models"
+
+// TruckAssignRequest asigna (o quita) entregas de un camión para una fecha. Al asignar, todas las
+// entregas deben estar abiertas y ser de fecha_accion.
+type TruckAssignRequest struct {
+	FechaAccion string `json:"fecha_accion" binding:"required,datetime=2006-01-02"`
+	DeliveryIDs []int  `json:"delivery_ids" binding:"required,min=1,max=200,dive,gt=0"`
+}
+
+// TruckUnassignRequest quita entregas de un camión
+type TruckUnassignRequest struct {
+	DeliveryIDs []int `json:"delivery_ids" binding:"required,min=1,max=200,dive,gt=0"`
+}
+
+// TruckLoadDelivery una entrega de la hoja de carga
+type TruckLoadDelivery struct {
+	ID             int                     `json:"id"`
+	NroCta         string                  `json:"nro_cta"`
+	NroRto         string                  `json:"nro_rto"`
+	Name           string                  `json:"name,omitempty"`
+	Address        string                  `json:"address,omitempty"`
+	Locality       string                  `json:"locality,omitempty"`
+	TipoEntrega    models.TipoEntrega      `json:"tipo_entrega"`
+	Estado         models.EstadoEntrega    `json:"estado"`
+	Cantidad       uint                    `json:"cantidad"`
+	ItemDispensers []ItemDispenserResponse `json:"item_dispensers"`
+}
+
+// TruckLoadSheet hoja de carga de un camión para una fecha: entregas asignadas (salvo las
+// canceladas), dispensers por tipo y lugar libre según la capacidad
+type TruckLoadSheet struct {
+	Truck           models.Truck        `json:"truck"`
+	Fecha           string              `json:"fecha"`
+	TotalDeliveries int                 `json:"total_deliveries"`
+	TotalDispenserP uint                `json:"total_dispensers_P"`
+	TotalDispenserM uint                `json:"total_dispensers_M"`
+	TotalDispensers uint                `json:"total_dispensers"`
+	Remaining       int                 `json:"remaining"`
+	Deliveries      []TruckLoadDelivery `json:"deliveries"`
+}
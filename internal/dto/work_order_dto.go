@@ -14,6 +14,7 @@ type WorkOrderRequest struct {
 	TipoAccion  string                      `json:"tipoAccion" binding:"required,oneof=Instalacion Retiro Recambio Mixto Service"`
 	Token       string                      `json:"token" binding:"omitempty,len=4,numeric"`
 	OrderNumber string                      `json:"order_number" binding:"omitempty"`
+	CompletedBy *int                        `json:"completed_by,omitempty"`
 }
 
 type WorkOrderDispenserRequest struct {
@@ -21,5 +21,6 @@ type WorkOrderMessageDTO struct {
 	TipoAccion  string             `json:"tipoAccion"`
 	Token       string             `json:"token"`
 	Operations  []OperationMessage `json:"operations"`
-	DeliveryID  int                `json:"deliveryId"` // Para actualizar después
+	DeliveryID  int                `json:"deliveryId"`            // Para actualizar después
+	CompletedBy *int               `json:"completedBy,omitempty"` // ID del Staff que completó la entrega
 }
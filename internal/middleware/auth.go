@@ -22,6 +22,7 @@ var validationHTTPClient = &http.Client{
 type TokenValidationResponse struct {
 	Valid  bool   `json:"valido"`
 	Detail string `json:"detail,omitempty"`
+	Legajo string `json:"legajo,omitempty"` // Presente cuando el token identifica a un repartidor o técnico
 }
 
 // AuthMiddleware valida el token JWT contra el servicio externo de autenticación
@@ -54,7 +55,7 @@ func AuthMiddleware(authServiceURL string) gin.HandlerFunc {
 		token := parts[1]
 
 		// Validar el token contra el servicio externo
-		isValid, detail := validateToken(c.Request.Context(), authServiceURL, token)
+		isValid, detail, legajo := validateToken(c.Request.Context(), authServiceURL, token)
 		if !isValid {
 			log.Warn().
 				Str("detail", detail).
@@ -73,13 +74,19 @@ func AuthMiddleware(authServiceURL string) gin.HandlerFunc {
 			Str("path", c.Request.URL.Path).
 			Msg(LogTokenValidated)
 
+		// El legajo del token identifica al personal (ver StaffIdentity)
+		if legajo != "" {
+			c.Set(ContextKeyAuthLegajo, legajo)
+		}
+
 		// Token válido, continuar con la request
 		c.Next()
 	}
 }
 
-// validateToken hace una llamada HTTP al servicio de validación de tokens
-func validateToken(ctx context.Context, authServiceURL, token string) (bool, string) {
+// validateToken hace una llamada HTTP al servicio de validación de tokens. Devuelve si el token
+// es válido, el detalle del rechazo y el legajo asociado al token, si lo hay.
+func validateToken(ctx context.Context, authServiceURL, token string) (bool, string, string) {
 	// Construir la URL del endpoint de validación
 	validationURL := fmt.Sprintf("%s/validar-token", authServiceURL)
 
@@ -87,7 +94,7 @@ func validateToken(ctx context.Context, authServiceURL, token string) (bool, str
 	req, err := http.NewRequestWithContext(ctx, "GET", validationURL, nil)
 	if err != nil {
 		log.Error().Err(err).Msg(LogErrorCreatingRequest)
-		return false, ErrInternalValidation
+		return false, ErrInternalValidation, ""
 	}
 
 	// Agregar el Bearer token al header
@@ -97,7 +104,7 @@ func validateToken(ctx context.Context, authServiceURL, token string) (bool, str
 	resp, err := validationHTTPClient.Do(req)
 	if err != nil {
 		log.Error().Err(err).Str("url", validationURL).Msg(LogErrorCallingService)
-		return false, ErrAuthServiceUnavailable
+		return false, ErrAuthServiceUnavailable, ""
 	}
 	defer resp.Body.Close()
 
@@ -105,7 +112,7 @@ func validateToken(ctx context.Context, authServiceURL, token string) (bool, str
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Error().Err(err).Msg(LogErrorReadingResponse)
-		return false, ErrProcessingResponse
+		return false, ErrProcessingResponse, ""
 	}
 
 	// Si el status code no es 200, el token es inválido
@@ -114,20 +121,20 @@ func validateToken(ctx context.Context, authServiceURL, token string) (bool, str
 			Int("statusCode", resp.StatusCode).
 			Str("response", string(body)).
 			Msg(LogServiceReturnedError)
-		return false, ErrTokenExpired
+		return false, ErrTokenExpired, ""
 	}
 
 	// Parsear la respuesta JSON
 	var validationResp TokenValidationResponse
 	if err := json.Unmarshal(body, &validationResp); err != nil {
 		log.Error().Err(err).Str("body", string(body)).Msg(LogErrorParsingResponse)
-		return false, ErrProcessingResponse
+		return false, ErrProcessingResponse, ""
 	}
 
 	// Retornar el resultado
 	if !validationResp.Valid {
-		return false, validationResp.Detail
+		return false, validationResp.Detail, ""
 	}
 
-	return true, ""
+	return true, "", strings.TrimSpace(validationResp.Legajo)
 }
@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"GoFrioCalor/internal/models"
+	"GoFrioCalor/internal/store"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// idempotencyWriter copia el body de la respuesta para poder guardarlo
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency respeta el header Idempotency-Key en solicitudes POST, PUT y PATCH. La primera
+// solicitud con una clave se procesa normalmente y su respuesta se guarda durante ttl; los
+// reintentos con la misma clave, ruta y body reciben la respuesta guardada sin volver a ejecutar
+// el handler. Reutilizar la clave con otro body devuelve 409. Las respuestas 5xx no se guardan
+// para que el cliente pueda reintentar. Sin header la solicitud se procesa como siempre.
+// Si la solicitud está autenticada la clave queda asociada al llamador (ver idempotencyScope).
+func Idempotency(idempotencyStore store.IdempotencyStore, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		method := c.Request.Method
+		if method != http.MethodPost && method != http.MethodPut && method != http.MethodPatch {
+			c.Next()
+			return
+		}
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > IdempotencyKeyMaxLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error":  ErrIdempotencyKeyInvalid,
+				"detail": ErrIdempotencyKeyDetail,
+			})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": ErrIdempotencyReadingBody})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// La ruta incluye el path real: la misma clave en /deliveries/1 y /deliveries/2 son solicitudes distintas
+		route := method + " " + c.Request.URL.Path
+		if scope := idempotencyScope(c); scope != "" {
+			route = scope + " " + route
+		}
+		hash := sha256.Sum256(body)
+		record := &models.IdempotencyKey{
+			Key:         key,
+			Route:       route,
+			RequestHash: hex.EncodeToString(hash[:]),
+			Status:      models.IdempotencyInProgress,
+			ExpiresAt:   time.Now().Add(ttl),
+		}
+
+		ctx := c.Request.Context()
+		existing, created, err := idempotencyStore.Reserve(ctx, record)
+		if err != nil {
+			log.Error().Err(err).Str("idempotency_key", key).Str("route", route).Msg(LogErrorReservingKey)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": ErrIdempotencyUnavailable})
+			return
+		}
+
+		if !created {
+			if existing.RequestHash != record.RequestHash {
+				log.Warn().Str("idempotency_key", key).Str("route", route).Msg(LogIdempotencyKeyReused)
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+					"error":  ErrIdempotencyKeyReused,
+					"detail": ErrIdempotencyKeyReusedDetail,
+				})
+				return
+			}
+			if existing.Status != models.IdempotencyCompleted {
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+					"error":  ErrIdempotencyInProgress,
+					"detail": ErrIdempotencyInProgressDetail,
+				})
+				return
+			}
+
+			log.Info().Str("idempotency_key", key).Str("route", route).Int("status", existing.StatusCode).Msg(LogIdempotentReplay)
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Data(existing.StatusCode, existing.ContentType, existing.ResponseBody)
+			c.Abort()
+			return
+		}
+
+		// El resultado se guarda aunque el cliente haya cortado la conexión
+		saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		defer func() {
+			// Si el handler entra en pánico se libera la clave antes de que Recovery responda 500
+			if r := recover(); r != nil {
+				if err := idempotencyStore.Release(saveCtx, record.ID); err != nil {
+					log.Error().Err(err).Str("idempotency_key", key).Str("route", route).Msg(LogErrorReleasingKey)
+				}
+				panic(r)
+			}
+		}()
+
+		writer := &idempotencyWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		status := writer.Status()
+		if status >= http.StatusInternalServerError {
+			if err := idempotencyStore.Release(saveCtx, record.ID); err != nil {
+				log.Error().Err(err).Str("idempotency_key", key).Str("route", route).Msg(LogErrorReleasingKey)
+			}
+			return
+		}
+		if err := idempotencyStore.Complete(saveCtx, record.ID, status, writer.Header().Get("Content-Type"), writer.body.Bytes()); err != nil {
+			log.Error().Err(err).Str("idempotency_key", key).Str("route", route).Msg(LogErrorSavingIdempotent)
+		}
+	}
+}
+
+// idempotencyScope identifica al llamador autenticado: el personal resuelto por StaffIdentity o,
+// si no hay, el legajo del token. Así un cliente no recibe la respuesta guardada de otro que usó
+// la misma clave. Las rutas públicas no tienen llamador y comparten el espacio de claves.
+func idempotencyScope(c *gin.Context) string {
+	if staff := CurrentStaff(c); staff != nil {
+		return fmt.Sprintf("staff:%d", staff.ID)
+	}
+	if legajo := c.GetString(ContextKeyAuthLegajo); legajo != "" {
+		return "legajo:" + legajo
+	}
+	return ""
+}
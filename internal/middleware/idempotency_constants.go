@@ -0,0 +1,28 @@
+package middleware
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	IdempotencyKeyMaxLength  = 255
+)
+
+// Constantes para mensajes de error de idempotencia
+const (
+	ErrIdempotencyKeyInvalid       = "Idempotency-Key inválida"
+	ErrIdempotencyKeyDetail        = "El header Idempotency-Key debe tener como máximo 255 caracteres"
+	ErrIdempotencyKeyReused        = "Idempotency-Key reutilizada con otro body"
+	ErrIdempotencyKeyReusedDetail  = "La clave ya se usó en esta ruta con una solicitud distinta; genere una clave nueva"
+	ErrIdempotencyInProgress       = "Solicitud en curso"
+	ErrIdempotencyInProgressDetail = "Hay una solicitud con la misma Idempotency-Key que todavía se está procesando"
+	ErrIdempotencyUnavailable      = "No se pudo verificar la Idempotency-Key"
+	ErrIdempotencyReadingBody      = "Error leyendo el body de la solicitud"
+)
+
+// Mensajes de log
+const (
+	LogIdempotentReplay      = "Respuesta idempotente reproducida"
+	LogIdempotencyKeyReused  = "Idempotency-Key reutilizada con otro body"
+	LogErrorReservingKey     = "Error reservando Idempotency-Key"
+	LogErrorSavingIdempotent = "Error guardando respuesta idempotente"
+	LogErrorReleasingKey     = "Error liberando Idempotency-Key"
+)
@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"GoFrioCalor/internal/models"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type memoryIdempotencyStore struct {
+	records map[string]*models.IdempotencyKey
+	nextID  int
+}
+
+func (s *memoryIdempotencyStore) Reserve(ctx context.Context, record *models.IdempotencyKey) (*models.IdempotencyKey, bool, error) {
+	id := record.Key + "|" + record.Route
+	if existing, ok := s.records[id]; ok {
+		return existing, false, nil
+	}
+	s.nextID++
+	record.ID = s.nextID
+	s.records[id] = record
+	return record, true, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, id, statusCode int, contentType string, body []byte) error {
+	for _, record := range s.records {
+		if record.ID == id {
+			record.Status = models.IdempotencyCompleted
+			record.StatusCode = statusCode
+			record.ContentType = contentType
+			record.ResponseBody = body
+		}
+	}
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, id int) error {
+	for key, record := range s.records {
+		if record.ID == id {
+			delete(s.records, key)
+		}
+	}
+	return nil
+}
+
+func (s *memoryIdempotencyStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestIdempotency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	idempotencyStore := &memoryIdempotencyStore{records: make(map[string]*models.IdempotencyKey)}
+	calls := 0
+	failNext := false
+
+	router := gin.New()
+	router.Use(Idempotency(idempotencyStore, time.Hour))
+	router.POST("/deliveries", func(c *gin.Context) {
+		calls++
+		if failNext {
+			failNext = false
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error temporal"})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"id": calls})
+	})
+
+	send := func(key, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/deliveries", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := send("abc", `{"nro_cta":"100"}`)
+	if first.Code != http.StatusCreated || first.Body.String() != `{"id":1}` {
+		t.Fatalf("primera solicitud = %d %s", first.Code, first.Body.String())
+	}
+
+	replay := send("abc", `{"nro_cta":"100"}`)
+	if replay.Code != http.StatusCreated || replay.Body.String() != `{"id":1}` {
+		t.Errorf("reintento = %d %s, se esperaba la respuesta guardada", replay.Code, replay.Body.String())
+	}
+	if replay.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("el reintento debería indicar %s", IdempotentReplayedHeader)
+	}
+	if calls != 1 {
+		t.Errorf("el handler se ejecutó %d veces, se esperaba 1", calls)
+	}
+
+	if reused := send("abc", `{"nro_cta":"200"}`); reused.Code != http.StatusConflict {
+		t.Errorf("misma clave con otro body = %d, se esperaba 409", reused.Code)
+	}
+
+	failNext = true
+	if failed := send("def", `{}`); failed.Code != http.StatusInternalServerError {
+		t.Fatalf("solicitud fallida = %d", failed.Code)
+	}
+	if retried := send("def", `{}`); retried.Code != http.StatusCreated {
+		t.Errorf("reintento luego de un 5xx = %d, se esperaba que se procese de nuevo", retried.Code)
+	}
+
+	send("", `{}`)
+	send("", `{}`)
+	if calls != 5 {
+		t.Errorf("sin Idempotency-Key el handler se ejecutó %d veces en total, se esperaban 5", calls)
+	}
+}
+
+func TestIdempotencyScopedByCaller(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	idempotencyStore := &memoryIdempotencyStore{records: make(map[string]*models.IdempotencyKey)}
+	calls := 0
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if legajo := c.GetHeader("X-Legajo"); legajo != "" {
+			c.Set(ContextKeyAuthLegajo, legajo)
+		}
+		c.Next()
+	})
+	router.Use(Idempotency(idempotencyStore, time.Hour))
+	router.POST("/deliveries", func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusCreated, gin.H{"id": calls})
+	})
+
+	send := func(legajo string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/deliveries", strings.NewReader(`{}`))
+		req.Header.Set(IdempotencyKeyHeader, "abc")
+		req.Header.Set("X-Legajo", legajo)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	send("1001")
+	other := send("2002")
+	if other.Code != http.StatusCreated || other.Body.String() != `{"id":2}` {
+		t.Errorf("otro llamador con la misma clave = %d %s, se esperaba una respuesta propia", other.Code, other.Body.String())
+	}
+	if replay := send("1001"); replay.Header().Get(IdempotentReplayedHeader) != "true" || calls != 2 {
+		t.Errorf("el mismo llamador debería recibir la respuesta guardada (calls = %d)", calls)
+	}
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/models"
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// StaffResolver resuelve qué repartidor o técnico opera el dispositivo
+type StaffResolver interface {
+	ResolveSession(ctx context.Context, sessionToken string) (*models.Staff, error)
+	ResolveLegajo(ctx context.Context, legajo string) (*models.Staff, error)
+}
+
+// StaffIdentity identifica al personal que hace la solicitud, por la sesión iniciada en el
+// dispositivo (header X-Staff-Session) o por el legajo que informa el servicio de autenticación.
+// La identidad es opcional: sin ninguna de las dos la solicitud sigue sin personal asociado.
+// Debe registrarse después de AuthMiddleware.
+func StaffIdentity(resolver StaffResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		if sessionToken := strings.TrimSpace(c.GetHeader(StaffSessionHeader)); sessionToken != "" {
+			staff, err := resolver.ResolveSession(ctx, sessionToken)
+			if err != nil {
+				if strings.Contains(err.Error(), constants.ErrStaffSessionInvalid) {
+					log.Warn().Str("ip", c.ClientIP()).Msg(LogStaffSessionInvalid)
+					c.JSON(http.StatusUnauthorized, gin.H{
+						"error":  ErrStaffSessionInvalid,
+						"detail": ErrStaffSessionInvalidDetail,
+					})
+				} else {
+					log.Error().Err(err).Msg(LogErrorResolvingStaff)
+					c.JSON(http.StatusServiceUnavailable, gin.H{"error": ErrStaffUnavailable})
+				}
+				c.Abort()
+				return
+			}
+			c.Set(ContextKeyStaff, staff)
+			c.Next()
+			return
+		}
+
+		if legajo := c.GetString(ContextKeyAuthLegajo); legajo != "" {
+			staff, err := resolver.ResolveLegajo(ctx, legajo)
+			if err != nil {
+				log.Error().Err(err).Str("legajo", legajo).Msg(LogErrorResolvingStaff)
+			} else if staff != nil {
+				c.Set(ContextKeyStaff, staff)
+			}
+		}
+		c.Next()
+	}
+}
+
+// CurrentStaff devuelve el personal identificado por StaffIdentity, o nil si la solicitud no
+// tiene personal asociado
+func CurrentStaff(c *gin.Context) *models.Staff {
+	if value, ok := c.Get(ContextKeyStaff); ok {
+		if staff, ok := value.(*models.Staff); ok {
+			return staff
+		}
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package middleware
+
+const (
+	StaffSessionHeader = "X-Staff-Session"
+	// Claves del contexto de gin con la identidad del personal
+	ContextKeyAuthLegajo = "auth_legajo"
+	ContextKeyStaff      = "staff"
+)
+
+// Constantes para mensajes de error de identidad del personal
+const (
+	ErrStaffSessionInvalid       = "Sesión de personal inválida"
+	ErrStaffSessionInvalidDetail = "El header X-Staff-Session no corresponde a una sesión vigente; inicie sesión nuevamente en el dispositivo"
+	ErrStaffUnavailable          = "No se pudo verificar la identidad del personal"
+)
+
+// Mensajes de log
+const (
+	LogStaffSessionInvalid = "Sesión de personal inválida o expirada"
+	LogErrorResolvingStaff = "Error resolviendo identidad del personal"
+)
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/models"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeStaffResolver struct {
+	sessions map[string]*models.Staff
+	legajos  map[string]*models.Staff
+}
+
+func (r *fakeStaffResolver) ResolveSession(ctx context.Context, sessionToken string) (*models.Staff, error) {
+	if staff, ok := r.sessions[sessionToken]; ok {
+		return staff, nil
+	}
+	return nil, fmt.Errorf(constants.ErrStaffSessionInvalid)
+}
+
+func (r *fakeStaffResolver) ResolveLegajo(ctx context.Context, legajo string) (*models.Staff, error) {
+	return r.legajos[legajo], nil
+}
+
+func TestStaffIdentity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repartidor := &models.Staff{ID: 7, Legajo: "R-007", Role: models.Repartidor}
+	tecnico := &models.Staff{ID: 9, Legajo: "T-009", Role: models.Tecnico}
+	resolver := &fakeStaffResolver{
+		sessions: map[string]*models.Staff{"sesion-valida": repartidor},
+		legajos:  map[string]*models.Staff{"T-009": tecnico},
+	}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		// Simula el legajo que informa el servicio de autenticación
+		if legajo := c.GetHeader("X-Test-Legajo"); legajo != "" {
+			c.Set(ContextKeyAuthLegajo, legajo)
+		}
+	})
+	router.Use(StaffIdentity(resolver))
+	router.GET("/whoami", func(c *gin.Context) {
+		if staff := CurrentStaff(c); staff != nil {
+			c.String(http.StatusOK, staff.Legajo)
+			return
+		}
+		c.String(http.StatusOK, "anonimo")
+	})
+
+	tests := []struct {
+		name     string
+		session  string
+		legajo   string
+		wantCode int
+		wantBody string
+	}{
+		{name: "sesión del dispositivo", session: "sesion-valida", wantCode: http.StatusOK, wantBody: "R-007"},
+		{name: "la sesión tiene prioridad sobre el legajo del token", session: "sesion-valida", legajo: "T-009", wantCode: http.StatusOK, wantBody: "R-007"},
+		{name: "legajo del token", legajo: "T-009", wantCode: http.StatusOK, wantBody: "T-009"},
+		{name: "legajo que no es personal", legajo: "ADM-1", wantCode: http.StatusOK, wantBody: "anonimo"},
+		{name: "sin identidad", wantCode: http.StatusOK, wantBody: "anonimo"},
+		{name: "sesión vencida", session: "sesion-vencida", wantCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+			if tt.session != "" {
+				req.Header.Set(StaffSessionHeader, tt.session)
+			}
+			if tt.legajo != "" {
+				req.Header.Set("X-Test-Legajo", tt.legajo)
+			}
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, se esperaba %d (%s)", w.Code, tt.wantCode, w.Body.String())
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("personal = %q, se esperaba %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
@@ -1,43 +1,77 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type TipoEntrega string
 type EstadoEntrega string
 type EntregadoPor string
 
 const (
-	Instalacion TipoEntrega   = "Instalacion"
-	Retiro      TipoEntrega   = "Retiro"
-	Service     TipoEntrega   = "Service"
-	Recambio    TipoEntrega   = "Recambio"
-	Mixto       TipoEntrega   = "Mixto"
-	Pendiente   EstadoEntrega = "Pendiente"
-	Completado  EstadoEntrega = "Completado"
-	Cancelado   EstadoEntrega = "Cancelado"
-	Repartidor  EntregadoPor  = "Repartidor"
-	Tecnico     EntregadoPor  = "Tecnico"
+	Instalacion  TipoEntrega   = "Instalacion"
+	Retiro       TipoEntrega   = "Retiro"
+	Service      TipoEntrega   = "Service"
+	Recambio     TipoEntrega   = "Recambio"
+	Mixto        TipoEntrega   = "Mixto"
+	Pendiente    EstadoEntrega = "Pendiente"
+	Programado   EstadoEntrega = "Programado"
+	EnCamino     EstadoEntrega = "EnCamino"
+	Reprogramado EstadoEntrega = "Reprogramado"
+	Completado   EstadoEntrega = "Completado"
+	Fallido      EstadoEntrega = "Fallido"
+	Cancelado    EstadoEntrega = "Cancelado"
+	Repartidor   EntregadoPor  = "Repartidor"
+	Tecnico      EntregadoPor  = "Tecnico"
 )
 
 type Delivery struct {
-	ID                  int             `gorm:"primaryKey" json:"id"`
-	NroCta              string          `gorm:"not null" json:"nro_cta" binding:"required,min=1,max=50"`
-	Name                string          `gorm:"type:varchar(200)" json:"name"`
-	Email               string          `gorm:"type:varchar(200)" json:"email"`
-	Address             string          `gorm:"type:varchar(300)" json:"address"`
-	Locality            string          `gorm:"type:varchar(100)" json:"locality"`
-	NroRto              string          `gorm:"not null" json:"nro_rto" binding:"required,min=1,max=50"`
-	ItemDispensers      []ItemDispenser `gorm:"foreignKey:DeliveryID" json:"item_dispensers"`
-	ValidatedDispensers StringArray     `gorm:"type:jsonb" json:"validated_dispensers,omitempty"`
-	OrderNumber         string          `gorm:"type:varchar(50)" json:"order_number,omitempty"`
-	Cantidad            uint            `gorm:"not null" json:"cantidad" binding:"required,min=1,max=3"`
-	Token               string          `gorm:"not null" json:"token"`
-	Estado              EstadoEntrega   `gorm:"not null" json:"estado" binding:"required,oneof=Pendiente Completado Cancelado"`
-	TipoEntrega         TipoEntrega     `gorm:"not null" json:"tipo_entrega" binding:"required,oneof=Instalacion Retiro Recambio Service Mixto"`
-	EntregadoPor        EntregadoPor    `gorm:"not null" json:"entregado_por" binding:"required,oneof=Repartidor Tecnico"`
-	ConversationID      *string         `gorm:"index:idx_conversation_id,unique" json:"conversation_id,omitempty"`
-	TermsSessionID      *int64          `gorm:"index" json:"terms_session_id,omitempty"`
-	FechaAccion         CustomDate      `json:"fecha_accion"`
-	CreatedAt           time.Time       `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt           time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+	ID                  int                 `gorm:"primaryKey" json:"id"`
+	NroCta              string              `gorm:"not null" json:"nro_cta" binding:"required,min=1,max=50"`
+	Name                string              `gorm:"type:varchar(200)" json:"name"`
+	Email               string              `gorm:"type:varchar(200)" json:"email"`
+	Address             string              `gorm:"type:varchar(300)" json:"address"`
+	Locality            string              `gorm:"type:varchar(100)" json:"locality"`
+	AddressLatitude     *float64            `json:"address_latitude,omitempty" binding:"omitempty,min=-90,max=90"`
+	AddressLongitude    *float64            `json:"address_longitude,omitempty" binding:"omitempty,min=-180,max=180"`
+	NroRto              string              `gorm:"not null" json:"nro_rto" binding:"required,min=1,max=50"`
+	ItemDispensers      []ItemDispenser     `gorm:"foreignKey:DeliveryID" json:"item_dispensers"`
+	Shortfalls          []DeliveryShortfall `gorm:"foreignKey:DeliveryID" json:"shortfalls,omitempty"`
+	ValidatedDispensers StringArray         `gorm:"type:jsonb" json:"validated_dispensers,omitempty"`
+	RetiredDispensers   StringArray         `gorm:"type:jsonb" json:"retired_dispensers,omitempty"`
+	SerialOverride      string              `gorm:"type:varchar(500)" json:"serial_override,omitempty"` // Motivo por el que se aceptaron números de serie que no pasaron la validación
+	OrderNumber         string              `gorm:"type:varchar(50)" json:"order_number,omitempty"`
+	Cantidad            uint                `gorm:"not null" json:"cantidad" binding:"required,min=1,max=3"`
+	Token               string              `gorm:"not null" json:"token"`
+	TokenFailedAttempts int                 `gorm:"not null;default:0" json:"token_failed_attempts"`
+	TokenLockedAt       *time.Time          `json:"token_locked_at,omitempty"`
+	Estado              EstadoEntrega       `gorm:"not null" json:"estado" binding:"required,oneof=Pendiente Programado EnCamino Reprogramado Completado Fallido Cancelado"`
+	TipoEntrega         TipoEntrega         `gorm:"not null" json:"tipo_entrega" binding:"required,oneof=Instalacion Retiro Recambio Service Mixto"`
+	EntregadoPor        EntregadoPor        `gorm:"not null" json:"entregado_por" binding:"required,oneof=Repartidor Tecnico"`
+	ConversationID      *string             `gorm:"index:idx_conversation_id,unique" json:"conversation_id,omitempty"`
+	TermsSessionID      *int64              `gorm:"index" json:"terms_session_id,omitempty"`
+	FechaAccion         CustomDate          `json:"fecha_accion"`
+	RescheduleCount     int                 `gorm:"not null;default:0" json:"reschedule_count"`
+	FailureReason       string              `gorm:"type:varchar(50)" json:"failure_reason,omitempty"`
+	FailureNotes        string              `gorm:"type:text" json:"failure_notes,omitempty"`
+	FailedAt            *time.Time          `json:"failed_at,omitempty"`
+	CompletedAt         *time.Time          `json:"completed_at,omitempty"`
+	CompletedBy         *int                `gorm:"index" json:"completed_by,omitempty"` // ID del Staff que cerró la entrega
+	ValidationLatitude  *float64            `json:"validation_latitude,omitempty"`
+	ValidationLongitude *float64            `json:"validation_longitude,omitempty"`
+	ValidationAccuracy  *float64            `json:"validation_accuracy,omitempty"`
+	CompletionLatitude  *float64            `json:"completion_latitude,omitempty"`
+	CompletionLongitude *float64            `json:"completion_longitude,omitempty"`
+	CompletionAccuracy  *float64            `json:"completion_accuracy,omitempty"`
+	CompletionDistance  *float64            `json:"completion_distance,omitempty"`
+	GeofenceFlagged     bool                `gorm:"not null;default:false;index" json:"geofence_flagged"`
+	RequiresFollowUp    bool                `gorm:"not null;default:false;index" json:"requires_follow_up"`
+	FollowUpOfID        *int                `gorm:"index" json:"follow_up_of_id,omitempty"`
+	TruckID             *int                `gorm:"index" json:"truck_id,omitempty"` // Camión asignado para la fecha_accion (ver POST /trucks/:id/assign)
+	Version             int                 `gorm:"not null;default:1" json:"version"`
+	CreatedAt           time.Time           `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt           time.Time           `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt           gorm.DeletedAt      `gorm:"index" json:"deleted_at,omitempty" swaggertype:"string"`
 }
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+type AttachmentKind string
+
+const (
+	AttachmentSignature AttachmentKind = "signature"
+	AttachmentPhoto     AttachmentKind = "photo"
+)
+
+// DeliveryAttachment es un archivo de prueba de entrega (firma del cliente o foto de la
+// instalación). El contenido vive en el blob storage bajo StorageKey; la tabla guarda solo
+// los metadatos y el vínculo con la entrega.
+type DeliveryAttachment struct {
+	ID          int            `gorm:"primaryKey" json:"id"`
+	DeliveryID  int            `gorm:"not null;index" json:"delivery_id"`
+	Kind        AttachmentKind `gorm:"type:varchar(20);not null" json:"kind"`
+	StorageKey  string         `gorm:"type:varchar(255);not null" json:"-"`
+	FileName    string         `gorm:"type:varchar(255)" json:"file_name"`
+	ContentType string         `gorm:"type:varchar(50);not null" json:"content_type"`
+	Size        int64          `gorm:"not null" json:"size"`
+	UploadedBy  string         `gorm:"type:varchar(100)" json:"uploaded_by,omitempty"`
+	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"created_at"`
+}
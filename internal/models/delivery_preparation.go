@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+type EstadoPreparacion string
+
+const (
+	PreparacionPendiente EstadoPreparacion = "Pendiente" // Sin registro de preparación
+	PreparacionPreparado EstadoPreparacion = "Preparado"
+	PreparacionCargado   EstadoPreparacion = "Cargado"
+)
+
+// DeliveryPreparation es la preparación en taller de una entrega: los números de serie apartados
+// para instalar y si los equipos ya se cargaron en el camión
+type DeliveryPreparation struct {
+	ID         int               `gorm:"primaryKey" json:"id"`
+	DeliveryID int               `gorm:"not null;uniqueIndex" json:"delivery_id"`
+	Status     EstadoPreparacion `gorm:"type:varchar(20);not null" json:"status"`
+	Serials    StringArray       `gorm:"type:jsonb" json:"serials,omitempty"`
+	Notes      string            `gorm:"type:varchar(500)" json:"notes,omitempty"`
+	PreparedBy *int              `json:"prepared_by,omitempty"` // ID del Staff que registró la preparación
+	PreparedAt *time.Time        `json:"prepared_at,omitempty"`
+	LoadedAt   *time.Time        `json:"loaded_at,omitempty"`
+	CreatedAt  time.Time         `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time         `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// DeliveryReschedule registra cada cambio de fecha_accion de una entrega
+type DeliveryReschedule struct {
+	ID               int       `gorm:"primaryKey" json:"id"`
+	DeliveryID       int       `gorm:"not null;index" json:"delivery_id"`
+	PreviousFecha    time.Time `gorm:"not null" json:"previous_fecha"`
+	NewFecha         time.Time `gorm:"not null" json:"new_fecha"`
+	Reason           string    `gorm:"type:text" json:"reason,omitempty"`
+	RescheduledBy    string    `gorm:"type:varchar(100);not null" json:"rescheduled_by"`
+	TokenRegenerated bool      `gorm:"not null;default:false" json:"token_regenerated"`
+	CreatedAt        time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// DeliveryShortfall registra, por tipo de dispenser, la diferencia entre lo pedido y lo realizado
+// en una entrega completada parcialmente
+type DeliveryShortfall struct {
+	ID                 int           `gorm:"primaryKey" json:"id"`
+	DeliveryID         int           `gorm:"not null;index" json:"delivery_id"`
+	Tipo               TipoDispenser `gorm:"type:varchar(1);not null" json:"tipo"`
+	Ordered            uint          `gorm:"not null" json:"ordered"`
+	Delivered          uint          `gorm:"not null" json:"delivered"`
+	Missing            uint          `gorm:"not null" json:"missing"`
+	FollowUpDeliveryID *int          `gorm:"index" json:"follow_up_delivery_id,omitempty"` // Entrega Pendiente creada por el faltante
+	CreatedAt          time.Time     `gorm:"autoCreateTime" json:"created_at"`
+}
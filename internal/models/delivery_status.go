@@ -0,0 +1,82 @@
+package models
+
+import "time"
+
+// deliveryTransitions define las transiciones de estado permitidas para una entrega.
+// Los estados Completado y Cancelado son finales: no admiten transiciones.
+var deliveryTransitions = map[EstadoEntrega][]EstadoEntrega{
+	Pendiente:    {Programado, EnCamino, Reprogramado, Completado, Fallido, Cancelado},
+	Programado:   {EnCamino, Reprogramado, Completado, Fallido, Cancelado},
+	EnCamino:     {Reprogramado, Completado, Fallido, Cancelado},
+	Reprogramado: {Programado, EnCamino, Reprogramado, Completado, Fallido, Cancelado},
+	Fallido:      {Reprogramado, Cancelado},
+	Completado:   {},
+	Cancelado:    {},
+}
+
+// EstadosAbiertos son los estados en los que la entrega todavía puede realizarse
+var EstadosAbiertos = []EstadoEntrega{Pendiente, Programado, EnCamino, Reprogramado}
+
+// IsValid indica si el estado pertenece al ciclo de vida conocido
+func (e EstadoEntrega) IsValid() bool {
+	_, ok := deliveryTransitions[e]
+	return ok
+}
+
+// IsFinal indica si el estado no admite más transiciones
+func (e EstadoEntrega) IsFinal() bool {
+	return e.IsValid() && len(deliveryTransitions[e]) == 0
+}
+
+// CanTransitionTo indica si la tabla de reglas permite pasar del estado actual al siguiente
+func (e EstadoEntrega) CanTransitionTo(next EstadoEntrega) bool {
+	for _, allowed := range deliveryTransitions[e] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// deliveryEditableFields define qué campos (por nombre JSON) se pueden modificar con un
+// merge patch según el estado. La fecha y el estado tienen endpoints propios
+// (reprogramación y cambio de estado) y las entregas finalizadas no se editan.
+var deliveryEditableFields = map[EstadoEntrega][]string{
+	Pendiente:    {"name", "email", "address", "locality", "nro_rto", "order_number", "tipo_entrega", "entregado_por"},
+	Programado:   {"name", "email", "address", "locality", "order_number"},
+	EnCamino:     {"name", "email"},
+	Reprogramado: {"name", "email", "address", "locality", "nro_rto", "order_number", "tipo_entrega", "entregado_por"},
+	Fallido:      {"name", "email", "address", "locality", "failure_notes"},
+	Completado:   {},
+	Cancelado:    {},
+}
+
+// EditableFields devuelve los campos que se pueden modificar en el estado actual
+func (e EstadoEntrega) EditableFields() []string {
+	return deliveryEditableFields[e]
+}
+
+// CanEditField indica si el campo (nombre JSON) se puede modificar en el estado actual
+func (e EstadoEntrega) CanEditField(field string) bool {
+	for _, editable := range deliveryEditableFields[e] {
+		if editable == field {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliveryStatusHistory registra cada transición de estado de una entrega
+type DeliveryStatusHistory struct {
+	ID         int           `gorm:"primaryKey" json:"id"`
+	DeliveryID int           `gorm:"not null;index" json:"delivery_id"`
+	FromEstado EstadoEntrega `gorm:"type:varchar(20);not null" json:"from_estado"`
+	ToEstado   EstadoEntrega `gorm:"type:varchar(20);not null" json:"to_estado"`
+	ChangedBy  string        `gorm:"type:varchar(100);not null" json:"changed_by"`
+	Reason     string        `gorm:"type:text" json:"reason,omitempty"`
+	ChangedAt  time.Time     `gorm:"not null;index" json:"changed_at"`
+}
+
+func (DeliveryStatusHistory) TableName() string {
+	return "delivery_status_history"
+}
@@ -0,0 +1,72 @@
+package models
+
+import "testing"
+
+func TestEstadoEntregaCanTransitionTo(t *testing.T) {
+	tests := []struct {
+		name string
+		from EstadoEntrega
+		to   EstadoEntrega
+		want bool
+	}{
+		{name: "Pendiente a Completado", from: Pendiente, to: Completado, want: true},
+		{name: "Pendiente a Cancelado", from: Pendiente, to: Cancelado, want: true},
+		{name: "Programado a EnCamino", from: Programado, to: EnCamino, want: true},
+		{name: "EnCamino a Fallido", from: EnCamino, to: Fallido, want: true},
+		{name: "Fallido a Reprogramado", from: Fallido, to: Reprogramado, want: true},
+		{name: "Fallido no puede completarse directamente", from: Fallido, to: Completado, want: false},
+		{name: "Cancelado no puede revivir", from: Cancelado, to: Pendiente, want: false},
+		{name: "Cancelado a Cancelado", from: Cancelado, to: Cancelado, want: false},
+		{name: "Completado es final", from: Completado, to: Cancelado, want: false},
+		{name: "EnCamino no vuelve a Pendiente", from: EnCamino, to: Pendiente, want: false},
+		{name: "Estado desconocido", from: EstadoEntrega("Perdido"), to: Cancelado, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.from.CanTransitionTo(tt.to); got != tt.want {
+				t.Errorf("%s.CanTransitionTo(%s) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEstadoEntregaIsFinal(t *testing.T) {
+	for _, e := range []EstadoEntrega{Completado, Cancelado} {
+		if !e.IsFinal() {
+			t.Errorf("%s debería ser un estado final", e)
+		}
+	}
+	for _, e := range EstadosAbiertos {
+		if e.IsFinal() {
+			t.Errorf("%s no debería ser un estado final", e)
+		}
+	}
+	if EstadoEntrega("Perdido").IsValid() {
+		t.Errorf("un estado desconocido no debería ser válido")
+	}
+}
+
+func TestEstadoEntregaCanEditField(t *testing.T) {
+	tests := []struct {
+		estado EstadoEntrega
+		field  string
+		want   bool
+	}{
+		{estado: Pendiente, field: "address", want: true},
+		{estado: Reprogramado, field: "tipo_entrega", want: true},
+		{estado: Programado, field: "tipo_entrega", want: false},
+		{estado: EnCamino, field: "address", want: false},
+		{estado: Fallido, field: "failure_notes", want: true},
+		{estado: Pendiente, field: "estado", want: false},
+		{estado: Pendiente, field: "fecha_accion", want: false},
+		{estado: Completado, field: "email", want: false},
+		{estado: Cancelado, field: "name", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.estado.CanEditField(tt.field); got != tt.want {
+			t.Errorf("%s.CanEditField(%q) = %v, want %v", tt.estado, tt.field, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+type DispenserStatus string
+type DispenserLocationType string
+
+const (
+	DispenserInstalado  DispenserStatus = "Instalado"
+	DispenserRetirado   DispenserStatus = "Retirado" // retirado de la cuenta, viaja en el reparto
+	DispenserDisponible DispenserStatus = "Disponible"
+	DispenserEnTaller   DispenserStatus = "EnTaller"
+	DispenserBaja       DispenserStatus = "Baja"
+
+	UbicacionCuenta   DispenserLocationType = "Cuenta"
+	UbicacionDeposito DispenserLocationType = "Deposito"
+	UbicacionCamion   DispenserLocationType = "Camion"
+	UbicacionTaller   DispenserLocationType = "Taller"
+)
+
+// Dispenser es el registro de un equipo por número de serie con su estado y ubicación actual.
+// LocationID identifica la ubicación según LocationType: nro_cta, depósito, reparto/camión o taller.
+type Dispenser struct {
+	ID           int                   `gorm:"primaryKey" json:"id"`
+	Serial       string                `gorm:"type:varchar(30);uniqueIndex;not null" json:"serial"`
+	Tipo         TipoDispenser         `gorm:"type:varchar(1)" json:"tipo,omitempty"`
+	Model        string                `gorm:"type:varchar(100)" json:"model,omitempty"`
+	Status       DispenserStatus       `gorm:"type:varchar(20);not null;index" json:"status"`
+	LocationType DispenserLocationType `gorm:"type:varchar(20);not null" json:"location_type"`
+	LocationID   string                `gorm:"type:varchar(50);not null" json:"location_id"`
+	CreatedAt    time.Time             `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time             `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// DispenserMovement registra cada cambio de ubicación o estado de un dispenser. Operation es la
+// operación que lo originó (installation, retirement, replacement, service).
+type DispenserMovement struct {
+	ID               int                   `gorm:"primaryKey" json:"id"`
+	DispenserID      int                   `gorm:"not null;index" json:"dispenser_id"`
+	Serial           string                `gorm:"type:varchar(30);not null;index" json:"serial"`
+	Operation        string                `gorm:"type:varchar(20);not null" json:"operation"`
+	Status           DispenserStatus       `gorm:"type:varchar(20);not null" json:"status"` // Estado después del movimiento
+	FromLocationType DispenserLocationType `gorm:"type:varchar(20)" json:"from_location_type,omitempty"`
+	FromLocationID   string                `gorm:"type:varchar(50)" json:"from_location_id,omitempty"`
+	ToLocationType   DispenserLocationType `gorm:"type:varchar(20);not null" json:"to_location_type"`
+	ToLocationID     string                `gorm:"type:varchar(50);not null" json:"to_location_id"`
+	DeliveryID       *int                  `gorm:"index" json:"delivery_id,omitempty"`
+	StaffID          *int                  `json:"staff_id,omitempty"`
+	OccurredAt       time.Time             `gorm:"not null" json:"occurred_at"`
+	CreatedAt        time.Time             `gorm:"autoCreateTime" json:"created_at"`
+}
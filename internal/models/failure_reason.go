@@ -0,0 +1,20 @@
+package models
+
+type FailurePolicy string
+
+const (
+	// PolicyFollowUp crea automáticamente una nueva entrega Pendiente
+	PolicyFollowUp FailurePolicy = "FOLLOW_UP"
+	// PolicyContactCenter marca la entrega para que contact center contacte al cliente
+	PolicyContactCenter FailurePolicy = "CONTACT_CENTER"
+)
+
+// FailureReason es una entrada del catálogo de motivos de visita fallida.
+// El catálogo se administra en la tabla failure_reasons (ver migración 015).
+type FailureReason struct {
+	Code         string        `gorm:"primaryKey;type:varchar(50)" json:"code"`
+	Description  string        `gorm:"type:varchar(200);not null" json:"description"`
+	Policy       FailurePolicy `gorm:"type:varchar(20);not null" json:"policy"`
+	FollowUpDays int           `gorm:"not null;default:1" json:"follow_up_days"`
+	Active       bool          `gorm:"not null;default:true" json:"active"`
+}
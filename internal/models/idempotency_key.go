@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+type IdempotencyStatus string
+
+const (
+	// IdempotencyInProgress la primera solicitud con la clave todavía se está procesando
+	IdempotencyInProgress IdempotencyStatus = "in_progress"
+	// IdempotencyCompleted la respuesta quedó guardada y se reproduce en los reintentos
+	IdempotencyCompleted IdempotencyStatus = "completed"
+)
+
+// IdempotencyKey guarda la respuesta de una solicitud POST/PUT/PATCH enviada con el header
+// Idempotency-Key. La clave es única por ruta; request_hash (SHA-256 del body) detecta que se
+// reutilice la misma clave con otro body.
+type IdempotencyKey struct {
+	ID           int               `gorm:"primaryKey" json:"id"`
+	Key          string            `gorm:"type:varchar(255);not null;uniqueIndex:idx_idempotency_keys_key_route" json:"key"`
+	Route        string            `gorm:"type:varchar(255);not null;uniqueIndex:idx_idempotency_keys_key_route" json:"route"`
+	RequestHash  string            `gorm:"type:varchar(64);not null" json:"request_hash"`
+	Status       IdempotencyStatus `gorm:"type:varchar(20);not null" json:"status"`
+	StatusCode   int               `json:"status_code,omitempty"`
+	ContentType  string            `gorm:"type:varchar(100)" json:"content_type,omitempty"`
+	ResponseBody []byte            `gorm:"type:bytea" json:"-"`
+	CreatedAt    time.Time         `gorm:"autoCreateTime" json:"created_at"`
+	ExpiresAt    time.Time         `gorm:"not null;index" json:"expires_at"`
+}
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type TipoDispenser string
 
@@ -10,10 +14,11 @@ const (
 )
 
 type ItemDispenser struct {
-	ID         int           `gorm:"primaryKey" json:"id,omitempty"`
-	Tipo       TipoDispenser `gorm:"not null" json:"tipo" binding:"required,oneof=P M"`
-	Cantidad   uint          `gorm:"not null" json:"cantidad" binding:"required,min=1"`
-	DeliveryID int           `gorm:"not null" json:"delivery_id"`
-	CreatedAt  time.Time     `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt  time.Time     `gorm:"autoUpdateTime" json:"updated_at"`
+	ID         int            `gorm:"primaryKey" json:"id,omitempty"`
+	Tipo       TipoDispenser  `gorm:"not null" json:"tipo" binding:"required,oneof=P M"`
+	Cantidad   uint           `gorm:"not null" json:"cantidad" binding:"required,min=1"`
+	DeliveryID int            `gorm:"not null" json:"delivery_id"`
+	CreatedAt  time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
 }
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// MaintenancePlan es el service preventivo (sanitización) periódico de una cuenta o de un dispenser
+// instalado en ella. Si Serial está vacío el plan cubre todos los dispensers de la cuenta.
+type MaintenancePlan struct {
+	ID             int        `gorm:"primaryKey" json:"id"`
+	NroCta         string     `gorm:"type:varchar(50);not null;uniqueIndex:idx_maintenance_plan_target" json:"nro_cta"`
+	Serial         string     `gorm:"type:varchar(100);not null;default:'';uniqueIndex:idx_maintenance_plan_target" json:"serial,omitempty"`
+	IntervalDays   int        `gorm:"not null" json:"interval_days"`
+	Active         bool       `gorm:"not null;default:true" json:"active"`
+	LastServiceAt  *time.Time `json:"last_service_at,omitempty"`  // Último service o instalación completados
+	NextDueAt      *time.Time `json:"next_due_at,omitempty"`      // Vencimiento calculado en la última corrida del scheduler
+	LastDeliveryID *int       `json:"last_delivery_id,omitempty"` // Última entrega de Service generada por el plan
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
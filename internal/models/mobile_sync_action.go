@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+type SyncActionType string
+type SyncActionStatus string
+
+const (
+	SyncValidateToken    SyncActionType = "validate_token"
+	SyncCompleteDelivery SyncActionType = "complete_delivery"
+	SyncFailDelivery     SyncActionType = "fail_delivery"
+
+	// SyncPending la acción se está aplicando; se registra antes de aplicarla para que dos
+	// sincronizaciones con la misma acción no la apliquen las dos
+	SyncPending SyncActionStatus = "pending"
+	// SyncApplied la acción se aplicó
+	SyncApplied SyncActionStatus = "applied"
+	// SyncConflict la entrega cambió en el servidor y la acción ya no se puede aplicar
+	// (por ejemplo, contact center la canceló mientras el repartidor estaba sin señal)
+	SyncConflict SyncActionStatus = "conflict"
+	// SyncRejected la acción es inválida y reenviarla no cambiará el resultado
+	SyncRejected SyncActionStatus = "rejected"
+	// SyncError error transitorio del servidor; no se registra para que el cliente la reintente
+	SyncError SyncActionStatus = "error"
+	// SyncDuplicate la acción ya se había procesado en una sincronización anterior
+	SyncDuplicate SyncActionStatus = "duplicate"
+)
+
+// MobileSyncAction registra cada acción offline procesada por POST /mobile/sync.
+// El client_action_id (UUID generado en el dispositivo) es único dentro de Scope, el dispositivo
+// o, sin device_id, el personal logueado, y hace idempotente el reenvío de un lote: una acción ya
+// registrada devuelve el resultado guardado sin volver a aplicarse.
+type MobileSyncAction struct {
+	ID              int              `gorm:"primaryKey" json:"id"`
+	Scope           string           `gorm:"type:varchar(120);not null;default:'';uniqueIndex:idx_mobile_sync_actions_scope_action" json:"-"`
+	ClientActionID  string           `gorm:"type:varchar(36);not null;uniqueIndex:idx_mobile_sync_actions_scope_action" json:"client_action_id"`
+	DeviceID        string           `gorm:"type:varchar(100)" json:"device_id,omitempty"`
+	ActionType      SyncActionType   `gorm:"type:varchar(30);not null" json:"action_type"`
+	DeliveryID      *int             `gorm:"index" json:"delivery_id,omitempty"`
+	Status          SyncActionStatus `gorm:"type:varchar(20);not null" json:"status"`
+	Error           string           `gorm:"type:text" json:"error,omitempty"`
+	Result          *string          `gorm:"type:jsonb" json:"result,omitempty"`
+	ClientTimestamp time.Time        `gorm:"not null" json:"client_timestamp"`
+	CreatedAt       time.Time        `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// RouteCapacity es el máximo de entregas que un reparto puede tomar en un día de la semana.
+// Con TipoEntrega vacío el límite es para el total de entregas del día; con un tipo, solo para las
+// de ese tipo. Un reparto sin reglas para el día no tiene límite.
+type RouteCapacity struct {
+	ID            int          `gorm:"primaryKey" json:"id"`
+	NroRto        string       `gorm:"type:varchar(50);not null;uniqueIndex:idx_route_capacity_rule" json:"nro_rto"`
+	Weekday       time.Weekday `gorm:"not null;uniqueIndex:idx_route_capacity_rule" json:"weekday" swaggertype:"integer"` // 0 = domingo ... 6 = sábado
+	TipoEntrega   TipoEntrega  `gorm:"type:varchar(20);not null;default:'';uniqueIndex:idx_route_capacity_rule" json:"tipo_entrega,omitempty"`
+	MaxDeliveries int          `gorm:"not null" json:"max_deliveries"`
+	CreatedAt     time.Time    `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time    `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// EstadosOcupanCapacidad son los estados en los que una entrega ocupa lugar en el día del reparto:
+// las abiertas y las ya completadas. Canceladas y fallidas liberan el lugar.
+var EstadosOcupanCapacidad = append(append([]EstadoEntrega{}, EstadosAbiertos...), Completado)
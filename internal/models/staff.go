@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Staff es un repartidor o técnico que opera la app móvil. Su ID queda registrado como
+// completed_by en las entregas y órdenes de trabajo que cierra.
+type Staff struct {
+	ID                int          `gorm:"primaryKey" json:"id"`
+	Legajo            string       `gorm:"type:varchar(20);not null;uniqueIndex" json:"legajo"`
+	Name              string       `gorm:"type:varchar(200);not null" json:"name"`
+	Role              EntregadoPor `gorm:"type:varchar(20);not null" json:"role"`
+	NroRto            string       `gorm:"type:varchar(50);index" json:"nro_rto,omitempty"` // Reparto asignado
+	PinHash           string       `gorm:"type:varchar(100)" json:"-"`
+	PinFailedAttempts int          `gorm:"not null;default:0" json:"-"`
+	PinLockedAt       *time.Time   `json:"pin_locked_at,omitempty"` // Se levanta al cargar un PIN nuevo
+	Active            bool         `gorm:"not null;default:true" json:"active"`
+	CreatedAt         time.Time    `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time    `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName evita el plural "staffs" que generaría GORM
+func (Staff) TableName() string {
+	return "staff"
+}
+
+// StaffSession es el login de un repartidor o técnico en un dispositivo. El token solo se
+// guarda hasheado (SHA-256); el dispositivo lo envía en X-Staff-Session.
+type StaffSession struct {
+	ID        int       `gorm:"primaryKey" json:"id"`
+	TokenHash string    `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	StaffID   int       `gorm:"not null;index" json:"staff_id"`
+	DeviceID  string    `gorm:"type:varchar(100)" json:"device_id,omitempty"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
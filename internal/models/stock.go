@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+type StockMovementKind string
+type EstadoReserva string
+
+const (
+	StockIngreso    StockMovementKind = "Ingreso"    // Entrada de equipos; puede tener fecha futura (recepción programada)
+	StockAjuste     StockMovementKind = "Ajuste"     // Corrección de inventario, positiva o negativa
+	StockReserva    StockMovementKind = "Reserva"    // Unidades apartadas para una entrega abierta
+	StockLiberacion StockMovementKind = "Liberacion" // Reserva devuelta al disponible (cancelación, fallo, cambio)
+	StockConsumo    StockMovementKind = "Consumo"    // Unidades instaladas al completar una entrega
+
+	ReservaActiva    EstadoReserva = "Reservado"
+	ReservaLiberada  EstadoReserva = "Liberado"
+	ReservaConsumida EstadoReserva = "Consumido"
+)
+
+// OnHandKinds son los movimientos que cambian la existencia física del depósito; reservas y
+// liberaciones solo cambian lo comprometido
+var OnHandKinds = []StockMovementKind{StockIngreso, StockAjuste, StockConsumo}
+
+// ConsumesStock indica si el tipo de entrega instala equipos del depósito
+func (t TipoEntrega) ConsumesStock() bool {
+	return t == Instalacion || t == Recambio
+}
+
+// Warehouse es un depósito con stock de dispensers. Abastece a los repartos de Routes; el depósito
+// IsDefault abastece a los repartos que no figuran en ningún otro.
+type Warehouse struct {
+	ID        int         `gorm:"primaryKey" json:"id"`
+	Code      string      `gorm:"type:varchar(20);uniqueIndex;not null" json:"code"`
+	Name      string      `gorm:"type:varchar(100);not null" json:"name"`
+	Routes    StringArray `gorm:"type:jsonb" json:"routes,omitempty"`
+	IsDefault bool        `gorm:"not null;default:false" json:"is_default"`
+	Active    bool        `gorm:"not null;default:true" json:"active"`
+	CreatedAt time.Time   `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time   `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// StockMovement es un asiento del libro de stock de un depósito. Quantity lleva signo: Ingreso y
+// Ajuste positivo suman existencia, Consumo la resta; Reserva y Liberacion mueven lo comprometido.
+// EffectiveDate es el día desde el que el movimiento cuenta para la existencia.
+type StockMovement struct {
+	ID            int               `gorm:"primaryKey" json:"id"`
+	WarehouseID   int               `gorm:"not null;index" json:"warehouse_id"`
+	Tipo          TipoDispenser     `gorm:"type:varchar(1);not null" json:"tipo"`
+	Kind          StockMovementKind `gorm:"type:varchar(20);not null" json:"kind"`
+	Quantity      int               `gorm:"not null" json:"quantity"`
+	EffectiveDate time.Time         `gorm:"type:date;not null" json:"effective_date"`
+	DeliveryID    *int              `gorm:"index" json:"delivery_id,omitempty"`
+	Reason        string            `gorm:"type:varchar(255)" json:"reason,omitempty"`
+	CreatedBy     string            `gorm:"type:varchar(100)" json:"created_by,omitempty"`
+	CreatedAt     time.Time         `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// StockReservation son las unidades de un tipo apartadas en un depósito para una entrega
+type StockReservation struct {
+	ID          int           `gorm:"primaryKey" json:"id"`
+	DeliveryID  int           `gorm:"not null;uniqueIndex:idx_stock_reservation_delivery_tipo" json:"delivery_id"`
+	Tipo        TipoDispenser `gorm:"type:varchar(1);not null;uniqueIndex:idx_stock_reservation_delivery_tipo" json:"tipo"`
+	WarehouseID int           `gorm:"not null;index" json:"warehouse_id"`
+	Cantidad    uint          `gorm:"not null" json:"cantidad"`
+	FechaAccion time.Time     `gorm:"type:date;not null" json:"fecha_accion"`
+	Status      EstadoReserva `gorm:"type:varchar(20);not null;index" json:"status"`
+	CreatedAt   time.Time     `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time     `gorm:"autoUpdateTime" json:"updated_at"`
+}
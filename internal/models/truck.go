@@ -2,11 +2,12 @@ package models
 
 import "time"
 
+// Truck es un camión de reparto. Capacity es la cantidad máxima de dispensers que carga por día;
+// las entregas se le asignan por fecha con Delivery.TruckID.
 type Truck struct {
-	ID        int        `gorm:"primaryKey" json:"id,omitempty"`
-	NroTruck  string     `gorm:"not null;unique" json:"nro_truck" binding:"required,min=1,max=20"`
-	Deliverys []Delivery `gorm:"foreignKey:TruckID" json:"deliverys"`
-	Capacity  int        `gorm:"not null" json:"capacity" binding:"required,gt=0"`
-	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+	ID        int       `gorm:"primaryKey" json:"id,omitempty"`
+	NroTruck  string    `gorm:"not null;unique" json:"nro_truck" binding:"required,min=1,max=20"`
+	Capacity  int       `gorm:"not null" json:"capacity" binding:"required,gt=0"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 }
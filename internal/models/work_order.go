@@ -13,6 +13,7 @@ type WorkOrder struct {
 	Address     string    `gorm:"not null" json:"address"`
 	Localidad   string    `gorm:"not null" json:"localidad"`
 	TipoAccion  string    `gorm:"not null" json:"tipo_accion"`
+	CompletedBy *int      `gorm:"index" json:"completed_by,omitempty"` // ID del Staff que realizó el trabajo
 	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 }
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"GoFrioCalor/internal/transport"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterDeliveryProofRoutes(router *gin.RouterGroup, handler *transport.DeliveryProofHandler) {
+	router.POST("/mobile/deliveries/:id/proof", handler.UploadProof)
+	router.GET("/deliveries/:id/attachments", handler.ListAttachments)
+	router.GET("/deliveries/:id/attachments/:attachmentId", handler.GetAttachment)
+}
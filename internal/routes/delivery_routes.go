@@ -11,7 +11,21 @@ func RegisterDeliveryRoutes(router *gin.RouterGroup, handler *transport.Delivery
 	{
 		deliveries.POST("", handler.CreateDelivery)
 		deliveries.POST("/infobip", handler.CreateDeliveryFromInfobip)
+		deliveries.POST("/import", handler.ImportDeliveries)
 		deliveries.DELETE("/:id", handler.DeleteDelivery)
+		deliveries.POST("/:id/restore", handler.RestoreDelivery)
+		deliveries.GET("/deleted", handler.GetDeletedDeliveries)
+		deliveries.GET("/export", handler.ExportDeliveries)
+		deliveries.GET("/follow-up", handler.GetFollowUpDeliveries)
+		deliveries.PATCH("/:id", handler.PatchDelivery)
+		deliveries.PATCH("/:id/status", handler.ChangeDeliveryStatus)
+		deliveries.PATCH("/:id/reschedule", handler.RescheduleDelivery)
+		deliveries.POST("/:id/token/regenerate", handler.RegenerateDeliveryToken)
+		deliveries.PUT("/:id", handler.UpdateDelivery)
+		deliveries.PATCH("/:id/cancel", handler.CancelDelivery)
+		// Los historiales incluyen quién hizo cada cambio
+		deliveries.GET("/:id/status-history", handler.GetDeliveryStatusHistory)
+		deliveries.GET("/:id/reschedules", handler.GetDeliveryReschedules)
 	}
 }
 
@@ -22,9 +36,8 @@ func RegisterPublicDeliveryGetRoutes(router *gin.RouterGroup, handler *transport
 		deliveries.GET("/by-rto", handler.GetDeliveriesByRto)
 		deliveries.GET("/by-cta", handler.GetDeliveriesByNroCta)
 		deliveries.GET("/infobip/pending", handler.GetPendingByNroCta)
+		deliveries.GET("/availability", handler.GetAvailability)
 		deliveries.GET("/:id", handler.GetDeliveryByID)
-		deliveries.PUT("/:id", handler.UpdateDelivery)
-		deliveries.PATCH("/:id/cancel", handler.CancelDelivery)
 	}
 }
 
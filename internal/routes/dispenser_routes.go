@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"GoFrioCalor/internal/transport"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterDispenserRoutes(router *gin.RouterGroup, handler *transport.DispenserHandler) {
+	dispensers := router.Group("/dispensers")
+	{
+		dispensers.GET("/:serial", handler.GetDispenser)
+		dispensers.GET("/:serial/history", handler.GetDispenserHistory)
+	}
+	router.GET("/accounts/:nro_cta/equipment", handler.GetAccountEquipment)
+}
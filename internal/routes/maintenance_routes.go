@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"GoFrioCalor/internal/transport"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterMaintenanceRoutes(router *gin.RouterGroup, handler *transport.MaintenanceHandler) {
+	plans := router.Group("/maintenance-plans")
+	{
+		plans.GET("", handler.ListMaintenancePlans)
+		plans.POST("", handler.CreateMaintenancePlan)
+		plans.GET("/:id", handler.GetMaintenancePlan)
+		plans.PUT("/:id", handler.UpdateMaintenancePlan)
+		plans.DELETE("/:id", handler.DeleteMaintenancePlan)
+	}
+}
@@ -14,5 +14,13 @@ func RegisterMobileRoutes(rg *gin.RouterGroup, handler *transport.MobileDelivery
 		mobile.POST("/complete-delivery", handler.CompleteDelivery)
 
 		mobile.GET("/deliveries/search", handler.SearchDeliveries)
+
+		mobile.GET("/route-manifest", handler.RouteManifest)
+
+		mobile.POST("/deliveries/:id/fail", handler.FailDelivery)
+
+		mobile.GET("/failure-reasons", handler.ListFailureReasons)
+
+		mobile.POST("/sync", handler.Sync)
 	}
 }
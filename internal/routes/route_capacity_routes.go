@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"GoFrioCalor/internal/transport"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRouteCapacityRoutes(router *gin.RouterGroup, handler *transport.RouteCapacityHandler) {
+	capacities := router.Group("/route-capacities")
+	{
+		capacities.GET("", handler.ListRouteCapacities)
+		capacities.POST("", handler.CreateRouteCapacity)
+		capacities.PUT("/:id", handler.UpdateRouteCapacity)
+		capacities.DELETE("/:id", handler.DeleteRouteCapacity)
+	}
+}
@@ -3,6 +3,7 @@ package routes
 import (
 	"GoFrioCalor/config"
 	"GoFrioCalor/internal/middleware"
+	"GoFrioCalor/internal/store"
 	"GoFrioCalor/internal/transport"
 	"time"
 
@@ -14,7 +15,9 @@ import (
 func SetupRouter(deliveryHandler *transport.DeliveryHandler,
 	workOrderHandler *transport.WorkOrderHandler, termsSessionHandler *transport.TermsSessionHandler,
 	deliveryWithTermsHandler *transport.DeliveryWithTermsHandler, mobileDeliveryHandler *transport.MobileDeliveryHandler,
-	auditHandler *transport.AuditHandler, cfg *config.Config) *gin.Engine {
+	auditHandler *transport.AuditHandler, deliveryProofHandler *transport.DeliveryProofHandler, staffHandler *transport.StaffHandler,
+	dispenserHandler *transport.DispenserHandler, maintenanceHandler *transport.MaintenanceHandler,
+	tallerHandler *transport.TallerHandler, stockHandler *transport.StockHandler, truckHandler *transport.TruckHandler, routeCapacityHandler *transport.RouteCapacityHandler, staffResolver middleware.StaffResolver, idempotencyStore store.IdempotencyStore, cfg *config.Config) *gin.Engine {
 	router := gin.New()
 	router.RedirectTrailingSlash = false
 	router.RedirectFixedPath = false
@@ -25,8 +28,8 @@ func SetupRouter(deliveryHandler *transport.DeliveryHandler,
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     cfg.GetCORSOrigins(),
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "x-api-key", "X-Request-ID"},
-		ExposeHeaders:    []string{"Content-Length", "X-Request-ID"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "x-api-key", "X-Request-ID", "If-Match", "Idempotency-Key", "X-Staff-Session"},
+		ExposeHeaders:    []string{"Content-Length", "X-Request-ID", "ETag", "Idempotent-Replayed"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -47,6 +50,11 @@ func SetupRouter(deliveryHandler *transport.DeliveryHandler,
 
 	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
+	// Idempotency-Key en POST/PUT/PATCH. En los grupos autenticados se aplica después de la
+	// autenticación y la clave queda asociada al llamador; en las rutas públicas (publicAPI y el
+	// alta de contact center) no hay llamador y la clave se comparte entre clientes
+	idempotency := middleware.Idempotency(idempotencyStore, time.Duration(cfg.IdempotencyTTLHours)*time.Hour)
+
 	authHandler := transport.NewAuthHandler(cfg.AuthServiceURL)
 	RegisterAuthRoutes(router, authHandler)
 
@@ -55,16 +63,30 @@ func SetupRouter(deliveryHandler *transport.DeliveryHandler,
 	// y no sean capturadas por rutas con parámetros dinámicos como /:id
 	router.GET("/dispenser-operations/api/v1/deliveries/taller-prep", deliveryHandler.GetTallerPrep)
 	router.GET("/dispenser-operations/api/v1/deliveries/contact-center/token", deliveryHandler.GetTokenByFechaAndCta)
-	router.POST("/dispenser-operations/api/v1/deliveries/contact-center", deliveryHandler.CreateDeliveryFromContactCenter)
+	router.POST("/dispenser-operations/api/v1/deliveries/contact-center", idempotency, deliveryHandler.CreateDeliveryFromContactCenter)
 
 	// Rutas públicas de términos y deliveries (GET sin autenticación)
 	publicAPI := router.Group("/dispenser-operations/api/v1")
+	publicAPI.Use(idempotency)
 	RegisterPublicDeliveryGetRoutes(publicAPI, deliveryHandler)
 	RegisterPublicTermsRoutes(publicAPI, termsSessionHandler)
 
 	// ===== RUTAS PROTEGIDAS (CON AUTENTICACIÓN) =====
+	if staffHandler != nil {
+		// Sin Idempotency-Key: la respuesta del login lleva el token de sesión en claro y no
+		// debe guardarse; reintentar el login solo abre otra sesión
+		staffSession := router.Group("/dispenser-operations/api/v1")
+		staffSession.Use(middleware.AuthMiddleware(cfg.AuthServiceURL))
+		RegisterStaffSessionRoutes(staffSession, staffHandler)
+	}
+
 	api := router.Group("/dispenser-operations/api/v1")
 	api.Use(middleware.AuthMiddleware(cfg.AuthServiceURL))
+	if staffResolver != nil {
+		// Identifica al repartidor o técnico por la sesión del dispositivo o el legajo del token
+		api.Use(middleware.StaffIdentity(staffResolver))
+	}
+	api.Use(idempotency)
 	{
 		RegisterDeliveryRoutes(api, deliveryHandler)
 		RegisterWorkOrderRoutes(api, workOrderHandler)
@@ -78,6 +100,38 @@ func SetupRouter(deliveryHandler *transport.DeliveryHandler,
 		if auditHandler != nil {
 			RegisterAuditRoutes(api, auditHandler)
 		}
+
+		if deliveryProofHandler != nil {
+			RegisterDeliveryProofRoutes(api, deliveryProofHandler)
+		}
+
+		if staffHandler != nil {
+			RegisterStaffRoutes(api, staffHandler)
+		}
+
+		if dispenserHandler != nil {
+			RegisterDispenserRoutes(api, dispenserHandler)
+		}
+
+		if maintenanceHandler != nil {
+			RegisterMaintenanceRoutes(api, maintenanceHandler)
+		}
+
+		if tallerHandler != nil {
+			RegisterTallerRoutes(api, tallerHandler)
+		}
+
+		if stockHandler != nil {
+			RegisterStockRoutes(api, stockHandler)
+		}
+
+		if truckHandler != nil {
+			RegisterTruckRoutes(api, truckHandler)
+		}
+
+		if routeCapacityHandler != nil {
+			RegisterRouteCapacityRoutes(api, routeCapacityHandler)
+		}
 	}
 	return router
 }
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"GoFrioCalor/internal/transport"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterStaffRoutes(router *gin.RouterGroup, handler *transport.StaffHandler) {
+	staff := router.Group("/staff")
+	{
+		staff.GET("", handler.ListStaff)
+		staff.POST("", handler.CreateStaff)
+		staff.GET("/:id", handler.GetStaff)
+		staff.PUT("/:id", handler.UpdateStaff)
+		staff.GET("/:id/deliveries", handler.GetStaffDeliveries)
+	}
+}
+
+// RegisterStaffSessionRoutes registra el login del personal en el dispositivo. Va en un grupo sin
+// StaffIdentity para que una sesión vencida no impida iniciar una nueva.
+func RegisterStaffSessionRoutes(router *gin.RouterGroup, handler *transport.StaffHandler) {
+	router.POST("/mobile/login", handler.Login)
+	router.POST("/mobile/logout", handler.Logout)
+}
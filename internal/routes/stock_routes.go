@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"GoFrioCalor/internal/transport"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterStockRoutes(router *gin.RouterGroup, handler *transport.StockHandler) {
+	warehouses := router.Group("/warehouses")
+	{
+		warehouses.GET("", handler.ListWarehouses)
+		warehouses.POST("", handler.CreateWarehouse)
+		warehouses.PUT("/:id", handler.UpdateWarehouse)
+		warehouses.GET("/:id/stock", handler.GetStock)
+		warehouses.GET("/:id/movements", handler.GetStockLedger)
+		warehouses.POST("/:id/movements", handler.RecordStockMovement)
+	}
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"GoFrioCalor/internal/transport"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterTallerRoutes(router *gin.RouterGroup, handler *transport.TallerHandler) {
+	taller := router.Group("/taller")
+	{
+		taller.GET("/board", handler.GetBoard)
+		taller.PATCH("/deliveries/:id/prepare", handler.PrepareDelivery)
+	}
+}
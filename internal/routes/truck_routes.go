@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"GoFrioCalor/internal/transport"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterTruckRoutes(router *gin.RouterGroup, handler *transport.TruckHandler) {
+	trucks := router.Group("/trucks")
+	{
+		trucks.GET("", handler.GetAllTrucks)
+		trucks.POST("", handler.CreateTruck)
+		trucks.GET("/:id", handler.GetTruckByID)
+		trucks.PUT("/:id", handler.UpdateTruck)
+		trucks.DELETE("/:id", handler.DeleteTruck)
+		trucks.POST("/:id/assign", handler.AssignDeliveries)
+		trucks.POST("/:id/unassign", handler.UnassignDeliveries)
+		trucks.GET("/:id/load-sheet", handler.GetLoadSheet)
+	}
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/dto"
+	"GoFrioCalor/internal/models"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AccountEquipment devuelve los dispensers instalados en la cuenta. La instalación y el último
+// servicio salen de los movimientos registrados al completar entregas, y cada uno se vincula con
+// la orden de trabajo de esa entrega.
+func (s *dispenserService) AccountEquipment(ctx context.Context, nroCta string) (*dto.AccountEquipmentResponse, error) {
+	nroCta = strings.TrimSpace(nroCta)
+	dispensers, err := s.store.FindInstalledAtAccount(ctx, nroCta)
+	if err != nil {
+		return nil, err
+	}
+	movements, err := s.store.FindAccountMovements(ctx, nroCta)
+	if err != nil {
+		return nil, err
+	}
+
+	workOrders := make(map[int]string)
+	if s.workOrderStore != nil {
+		deliveryIDs := make([]int, 0, len(movements))
+		for _, movement := range movements {
+			if movement.DeliveryID != nil {
+				deliveryIDs = append(deliveryIDs, *movement.DeliveryID)
+			}
+		}
+		orders, err := s.workOrderStore.FindByDeliveryIDs(ctx, deliveryIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, order := range orders {
+			workOrders[order.DeliveryID] = order.OrderNumber
+		}
+	}
+
+	equipment := buildAccountEquipment(nroCta, dispensers, movements, workOrders)
+	return &dto.AccountEquipmentResponse{
+		NroCta:    nroCta,
+		Total:     len(equipment),
+		Equipment: equipment,
+	}, nil
+}
+
+// buildAccountEquipment arma la vista de equipos instalados. Los movimientos vienen ordenados del
+// más antiguo al más reciente: cada llegada a la cuenta desde otra ubicación es una instalación y
+// reinicia el último servicio, de modo que solo cuenta la estadía actual del equipo.
+func buildAccountEquipment(nroCta string, dispensers []models.Dispenser, movements []models.DispenserMovement, workOrders map[int]string) []dto.AccountEquipmentItem {
+	items := make(map[int]*dto.AccountEquipmentItem, len(dispensers))
+	equipment := make([]dto.AccountEquipmentItem, len(dispensers))
+	for i, dispenser := range dispensers {
+		equipment[i] = dto.AccountEquipmentItem{
+			Serial: dispenser.Serial,
+			Tipo:   string(dispenser.Tipo),
+			Model:  dispenser.Model,
+		}
+		items[dispenser.ID] = &equipment[i]
+	}
+
+	for _, movement := range movements {
+		item, ok := items[movement.DispenserID]
+		if !ok {
+			continue
+		}
+		arrived := movement.FromLocationType != models.UbicacionCuenta || movement.FromLocationID != nroCta
+		if arrived {
+			item.InstalledAt = movement.OccurredAt.Format(time.RFC3339)
+			item.InstallDeliveryID = movement.DeliveryID
+			item.InstallWorkOrder = workOrderFor(workOrders, movement.DeliveryID)
+			item.LastServiceAt = ""
+			item.LastServiceDeliveryID = nil
+			item.LastServiceWorkOrder = ""
+		}
+		if movement.Operation == "service" {
+			item.LastServiceAt = movement.OccurredAt.Format(time.RFC3339)
+			item.LastServiceDeliveryID = movement.DeliveryID
+			item.LastServiceWorkOrder = workOrderFor(workOrders, movement.DeliveryID)
+		}
+	}
+	return equipment
+}
+
+func workOrderFor(workOrders map[int]string, deliveryID *int) string {
+	if deliveryID == nil {
+		return ""
+	}
+	return workOrders[*deliveryID]
+}
+
+// requiresInstalledEquipment indica si el tipo de entrega opera sobre equipos ya instalados
+func requiresInstalledEquipment(tipo models.TipoEntrega) bool {
+	return tipo == models.Retiro || tipo == models.Service
+}
+
+// checkAccountEquipment rechaza retiros y services para cuentas sin dispensers instalados
+func (s *deliveryService) checkAccountEquipment(ctx context.Context, nroCta string, tipo models.TipoEntrega) error {
+	if s.dispenserStore == nil || !requiresInstalledEquipment(tipo) {
+		return nil
+	}
+	count, err := s.dispenserStore.CountInstalledAtAccount(ctx, nroCta)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf(constants.ErrAccountWithoutEquipment, nroCta, tipo)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"GoFrioCalor/internal/models"
+	"testing"
+	"time"
+)
+
+func TestBuildAccountEquipment(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 5, d, 10, 0, 0, 0, time.UTC) }
+	id := func(v int) *int { return &v }
+	dispensers := []models.Dispenser{
+		{ID: 1, Serial: "DSP-000001", Tipo: models.TipoDispenserPie},
+		{ID: 2, Serial: "DSP-000002", Tipo: models.TipoDispenserMesada},
+	}
+	movements := []models.DispenserMovement{
+		// DSP-000001: instalado, atendido, retirado y vuelto a instalar
+		{DispenserID: 1, Operation: "installation", ToLocationType: models.UbicacionCuenta, ToLocationID: "100", DeliveryID: id(10), OccurredAt: day(1)},
+		{DispenserID: 1, Operation: "service", FromLocationType: models.UbicacionCuenta, FromLocationID: "100", ToLocationType: models.UbicacionCuenta, ToLocationID: "100", DeliveryID: id(11), OccurredAt: day(5)},
+		{DispenserID: 1, Operation: "replacement", FromLocationType: models.UbicacionTaller, FromLocationID: "central", ToLocationType: models.UbicacionCuenta, ToLocationID: "100", DeliveryID: id(12), OccurredAt: day(9)},
+		// DSP-000002: instalado y atendido
+		{DispenserID: 2, Operation: "installation", FromLocationType: models.UbicacionDeposito, FromLocationID: "D1", ToLocationType: models.UbicacionCuenta, ToLocationID: "100", DeliveryID: id(10), OccurredAt: day(1)},
+		{DispenserID: 2, Operation: "service", FromLocationType: models.UbicacionCuenta, FromLocationID: "100", ToLocationType: models.UbicacionCuenta, ToLocationID: "100", DeliveryID: id(11), OccurredAt: day(5)},
+		// Movimiento de un equipo que ya no está en la cuenta
+		{DispenserID: 3, Operation: "installation", ToLocationType: models.UbicacionCuenta, ToLocationID: "100", DeliveryID: id(10), OccurredAt: day(1)},
+	}
+	workOrders := map[int]string{10: "OT-000010", 11: "OT-000011"}
+
+	equipment := buildAccountEquipment("100", dispensers, movements, workOrders)
+
+	if len(equipment) != 2 {
+		t.Fatalf("equipos = %+v, se esperaban 2", equipment)
+	}
+	reinstalled := equipment[0]
+	if reinstalled.InstalledAt != day(9).Format(time.RFC3339) || *reinstalled.InstallDeliveryID != 12 || reinstalled.InstallWorkOrder != "" {
+		t.Errorf("la instalación vigente de DSP-000001 es la del recambio: %+v", reinstalled)
+	}
+	if reinstalled.LastServiceAt != "" || reinstalled.LastServiceDeliveryID != nil {
+		t.Errorf("el servicio anterior a la reinstalación no cuenta: %+v", reinstalled)
+	}
+	serviced := equipment[1]
+	if serviced.InstalledAt != day(1).Format(time.RFC3339) || serviced.InstallWorkOrder != "OT-000010" {
+		t.Errorf("instalación de DSP-000002 = %+v", serviced)
+	}
+	if serviced.LastServiceAt != day(5).Format(time.RFC3339) || serviced.LastServiceWorkOrder != "OT-000011" {
+		t.Errorf("último servicio de DSP-000002 = %+v", serviced)
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BlobStorage guarda archivos binarios por clave. La implementación local escribe en disco;
+// otra implementación (S3, GCS) solo necesita respetar la misma interfaz.
+type BlobStorage interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type localBlobStorage struct {
+	baseDir string
+}
+
+// NewLocalBlobStorage crea un blob storage sobre el directorio indicado, creándolo si no existe
+func NewLocalBlobStorage(baseDir string) (BlobStorage, error) {
+	if baseDir == "" {
+		return nil, fmt.Errorf("directorio de blob storage no configurado")
+	}
+	absDir, err := filepath.Abs(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("directorio de blob storage inválido: %w", err)
+	}
+	if err := os.MkdirAll(absDir, 0o750); err != nil {
+		return nil, fmt.Errorf("error creando directorio de blob storage: %w", err)
+	}
+	return &localBlobStorage{baseDir: absDir}, nil
+}
+
+// path resuelve la clave dentro de baseDir y rechaza claves que intenten salir del directorio
+func (s *localBlobStorage) path(key string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("clave de blob inválida: %q", key)
+	}
+	return filepath.Join(s.baseDir, cleaned), nil
+}
+
+func (s *localBlobStorage) Put(ctx context.Context, key string, data []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("error creando directorio para %s: %w", key, err)
+	}
+	// Se escribe en un temporal y se renombra para no dejar archivos a medio escribir
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("error guardando %s: %w", key, err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("error guardando %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("error guardando %s: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("error guardando %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *localBlobStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo %s: %w", key, err)
+	}
+	return data, nil
+}
+
+func (s *localBlobStorage) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error eliminando %s: %w", key, err)
+	}
+	return nil
+}
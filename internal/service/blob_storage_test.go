@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLocalBlobStorage(t *testing.T) {
+	ctx := context.Background()
+	storage, err := NewLocalBlobStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalBlobStorage() error = %v", err)
+	}
+
+	key := "deliveries/7/signature-abc.png"
+	if err := storage.Put(ctx, key, []byte("firma")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	data, err := storage.Get(ctx, key)
+	if err != nil || string(data) != "firma" {
+		t.Fatalf("Get() = %q, %v", data, err)
+	}
+	if err := storage.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := storage.Get(ctx, key); err == nil {
+		t.Error("Get() después de Delete() debería fallar")
+	}
+	if err := storage.Delete(ctx, key); err != nil {
+		t.Errorf("Delete() de una clave inexistente no debería fallar: %v", err)
+	}
+
+	for _, invalid := range []string{"", "../fuera.png", "deliveries/../../fuera.png", "/etc/passwd"} {
+		if err := storage.Put(ctx, invalid, []byte("x")); err == nil {
+			t.Errorf("Put(%q) debería rechazar la clave", invalid)
+		}
+	}
+}
+
+func TestFitImage(t *testing.T) {
+	tests := []struct {
+		width, height, maxW, maxH float64
+		wantW, wantH              float64
+	}{
+		{width: 200, height: 100, maxW: 70, maxH: 30, wantW: 60, wantH: 30},
+		{width: 100, height: 20, maxW: 70, maxH: 30, wantW: 70, wantH: 14},
+		{width: 0, height: 0, maxW: 70, maxH: 30, wantW: 70, wantH: 30},
+	}
+	for _, tt := range tests {
+		w, h := fitImage(tt.width, tt.height, tt.maxW, tt.maxH)
+		if w != tt.wantW || h != tt.wantH {
+			t.Errorf("fitImage(%v, %v) = (%v, %v), want (%v, %v)", tt.width, tt.height, w, h, tt.wantW, tt.wantH)
+		}
+	}
+}
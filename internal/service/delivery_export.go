@@ -0,0 +1,150 @@
+package service
+
+import (
+	"GoFrioCalor/internal/store"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatXLSX = "xlsx"
+
+	// exportFlushEvery define cada cuántas filas se vacía el buffer del CSV hacia el cliente
+	exportFlushEvery = 500
+)
+
+var exportHeaders = []string{
+	"id", "nro_cta", "nombre", "email", "direccion", "localidad", "nro_rto",
+	"tipo_entrega", "entregado_por", "estado", "fecha_accion", "fecha_creacion",
+	"cantidad", "total_p", "total_m", "order_number", "validated_dispensers",
+}
+
+// ExportDeliveries escribe en w el reporte de entregas que cumplen el filtro en formato csv o xlsx.
+// Las filas se leen de la base con un cursor; el CSV se envía a medida que se genera y el XLSX
+// se arma con el stream writer de excelize, que mantiene acotado el uso de memoria.
+func (s *deliveryService) ExportDeliveries(ctx context.Context, filter store.DeliveryFilter, format string, w io.Writer) error {
+	switch format {
+	case ExportFormatCSV:
+		return s.exportCSV(ctx, filter, w)
+	case ExportFormatXLSX:
+		return s.exportXLSX(ctx, filter, w)
+	default:
+		return fmt.Errorf("formato de exportación inválido: %s (use csv o xlsx)", format)
+	}
+}
+
+func (s *deliveryService) exportCSV(ctx context.Context, filter store.DeliveryFilter, w io.Writer) error {
+	// BOM UTF-8 para que Excel respete los acentos al abrir el archivo
+	if _, err := io.WriteString(w, "\xef\xbb\xbf"); err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write(exportHeaders); err != nil {
+		return err
+	}
+
+	count := 0
+	err := s.store.StreamForExport(ctx, filter, func(row *store.DeliveryExportRow) error {
+		if err := writer.Write(exportRecord(row)); err != nil {
+			return err
+		}
+		count++
+		if count%exportFlushEvery == 0 {
+			writer.Flush()
+			return writer.Error()
+		}
+		return nil
+	})
+	writer.Flush()
+	if err != nil {
+		return err
+	}
+
+	log.Info().Int("rows", count).Str("format", ExportFormatCSV).Msg("Exportación de entregas completada")
+	return writer.Error()
+}
+
+func (s *deliveryService) exportXLSX(ctx context.Context, filter store.DeliveryFilter, w io.Writer) error {
+	workbook := excelize.NewFile()
+	defer workbook.Close()
+
+	const sheet = "Entregas"
+	if err := workbook.SetSheetName("Sheet1", sheet); err != nil {
+		return err
+	}
+	stream, err := workbook.NewStreamWriter(sheet)
+	if err != nil {
+		return fmt.Errorf("error creando planilla de exportación: %w", err)
+	}
+
+	header := make([]interface{}, len(exportHeaders))
+	for i, h := range exportHeaders {
+		header[i] = h
+	}
+	if err := stream.SetRow("A1", header); err != nil {
+		return err
+	}
+
+	count := 0
+	err = s.store.StreamForExport(ctx, filter, func(row *store.DeliveryExportRow) error {
+		record := exportRecord(row)
+		values := make([]interface{}, len(record))
+		for i, v := range record {
+			values[i] = v
+		}
+		// Columnas numéricas como número para que se puedan sumar en Excel
+		values[0] = row.ID
+		values[12] = row.Cantidad
+		values[13] = row.TotalP
+		values[14] = row.TotalM
+
+		count++
+		cell, err := excelize.CoordinatesToCellName(1, count+1)
+		if err != nil {
+			return err
+		}
+		return stream.SetRow(cell, values)
+	})
+	if err != nil {
+		return err
+	}
+	if err := stream.Flush(); err != nil {
+		return fmt.Errorf("error generando planilla de exportación: %w", err)
+	}
+	if _, err := workbook.WriteTo(w); err != nil {
+		return err
+	}
+
+	log.Info().Int("rows", count).Str("format", ExportFormatXLSX).Msg("Exportación de entregas completada")
+	return nil
+}
+
+func exportRecord(row *store.DeliveryExportRow) []string {
+	return []string{
+		strconv.Itoa(row.ID),
+		row.NroCta,
+		row.Name,
+		row.Email,
+		row.Address,
+		row.Locality,
+		row.NroRto,
+		string(row.TipoEntrega),
+		string(row.EntregadoPor),
+		string(row.Estado),
+		row.FechaAccion.Format("2006-01-02"),
+		row.CreatedAt.Format("2006-01-02 15:04:05"),
+		strconv.FormatUint(uint64(row.Cantidad), 10),
+		strconv.FormatUint(uint64(row.TotalP), 10),
+		strconv.FormatUint(uint64(row.TotalM), 10),
+		row.OrderNumber,
+		strings.Join(row.ValidatedDispensers, " | "),
+	}
+}
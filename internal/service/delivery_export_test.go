@@ -0,0 +1,124 @@
+package service
+
+import (
+	"GoFrioCalor/internal/models"
+	"GoFrioCalor/internal/store"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// exportStore devuelve las filas de la exportación sin base de datos y guarda el filtro recibido
+type exportStore struct {
+	store.DeliveryStore
+	rows   []store.DeliveryExportRow
+	filter store.DeliveryFilter
+}
+
+func (s *exportStore) StreamForExport(ctx context.Context, filter store.DeliveryFilter, fn func(row *store.DeliveryExportRow) error) error {
+	s.filter = filter
+	for i := range s.rows {
+		if err := fn(&s.rows[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newExportFixture() *exportStore {
+	return &exportStore{rows: []store.DeliveryExportRow{
+		{
+			ID: 7, NroCta: "1001", Name: "Juan Pérez", Email: "juan@example.com", Address: "Calle 1, PB", Locality: "Rosario",
+			NroRto: "R1", TipoEntrega: models.Instalacion, EntregadoPor: models.Repartidor, Estado: models.Completado,
+			FechaAccion: time.Date(2026, 5, 20, 0, 0, 0, 0, time.UTC), CreatedAt: time.Date(2026, 5, 18, 9, 30, 0, 0, time.UTC),
+			Cantidad: 2, TotalP: 1, TotalM: 1, OrderNumber: "OV-9", ValidatedDispensers: models.StringArray{"LM1", "LM2"},
+		},
+		{
+			ID: 8, NroCta: "1002", NroRto: "R1", TipoEntrega: models.Retiro, EntregadoPor: models.Tecnico, Estado: models.Pendiente,
+			FechaAccion: time.Date(2026, 5, 21, 0, 0, 0, 0, time.UTC), CreatedAt: time.Date(2026, 5, 19, 16, 0, 0, 0, time.UTC),
+			Cantidad: 1, TotalP: 1,
+		},
+	}}
+}
+
+func TestExportDeliveriesCSV(t *testing.T) {
+	deliveryStore := newExportFixture()
+	s := &deliveryService{store: deliveryStore}
+	estado := models.Completado
+	filter := store.DeliveryFilter{NroRto: []string{"R1"}, Estado: &estado}
+
+	var out bytes.Buffer
+	if err := s.ExportDeliveries(context.Background(), filter, ExportFormatCSV, &out); err != nil {
+		t.Fatalf("ExportDeliveries() error = %v", err)
+	}
+	if !reflect.DeepEqual(deliveryStore.filter, filter) {
+		t.Errorf("filtro = %+v, want %+v", deliveryStore.filter, filter)
+	}
+	if !bytes.HasPrefix(out.Bytes(), []byte("\xef\xbb\xbf")) {
+		t.Errorf("el CSV debería empezar con el BOM UTF-8")
+	}
+
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(out.String(), "\xef\xbb\xbf"))).ReadAll()
+	if err != nil {
+		t.Fatalf("CSV inválido: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("filas = %d, want encabezado y 2 entregas", len(records))
+	}
+	if !reflect.DeepEqual(records[0], exportHeaders) {
+		t.Errorf("encabezado = %v", records[0])
+	}
+	want := []string{"7", "1001", "Juan Pérez", "juan@example.com", "Calle 1, PB", "Rosario", "R1", "Instalacion", "Repartidor",
+		"Completado", "2026-05-20", "2026-05-18 09:30:00", "2", "1", "1", "OV-9", "LM1 | LM2"}
+	if !reflect.DeepEqual(records[1], want) {
+		t.Errorf("fila = %v\nwant %v", records[1], want)
+	}
+	if records[2][0] != "8" || records[2][2] != "" || records[2][16] != "" {
+		t.Errorf("fila sin datos opcionales = %v", records[2])
+	}
+}
+
+func TestExportDeliveriesXLSX(t *testing.T) {
+	s := &deliveryService{store: newExportFixture()}
+
+	var out bytes.Buffer
+	if err := s.ExportDeliveries(context.Background(), store.DeliveryFilter{}, ExportFormatXLSX, &out); err != nil {
+		t.Fatalf("ExportDeliveries() error = %v", err)
+	}
+	workbook, err := excelize.OpenReader(&out)
+	if err != nil {
+		t.Fatalf("XLSX inválido: %v", err)
+	}
+	defer workbook.Close()
+
+	rows, err := workbook.GetRows("Entregas")
+	if err != nil {
+		t.Fatalf("GetRows() error = %v", err)
+	}
+	if len(rows) != 3 || !reflect.DeepEqual(rows[0], exportHeaders) {
+		t.Fatalf("planilla = %v", rows)
+	}
+	if rows[1][1] != "1001" || rows[1][16] != "LM1 | LM2" {
+		t.Errorf("fila = %v", rows[1])
+	}
+	// id y cantidades se guardan como número para poder sumarlas
+	for _, cell := range []string{"A2", "M2", "N2", "O2"} {
+		cellType, err := workbook.GetCellType("Entregas", cell)
+		if err != nil || cellType == excelize.CellTypeSharedString || cellType == excelize.CellTypeInlineString {
+			t.Errorf("%s tipo = %v (%v), want número", cell, cellType, err)
+		}
+	}
+}
+
+func TestExportDeliveriesRejectsUnknownFormat(t *testing.T) {
+	s := &deliveryService{store: newExportFixture()}
+	if err := s.ExportDeliveries(context.Background(), store.DeliveryFilter{}, "pdf", &bytes.Buffer{}); err == nil {
+		t.Errorf("un formato desconocido debería rechazarse")
+	}
+}
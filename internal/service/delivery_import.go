@@ -0,0 +1,327 @@
+package service
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/dto"
+	"GoFrioCalor/internal/models"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog/log"
+	"github.com/xuri/excelize/v2"
+)
+
+// importColumnAliases mapea los encabezados aceptados en el archivo a su columna canónica
+var importColumnAliases = map[string]string{
+	"nro_cta":       "nro_cta",
+	"nro_rto":       "nro_rto",
+	"fecha_accion":  "fecha_accion",
+	"p":             "p",
+	"tipos_p":       "p",
+	"m":             "m",
+	"tipos_m":       "m",
+	"tipo_entrega":  "tipo_entrega",
+	"entregado_por": "entregado_por",
+	"email":         "email",
+}
+
+var importRequiredColumns = []string{"nro_cta", "nro_rto", "tipo_entrega", "entregado_por"}
+
+// importRow es una fila del archivo ya convertida al request de Infobip.
+// Line es el número de fila en la planilla (el encabezado es la fila 1).
+type importRow struct {
+	Line    int
+	Request dto.InfobipDeliveryRequest
+	Errors  []string
+}
+
+// ImportDeliveries valida cada fila del archivo con las mismas reglas que CreateFromInfobip.
+// En modo dry-run solo devuelve el reporte; en modo commit inserta todas las filas válidas
+// en una única transacción con tokens generados.
+func (s *deliveryService) ImportDeliveries(ctx context.Context, filename string, file io.Reader, dryRun bool) (*dto.DeliveryImportResponse, error) {
+	records, err := readImportFile(filename, file)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := parseImportRecords(records)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.DeliveryImportResponse{
+		DryRun:    dryRun,
+		TotalRows: len(rows),
+		Created:   []dto.DeliveryImportCreated{},
+		Errors:    []dto.DeliveryImportRowError{},
+	}
+
+	deliveries := make([]*models.Delivery, 0, len(rows))
+	lines := make([]int, 0, len(rows))
+	for i := range rows {
+		row := &rows[i]
+		delivery := buildImportDelivery(row)
+		if len(row.Errors) > 0 {
+			response.Errors = append(response.Errors, dto.DeliveryImportRowError{
+				Row:    row.Line,
+				NroCta: row.Request.NroCta,
+				Errors: row.Errors,
+			})
+			continue
+		}
+		deliveries = append(deliveries, delivery)
+		lines = append(lines, row.Line)
+	}
+	response.ValidRows = len(deliveries)
+	response.InvalidRows = len(response.Errors)
+
+	if dryRun || len(deliveries) == 0 {
+		return response, nil
+	}
+
+	if err := s.tokenPolicy.saveWithUniqueTokens(ctx, s.store, deliveries, func() error {
+		return s.store.CreateBatch(ctx, deliveries)
+	}); err != nil {
+		return nil, err
+	}
+	for i, delivery := range deliveries {
+		response.Created = append(response.Created, dto.DeliveryImportCreated{
+			Row:        lines[i],
+			DeliveryID: delivery.ID,
+			NroCta:     delivery.NroCta,
+			Token:      delivery.Token,
+		})
+	}
+
+	log.Info().
+		Str("filename", filename).
+		Int("created", len(deliveries)).
+		Int("invalid", response.InvalidRows).
+		Msg("Importación masiva de entregas completada")
+
+	return response, nil
+}
+
+// buildImportDelivery valida la fila con los tags `binding` de InfobipDeliveryRequest
+// y, si no tiene errores, arma la entrega a crear
+func buildImportDelivery(row *importRow) *models.Delivery {
+	req := row.Request
+	if err := bindingValidator.StructExcept(req, "ConversationID"); err != nil {
+		var validationErrors validator.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			for _, fieldError := range validationErrors {
+				row.Errors = append(row.Errors, formatFieldError(fieldError))
+			}
+		} else {
+			row.Errors = append(row.Errors, err.Error())
+		}
+	}
+
+	cantidadTotal := req.Tipos.P + req.Tipos.M
+	if err := validateDispenserQuantity(cantidadTotal); err != nil {
+		row.Errors = append(row.Errors, err.Error())
+	}
+	fechaAccion, err := parseFechaAccion(req.FechaAccion)
+	if err != nil {
+		row.Errors = append(row.Errors, err.Error())
+	}
+
+	if len(row.Errors) > 0 {
+		return nil
+	}
+	return &models.Delivery{
+		NroCta:         req.NroCta,
+		NroRto:         req.NroRto,
+		Email:          req.Email,
+		ItemDispensers: createItemDispensers(req.Tipos.P, req.Tipos.M),
+		Cantidad:       cantidadTotal,
+		Estado:         models.Pendiente,
+		TipoEntrega:    req.TipoEntrega,
+		EntregadoPor:   req.EntregadoPor,
+		FechaAccion:    fechaAccion,
+	}
+}
+
+func formatFieldError(fieldError validator.FieldError) string {
+	field := fieldError.Field()
+	switch fieldError.Tag() {
+	case "required":
+		return fmt.Sprintf(constants.ValidationRequired, field)
+	case "min":
+		return fmt.Sprintf(constants.ValidationMinLength, field, fieldError.Param())
+	case "max":
+		return fmt.Sprintf(constants.ValidationMaxLength, field, fieldError.Param())
+	case "oneof":
+		return fmt.Sprintf(constants.ValidationOneOf, field, fieldError.Param())
+	default:
+		return fmt.Sprintf(constants.ValidationInvalid, field)
+	}
+}
+
+// readImportFile lee el archivo como matriz de celdas según su extensión (.csv o .xlsx)
+func readImportFile(filename string, file io.Reader) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return readImportCSV(file)
+	case ".xlsx":
+		return readImportXLSX(file)
+	default:
+		return nil, fmt.Errorf(constants.ErrImportUnsupportedFormat)
+	}
+}
+
+// readImportCSV acepta separador coma o punto y coma (exportación de Excel en es-AR)
+func readImportCSV(file io.Reader) ([][]string, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo archivo CSV: %w", err)
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo archivo CSV: %w", err)
+	}
+	return records, nil
+}
+
+// readImportXLSX lee la primera hoja del libro con los valores crudos de las celdas
+// (las fechas llegan como número de serie de Excel y se convierten en normalizeImportDate)
+func readImportXLSX(file io.Reader) ([][]string, error) {
+	workbook, err := excelize.OpenReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo archivo XLSX: %w", err)
+	}
+	defer workbook.Close()
+
+	sheets := workbook.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf(constants.ErrImportEmptyFile)
+	}
+	rows, err := workbook.GetRows(sheets[0], excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo archivo XLSX: %w", err)
+	}
+	return rows, nil
+}
+
+// parseImportRecords interpreta la primera fila como encabezado y convierte el resto
+// en requests de Infobip. Las filas vacías se ignoran.
+func parseImportRecords(records [][]string) ([]importRow, error) {
+	if len(records) < 2 {
+		return nil, fmt.Errorf(constants.ErrImportEmptyFile)
+	}
+
+	columns := make(map[string]int)
+	for i, header := range records[0] {
+		if canonical, ok := importColumnAliases[strings.ToLower(strings.TrimSpace(header))]; ok {
+			columns[canonical] = i
+		}
+	}
+	var missing []string
+	for _, column := range importRequiredColumns {
+		if _, ok := columns[column]; !ok {
+			missing = append(missing, column)
+		}
+	}
+	_, hasP := columns["p"]
+	_, hasM := columns["m"]
+	if !hasP && !hasM {
+		missing = append(missing, "P/M")
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf(constants.ErrImportMissingColumns, strings.Join(missing, ", "))
+	}
+
+	cell := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	rows := make([]importRow, 0, len(records)-1)
+	for i, record := range records[1:] {
+		if isBlankRecord(record) {
+			continue
+		}
+		if len(rows) == constants.MAX_IMPORT_ROWS {
+			return nil, fmt.Errorf(constants.ErrImportTooManyRows, constants.MAX_IMPORT_ROWS)
+		}
+
+		row := importRow{Line: i + 2}
+		p, err := parseImportQuantity(cell(record, "p"))
+		if err != nil {
+			row.Errors = append(row.Errors, fmt.Sprintf(constants.ValidationNumeric, "P"))
+		}
+		m, err := parseImportQuantity(cell(record, "m"))
+		if err != nil {
+			row.Errors = append(row.Errors, fmt.Sprintf(constants.ValidationNumeric, "M"))
+		}
+		row.Request = dto.InfobipDeliveryRequest{
+			NroCta:       cell(record, "nro_cta"),
+			NroRto:       cell(record, "nro_rto"),
+			Email:        cell(record, "email"),
+			Tipos:        dto.DispenserTypesQuantity{P: p, M: m},
+			TipoEntrega:  models.TipoEntrega(cell(record, "tipo_entrega")),
+			EntregadoPor: models.EntregadoPor(cell(record, "entregado_por")),
+			FechaAccion:  normalizeImportDate(cell(record, "fecha_accion")),
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf(constants.ErrImportEmptyFile)
+	}
+	return rows, nil
+}
+
+func parseImportQuantity(value string) (uint, error) {
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint(n), nil
+}
+
+// normalizeImportDate convierte las celdas de fecha de Excel (número de serie) a YYYY-MM-DD;
+// cualquier otro valor se devuelve tal cual para que lo valide parseFechaAccion
+func normalizeImportDate(value string) string {
+	serial, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return value
+	}
+	date, err := excelize.ExcelDateToTime(serial, false)
+	if err != nil {
+		return value
+	}
+	return date.Format("2006-01-02")
+}
+
+func isBlankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestParseImportCSV(t *testing.T) {
+	csvData := "nro_cta;nro_rto;fecha_accion;P;M;tipo_entrega;entregado_por;email\n" +
+		"1001;R1;2026-05-20;1;1;Instalacion;Repartidor;cliente@mail.com\n" +
+		";;;;;;;\n" +
+		"1002;R1;20/05/2026;0;0;Service;Tecnico;no-es-email\n" +
+		"1003;R2;;x;1;Retiro;Tecnico;\n"
+
+	records, err := readImportFile("campania.csv", strings.NewReader(csvData))
+	if err != nil {
+		t.Fatalf("readImportFile() error = %v", err)
+	}
+	rows, err := parseImportRecords(records)
+	if err != nil {
+		t.Fatalf("parseImportRecords() error = %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("se esperaban 3 filas (la vacía se ignora), got %d", len(rows))
+	}
+
+	valid := buildImportDelivery(&rows[0])
+	if valid == nil {
+		t.Fatalf("fila %d debería ser válida: %v", rows[0].Line, rows[0].Errors)
+	}
+	if valid.Cantidad != 2 || len(valid.ItemDispensers) != 2 || valid.Estado != models.Pendiente {
+		t.Errorf("entrega mal armada: %+v", valid)
+	}
+
+	if buildImportDelivery(&rows[1]) != nil {
+		t.Fatalf("fila %d debería ser inválida", rows[1].Line)
+	}
+	if rows[1].Line != 4 {
+		t.Errorf("Line = %d, want 4", rows[1].Line)
+	}
+	// tipo_entrega fuera de oneof, email inválido, cantidad cero y fecha con formato inválido
+	if len(rows[1].Errors) != 4 {
+		t.Errorf("se esperaban 4 errores, got %d: %v", len(rows[1].Errors), rows[1].Errors)
+	}
+
+	if buildImportDelivery(&rows[2]) != nil || len(rows[2].Errors) != 1 {
+		t.Errorf("fila con P no numérico debería tener 1 error, got %v", rows[2].Errors)
+	}
+}
+
+func TestParseImportRecordsMissingColumns(t *testing.T) {
+	records := [][]string{
+		{"nro_cta", "tipo_entrega"},
+		{"1001", "Instalacion"},
+	}
+	_, err := parseImportRecords(records)
+	if err == nil || !strings.Contains(err.Error(), "nro_rto") || !strings.Contains(err.Error(), "P/M") {
+		t.Errorf("se esperaba error de columnas faltantes, got %v", err)
+	}
+}
+
+func TestReadImportFileUnsupportedFormat(t *testing.T) {
+	_, err := readImportFile("campania.txt", strings.NewReader(""))
+	if err == nil || err.Error() != constants.ErrImportUnsupportedFormat {
+		t.Errorf("se esperaba %q, got %v", constants.ErrImportUnsupportedFormat, err)
+	}
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog/log"
+)
+
+// deliveryPatchFields mapea el nombre JSON de cada campo que admite merge patch al campo
+// del modelo. Todos son de tipo texto; qué campos se pueden tocar en cada estado lo define
+// models.EstadoEntrega.EditableFields.
+var deliveryPatchFields = map[string]string{
+	"name":          "Name",
+	"email":         "Email",
+	"address":       "Address",
+	"locality":      "Locality",
+	"nro_rto":       "NroRto",
+	"order_number":  "OrderNumber",
+	"tipo_entrega":  "TipoEntrega",
+	"entregado_por": "EntregadoPor",
+	"failure_notes": "FailureNotes",
+}
+
+// DeliveryPatchResult contiene la entrega antes y después del patch y los campos que cambiaron
+type DeliveryPatchResult struct {
+	Before        *models.Delivery
+	After         *models.Delivery
+	ChangedFields []string
+}
+
+// PatchDelivery aplica un JSON merge patch (RFC 7396) sobre la entrega. Solo se aceptan los
+// campos editables en el estado actual; un valor null vuelve el campo a vacío. El resultado
+// se valida con los tags `binding` del modelo antes de guardarse con control de versión.
+// Si el patch no modifica ningún valor no se escribe en la base.
+func (s *deliveryService) PatchDelivery(ctx context.Context, id int, patch []byte, expectedVersion int, changedBy string) (*DeliveryPatchResult, error) {
+	fields, err := parseMergePatch(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.store.FindByID(ctx, id)
+	if err != nil || current == nil {
+		return nil, fmt.Errorf(constants.ErrDeliveryNotFound)
+	}
+	if current.Version != expectedVersion {
+		return nil, fmt.Errorf(constants.ErrVersionConflictDetail, expectedVersion, current.Version)
+	}
+
+	patched := *current
+	changed, err := applyDeliveryPatch(&patched, fields)
+	if err != nil {
+		return nil, err
+	}
+	result := &DeliveryPatchResult{Before: current, After: &patched, ChangedFields: changed}
+	if len(changed) == 0 {
+		return result, nil
+	}
+
+	if err := s.store.Patch(ctx, &patched, changedBy); err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Int("delivery_id", id).
+		Strs("changed_fields", changed).
+		Int("version", patched.Version).
+		Str("changed_by", changedBy).
+		Msg("Entrega actualizada parcialmente")
+
+	return result, nil
+}
+
+// parseMergePatch exige que el documento sea un objeto JSON con al menos un campo
+func parseMergePatch(patch []byte) (map[string]json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &fields); err != nil || fields == nil {
+		return nil, fmt.Errorf(constants.ErrPatchInvalidBody)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf(constants.ErrPatchEmpty)
+	}
+	return fields, nil
+}
+
+// applyDeliveryPatch copia los valores del patch sobre delivery respetando la tabla de
+// campos editables del estado y devuelve, ordenados, los campos cuyo valor cambió
+func applyDeliveryPatch(delivery *models.Delivery, fields map[string]json.RawMessage) ([]string, error) {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var notEditable []string
+	for _, name := range names {
+		if _, ok := deliveryPatchFields[name]; !ok {
+			return nil, fmt.Errorf(constants.ErrPatchUnknownField, name)
+		}
+		if !delivery.Estado.CanEditField(name) {
+			notEditable = append(notEditable, name)
+		}
+	}
+	if len(notEditable) > 0 {
+		editable := strings.Join(delivery.Estado.EditableFields(), ", ")
+		if editable == "" {
+			editable = "ninguno"
+		}
+		return nil, fmt.Errorf(constants.ErrPatchFieldNotEditable, delivery.Estado, strings.Join(notEditable, ", "), editable)
+	}
+
+	target := reflect.ValueOf(delivery).Elem()
+	var changed, structFields []string
+	for _, name := range names {
+		value := ""
+		if raw := fields[name]; !bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return nil, fmt.Errorf(constants.ErrPatchInvalidValue, name, "se esperaba un texto o null")
+			}
+			value = strings.TrimSpace(value)
+		}
+		if name == "email" && value != "" {
+			if err := bindingValidator.Var(value, "email"); err != nil {
+				return nil, fmt.Errorf(constants.ErrPatchInvalidValue, name, "email inválido")
+			}
+		}
+
+		field := target.FieldByName(deliveryPatchFields[name])
+		if field.String() == value {
+			continue
+		}
+		field.SetString(value)
+		changed = append(changed, name)
+		structFields = append(structFields, deliveryPatchFields[name])
+	}
+
+	if len(structFields) > 0 {
+		if err := bindingValidator.StructPartial(delivery, structFields...); err != nil {
+			var validationErrors validator.ValidationErrors
+			if errors.As(err, &validationErrors) && len(validationErrors) > 0 {
+				fieldError := validationErrors[0]
+				return nil, fmt.Errorf(constants.ErrPatchInvalidValue, fieldError.Field(), formatFieldError(fieldError))
+			}
+			return nil, err
+		}
+	}
+	return changed, nil
+}
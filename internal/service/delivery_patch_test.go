@@ -0,0 +1,74 @@
+package service
+
+import (
+	"GoFrioCalor/internal/models"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestApplyDeliveryPatch(t *testing.T) {
+	newDelivery := func(estado models.EstadoEntrega) *models.Delivery {
+		return &models.Delivery{
+			NroCta:       "1001",
+			NroRto:       "R1",
+			Name:         "Juan",
+			Address:      "Calle 1",
+			Estado:       estado,
+			TipoEntrega:  models.Instalacion,
+			EntregadoPor: models.Repartidor,
+		}
+	}
+	patch := func(body string) map[string]json.RawMessage {
+		fields, err := parseMergePatch([]byte(body))
+		if err != nil {
+			t.Fatalf("parseMergePatch(%s) error = %v", body, err)
+		}
+		return fields
+	}
+
+	delivery := newDelivery(models.Pendiente)
+	changed, err := applyDeliveryPatch(delivery, patch(`{"address": " Calle 2 ", "name": "Juan", "order_number": "OV-9", "locality": null}`))
+	if err != nil {
+		t.Fatalf("applyDeliveryPatch() error = %v", err)
+	}
+	if want := []string{"address", "order_number"}; !reflect.DeepEqual(changed, want) {
+		t.Errorf("changed = %v, want %v", changed, want)
+	}
+	if delivery.Address != "Calle 2" || delivery.OrderNumber != "OV-9" {
+		t.Errorf("patch mal aplicado: %+v", delivery)
+	}
+
+	tests := []struct {
+		name    string
+		estado  models.EstadoEntrega
+		body    string
+		wantErr string
+	}{
+		{name: "campo desconocido", estado: models.Pendiente, body: `{"token": "ABC123"}`, wantErr: "campo desconocido"},
+		{name: "estado se cambia por /status", estado: models.Pendiente, body: `{"estado": "Cancelado"}`, wantErr: "campo desconocido"},
+		{name: "dirección en camino", estado: models.EnCamino, body: `{"address": "Calle 3"}`, wantErr: "campo no editable en estado EnCamino"},
+		{name: "entrega completada", estado: models.Completado, body: `{"email": "a@b.com"}`, wantErr: "campos editables: ninguno"},
+		{name: "null en campo requerido", estado: models.Pendiente, body: `{"nro_rto": null}`, wantErr: "valor inválido para nro_rto"},
+		{name: "oneof", estado: models.Pendiente, body: `{"tipo_entrega": "Mudanza"}`, wantErr: "valor inválido para tipo_entrega"},
+		{name: "email inválido", estado: models.EnCamino, body: `{"email": "no-es-email"}`, wantErr: "email inválido"},
+		{name: "tipo no texto", estado: models.Pendiente, body: `{"name": 12}`, wantErr: "se esperaba un texto"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := applyDeliveryPatch(newDelivery(tt.estado), patch(tt.body))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("se esperaba error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestParseMergePatchInvalid(t *testing.T) {
+	for _, body := range []string{``, `[]`, `"texto"`, `null`, `{}`} {
+		if _, err := parseMergePatch([]byte(body)); err == nil {
+			t.Errorf("parseMergePatch(%q) debería fallar", body)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/models"
+	"GoFrioCalor/internal/store"
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// ProofFile es un archivo recibido como prueba de entrega
+type ProofFile struct {
+	FileName string
+	Data     []byte
+}
+
+type DeliveryProofService interface {
+	UploadProof(ctx context.Context, deliveryID int, signature *ProofFile, photos []ProofFile, uploadedBy string) ([]models.DeliveryAttachment, error)
+	ListAttachments(ctx context.Context, deliveryID int) ([]models.DeliveryAttachment, error)
+	GetAttachment(ctx context.Context, deliveryID, attachmentID int) (*models.DeliveryAttachment, []byte, error)
+}
+
+type deliveryProofService struct {
+	deliveryStore   store.DeliveryStore
+	attachmentStore store.DeliveryAttachmentStore
+	blobStorage     BlobStorage
+	maxPhotos       int
+}
+
+func NewDeliveryProofService(deliveryStore store.DeliveryStore, attachmentStore store.DeliveryAttachmentStore, blobStorage BlobStorage, maxPhotos int) DeliveryProofService {
+	if maxPhotos <= 0 {
+		maxPhotos = constants.DEFAULT_MAX_DELIVERY_PHOTOS
+	}
+	return &deliveryProofService{
+		deliveryStore:   deliveryStore,
+		attachmentStore: attachmentStore,
+		blobStorage:     blobStorage,
+		maxPhotos:       maxPhotos,
+	}
+}
+
+// proofFileExtensions mapea el tipo de contenido detectado a la extensión con la que se guarda
+var proofFileExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+}
+
+// UploadProof guarda la firma del cliente y las fotos de la instalación de una entrega abierta o
+// completada. Una firma nueva reemplaza a la anterior; las fotos se acumulan hasta maxPhotos.
+// El tipo de cada archivo se detecta por su contenido, no por el nombre.
+func (s *deliveryProofService) UploadProof(ctx context.Context, deliveryID int, signature *ProofFile, photos []ProofFile, uploadedBy string) ([]models.DeliveryAttachment, error) {
+	if signature == nil && len(photos) == 0 {
+		return nil, fmt.Errorf(constants.ErrProofEmpty)
+	}
+
+	delivery, err := s.deliveryStore.FindByID(ctx, deliveryID)
+	if err != nil || delivery == nil {
+		return nil, fmt.Errorf(constants.ErrDeliveryNotFound)
+	}
+	if !canAttachProof(delivery.Estado) {
+		return nil, fmt.Errorf(constants.ErrProofNotAllowed, delivery.Estado)
+	}
+
+	if signature != nil {
+		if err := validateProofFile(*signature); err != nil {
+			return nil, err
+		}
+		if http.DetectContentType(signature.Data) != "image/png" {
+			return nil, fmt.Errorf(constants.ErrProofSignatureFormat)
+		}
+	}
+	for _, photo := range photos {
+		if err := validateProofFile(photo); err != nil {
+			return nil, err
+		}
+		if _, ok := proofFileExtensions[http.DetectContentType(photo.Data)]; !ok {
+			return nil, fmt.Errorf(constants.ErrProofPhotoFormat, photo.FileName)
+		}
+	}
+
+	existing, err := s.attachmentStore.ListByDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	var previousSignatures []models.DeliveryAttachment
+	existingPhotos := 0
+	for _, attachment := range existing {
+		if attachment.Kind == models.AttachmentSignature {
+			previousSignatures = append(previousSignatures, attachment)
+		} else {
+			existingPhotos++
+		}
+	}
+	if existingPhotos+len(photos) > s.maxPhotos {
+		return nil, fmt.Errorf(constants.ErrProofTooManyPhotos, s.maxPhotos, existingPhotos)
+	}
+
+	created := make([]models.DeliveryAttachment, 0, len(photos)+1)
+	if signature != nil {
+		attachment, err := s.storeAttachment(ctx, deliveryID, models.AttachmentSignature, *signature, uploadedBy)
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, *attachment)
+		for _, previous := range previousSignatures {
+			s.removeAttachment(ctx, previous)
+		}
+	}
+	for _, photo := range photos {
+		attachment, err := s.storeAttachment(ctx, deliveryID, models.AttachmentPhoto, photo, uploadedBy)
+		if err != nil {
+			return created, err
+		}
+		created = append(created, *attachment)
+	}
+
+	log.Info().
+		Int("delivery_id", deliveryID).
+		Bool("signature", signature != nil).
+		Int("photos", len(photos)).
+		Str("uploaded_by", uploadedBy).
+		Msg("Proof of delivery stored")
+
+	return created, nil
+}
+
+func (s *deliveryProofService) ListAttachments(ctx context.Context, deliveryID int) ([]models.DeliveryAttachment, error) {
+	delivery, err := s.deliveryStore.FindByID(ctx, deliveryID)
+	if err != nil || delivery == nil {
+		return nil, fmt.Errorf(constants.ErrDeliveryNotFound)
+	}
+	return s.attachmentStore.ListByDelivery(ctx, deliveryID)
+}
+
+func (s *deliveryProofService) GetAttachment(ctx context.Context, deliveryID, attachmentID int) (*models.DeliveryAttachment, []byte, error) {
+	attachment, err := s.attachmentStore.FindByID(ctx, deliveryID, attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := s.blobStorage.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return attachment, data, nil
+}
+
+func (s *deliveryProofService) storeAttachment(ctx context.Context, deliveryID int, kind models.AttachmentKind, file ProofFile, uploadedBy string) (*models.DeliveryAttachment, error) {
+	contentType := http.DetectContentType(file.Data)
+	key := fmt.Sprintf("deliveries/%d/%s-%s%s", deliveryID, kind, uuid.NewString(), proofFileExtensions[contentType])
+	if err := s.blobStorage.Put(ctx, key, file.Data); err != nil {
+		return nil, fmt.Errorf(constants.ErrProofStorage, err)
+	}
+
+	attachment := &models.DeliveryAttachment{
+		DeliveryID:  deliveryID,
+		Kind:        kind,
+		StorageKey:  key,
+		FileName:    file.FileName,
+		ContentType: contentType,
+		Size:        int64(len(file.Data)),
+		UploadedBy:  uploadedBy,
+	}
+	if err := s.attachmentStore.Create(ctx, attachment); err != nil {
+		if delErr := s.blobStorage.Delete(ctx, key); delErr != nil {
+			log.Error().Err(delErr).Str("key", key).Msg("Error removing orphan proof file")
+		}
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// removeAttachment elimina un adjunto reemplazado; un error no invalida la carga nueva
+func (s *deliveryProofService) removeAttachment(ctx context.Context, attachment models.DeliveryAttachment) {
+	if err := s.attachmentStore.Delete(ctx, attachment.ID); err != nil {
+		log.Error().Err(err).Int("attachment_id", attachment.ID).Msg("Error removing replaced attachment")
+		return
+	}
+	if err := s.blobStorage.Delete(ctx, attachment.StorageKey); err != nil {
+		log.Error().Err(err).Str("key", attachment.StorageKey).Msg("Error removing replaced proof file")
+	}
+}
+
+func validateProofFile(file ProofFile) error {
+	if len(file.Data) > constants.MAX_PROOF_FILE_SIZE {
+		return fmt.Errorf(constants.ErrProofFileTooLarge, file.FileName, constants.MAX_PROOF_FILE_SIZE>>20)
+	}
+	return nil
+}
+
+// canAttachProof admite la carga mientras la entrega está abierta (antes de completarla, para
+// que la firma salga en la orden de trabajo) o ya completada (sincronización offline)
+func canAttachProof(estado models.EstadoEntrega) bool {
+	return estado == models.Completado || isOpenEstado(estado)
+}
+
+func isOpenEstado(estado models.EstadoEntrega) bool {
+	for _, open := range models.EstadosAbiertos {
+		if estado == open {
+			return true
+		}
+	}
+	return false
+}
@@ -3,12 +3,14 @@ package service
 import (
 	"GoFrioCalor/internal/constants"
 	"GoFrioCalor/internal/dto"
+	"GoFrioCalor/internal/metrics"
 	"GoFrioCalor/internal/models"
 	"GoFrioCalor/internal/store"
 	"context"
-	"crypto/rand"
+	"errors"
 	"fmt"
-	"math/big"
+	"io"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -18,27 +20,47 @@ type DeliveryService interface {
 	FindAll(ctx context.Context, limit, offset int) ([]models.Delivery, error)
 	CountAll(ctx context.Context) (int64, error)
 	FindByID(ctx context.Context, id int) (*models.Delivery, error)
-	FindByFilters(ctx context.Context, nroCta string, fechaAccion, fechaCreacion *time.Time, estado *models.EstadoEntrega, limit, offset int) ([]models.Delivery, error)
-	CountByFilters(ctx context.Context, nroCta string, fechaAccion, fechaCreacion *time.Time, estado *models.EstadoEntrega) (int64, error)
+	FindByFilters(ctx context.Context, filter store.DeliveryFilter, page store.DeliveryPage) ([]models.Delivery, error)
+	CountByFilters(ctx context.Context, filter store.DeliveryFilter) (int64, error)
 	FindByRto(ctx context.Context, nroRto string, fechaAccion *time.Time) ([]models.Delivery, error)
 	FindByFechaAccion(ctx context.Context, fecha string) ([]models.Delivery, error)
 	FindByFechaAndNroCta(ctx context.Context, fechaAccion, nroCta string) (*models.Delivery, error)
 	Create(ctx context.Context, delivery *models.Delivery) error
-	Update(ctx context.Context, delivery *models.Delivery) error
+	Update(ctx context.Context, delivery *models.Delivery, changedBy string) error
+	PatchDelivery(ctx context.Context, id int, patch []byte, expectedVersion int, changedBy string) (*DeliveryPatchResult, error)
 	Delete(ctx context.Context, id int) error
-	CancelDelivery(ctx context.Context, id int) (*models.Delivery, error)
+	Restore(ctx context.Context, id int) (*models.Delivery, error)
+	FindDeleted(ctx context.Context, limit, offset int) ([]models.Delivery, error)
+	CountDeleted(ctx context.Context) (int64, error)
+	CancelDelivery(ctx context.Context, id, expectedVersion int, changedBy, reason string) (*models.Delivery, error)
+	ChangeStatus(ctx context.Context, id int, estado models.EstadoEntrega, expectedVersion int, changedBy, reason string) (*models.Delivery, error)
+	FindStatusHistory(ctx context.Context, id int) ([]models.DeliveryStatusHistory, error)
+	Reschedule(ctx context.Context, id int, req dto.RescheduleDeliveryRequest, expectedVersion int, changedBy string) (*models.Delivery, error)
+	RegenerateToken(ctx context.Context, id, expectedVersion int, changedBy string) (*models.Delivery, bool, error)
+	FindReschedules(ctx context.Context, id int) ([]models.DeliveryReschedule, error)
 	CreateFromInfobip(ctx context.Context, req dto.InfobipDeliveryRequest) (*models.Delivery, bool, error)
+	Availability(ctx context.Context, nroRto string, from, to time.Time, tipo models.TipoEntrega) (*dto.RouteAvailabilityResponse, error)
 	FindPendingByNroCta(ctx context.Context, nroCta string) ([]models.Delivery, error)
+	FindRequiringFollowUp(ctx context.Context) ([]models.Delivery, error)
+	ImportDeliveries(ctx context.Context, filename string, file io.Reader, dryRun bool) (*dto.DeliveryImportResponse, error)
+	ExportDeliveries(ctx context.Context, filter store.DeliveryFilter, format string, w io.Writer) error
 }
 type deliveryService struct {
-	store        store.DeliveryStore
-	emailService EmailService
+	store          store.DeliveryStore
+	emailService   EmailService
+	dispenserStore store.DispenserStore
+	stockStore     store.StockStore
+	capacityStore  store.RouteCapacityStore
+	tokenPolicy    TokenPolicy
+	stockPolicy    StockPolicy
 }
 
 func NewDeliveryService(store store.DeliveryStore) DeliveryService {
 	return &deliveryService{
 		store:        store,
 		emailService: nil,
+		tokenPolicy:  DefaultTokenPolicy,
+		stockPolicy:  DefaultStockPolicy,
 	}
 }
 
@@ -46,6 +68,20 @@ func NewDeliveryServiceWithEmail(store store.DeliveryStore, emailService EmailSe
 	return &deliveryService{
 		store:        store,
 		emailService: emailService,
+		tokenPolicy:  DefaultTokenPolicy,
+		stockPolicy:  DefaultStockPolicy,
+	}
+}
+
+func NewDeliveryServiceWithServices(store store.DeliveryStore, emailService EmailService, dispenserStore store.DispenserStore, stockStore store.StockStore, capacityStore store.RouteCapacityStore, tokenPolicy TokenPolicy, stockPolicy StockPolicy) DeliveryService {
+	return &deliveryService{
+		store:          store,
+		emailService:   emailService,
+		dispenserStore: dispenserStore,
+		stockStore:     stockStore,
+		capacityStore:  capacityStore,
+		tokenPolicy:    tokenPolicy,
+		stockPolicy:    stockPolicy,
 	}
 }
 
@@ -61,12 +97,12 @@ func (s *deliveryService) FindByID(ctx context.Context, id int) (*models.Deliver
 	return s.store.FindByID(ctx, id)
 }
 
-func (s *deliveryService) FindByFilters(ctx context.Context, nroCta string, fechaAccion, fechaCreacion *time.Time, estado *models.EstadoEntrega, limit, offset int) ([]models.Delivery, error) {
-	return s.store.FindByFilters(ctx, nroCta, fechaAccion, fechaCreacion, estado, limit, offset)
+func (s *deliveryService) FindByFilters(ctx context.Context, filter store.DeliveryFilter, page store.DeliveryPage) ([]models.Delivery, error) {
+	return s.store.FindByFilters(ctx, filter, page)
 }
 
-func (s *deliveryService) CountByFilters(ctx context.Context, nroCta string, fechaAccion, fechaCreacion *time.Time, estado *models.EstadoEntrega) (int64, error) {
-	return s.store.CountByFilters(ctx, nroCta, fechaAccion, fechaCreacion, estado)
+func (s *deliveryService) CountByFilters(ctx context.Context, filter store.DeliveryFilter) (int64, error) {
+	return s.store.CountByFilters(ctx, filter)
 }
 
 func (s *deliveryService) FindByRto(ctx context.Context, rto string, fechaAccion *time.Time) ([]models.Delivery, error) {
@@ -82,36 +118,228 @@ func (s *deliveryService) FindByFechaAndNroCta(ctx context.Context, fechaAccion,
 }
 
 func (s *deliveryService) Create(ctx context.Context, delivery *models.Delivery) error {
-	delivery.Token = s.generateToken()
 	if delivery.FechaAccion.IsZero() {
 		delivery.FechaAccion = models.CustomDate{Time: time.Now()}
 	}
-	return s.store.Create(ctx, delivery)
+	return s.tokenPolicy.saveWithUniqueTokens(ctx, s.store, []*models.Delivery{delivery}, func() error {
+		return s.store.Create(ctx, delivery)
+	})
 }
 
-func (s *deliveryService) Update(ctx context.Context, delivery *models.Delivery) error {
-	return s.store.Update(ctx, delivery)
+// Update guarda la entrega si delivery.Version coincide con la versión persistida; si el estado
+// cambia, la transición pasa por la tabla de reglas y queda registrada en el historial.
+// Rechaza cambios de fecha, reparto o cantidades: ocupan lugar del reparto y stock del depósito,
+// y la fecha solo cambia con Reschedule.
+func (s *deliveryService) Update(ctx context.Context, delivery *models.Delivery, changedBy string) error {
+	current, err := s.store.FindByID(ctx, delivery.ID)
+	if err != nil || current == nil {
+		return fmt.Errorf(constants.ErrDeliveryNotFound)
+	}
+	// Con la versión verificada, el store garantiza que current es lo que se va a reemplazar
+	if current.Version != delivery.Version {
+		return fmt.Errorf(constants.ErrVersionConflictDetail, delivery.Version, current.Version)
+	}
+	if changed := bookingFieldChanges(delivery, current); len(changed) > 0 {
+		return fmt.Errorf(constants.ErrUpdateBookingFields, strings.Join(changed, ", "))
+	}
+	return s.store.Update(ctx, delivery, changedBy)
+}
+
+// bookingFieldChanges devuelve los campos de la reserva que delivery cambia respecto de current.
+// Sin item_dispensers en el pedido los ítems no se tocan.
+func bookingFieldChanges(delivery, current *models.Delivery) []string {
+	var changed []string
+	if delivery.FechaAccion.UTC().Format("2006-01-02") != current.FechaAccion.UTC().Format("2006-01-02") {
+		changed = append(changed, "fecha_accion")
+	}
+	if delivery.NroRto != current.NroRto {
+		changed = append(changed, "nro_rto")
+	}
+	if delivery.Cantidad != current.Cantidad {
+		changed = append(changed, "cantidad")
+	}
+	if len(delivery.ItemDispensers) > 0 && dispenserTypes(delivery.ItemDispensers) != dispenserTypes(current.ItemDispensers) {
+		changed = append(changed, "item_dispensers")
+	}
+	return changed
 }
 
 func (s *deliveryService) Delete(ctx context.Context, id int) error {
 	return s.store.Delete(ctx, id)
 }
 
-func (s *deliveryService) CancelDelivery(ctx context.Context, id int) (*models.Delivery, error) {
+func (s *deliveryService) Restore(ctx context.Context, id int) (*models.Delivery, error) {
+	return s.store.Restore(ctx, id)
+}
+
+func (s *deliveryService) FindDeleted(ctx context.Context, limit, offset int) ([]models.Delivery, error) {
+	return s.store.FindDeleted(ctx, limit, offset)
+}
+
+func (s *deliveryService) CountDeleted(ctx context.Context) (int64, error) {
+	return s.store.CountDeleted(ctx)
+}
+
+func (s *deliveryService) CancelDelivery(ctx context.Context, id, expectedVersion int, changedBy, reason string) (*models.Delivery, error) {
 	delivery, err := s.store.FindByID(ctx, id)
 	if err != nil || delivery == nil {
-		return nil, fmt.Errorf("entrega no encontrada")
+		return nil, fmt.Errorf(constants.ErrDeliveryNotFound)
 	}
 	if delivery.Estado == models.Cancelado {
 		return nil, fmt.Errorf("la entrega ya se encuentra cancelada")
 	}
-	return s.store.CancelDelivery(ctx, id)
+	return s.store.CancelDelivery(ctx, id, expectedVersion, changedBy, reason)
+}
+
+// ChangeStatus aplica una transición explícita. Completado y Fallido no se aceptan: el cierre
+// pasa por la app móvil, que registra token, ubicación, faltantes, motivo y seguimiento.
+func (s *deliveryService) ChangeStatus(ctx context.Context, id int, estado models.EstadoEntrega, expectedVersion int, changedBy, reason string) (*models.Delivery, error) {
+	if !estado.IsValid() {
+		return nil, fmt.Errorf("estado desconocido: %s", estado)
+	}
+	if estado == models.Completado || estado == models.Fallido {
+		return nil, fmt.Errorf(constants.ErrStatusRequiresMobileClose, estado)
+	}
+	return s.store.TransitionStatus(ctx, id, estado, expectedVersion, changedBy, reason)
+}
+
+func (s *deliveryService) FindStatusHistory(ctx context.Context, id int) ([]models.DeliveryStatusHistory, error) {
+	if _, err := s.store.FindByID(ctx, id); err != nil {
+		return nil, fmt.Errorf(constants.ErrDeliveryNotFound)
+	}
+	return s.store.FindStatusHistory(ctx, id)
+}
+
+// Reschedule cambia la fecha_accion de la entrega conservando conversation_id y, salvo que se pida
+// regenerarlo, el token. Rechaza fechas pasadas, sin lugar en el reparto o sin stock en el depósito
+// y notifica al cliente por email si tiene dirección.
+func (s *deliveryService) Reschedule(ctx context.Context, id int, req dto.RescheduleDeliveryRequest, expectedVersion int, changedBy string) (*models.Delivery, error) {
+	current, err := s.store.FindByID(ctx, id)
+	if err != nil || current == nil {
+		return nil, fmt.Errorf(constants.ErrDeliveryNotFound)
+	}
+
+	newFecha, err := parseFechaAccion(req.FechaAccion)
+	if err != nil {
+		return nil, err
+	}
+	newDay := newFecha.Format("2006-01-02")
+	if newDay < time.Now().Format("2006-01-02") {
+		return nil, fmt.Errorf(constants.ErrRescheduleDateInPast)
+	}
+	if newDay == current.FechaAccion.Format("2006-01-02") {
+		return nil, fmt.Errorf(constants.ErrRescheduleSameDate)
+	}
+
+	// El token se regenera si se pide o si otra entrega abierta de la ruta ya lo usa en la nueva fecha
+	tokenRegenerated := req.RegenerateToken
+	if !tokenRegenerated {
+		inUse, err := s.store.TokenInUse(ctx, current.Token, current.NroRto, newFecha.Time, current.ID)
+		if err != nil {
+			return nil, err
+		}
+		tokenRegenerated = inUse
+	}
+
+	guard, err := s.bookingGuard(ctx, current.NroRto, current.TipoEntrega, newFecha.Time, dispenserTypes(current.ItemDispensers), current.ID)
+	if err != nil {
+		return nil, err
+	}
+	var delivery *models.Delivery
+	for i := 0; i < constants.TOKEN_GENERATION_RETRIES; i++ {
+		newToken := ""
+		if tokenRegenerated {
+			newToken, err = s.tokenPolicy.uniqueToken(ctx, s.store, current.NroRto, newFecha.Time, current.ID, nil)
+			if err != nil {
+				return nil, err
+			}
+		}
+		// El store verifica el token con el lock de la ruta tomado: si otra entrega lo tomó se regenera
+		delivery, err = s.store.Reschedule(ctx, id, newFecha.Time, newToken, expectedVersion, changedBy, req.Reason, guard)
+		if !errors.Is(err, store.ErrTokenInUse) {
+			break
+		}
+		tokenRegenerated = true
+	}
+	if errors.Is(err, store.ErrTokenInUse) {
+		return nil, fmt.Errorf(constants.ErrTokenGeneration)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Int("delivery_id", delivery.ID).
+		Str("previous_fecha", current.FechaAccion.Format("2006-01-02")).
+		Str("new_fecha", delivery.FechaAccion.Format("2006-01-02")).
+		Int("reschedule_count", delivery.RescheduleCount).
+		Bool("token_regenerated", tokenRegenerated).
+		Msg("Delivery rescheduled")
+
+	if s.emailService != nil && delivery.Email != "" {
+		go s.sendRescheduleEmail(context.Background(), delivery, current.FechaAccion.Time, tokenRegenerated)
+	}
+	return delivery, nil
+}
+
+// RegenerateToken asigna un token nuevo a una entrega abierta, levanta el bloqueo por intentos
+// fallidos y reenvía el token al cliente. Devuelve si se pudo notificar (la entrega tiene email).
+func (s *deliveryService) RegenerateToken(ctx context.Context, id, expectedVersion int, changedBy string) (*models.Delivery, bool, error) {
+	var current, delivery *models.Delivery
+	var err error
+	for i := 0; i < constants.TOKEN_GENERATION_RETRIES; i++ {
+		current, err = s.store.FindByID(ctx, id)
+		if err != nil || current == nil {
+			return nil, false, fmt.Errorf(constants.ErrDeliveryNotFound)
+		}
+		var newToken string
+		newToken, err = s.tokenPolicy.uniqueToken(ctx, s.store, current.NroRto, current.FechaAccion.Time, current.ID, map[string]bool{current.Token: true})
+		if err != nil {
+			return nil, false, err
+		}
+		// El store verifica el token con el lock de la ruta tomado: si otra entrega lo tomó se elige otro
+		delivery, err = s.store.RegenerateToken(ctx, id, newToken, expectedVersion)
+		if !errors.Is(err, store.ErrTokenInUse) {
+			break
+		}
+	}
+	if errors.Is(err, store.ErrTokenInUse) {
+		return nil, false, fmt.Errorf(constants.ErrTokenGeneration)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	log.Info().
+		Int("delivery_id", delivery.ID).
+		Int("previous_failed_attempts", current.TokenFailedAttempts).
+		Bool("was_locked", current.TokenLockedAt != nil).
+		Str("changed_by", changedBy).
+		Msg("Delivery token regenerated")
+
+	notified := s.emailService != nil && delivery.Email != ""
+	if notified {
+		go s.sendTokenRegeneratedEmail(context.Background(), delivery)
+	}
+	return delivery, notified, nil
+}
+
+func (s *deliveryService) FindReschedules(ctx context.Context, id int) ([]models.DeliveryReschedule, error) {
+	if _, err := s.store.FindByID(ctx, id); err != nil {
+		return nil, fmt.Errorf(constants.ErrDeliveryNotFound)
+	}
+	return s.store.FindReschedules(ctx, id)
 }
 
 func (s *deliveryService) FindPendingByNroCta(ctx context.Context, nroCta string) ([]models.Delivery, error) {
 	return s.store.FindPendingByNroCta(ctx, nroCta)
 }
 
+// FindRequiringFollowUp devuelve las visitas fallidas derivadas a contact center
+func (s *deliveryService) FindRequiringFollowUp(ctx context.Context) ([]models.Delivery, error) {
+	return s.store.FindRequiringFollowUp(ctx)
+}
+
 func (s *deliveryService) CreateFromInfobip(ctx context.Context, req dto.InfobipDeliveryRequest) (*models.Delivery, bool, error) {
 
 	log.Info().
@@ -149,6 +377,9 @@ func (s *deliveryService) CreateFromInfobip(ctx context.Context, req dto.Infobip
 	if err := validateDispenserQuantity(cantidadTotal); err != nil {
 		return nil, false, err
 	}
+	if err := s.checkAccountEquipment(ctx, req.NroCta, req.TipoEntrega); err != nil {
+		return nil, false, err
+	}
 	fechaAccion, err := parseFechaAccion(req.FechaAccion)
 	if err != nil {
 		return nil, false, err
@@ -173,21 +404,17 @@ func (s *deliveryService) CreateFromInfobip(ctx context.Context, req dto.Infobip
 		FechaAccion:    fechaAccion,
 	}
 
-	delivery.Token = s.generateToken()
-	if err := s.store.Create(ctx, delivery); err != nil {
-		return nil, false, fmt.Errorf("error creando entrega: %w", err)
-	}
-	return delivery, false, nil
-}
-
-func (s *deliveryService) generateToken() string {
-	rangeSize := int64(constants.TOKEN_MAX - constants.TOKEN_MIN + 1)
-	n, err := rand.Int(rand.Reader, big.NewInt(rangeSize))
+	// El lugar del reparto y el stock se verifican dentro de la transacción que crea la entrega
+	guard, err := s.bookingGuard(ctx, req.NroRto, req.TipoEntrega, fechaAccion.Time, req.Tipos, 0)
 	if err != nil {
-		return fmt.Sprintf("%04d", time.Now().UnixNano()%10000)
+		return nil, false, err
 	}
-	token := int(n.Int64()) + constants.TOKEN_MIN
-	return fmt.Sprintf("%04d", token)
+	if err := s.tokenPolicy.saveWithUniqueTokens(ctx, s.store, []*models.Delivery{delivery}, func() error {
+		return s.store.CreateGuarded(ctx, delivery, guard)
+	}); err != nil {
+		return nil, false, err
+	}
+	return delivery, false, nil
 }
 
 func (s *deliveryService) sendDeliveryConfirmationEmail(ctx context.Context, delivery *models.Delivery) {
@@ -249,3 +476,125 @@ func (s *deliveryService) sendDeliveryConfirmationEmail(ctx context.Context, del
 			Msg("Email de confirmación enviado exitosamente")
 	}
 }
+
+func (s *deliveryService) sendRescheduleEmail(ctx context.Context, delivery *models.Delivery, previousFecha time.Time, tokenRegenerated bool) {
+	subject := fmt.Sprintf("Entrega Reprogramada - Nueva fecha: %s", delivery.FechaAccion.Format("02/01/2006"))
+
+	tokenNotice := "Su token de validación no cambió."
+	if tokenRegenerated {
+		tokenNotice = "Se generó un nuevo token de validación. El token anterior ya no es válido."
+	}
+
+	htmlBody := fmt.Sprintf(`
+		<html>
+		<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333;">
+			<div style="max-width: 600px; margin: 0 auto; padding: 20px;">
+				<h2 style="color: #2c3e50; border-bottom: 2px solid #3498db; padding-bottom: 10px;">
+					📅 Su entrega fue reprogramada
+				</h2>
+
+				<p>Estimado cliente,</p>
+
+				<p>Le informamos que la fecha de su entrega fue modificada. A continuación los detalles actualizados:</p>
+
+				<div style="background-color: #f8f9fa; padding: 15px; border-radius: 5px; margin: 20px 0;">
+					<p style="margin: 5px 0;"><strong>📅 Nueva Fecha:</strong> <span style="font-size: 20px; color: #27ae60; font-weight: bold;">%s</span></p>
+					<p style="margin: 5px 0;"><strong>🗓️ Fecha Anterior:</strong> %s</p>
+					<p style="margin: 5px 0;"><strong>🔑 Token de Validación:</strong> <span style="font-size: 24px; color: #e74c3c; font-weight: bold;">%s</span></p>
+					<p style="margin: 5px 0;"><strong>📦 Cuenta:</strong> %s</p>
+					<p style="margin: 5px 0;"><strong>🔧 Tipo de Entrega:</strong> %s</p>
+				</div>
+
+				<div style="background-color: #fff3cd; padding: 15px; border-left: 4px solid #ffc107; margin: 20px 0;">
+					<p style="margin: 0;"><strong>⚠️ Importante:</strong> %s Tenga el token a mano cuando llegue el repartidor.</p>
+				</div>
+
+				<p style="color: #7f8c8d; font-size: 12px; margin-top: 30px; border-top: 1px solid #ecf0f1; padding-top: 15px;">
+					Este es un email automático. Por favor no responda a este mensaje.<br>
+					<strong>El Jumillano - Sistema de Gestión de Entregas</strong>
+				</p>
+			</div>
+		</body>
+		</html>
+	`,
+		delivery.FechaAccion.Format("02/01/2006"),
+		previousFecha.Format("02/01/2006"),
+		delivery.Token,
+		delivery.NroCta,
+		string(delivery.TipoEntrega),
+		tokenNotice,
+	)
+
+	err := s.emailService.SendHTMLEmail(ctx, delivery.Email, subject, htmlBody)
+	if err != nil {
+		metrics.EmailSent("reschedule", false)
+		log.Error().
+			Err(err).
+			Int("delivery_id", delivery.ID).
+			Str("email", delivery.Email).
+			Msg("Error enviando email de reprogramación")
+		return
+	}
+	metrics.EmailSent("reschedule", true)
+	log.Info().
+		Int("delivery_id", delivery.ID).
+		Str("email", delivery.Email).
+		Msg("Email de reprogramación enviado exitosamente")
+}
+
+func (s *deliveryService) sendTokenRegeneratedEmail(ctx context.Context, delivery *models.Delivery) {
+	subject := fmt.Sprintf("Nuevo token de validación - Entrega del %s", delivery.FechaAccion.Format("02/01/2006"))
+
+	htmlBody := fmt.Sprintf(`
+		<html>
+		<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333;">
+			<div style="max-width: 600px; margin: 0 auto; padding: 20px;">
+				<h2 style="color: #2c3e50; border-bottom: 2px solid #3498db; padding-bottom: 10px;">
+					🔑 Nuevo token de validación
+				</h2>
+
+				<p>Estimado cliente,</p>
+
+				<p>Generamos un nuevo token de validación para su entrega. El token anterior ya no es válido.</p>
+
+				<div style="background-color: #f8f9fa; padding: 15px; border-radius: 5px; margin: 20px 0;">
+					<p style="margin: 5px 0;"><strong>🔑 Token de Validación:</strong> <span style="font-size: 24px; color: #e74c3c; font-weight: bold;">%s</span></p>
+					<p style="margin: 5px 0;"><strong>📅 Fecha:</strong> %s</p>
+					<p style="margin: 5px 0;"><strong>📦 Cuenta:</strong> %s</p>
+					<p style="margin: 5px 0;"><strong>🔧 Tipo de Entrega:</strong> %s</p>
+				</div>
+
+				<div style="background-color: #fff3cd; padding: 15px; border-left: 4px solid #ffc107; margin: 20px 0;">
+					<p style="margin: 0;"><strong>⚠️ Importante:</strong> Tenga el token a mano cuando llegue el repartidor. No lo comparta por otros medios.</p>
+				</div>
+
+				<p style="color: #7f8c8d; font-size: 12px; margin-top: 30px; border-top: 1px solid #ecf0f1; padding-top: 15px;">
+					Este es un email automático. Por favor no responda a este mensaje.<br>
+					<strong>El Jumillano - Sistema de Gestión de Entregas</strong>
+				</p>
+			</div>
+		</body>
+		</html>
+	`,
+		delivery.Token,
+		delivery.FechaAccion.Format("02/01/2006"),
+		delivery.NroCta,
+		string(delivery.TipoEntrega),
+	)
+
+	err := s.emailService.SendHTMLEmail(ctx, delivery.Email, subject, htmlBody)
+	if err != nil {
+		metrics.EmailSent("token_regenerated", false)
+		log.Error().
+			Err(err).
+			Int("delivery_id", delivery.ID).
+			Str("email", delivery.Email).
+			Msg("Error enviando email de token regenerado")
+		return
+	}
+	metrics.EmailSent("token_regenerated", true)
+	log.Info().
+		Int("delivery_id", delivery.ID).
+		Str("email", delivery.Email).
+		Msg("Email de token regenerado enviado exitosamente")
+}
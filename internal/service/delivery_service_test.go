@@ -0,0 +1,192 @@
+package service
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/dto"
+	"GoFrioCalor/internal/models"
+	"GoFrioCalor/internal/store"
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBookingFieldChanges(t *testing.T) {
+	fecha := models.CustomDate{Time: time.Date(2026, 5, 4, 0, 0, 0, 0, time.UTC)}
+	current := &models.Delivery{
+		NroRto:         "R1",
+		Cantidad:       2,
+		FechaAccion:    fecha,
+		ItemDispensers: []models.ItemDispenser{{Tipo: models.TipoDispenserPie, Cantidad: 1}, {Tipo: models.TipoDispenserMesada, Cantidad: 1}},
+	}
+
+	tests := []struct {
+		name   string
+		update func(d *models.Delivery)
+		want   []string
+	}{
+		{name: "sin cambios de reserva", update: func(d *models.Delivery) { d.Address = "Calle 2" }},
+		{name: "misma fecha con otra hora", update: func(d *models.Delivery) { d.FechaAccion = models.CustomDate{Time: fecha.Add(15 * time.Hour)} }},
+		{name: "sin item_dispensers no los toca", update: func(d *models.Delivery) { d.ItemDispensers = nil }},
+		{name: "ítems reordenados", update: func(d *models.Delivery) {
+			d.ItemDispensers = []models.ItemDispenser{{Tipo: models.TipoDispenserMesada, Cantidad: 1}, {Tipo: models.TipoDispenserPie, Cantidad: 1}}
+		}},
+		{name: "otra fecha", update: func(d *models.Delivery) { d.FechaAccion = models.CustomDate{Time: fecha.AddDate(0, 0, 1)} }, want: []string{"fecha_accion"}},
+		{name: "otro reparto y cantidad", update: func(d *models.Delivery) { d.NroRto = "R2"; d.Cantidad = 3 }, want: []string{"nro_rto", "cantidad"}},
+		{name: "otros ítems", update: func(d *models.Delivery) {
+			d.ItemDispensers = []models.ItemDispenser{{Tipo: models.TipoDispenserPie, Cantidad: 2}}
+		}, want: []string{"item_dispensers"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delivery := *current
+			tt.update(&delivery)
+			if got := bookingFieldChanges(&delivery, current); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("bookingFieldChanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangeStatusRejectsMobileClose(t *testing.T) {
+	s := &deliveryService{}
+	for _, estado := range []models.EstadoEntrega{models.Completado, models.Fallido} {
+		_, err := s.ChangeStatus(context.Background(), 1, estado, 1, "legajo:100", "")
+		if err == nil || !strings.Contains(err.Error(), constants.ErrInvalidStatusTransitionID) {
+			t.Errorf("ChangeStatus(%s) error = %v, want transición inválida", estado, err)
+		}
+	}
+}
+
+// rescheduleStore reprograma una única entrega en memoria, como deliveryStore.Reschedule: verifica
+// la versión y que el token que queda esté libre en la nueva fecha, e incrementa el contador
+type rescheduleStore struct {
+	store.DeliveryStore
+	delivery models.Delivery
+	// taken son los tokens que usan otras entregas de la ruta en la nueva fecha
+	taken map[string]bool
+	// raceTokens son tokens que otra entrega toma entre TokenInUse y el lock del store
+	raceTokens map[string]bool
+	tokens     []string
+}
+
+func (s *rescheduleStore) FindByID(ctx context.Context, id int) (*models.Delivery, error) {
+	delivery := s.delivery
+	return &delivery, nil
+}
+
+func (s *rescheduleStore) TokenInUse(ctx context.Context, token, nroRto string, fechaAccion time.Time, excludeID int) (bool, error) {
+	return s.taken[token], nil
+}
+
+func (s *rescheduleStore) Reschedule(ctx context.Context, id int, newFecha time.Time, newToken string, expectedVersion int, changedBy, reason string, guard *store.BookingGuard) (*models.Delivery, error) {
+	s.tokens = append(s.tokens, newToken)
+	if expectedVersion != store.AnyVersion && expectedVersion != s.delivery.Version {
+		return nil, fmt.Errorf(constants.ErrVersionConflictDetail, expectedVersion, s.delivery.Version)
+	}
+	token := s.delivery.Token
+	if newToken != "" {
+		token = newToken
+	}
+	if s.taken[token] || s.raceTokens[token] {
+		return nil, store.ErrTokenInUse
+	}
+	s.delivery.Token = token
+	s.delivery.FechaAccion = models.CustomDate{Time: newFecha}
+	s.delivery.Estado = models.Reprogramado
+	s.delivery.RescheduleCount++
+	s.delivery.Version++
+	delivery := s.delivery
+	return &delivery, nil
+}
+
+func TestReschedule(t *testing.T) {
+	// El servicio compara contra la fecha local; fecha_accion se guarda como día UTC
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	tomorrow := today.AddDate(0, 0, 1).Format("2006-01-02")
+	newStore := func() *rescheduleStore {
+		return &rescheduleStore{delivery: models.Delivery{
+			ID:              42,
+			NroRto:          "R1",
+			Token:           "111111",
+			Estado:          models.Programado,
+			TipoEntrega:     models.Instalacion,
+			FechaAccion:     models.CustomDate{Time: today},
+			RescheduleCount: 1,
+			Version:         3,
+		}}
+	}
+	newService := func(deliveryStore store.DeliveryStore) *deliveryService {
+		return &deliveryService{store: deliveryStore, tokenPolicy: DefaultTokenPolicy}
+	}
+
+	t.Run("fecha pasada", func(t *testing.T) {
+		deliveryStore := newStore()
+		_, err := newService(deliveryStore).Reschedule(context.Background(), 42, dto.RescheduleDeliveryRequest{FechaAccion: today.AddDate(0, 0, -1).Format("2006-01-02")}, 3, "legajo:100")
+		if err == nil || err.Error() != constants.ErrRescheduleDateInPast {
+			t.Errorf("error = %v, want %q", err, constants.ErrRescheduleDateInPast)
+		}
+		if len(deliveryStore.tokens) != 0 {
+			t.Errorf("no debería llegar al store")
+		}
+	})
+
+	t.Run("misma fecha", func(t *testing.T) {
+		_, err := newService(newStore()).Reschedule(context.Background(), 42, dto.RescheduleDeliveryRequest{FechaAccion: today.Format("2006-01-02")}, 3, "legajo:100")
+		if err == nil || err.Error() != constants.ErrRescheduleSameDate {
+			t.Errorf("error = %v, want %q", err, constants.ErrRescheduleSameDate)
+		}
+	})
+
+	t.Run("conserva el token e incrementa el contador", func(t *testing.T) {
+		deliveryStore := newStore()
+		delivery, err := newService(deliveryStore).Reschedule(context.Background(), 42, dto.RescheduleDeliveryRequest{FechaAccion: tomorrow}, 3, "legajo:100")
+		if err != nil {
+			t.Fatalf("Reschedule() error = %v", err)
+		}
+		if delivery.Token != "111111" || deliveryStore.tokens[0] != "" {
+			t.Errorf("token = %q (pedido %q), want el token actual sin regenerar", delivery.Token, deliveryStore.tokens[0])
+		}
+		if delivery.RescheduleCount != 2 || delivery.Estado != models.Reprogramado || delivery.FechaAccion.Format("2006-01-02") != tomorrow {
+			t.Errorf("entrega reprogramada = %+v", delivery)
+		}
+	})
+
+	t.Run("regenera el token si otra entrega lo usa en la nueva fecha", func(t *testing.T) {
+		deliveryStore := newStore()
+		deliveryStore.taken = map[string]bool{"111111": true}
+		delivery, err := newService(deliveryStore).Reschedule(context.Background(), 42, dto.RescheduleDeliveryRequest{FechaAccion: tomorrow}, 3, "legajo:100")
+		if err != nil {
+			t.Fatalf("Reschedule() error = %v", err)
+		}
+		if delivery.Token == "" || delivery.Token == "111111" || len(deliveryStore.tokens) != 1 {
+			t.Errorf("token = %q en %d intentos, want un token nuevo al primer intento", delivery.Token, len(deliveryStore.tokens))
+		}
+	})
+
+	t.Run("regenera el token si otra entrega lo toma antes del lock", func(t *testing.T) {
+		deliveryStore := newStore()
+		deliveryStore.raceTokens = map[string]bool{"111111": true}
+		delivery, err := newService(deliveryStore).Reschedule(context.Background(), 42, dto.RescheduleDeliveryRequest{FechaAccion: tomorrow}, 3, "legajo:100")
+		if err != nil {
+			t.Fatalf("Reschedule() error = %v", err)
+		}
+		if len(deliveryStore.tokens) != 2 || deliveryStore.tokens[1] == "" || delivery.Token != deliveryStore.tokens[1] {
+			t.Errorf("tokens pedidos = %q, token = %q; se esperaba un reintento con token nuevo", deliveryStore.tokens, delivery.Token)
+		}
+	})
+
+	t.Run("versión desactualizada", func(t *testing.T) {
+		deliveryStore := newStore()
+		_, err := newService(deliveryStore).Reschedule(context.Background(), 42, dto.RescheduleDeliveryRequest{FechaAccion: tomorrow}, 2, "legajo:100")
+		if err == nil || !strings.Contains(err.Error(), constants.ErrDeliveryVersionConflict) {
+			t.Errorf("error = %v, want conflicto de versión", err)
+		}
+		if deliveryStore.delivery.RescheduleCount != 1 {
+			t.Errorf("RescheduleCount = %d, no debería cambiar", deliveryStore.delivery.RescheduleCount)
+		}
+	})
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/models"
+	"GoFrioCalor/internal/store"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// TokenPolicy define cómo se generan y validan los tokens de validación de entregas
+type TokenPolicy struct {
+	// Length es la cantidad de caracteres del token
+	Length int
+	// Alphabet son los caracteres admitidos (por defecto solo dígitos, para dictarlo por teléfono)
+	Alphabet string
+	// MaxAttempts es la cantidad de validaciones fallidas que bloquean el token
+	MaxAttempts int
+	// GraceDays extiende la validez del token más allá del día de fecha_accion
+	GraceDays int
+}
+
+// DefaultTokenPolicy es la política usada si no se configura otra
+var DefaultTokenPolicy = TokenPolicy{
+	Length:      constants.TOKEN_DEFAULT_LENGTH,
+	Alphabet:    constants.TOKEN_DEFAULT_ALPHABET,
+	MaxAttempts: constants.TOKEN_DEFAULT_MAX_ATTEMPTS,
+	GraceDays:   0,
+}
+
+// Validate verifica la política antes de pasarla a los servicios
+func (policy TokenPolicy) Validate() error {
+	if policy.Length < constants.TOKEN_MIN_LENGTH || policy.Length > constants.TOKEN_MAX_LENGTH {
+		return fmt.Errorf("longitud de token inválida: %d (debe estar entre %d y %d)", policy.Length, constants.TOKEN_MIN_LENGTH, constants.TOKEN_MAX_LENGTH)
+	}
+	seen := make(map[rune]bool)
+	for _, r := range policy.Alphabet {
+		if seen[r] {
+			return fmt.Errorf("alfabeto de token inválido: el carácter %q está repetido", r)
+		}
+		seen[r] = true
+	}
+	if len(seen) < 2 {
+		return fmt.Errorf("alfabeto de token inválido: debe tener al menos 2 caracteres")
+	}
+	if policy.MaxAttempts < 1 {
+		return fmt.Errorf("máximo de intentos de token inválido: %d", policy.MaxAttempts)
+	}
+	if policy.GraceDays < 0 {
+		return fmt.Errorf("días de gracia de token inválidos: %d", policy.GraceDays)
+	}
+	return nil
+}
+
+// generate genera un token aleatorio con crypto/rand según la política
+func (policy TokenPolicy) generate() string {
+	alphabet := []rune(policy.Alphabet)
+	max := big.NewInt(int64(len(alphabet)))
+	token := make([]rune, policy.Length)
+	for i := range token {
+		// desde Go 1.24 rand.Reader no devuelve errores
+		n, _ := rand.Int(rand.Reader, max)
+		token[i] = alphabet[n.Int64()]
+	}
+	return string(token)
+}
+
+// expiresAt devuelve el momento en que vence el token de una entrega: el fin del día de
+// fecha_accion (en hora local del servidor) más los días de gracia configurados
+func (policy TokenPolicy) expiresAt(fechaAccion time.Time) time.Time {
+	day := time.Date(fechaAccion.Year(), fechaAccion.Month(), fechaAccion.Day(), 0, 0, 0, 0, time.Local)
+	return day.AddDate(0, 0, 1+policy.GraceDays)
+}
+
+// expired indica si el token de la entrega ya venció
+func (policy TokenPolicy) expired(delivery *models.Delivery, now time.Time) bool {
+	return !now.Before(policy.expiresAt(delivery.FechaAccion.Time))
+}
+
+// uniqueToken genera un token que no usa ninguna otra entrega abierta de la misma ruta y fecha.
+// reserved contiene tokens ya asignados que todavía no están en la base (importación masiva).
+func (policy TokenPolicy) uniqueToken(ctx context.Context, deliveryStore store.DeliveryStore, nroRto string, fechaAccion time.Time, excludeID int, reserved map[string]bool) (string, error) {
+	for i := 0; i < constants.TOKEN_GENERATION_RETRIES; i++ {
+		token := policy.generate()
+		if reserved[token] {
+			continue
+		}
+		inUse, err := deliveryStore.TokenInUse(ctx, token, nroRto, fechaAccion, excludeID)
+		if err != nil {
+			return "", err
+		}
+		if !inUse {
+			return token, nil
+		}
+	}
+	return "", fmt.Errorf(constants.ErrTokenGeneration)
+}
+
+// assignUniqueTokens asigna a cada entrega nueva un token único dentro de su ruta y fecha,
+// considerando también las demás entregas del mismo lote
+func (policy TokenPolicy) assignUniqueTokens(ctx context.Context, deliveryStore store.DeliveryStore, deliveries []*models.Delivery) error {
+	reserved := make(map[string]map[string]bool)
+	for _, delivery := range deliveries {
+		key := delivery.NroRto + "|" + delivery.FechaAccion.Format("2006-01-02")
+		if reserved[key] == nil {
+			reserved[key] = make(map[string]bool)
+		}
+		token, err := policy.uniqueToken(ctx, deliveryStore, delivery.NroRto, delivery.FechaAccion.Time, delivery.ID, reserved[key])
+		if err != nil {
+			return err
+		}
+		delivery.Token = token
+		reserved[key][token] = true
+	}
+	return nil
+}
+
+// saveWithUniqueTokens asigna tokens a las entregas nuevas y las guarda con save. El store vuelve a
+// verificar los tokens con el lock de la ruta y fecha tomado; si entretanto otra entrega tomó
+// alguno (store.ErrTokenInUse) se eligen de nuevo y se reintenta.
+func (policy TokenPolicy) saveWithUniqueTokens(ctx context.Context, deliveryStore store.DeliveryStore, deliveries []*models.Delivery, save func() error) error {
+	for i := 0; i < constants.TOKEN_GENERATION_RETRIES; i++ {
+		if err := policy.assignUniqueTokens(ctx, deliveryStore, deliveries); err != nil {
+			return err
+		}
+		if err := save(); !errors.Is(err, store.ErrTokenInUse) {
+			return err
+		}
+	}
+	return fmt.Errorf(constants.ErrTokenGeneration)
+}
+
+// errInvalidCompletionToken indica que el token informado al completar no es el de la entrega
+var errInvalidCompletionToken = errors.New("token inválido")
+
+// checkCompletionToken verifica el token al completar una entrega. Una entrega bloqueada por
+// intentos fallidos no se completa aunque no se envíe token, y si la entrega tiene token es
+// obligatorio: omitirlo no saltea el bloqueo ni el vencimiento.
+func (policy TokenPolicy) checkCompletionToken(delivery *models.Delivery, token string, completedAt time.Time) error {
+	if delivery.TokenLockedAt != nil {
+		return fmt.Errorf(constants.ErrTokenLocked)
+	}
+	if delivery.Token == "" {
+		return nil
+	}
+	if token == "" {
+		return fmt.Errorf(constants.ValidationRequired, "token")
+	}
+	if token != delivery.Token {
+		return errInvalidCompletionToken
+	}
+	if policy.expired(delivery, completedAt) {
+		return fmt.Errorf(constants.MsgSessionExpired)
+	}
+	return nil
+}
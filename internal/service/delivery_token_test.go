@@ -0,0 +1,144 @@
+package service
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/models"
+	"GoFrioCalor/internal/store"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateTokenUsesPolicy(t *testing.T) {
+	policy := TokenPolicy{Length: 8, Alphabet: "ACDEFHJKMNPRTUVWXY", MaxAttempts: 3}
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	for i := 0; i < 50; i++ {
+		token := policy.generate()
+		if len(token) != 8 {
+			t.Fatalf("len(%q) = %d, want 8", token, len(token))
+		}
+		for _, r := range token {
+			if !strings.ContainsRune("ACDEFHJKMNPRTUVWXY", r) {
+				t.Fatalf("token %q contiene %q fuera del alfabeto", token, r)
+			}
+		}
+	}
+}
+
+func TestTokenPolicyValidateInvalid(t *testing.T) {
+	invalid := []TokenPolicy{
+		{Length: 3, Alphabet: "0123456789", MaxAttempts: 5},
+		{Length: 13, Alphabet: "0123456789", MaxAttempts: 5},
+		{Length: 6, Alphabet: "0", MaxAttempts: 5},
+		{Length: 6, Alphabet: "00123", MaxAttempts: 5},
+		{Length: 6, Alphabet: "0123456789", MaxAttempts: 0},
+		{Length: 6, Alphabet: "0123456789", MaxAttempts: 5, GraceDays: -1},
+	}
+	for _, policy := range invalid {
+		if err := policy.Validate(); err == nil {
+			t.Errorf("Validate(%+v) debería fallar", policy)
+		}
+	}
+	if err := DefaultTokenPolicy.Validate(); err != nil {
+		t.Errorf("la política por defecto debería ser válida: %v", err)
+	}
+}
+
+func TestTokenExpired(t *testing.T) {
+	policy := DefaultTokenPolicy
+	delivery := &models.Delivery{FechaAccion: models.CustomDate{Time: time.Date(2026, 5, 20, 0, 0, 0, 0, time.UTC)}}
+	lastMinute := time.Date(2026, 5, 20, 23, 59, 0, 0, time.Local)
+	nextDay := time.Date(2026, 5, 21, 8, 0, 0, 0, time.Local)
+
+	if policy.expired(delivery, lastMinute) {
+		t.Errorf("el token debería ser válido hasta el fin del día de fecha_accion")
+	}
+	if !policy.expired(delivery, nextDay) {
+		t.Errorf("el token debería vencer al día siguiente de fecha_accion")
+	}
+
+	policy.GraceDays = 1
+	if policy.expired(delivery, nextDay) {
+		t.Errorf("con un día de gracia el token debería seguir vigente")
+	}
+}
+
+func TestCheckCompletionToken(t *testing.T) {
+	policy := DefaultTokenPolicy
+	fecha := time.Date(2026, 5, 20, 0, 0, 0, 0, time.UTC)
+	sameDay := time.Date(2026, 5, 20, 15, 0, 0, 0, time.Local)
+	nextDay := time.Date(2026, 5, 21, 9, 0, 0, 0, time.Local)
+	lockedAt := sameDay
+
+	tests := []struct {
+		name     string
+		delivery models.Delivery
+		token    string
+		at       time.Time
+		wantErr  string
+	}{
+		{name: "token correcto", delivery: models.Delivery{Token: "1234"}, token: "1234", at: sameDay},
+		{name: "entrega sin token", delivery: models.Delivery{}, at: sameDay},
+		{name: "token omitido", delivery: models.Delivery{Token: "1234"}, at: sameDay, wantErr: "token es requerido"},
+		{name: "token incorrecto", delivery: models.Delivery{Token: "1234"}, token: "9999", at: sameDay, wantErr: "token inválido"},
+		{name: "bloqueada sin token", delivery: models.Delivery{Token: "1234", TokenLockedAt: &lockedAt}, at: sameDay, wantErr: constants.ErrTokenLocked},
+		{name: "bloqueada con token correcto", delivery: models.Delivery{Token: "1234", TokenLockedAt: &lockedAt}, token: "1234", at: sameDay, wantErr: constants.ErrTokenLocked},
+		{name: "token vencido", delivery: models.Delivery{Token: "1234"}, token: "1234", at: nextDay, wantErr: constants.MsgSessionExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.delivery.FechaAccion = models.CustomDate{Time: fecha}
+			err := policy.checkCompletionToken(&tt.delivery, tt.token, tt.at)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkCompletionToken() error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("checkCompletionToken() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// tokenStore responde TokenInUse sin base de datos; el resto de DeliveryStore no se usa
+type tokenStore struct {
+	store.DeliveryStore
+	taken map[string]bool
+}
+
+func (s *tokenStore) TokenInUse(ctx context.Context, token, nroRto string, fechaAccion time.Time, excludeID int) (bool, error) {
+	return s.taken[token], nil
+}
+
+func TestSaveWithUniqueTokensRetriesWhenTokenTaken(t *testing.T) {
+	deliveryStore := &tokenStore{taken: map[string]bool{}}
+	delivery := &models.Delivery{NroRto: "R1", FechaAccion: models.CustomDate{Time: time.Date(2026, 5, 20, 0, 0, 0, 0, time.UTC)}}
+
+	saves := 0
+	err := DefaultTokenPolicy.saveWithUniqueTokens(context.Background(), deliveryStore, []*models.Delivery{delivery}, func() error {
+		saves++
+		if saves == 1 {
+			// Otra entrega tomó el token entre la elección y el lock
+			return store.ErrTokenInUse
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("saveWithUniqueTokens() error = %v", err)
+	}
+	if saves != 2 || delivery.Token == "" {
+		t.Errorf("saves = %d, token = %q; se esperaba un reintento con token asignado", saves, delivery.Token)
+	}
+
+	err = DefaultTokenPolicy.saveWithUniqueTokens(context.Background(), deliveryStore, []*models.Delivery{delivery}, func() error {
+		return store.ErrTokenInUse
+	})
+	if err == nil || err.Error() != constants.ErrTokenGeneration {
+		t.Errorf("sin tokens libres error = %v, want %q", err, constants.ErrTokenGeneration)
+	}
+}
@@ -22,17 +22,20 @@ type deliveryWithTermsService struct {
 	deliveryStore       store.DeliveryStore
 	termsSessionStore   store.TermsSessionStore
 	termsSessionService TermsSessionService
+	tokenPolicy         TokenPolicy
 }
 
 func NewDeliveryWithTermsService(
 	deliveryStore store.DeliveryStore,
 	termsSessionStore store.TermsSessionStore,
 	termsSessionService TermsSessionService,
+	tokenPolicy TokenPolicy,
 ) DeliveryWithTermsService {
 	return &deliveryWithTermsService{
 		deliveryStore:       deliveryStore,
 		termsSessionStore:   termsSessionStore,
 		termsSessionService: termsSessionService,
+		tokenPolicy:         tokenPolicy,
 	}
 }
 
@@ -122,8 +125,9 @@ func (s *deliveryWithTermsService) CompleteDelivery(ctx context.Context, termsTo
 		TermsSessionID: &termsSession.ID,
 		FechaAccion:    fechaAccion,
 	}
-	delivery.Token = generateDeliveryToken()
-	if err := s.deliveryStore.Create(ctx, delivery); err != nil {
+	if err := s.tokenPolicy.saveWithUniqueTokens(ctx, s.deliveryStore, []*models.Delivery{delivery}, func() error {
+		return s.deliveryStore.Create(ctx, delivery)
+	}); err != nil {
 		return nil, fmt.Errorf("error creando entrega: %w", err)
 	}
 	log.Info().
@@ -145,7 +149,3 @@ func (s *deliveryWithTermsService) GetDeliveryByTermsToken(ctx context.Context,
 	}
 	return nil, fmt.Errorf("funcionalidad no implementada")
 }
-
-func generateDeliveryToken() string {
-	return fmt.Sprintf("%04d", time.Now().UnixNano()%10000)
-}
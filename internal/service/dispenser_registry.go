@@ -0,0 +1,133 @@
+package service
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/dto"
+	"GoFrioCalor/internal/models"
+	"GoFrioCalor/internal/store"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+type DispenserService interface {
+	FindBySerial(ctx context.Context, serial string) (*models.Dispenser, error)
+	History(ctx context.Context, serial string) (*dto.DispenserHistoryResponse, error)
+	AccountEquipment(ctx context.Context, nroCta string) (*dto.AccountEquipmentResponse, error)
+}
+
+type dispenserService struct {
+	store          store.DispenserStore
+	workOrderStore store.WorkOrderStore
+}
+
+func NewDispenserService(store store.DispenserStore, workOrderStore store.WorkOrderStore) DispenserService {
+	return &dispenserService{store: store, workOrderStore: workOrderStore}
+}
+
+func (s *dispenserService) FindBySerial(ctx context.Context, serial string) (*models.Dispenser, error) {
+	dispenser, err := s.store.FindBySerial(ctx, normalizeSerial(serial))
+	if err != nil {
+		return nil, err
+	}
+	if dispenser == nil {
+		return nil, fmt.Errorf("%s: %s", constants.ErrDispenserNotFound, normalizeSerial(serial))
+	}
+	return dispenser, nil
+}
+
+func (s *dispenserService) History(ctx context.Context, serial string) (*dto.DispenserHistoryResponse, error) {
+	dispenser, err := s.FindBySerial(ctx, serial)
+	if err != nil {
+		return nil, err
+	}
+	movements, err := s.store.FindMovements(ctx, dispenser.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.DispenserHistoryResponse{
+		Dispenser: *dispenser,
+		Total:     len(movements),
+		Movements: movements,
+	}, nil
+}
+
+// dispenserMoves traduce las operaciones de una entrega completada a movimientos del registro.
+// Lo instalado o atendido queda en la cuenta; lo retirado viaja en el reparto hasta que se
+// descarga en depósito o taller.
+func dispenserMoves(delivery *models.Delivery, operations []dto.DispenserOperation, occurredAt time.Time) []store.DispenserMove {
+	moves := make([]store.DispenserMove, 0, len(operations))
+	add := func(op dto.DispenserOperation, serial string, status models.DispenserStatus, locationType models.DispenserLocationType, locationID string) {
+		if serial == "" {
+			return
+		}
+		deliveryID := delivery.ID
+		moves = append(moves, store.DispenserMove{
+			Tipo: models.TipoDispenser(op.Tipo),
+			Movement: models.DispenserMovement{
+				Serial:         serial,
+				Operation:      op.Type,
+				Status:         status,
+				ToLocationType: locationType,
+				ToLocationID:   locationID,
+				DeliveryID:     &deliveryID,
+				StaffID:        delivery.CompletedBy,
+				OccurredAt:     occurredAt,
+			},
+		})
+	}
+	for _, op := range operations {
+		switch op.Type {
+		case "installation":
+			add(op, op.InstalledDispenserCode, models.DispenserInstalado, models.UbicacionCuenta, delivery.NroCta)
+		case "retirement":
+			add(op, op.RetiredDispenserCode, models.DispenserRetirado, models.UbicacionCamion, delivery.NroRto)
+		case "replacement":
+			add(op, op.RetiredDispenserCode, models.DispenserRetirado, models.UbicacionCamion, delivery.NroRto)
+			add(op, op.InstalledDispenserCode, models.DispenserInstalado, models.UbicacionCuenta, delivery.NroCta)
+		case "service":
+			add(op, op.ServiceDispenserCode, models.DispenserInstalado, models.UbicacionCuenta, delivery.NroCta)
+		}
+	}
+	return moves
+}
+
+// recordDispenserMovements actualiza el registro de dispensers con las operaciones de la entrega.
+// La entrega ya quedó completada: un error acá se registra en el log sin rechazar el cierre.
+func (s *mobileDeliveryService) recordDispenserMovements(ctx context.Context, delivery *models.Delivery, operations []dto.DispenserOperation, occurredAt time.Time) {
+	if s.dispenserStore == nil {
+		return
+	}
+	moves := dispenserMoves(delivery, operations, occurredAt)
+	if len(moves) == 0 {
+		return
+	}
+	if err := s.dispenserStore.ApplyMovements(ctx, moves); err != nil {
+		log.Error().Err(err).Int("delivery_id", delivery.ID).Msg("Error recording dispenser movements")
+		return
+	}
+	log.Info().Int("delivery_id", delivery.ID).Int("movements", len(moves)).Msg("Dispenser movements recorded")
+}
+
+// locateDispenser ubica un número de serie para la validación del cierre. El registro de
+// dispensers tiene prioridad; los equipos que todavía no figuran en él se ubican por el
+// historial de entregas completadas.
+func (s *mobileDeliveryService) locateDispenser(ctx context.Context, serial string, excludeDeliveryID int) (*store.DispenserLocation, error) {
+	if s.dispenserStore != nil {
+		dispenser, err := s.dispenserStore.FindBySerial(ctx, serial)
+		if err != nil {
+			return nil, err
+		}
+		if dispenser != nil {
+			installed := dispenser.Status == models.DispenserInstalado && dispenser.LocationType == models.UbicacionCuenta
+			location := &store.DispenserLocation{Installed: installed}
+			if installed {
+				location.NroCta = dispenser.LocationID
+			}
+			return location, nil
+		}
+	}
+	return s.deliveryStore.FindDispenserLocation(ctx, serial, excludeDeliveryID)
+}
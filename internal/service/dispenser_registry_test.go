@@ -0,0 +1,49 @@
+package service
+
+import (
+	"GoFrioCalor/internal/dto"
+	"GoFrioCalor/internal/models"
+	"testing"
+	"time"
+)
+
+func TestDispenserMoves(t *testing.T) {
+	staffID := 7
+	delivery := &models.Delivery{ID: 10, NroCta: "100", NroRto: "R1", CompletedBy: &staffID}
+	operations := []dto.DispenserOperation{
+		{Type: "installation", Tipo: "P", InstalledDispenserCode: "DSP-000001"},
+		{Type: "replacement", Tipo: "M", RetiredDispenserCode: "DSP-000002", InstalledDispenserCode: "DSP-000003"},
+		{Type: "service", ServiceDispenserCode: "DSP-000004"},
+		{Type: "retirement"},
+	}
+	occurredAt := time.Date(2026, 5, 14, 15, 0, 0, 0, time.UTC)
+
+	moves := dispenserMoves(delivery, operations, occurredAt)
+
+	want := []struct {
+		serial       string
+		status       models.DispenserStatus
+		locationType models.DispenserLocationType
+		locationID   string
+	}{
+		{"DSP-000001", models.DispenserInstalado, models.UbicacionCuenta, "100"},
+		{"DSP-000002", models.DispenserRetirado, models.UbicacionCamion, "R1"},
+		{"DSP-000003", models.DispenserInstalado, models.UbicacionCuenta, "100"},
+		{"DSP-000004", models.DispenserInstalado, models.UbicacionCuenta, "100"},
+	}
+	if len(moves) != len(want) {
+		t.Fatalf("movimientos = %+v, se esperaban %d", moves, len(want))
+	}
+	for i, w := range want {
+		m := moves[i].Movement
+		if m.Serial != w.serial || m.Status != w.status || m.ToLocationType != w.locationType || m.ToLocationID != w.locationID {
+			t.Errorf("movimiento %d = %+v, se esperaba %+v", i, m, w)
+		}
+		if m.DeliveryID == nil || *m.DeliveryID != 10 || m.StaffID != &staffID || !m.OccurredAt.Equal(occurredAt) {
+			t.Errorf("movimiento %d sin entrega, personal o fecha: %+v", i, m)
+		}
+	}
+	if moves[1].Movement.Operation != "replacement" || moves[1].Tipo != models.TipoDispenserMesada {
+		t.Errorf("el retiro del recambio debe conservar operación y tipo: %+v", moves[1])
+	}
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/dto"
+	"GoFrioCalor/internal/models"
+	"GoFrioCalor/internal/store"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Códigos de error de validación de números de serie
+const (
+	SerialRequired           = "serial_required"
+	SerialFormat             = "serial_format"
+	SerialInstalledElsewhere = "serial_installed_elsewhere"
+	SerialNotInstalledHere   = "serial_not_installed_here"
+)
+
+// DispenserSerialPolicy define el formato admitido de número de serie para cada tipo de dispenser.
+// Un código se valida con el formato de su tipo; si no se conoce el tipo, basta con que cumpla
+// el de alguno.
+type DispenserSerialPolicy struct {
+	Formats map[models.TipoDispenser]string
+}
+
+// DefaultDispenserSerialPolicy admite códigos alfanuméricos en mayúsculas de 6 a 30 caracteres
+var DefaultDispenserSerialPolicy = DispenserSerialPolicy{
+	Formats: map[models.TipoDispenser]string{
+		models.TipoDispenserPie:    constants.DISPENSER_SERIAL_DEFAULT_FORMAT,
+		models.TipoDispenserMesada: constants.DISPENSER_SERIAL_DEFAULT_FORMAT,
+	},
+}
+
+// serialFormats son los formatos compilados de una DispenserSerialPolicy
+type serialFormats map[models.TipoDispenser]*regexp.Regexp
+
+// Validate verifica que haya al menos un formato y que todos compilen
+func (policy DispenserSerialPolicy) Validate() error {
+	_, err := policy.compile()
+	return err
+}
+
+func (policy DispenserSerialPolicy) compile() (serialFormats, error) {
+	if len(policy.Formats) == 0 {
+		return nil, fmt.Errorf("se requiere al menos un formato de número de serie")
+	}
+	formats := make(serialFormats, len(policy.Formats))
+	for tipo, pattern := range policy.Formats {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("formato de número de serie inválido para el tipo %s: %w", tipo, err)
+		}
+		formats[tipo] = re
+	}
+	return formats, nil
+}
+
+// mustCompile compila una política ya validada con Validate
+func (policy DispenserSerialPolicy) mustCompile() serialFormats {
+	formats, err := policy.compile()
+	if err != nil {
+		panic(err)
+	}
+	return formats
+}
+
+// normalizeSerial unifica los códigos escaneados o tipeados: sin espacios y en mayúsculas
+func normalizeSerial(serial string) string {
+	return strings.ToUpper(strings.TrimSpace(serial))
+}
+
+// normalizeOperations devuelve una copia de las operaciones con los códigos normalizados
+func normalizeOperations(operations []dto.DispenserOperation) []dto.DispenserOperation {
+	normalized := make([]dto.DispenserOperation, len(operations))
+	for i, op := range operations {
+		op.InstalledDispenserCode = normalizeSerial(op.InstalledDispenserCode)
+		op.RetiredDispenserCode = normalizeSerial(op.RetiredDispenserCode)
+		op.ServiceDispenserCode = normalizeSerial(op.ServiceDispenserCode)
+		normalized[i] = op
+	}
+	return normalized
+}
+
+// valid valida el código con el formato del tipo. Sin tipo, o si el tipo no tiene formato
+// propio, se acepta cualquiera de los formatos configurados.
+func (formats serialFormats) valid(serial string, tipo models.TipoDispenser) bool {
+	if re, ok := formats[tipo]; ok {
+		return re.MatchString(serial)
+	}
+	for _, re := range formats {
+		if re.MatchString(serial) {
+			return true
+		}
+	}
+	return false
+}
+
+// DispenserValidationError agrupa los errores de número de serie de las operaciones de una entrega
+type DispenserValidationError struct {
+	Errors []dto.DispenserSerialError
+}
+
+func (e *DispenserValidationError) Error() string {
+	operations := make(map[int]bool)
+	for _, serialError := range e.Errors {
+		operations[serialError.OperationIndex] = true
+	}
+	return fmt.Sprintf(constants.ErrDispenserSerialsInvalid, len(operations))
+}
+
+// dispenserLocator busca el último movimiento de un número de serie (ver DeliveryStore.FindDispenserLocation)
+type dispenserLocator func(ctx context.Context, serial string, excludeDeliveryID int) (*store.DispenserLocation, error)
+
+// validateDispenserSerials verifica los códigos de cada operación contra el formato configurado y
+// el historial de la cuenta: un dispenser instalado no puede figurar instalado en otra cuenta y uno
+// retirado tiene que figurar instalado en esta. Los errores de acceso al historial se devuelven
+// aparte: no son errores de los códigos.
+func validateDispenserSerials(ctx context.Context, formats serialFormats, locate dispenserLocator, nroCta string, deliveryID int, operations []dto.DispenserOperation) ([]dto.DispenserSerialError, error) {
+	var serialErrors []dto.DispenserSerialError
+	for i, op := range operations {
+		add := func(field, serial, code, message string) {
+			serialErrors = append(serialErrors, dto.DispenserSerialError{
+				OperationIndex: i,
+				Type:           op.Type,
+				Field:          field,
+				Serial:         serial,
+				Code:           code,
+				Message:        message,
+			})
+		}
+		// check valida un código; historyCheck es la regla de historial a aplicar (nil: solo formato)
+		check := func(field, serial string, historyCheck func(serial string, location *store.DispenserLocation) (string, string)) error {
+			if serial == "" {
+				add(field, "", SerialRequired, fmt.Sprintf(constants.ErrSerialRequired, field, op.Type))
+				return nil
+			}
+			if !formats.valid(serial, models.TipoDispenser(op.Tipo)) {
+				add(field, serial, SerialFormat, fmt.Sprintf(constants.ErrSerialFormat, serial))
+				return nil
+			}
+			if historyCheck == nil {
+				return nil
+			}
+			location, err := locate(ctx, serial, deliveryID)
+			if err != nil {
+				return err
+			}
+			if code, message := historyCheck(serial, location); code != "" {
+				add(field, serial, code, message)
+			}
+			return nil
+		}
+
+		notInstalledElsewhere := func(serial string, location *store.DispenserLocation) (string, string) {
+			if location != nil && location.Installed && location.NroCta != nroCta {
+				return SerialInstalledElsewhere, fmt.Sprintf(constants.ErrSerialInstalledElsewhere, serial, location.NroCta)
+			}
+			return "", ""
+		}
+		installedHere := func(serial string, location *store.DispenserLocation) (string, string) {
+			if location == nil || !location.Installed || location.NroCta != nroCta {
+				return SerialNotInstalledHere, fmt.Sprintf(constants.ErrSerialNotInstalledHere, serial, nroCta)
+			}
+			return "", ""
+		}
+
+		var err error
+		switch op.Type {
+		case "installation":
+			err = check("installed_dispenser_code", op.InstalledDispenserCode, notInstalledElsewhere)
+		case "retirement":
+			err = check("retired_dispenser_code", op.RetiredDispenserCode, installedHere)
+		case "replacement":
+			if err = check("retired_dispenser_code", op.RetiredDispenserCode, installedHere); err == nil {
+				err = check("installed_dispenser_code", op.InstalledDispenserCode, notInstalledElsewhere)
+			}
+		case "service":
+			err = check("service_dispenser_code", op.ServiceDispenserCode, nil)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return serialErrors, nil
+}
+
+// checkDispenserSerials aplica la validación de números de serie a un cierre de entrega. Sin override
+// los errores rechazan el cierre; con override se aceptan y se devuelven como advertencias.
+func (s *mobileDeliveryService) checkDispenserSerials(ctx context.Context, req dto.MobileCompleteDeliveryRequest, nroCta string, deliveryID int) ([]dto.DispenserSerialError, error) {
+	serialErrors, err := validateDispenserSerials(ctx, s.serialFormats, s.locateDispenser, nroCta, deliveryID, req.Operations)
+	if err != nil {
+		log.Error().Err(err).Str("nro_cta", nroCta).Msg("Error checking dispenser serial history")
+		return nil, fmt.Errorf("error verificando números de serie: %w", err)
+	}
+	if len(serialErrors) == 0 {
+		return nil, nil
+	}
+	if !req.OverrideSerialValidation {
+		log.Warn().
+			Int("delivery_id", deliveryID).
+			Str("nro_cta", nroCta).
+			Int("errors", len(serialErrors)).
+			Msg("Delivery completion rejected by dispenser serial validation")
+		return nil, &DispenserValidationError{Errors: serialErrors}
+	}
+	log.Warn().
+		Int("delivery_id", deliveryID).
+		Str("nro_cta", nroCta).
+		Int("errors", len(serialErrors)).
+		Str("reason", req.OverrideReason).
+		Msg("Dispenser serial validation overridden")
+	return serialErrors, nil
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"GoFrioCalor/internal/dto"
+	"GoFrioCalor/internal/models"
+	"GoFrioCalor/internal/store"
+	"context"
+	"testing"
+)
+
+func TestValidateDispenserSerials(t *testing.T) {
+	history := map[string]*store.DispenserLocation{
+		"DSP-100001": {DeliveryID: 1, NroCta: "100", Installed: true},
+		"DSP-200001": {DeliveryID: 2, NroCta: "200", Installed: true},
+		"DSP-300001": {DeliveryID: 3, NroCta: "100", Installed: false},
+	}
+	locate := func(ctx context.Context, serial string, excludeDeliveryID int) (*store.DispenserLocation, error) {
+		return history[serial], nil
+	}
+
+	tests := []struct {
+		name      string
+		op        dto.DispenserOperation
+		wantCodes []string
+	}{
+		{name: "instalación de dispenser nuevo", op: dto.DispenserOperation{Type: "installation", InstalledDispenserCode: "DSP-900001"}},
+		{name: "instalación de dispenser retirado antes", op: dto.DispenserOperation{Type: "installation", InstalledDispenserCode: "DSP-300001"}},
+		{name: "instalación de dispenser instalado en otra cuenta", op: dto.DispenserOperation{Type: "installation", InstalledDispenserCode: "DSP-200001"}, wantCodes: []string{SerialInstalledElsewhere}},
+		{name: "instalación sin código", op: dto.DispenserOperation{Type: "installation"}, wantCodes: []string{SerialRequired}},
+		{name: "formato inválido", op: dto.DispenserOperation{Type: "installation", InstalledDispenserCode: "AB"}, wantCodes: []string{SerialFormat}},
+		{name: "retiro de dispenser de la cuenta", op: dto.DispenserOperation{Type: "retirement", RetiredDispenserCode: "DSP-100001"}},
+		{name: "retiro de dispenser de otra cuenta", op: dto.DispenserOperation{Type: "retirement", RetiredDispenserCode: "DSP-200001"}, wantCodes: []string{SerialNotInstalledHere}},
+		{name: "retiro de dispenser ya retirado", op: dto.DispenserOperation{Type: "retirement", RetiredDispenserCode: "DSP-300001"}, wantCodes: []string{SerialNotInstalledHere}},
+		{name: "recambio con ambos errores", op: dto.DispenserOperation{Type: "replacement", RetiredDispenserCode: "DSP-900001", InstalledDispenserCode: "DSP-200001"}, wantCodes: []string{SerialNotInstalledHere, SerialInstalledElsewhere}},
+		{name: "service solo valida formato", op: dto.DispenserOperation{Type: "service", ServiceDispenserCode: "DSP-200001"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operations := normalizeOperations([]dto.DispenserOperation{tt.op})
+			serialErrors, err := validateDispenserSerials(context.Background(), DefaultDispenserSerialPolicy.mustCompile(), locate, "100", 0, operations)
+			if err != nil {
+				t.Fatalf("validateDispenserSerials() error = %v", err)
+			}
+			if len(serialErrors) != len(tt.wantCodes) {
+				t.Fatalf("errores = %+v, se esperaban %v", serialErrors, tt.wantCodes)
+			}
+			for i, code := range tt.wantCodes {
+				if serialErrors[i].Code != code || serialErrors[i].OperationIndex != 0 {
+					t.Errorf("error %d = %+v, se esperaba %s", i, serialErrors[i], code)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeOperations(t *testing.T) {
+	ops := []dto.DispenserOperation{{Type: "installation", InstalledDispenserCode: "  dsp-100001 "}}
+	normalized := normalizeOperations(ops)
+	if normalized[0].InstalledDispenserCode != "DSP-100001" {
+		t.Errorf("código normalizado = %q", normalized[0].InstalledDispenserCode)
+	}
+	if ops[0].InstalledDispenserCode != "  dsp-100001 " {
+		t.Errorf("normalizeOperations modificó las operaciones originales")
+	}
+}
+
+func TestSerialFormatValidByTipo(t *testing.T) {
+	policy := DispenserSerialPolicy{Formats: map[models.TipoDispenser]string{
+		models.TipoDispenserPie:    `^P-[0-9]{6}$`,
+		models.TipoDispenserMesada: `^M-[0-9]{6}$`,
+	}}
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	formats := policy.mustCompile()
+
+	tests := []struct {
+		serial string
+		tipo   models.TipoDispenser
+		want   bool
+	}{
+		{serial: "P-123456", tipo: models.TipoDispenserPie, want: true},
+		{serial: "M-123456", tipo: models.TipoDispenserPie, want: false},
+		{serial: "M-123456", tipo: models.TipoDispenserMesada, want: true},
+		{serial: "P-123456", tipo: models.TipoDispenserMesada, want: false},
+		{serial: "M-123456", tipo: "", want: true},
+		{serial: "X-123456", tipo: "", want: false},
+	}
+	for _, tt := range tests {
+		if got := formats.valid(tt.serial, tt.tipo); got != tt.want {
+			t.Errorf("valid(%q, %q) = %v, want %v", tt.serial, tt.tipo, got, tt.want)
+		}
+	}
+
+	operations := []dto.DispenserOperation{{Type: "service", Tipo: "P", ServiceDispenserCode: "M-123456"}}
+	serialErrors, err := validateDispenserSerials(context.Background(), formats, nil, "100", 0, operations)
+	if err != nil {
+		t.Fatalf("validateDispenserSerials() error = %v", err)
+	}
+	if len(serialErrors) != 1 || serialErrors[0].Code != SerialFormat {
+		t.Errorf("errores = %+v, se esperaba %s", serialErrors, SerialFormat)
+	}
+}
+
+func TestDispenserSerialPolicyValidateRejectsInvalidPattern(t *testing.T) {
+	if err := (DispenserSerialPolicy{}).Validate(); err == nil {
+		t.Error("se esperaba error sin formatos")
+	}
+	invalid := DispenserSerialPolicy{Formats: map[models.TipoDispenser]string{models.TipoDispenserPie: "^[A-Z"}}
+	if err := invalid.Validate(); err == nil {
+		t.Error("se esperaba error con una expresión regular inválida")
+	}
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/models"
+	"fmt"
+	"math"
+)
+
+const earthRadiusMeters = 6371000.0
+
+// Resultado de la verificación de geocerca al completar una entrega
+const (
+	GeofenceInside      = "inside"
+	GeofenceOutside     = "outside"
+	GeofenceMissing     = "missing"     // la dirección tiene coordenadas pero el dispositivo no envió lectura
+	GeofenceUnavailable = "unavailable" // sin coordenadas de la dirección
+)
+
+// GeofencePolicy define el radio admitido entre la lectura GPS de cierre y la dirección del cliente
+type GeofencePolicy struct {
+	// RadiusMeters es la distancia máxima a la dirección del cliente
+	RadiusMeters float64
+	// Reject rechaza la entrega fuera del radio en lugar de solo marcarla
+	Reject bool
+}
+
+// DefaultGeofencePolicy marca, sin rechazar, las entregas completadas a más de 200 m
+var DefaultGeofencePolicy = GeofencePolicy{
+	RadiusMeters: constants.GEOFENCE_DEFAULT_RADIUS_METERS,
+	Reject:       false,
+}
+
+// Validate verifica que el radio de la geocerca sea positivo
+func (policy GeofencePolicy) Validate() error {
+	if policy.RadiusMeters <= 0 {
+		return fmt.Errorf("radio de geocerca inválido: %.0f", policy.RadiusMeters)
+	}
+	return nil
+}
+
+// geofenceCheck es el resultado de comparar la lectura del dispositivo con la dirección del cliente
+type geofenceCheck struct {
+	Status   string
+	Distance *float64
+	Flagged  bool
+}
+
+// check compara la lectura del dispositivo con las coordenadas de la dirección del cliente.
+// La precisión informada por el dispositivo amplía el radio, como máximo al doble, para no marcar
+// entregas por el error propio del GPS. Si la dirección tiene coordenadas, cerrar sin lectura
+// también se marca: de lo contrario omitir el GPS evitaría la geocerca.
+func (policy GeofencePolicy) check(delivery *models.Delivery, latitude, longitude, accuracy *float64) geofenceCheck {
+	if delivery.AddressLatitude == nil || delivery.AddressLongitude == nil {
+		return geofenceCheck{Status: GeofenceUnavailable}
+	}
+	if latitude == nil || longitude == nil {
+		return geofenceCheck{Status: GeofenceMissing, Flagged: true}
+	}
+	distance := math.Round(distanceMeters(*latitude, *longitude, *delivery.AddressLatitude, *delivery.AddressLongitude))
+
+	tolerance := policy.RadiusMeters
+	if accuracy != nil && *accuracy > 0 {
+		tolerance += math.Min(*accuracy, policy.RadiusMeters)
+	}
+	if distance > tolerance {
+		return geofenceCheck{Status: GeofenceOutside, Distance: &distance, Flagged: true}
+	}
+	return geofenceCheck{Status: GeofenceInside, Distance: &distance}
+}
+
+// distanceMeters calcula la distancia entre dos coordenadas con la fórmula de haversine
+func distanceMeters(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(a))
+}
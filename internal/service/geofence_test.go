@@ -0,0 +1,54 @@
+package service
+
+import (
+	"GoFrioCalor/internal/models"
+	"math"
+	"testing"
+)
+
+func TestDistanceMeters(t *testing.T) {
+	// Obelisco → Casa Rosada (Buenos Aires), ~1.1 km
+	got := distanceMeters(-34.603722, -58.381592, -34.608056, -58.370278)
+	if math.Abs(got-1135) > 30 {
+		t.Errorf("distanceMeters() = %.0f, se esperaban ~1135 m", got)
+	}
+	if d := distanceMeters(-34.6, -58.4, -34.6, -58.4); d != 0 {
+		t.Errorf("distanceMeters() del mismo punto = %v, want 0", d)
+	}
+}
+
+func TestCheckGeofence(t *testing.T) {
+	lat, lng := -34.603722, -58.381592
+	delivery := &models.Delivery{AddressLatitude: &lat, AddressLongitude: &lng}
+	ptr := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name        string
+		delivery    *models.Delivery
+		lat, lng    *float64
+		accuracy    *float64
+		wantStatus  string
+		wantFlagged bool
+	}{
+		{name: "En la dirección", delivery: delivery, lat: ptr(-34.6038), lng: ptr(-58.3817), wantStatus: GeofenceInside},
+		{name: "A 1 km", delivery: delivery, lat: ptr(-34.608056), lng: ptr(-58.370278), wantStatus: GeofenceOutside, wantFlagged: true},
+		// ~250 m: fuera del radio, pero dentro con la precisión informada por el dispositivo
+		{name: "Precisión amplía el radio", delivery: delivery, lat: ptr(-34.605970), lng: ptr(-58.381592), accuracy: ptr(80), wantStatus: GeofenceInside},
+		{name: "Precisión acotada al radio", delivery: delivery, lat: ptr(-34.608056), lng: ptr(-58.370278), accuracy: ptr(5000), wantStatus: GeofenceOutside, wantFlagged: true},
+		{name: "Sin lectura del dispositivo", delivery: delivery, wantStatus: GeofenceMissing, wantFlagged: true},
+		{name: "Sin lectura ni coordenadas de la dirección", delivery: &models.Delivery{}, wantStatus: GeofenceUnavailable},
+		{name: "Sin coordenadas de la dirección", delivery: &models.Delivery{}, lat: ptr(lat), lng: ptr(lng), wantStatus: GeofenceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DefaultGeofencePolicy.check(tt.delivery, tt.lat, tt.lng, tt.accuracy)
+			if got.Status != tt.wantStatus || got.Flagged != tt.wantFlagged {
+				t.Errorf("check() = %+v, want status %s flagged %v", got, tt.wantStatus, tt.wantFlagged)
+			}
+			if (tt.wantStatus == GeofenceInside || tt.wantStatus == GeofenceOutside) && got.Distance == nil {
+				t.Error("check() debería informar la distancia")
+			}
+		})
+	}
+}
@@ -4,9 +4,24 @@ import (
 	"GoFrioCalor/internal/constants"
 	"GoFrioCalor/internal/models"
 	"fmt"
+	"reflect"
+	"strings"
 	"time"
+
+	"github.com/go-playground/validator/v10"
 )
 
+// bindingValidator valida fuera de gin con los mismos tags `binding` de modelos y DTOs;
+// los errores usan el nombre JSON del campo
+var bindingValidator = func() *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	})
+	return v
+}()
+
 func parseFechaAccion(fechaStr string) (models.CustomDate, error) {
 	if fechaStr == "" {
 		return models.CustomDate{Time: time.Now()}, nil
@@ -48,3 +63,16 @@ func createItemDispensers(cantidadPie, cantidadMesada uint) []models.ItemDispens
 	}
 	return items
 }
+
+// parseClientTimestamp interpreta una marca de tiempo RFC3339 informada por la app móvil.
+// Se tolera un pequeño adelanto del reloj del dispositivo respecto del servidor.
+func parseClientTimestamp(field, value string, now time.Time) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(constants.ErrClientTimestampFormat, field)
+	}
+	if t.After(now.Add(constants.CLIENT_CLOCK_SKEW_MINUTES * time.Minute)) {
+		return time.Time{}, fmt.Errorf(constants.ErrClientTimestampFuture, field)
+	}
+	return t, nil
+}
@@ -0,0 +1,454 @@
+package service
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/dto"
+	"GoFrioCalor/internal/metrics"
+	"GoFrioCalor/internal/models"
+	"GoFrioCalor/internal/store"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// MaintenancePolicy define cuándo y en qué fecha se generan los services preventivos
+type MaintenancePolicy struct {
+	// LeadDays es la anticipación, en días, con la que se crea el Service antes del vencimiento
+	LeadDays int
+	// SearchDays es la cantidad de días, a partir del vencimiento, en los que se busca lugar en el reparto
+	SearchDays int
+	// RouteDailyCapacity es el máximo de entregas abiertas por reparto y día; 0 no limita
+	RouteDailyCapacity int
+	// SkipWeekends evita programar services en sábado o domingo
+	SkipWeekends bool
+}
+
+// DefaultMaintenancePolicy crea el Service una semana antes del vencimiento, sin límite por reparto
+var DefaultMaintenancePolicy = MaintenancePolicy{
+	LeadDays:     constants.MAINTENANCE_DEFAULT_LEAD_DAYS,
+	SearchDays:   constants.MAINTENANCE_DEFAULT_SEARCH_DAYS,
+	SkipWeekends: true,
+}
+
+// Validate verifica la anticipación, la ventana de búsqueda y la capacidad por reparto
+func (policy MaintenancePolicy) Validate() error {
+	if policy.LeadDays < 0 {
+		return fmt.Errorf("anticipación de mantenimiento inválida: %d", policy.LeadDays)
+	}
+	if policy.SearchDays < 1 {
+		return fmt.Errorf("días de búsqueda de mantenimiento inválidos: %d", policy.SearchDays)
+	}
+	if policy.RouteDailyCapacity < 0 {
+		return fmt.Errorf("capacidad diaria por reparto inválida: %d", policy.RouteDailyCapacity)
+	}
+	return nil
+}
+
+// dueDate devuelve el día en que vence el service: intervalDays después del último
+func (p MaintenancePolicy) dueDate(lastService time.Time, intervalDays int) time.Time {
+	return time.Date(lastService.Year(), lastService.Month(), lastService.Day()+intervalDays, 0, 0, 0, 0, time.UTC)
+}
+
+// shouldSchedule indica si el vencimiento ya entró en la ventana de anticipación
+func (p MaintenancePolicy) shouldSchedule(due, today time.Time) bool {
+	return !today.Before(due.AddDate(0, 0, -p.LeadDays))
+}
+
+// candidateDates devuelve las fechas, en orden, en las que se intenta programar el service: desde el
+// vencimiento (o mañana, si ya venció) durante SearchDays días
+func (p MaintenancePolicy) candidateDates(due, today time.Time) []time.Time {
+	tomorrow := time.Date(today.Year(), today.Month(), today.Day()+1, 0, 0, 0, 0, time.UTC)
+	fecha := due
+	if fecha.Before(tomorrow) {
+		fecha = tomorrow
+	}
+	dates := make([]time.Time, 0, p.SearchDays)
+	for len(dates) < p.SearchDays {
+		weekday := fecha.Weekday()
+		if !p.SkipWeekends || (weekday != time.Saturday && weekday != time.Sunday) {
+			dates = append(dates, fecha)
+		}
+		fecha = fecha.AddDate(0, 0, 1)
+	}
+	return dates
+}
+
+type MaintenanceService interface {
+	List(ctx context.Context, filter store.MaintenancePlanFilter) ([]models.MaintenancePlan, error)
+	FindByID(ctx context.Context, id int) (*models.MaintenancePlan, error)
+	Create(ctx context.Context, req dto.MaintenancePlanRequest) (*models.MaintenancePlan, error)
+	Update(ctx context.Context, id int, req dto.MaintenancePlanRequest) (*models.MaintenancePlan, error)
+	Delete(ctx context.Context, id int) error
+	ScheduleDue(ctx context.Context, now time.Time) (int, error)
+}
+
+type maintenanceService struct {
+	store          store.MaintenanceStore
+	deliveryStore  store.DeliveryStore
+	dispenserStore store.DispenserStore
+	capacityStore  store.RouteCapacityStore
+	emailService   EmailService
+	tokenPolicy    TokenPolicy
+	policy         MaintenancePolicy
+}
+
+func NewMaintenanceService(store store.MaintenanceStore, deliveryStore store.DeliveryStore, dispenserStore store.DispenserStore, capacityStore store.RouteCapacityStore, emailService EmailService, tokenPolicy TokenPolicy, policy MaintenancePolicy) MaintenanceService {
+	return &maintenanceService{
+		store:          store,
+		deliveryStore:  deliveryStore,
+		dispenserStore: dispenserStore,
+		capacityStore:  capacityStore,
+		emailService:   emailService,
+		tokenPolicy:    tokenPolicy,
+		policy:         policy,
+	}
+}
+
+func (s *maintenanceService) List(ctx context.Context, filter store.MaintenancePlanFilter) ([]models.MaintenancePlan, error) {
+	return s.store.List(ctx, filter)
+}
+
+func (s *maintenanceService) FindByID(ctx context.Context, id int) (*models.MaintenancePlan, error) {
+	return s.store.FindByID(ctx, id)
+}
+
+func (s *maintenanceService) Create(ctx context.Context, req dto.MaintenancePlanRequest) (*models.MaintenancePlan, error) {
+	nroCta := strings.TrimSpace(req.NroCta)
+	serial := normalizeSerial(req.Serial)
+	if err := s.checkPlanTarget(ctx, 0, nroCta, serial); err != nil {
+		return nil, err
+	}
+
+	plan := &models.MaintenancePlan{
+		NroCta:       nroCta,
+		Serial:       serial,
+		IntervalDays: req.IntervalDays,
+		Active:       true,
+	}
+	if err := s.store.Create(ctx, plan); err != nil {
+		return nil, err
+	}
+	// default:true hace que GORM ignore Active=false en el INSERT
+	if req.Active != nil && !*req.Active {
+		plan.Active = false
+		if err := s.store.Update(ctx, plan); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+func (s *maintenanceService) Update(ctx context.Context, id int, req dto.MaintenancePlanRequest) (*models.MaintenancePlan, error) {
+	plan, err := s.store.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	nroCta := strings.TrimSpace(req.NroCta)
+	serial := normalizeSerial(req.Serial)
+	if nroCta != plan.NroCta || serial != plan.Serial {
+		if err := s.checkPlanTarget(ctx, plan.ID, nroCta, serial); err != nil {
+			return nil, err
+		}
+		// El vencimiento se recalcula con el nuevo destino en la próxima corrida
+		plan.LastServiceAt = nil
+		plan.NextDueAt = nil
+	}
+
+	plan.NroCta = nroCta
+	plan.Serial = serial
+	plan.IntervalDays = req.IntervalDays
+	if req.Active != nil {
+		plan.Active = *req.Active
+	}
+	if err := s.store.Update(ctx, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (s *maintenanceService) Delete(ctx context.Context, id int) error {
+	return s.store.Delete(ctx, id)
+}
+
+// checkPlanTarget rechaza un segundo plan para la misma cuenta o dispenser, y un plan por dispenser
+// que no está instalado en la cuenta
+func (s *maintenanceService) checkPlanTarget(ctx context.Context, planID int, nroCta, serial string) error {
+	existing, err := s.store.FindByTarget(ctx, nroCta, serial)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != planID {
+		target := "la cuenta " + nroCta
+		if serial != "" {
+			target = "el dispenser " + serial
+		}
+		return fmt.Errorf(constants.ErrMaintenancePlanExists, target)
+	}
+	if serial == "" {
+		return nil
+	}
+	dispenser, err := s.dispenserStore.FindBySerial(ctx, serial)
+	if err != nil {
+		return err
+	}
+	if !installedAt(dispenser, nroCta) {
+		return fmt.Errorf(constants.ErrSerialNotInstalledHere, serial, nroCta)
+	}
+	return nil
+}
+
+func installedAt(dispenser *models.Dispenser, nroCta string) bool {
+	return dispenser != nil &&
+		dispenser.Status == models.DispenserInstalado &&
+		dispenser.LocationType == models.UbicacionCuenta &&
+		dispenser.LocationID == nroCta
+}
+
+// ScheduleDue recorre los planes activos y crea una entrega Pendiente de Service para cada uno cuyo
+// vencimiento entró en la ventana de anticipación. Devuelve la cantidad de entregas creadas; los
+// errores de un plan se registran y no detienen la corrida.
+func (s *maintenanceService) ScheduleDue(ctx context.Context, now time.Time) (int, error) {
+	plans, err := s.store.List(ctx, store.MaintenancePlanFilter{ActiveOnly: true})
+	if err != nil {
+		return 0, err
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	created := 0
+	for i := range plans {
+		plan := &plans[i]
+		delivery, err := s.schedulePlan(ctx, plan, today)
+		if err != nil {
+			log.Error().Err(err).Int("plan_id", plan.ID).Str("nro_cta", plan.NroCta).Msg("Mantenimiento: error procesando plan")
+			continue
+		}
+		if delivery != nil {
+			created++
+			s.sendMaintenanceEmail(ctx, delivery)
+		}
+	}
+	return created, nil
+}
+
+// schedulePlan actualiza el vencimiento del plan y, si corresponde, crea el Service. Devuelve nil si
+// no se creó ninguna entrega.
+func (s *maintenanceService) schedulePlan(ctx context.Context, plan *models.MaintenancePlan, today time.Time) (*models.Delivery, error) {
+	lastService, err := s.store.LastServiceAt(ctx, plan)
+	if err != nil {
+		return nil, err
+	}
+	baseline := plan.CreatedAt
+	if lastService != nil {
+		baseline = *lastService
+	}
+	due := s.policy.dueDate(baseline, plan.IntervalDays)
+	plan.LastServiceAt = lastService
+	plan.NextDueAt = &due
+
+	delivery, err := s.createServiceDelivery(ctx, plan, due, today)
+	if err != nil {
+		return nil, err
+	}
+	if delivery != nil {
+		plan.LastDeliveryID = &delivery.ID
+	}
+	if err := s.store.Update(ctx, plan); err != nil {
+		return delivery, err
+	}
+	return delivery, nil
+}
+
+func (s *maintenanceService) createServiceDelivery(ctx context.Context, plan *models.MaintenancePlan, due, today time.Time) (*models.Delivery, error) {
+	if !s.policy.shouldSchedule(due, today) {
+		return nil, nil
+	}
+	open, err := s.store.HasOpenService(ctx, plan.NroCta)
+	if err != nil || open {
+		return nil, err
+	}
+
+	dispensers, err := s.dispenserStore.FindInstalledAtAccount(ctx, plan.NroCta)
+	if err != nil {
+		return nil, err
+	}
+	dispensers = planDispensers(plan, dispensers)
+	if len(dispensers) == 0 {
+		log.Warn().Int("plan_id", plan.ID).Str("nro_cta", plan.NroCta).Str("serial", plan.Serial).
+			Msg("Mantenimiento: sin dispensers instalados, no se genera el service")
+		return nil, nil
+	}
+
+	latest, err := s.store.FindLatestDelivery(ctx, plan.NroCta)
+	if err != nil {
+		return nil, err
+	}
+	if latest == nil {
+		log.Warn().Int("plan_id", plan.ID).Str("nro_cta", plan.NroCta).
+			Msg("Mantenimiento: la cuenta no tiene entregas de las que tomar el reparto")
+		return nil, nil
+	}
+
+	fecha, ok, err := s.findRouteDate(ctx, latest.NroRto, due, today)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		log.Warn().Int("plan_id", plan.ID).Str("nro_cta", plan.NroCta).Str("nro_rto", latest.NroRto).
+			Time("due", due).Msg("Mantenimiento: el reparto no tiene lugar en los próximos días, se reintenta mañana")
+		return nil, nil
+	}
+
+	delivery := buildMaintenanceDelivery(latest, dispensers, fecha)
+	if err := s.tokenPolicy.saveWithUniqueTokens(ctx, s.deliveryStore, []*models.Delivery{delivery}, func() error {
+		return s.deliveryStore.Create(ctx, delivery)
+	}); err != nil {
+		return nil, fmt.Errorf("error creando service preventivo: %w", err)
+	}
+	log.Info().Int("plan_id", plan.ID).Int("delivery_id", delivery.ID).Str("nro_cta", plan.NroCta).
+		Str("fecha_accion", fecha.Format("2006-01-02")).Msg("Mantenimiento: service preventivo programado")
+	return delivery, nil
+}
+
+// findRouteDate devuelve la primera fecha candidata en la que el reparto tiene lugar para un Service,
+// según las reglas de capacidad del reparto y RouteDailyCapacity
+func (s *maintenanceService) findRouteDate(ctx context.Context, nroRto string, due, today time.Time) (time.Time, bool, error) {
+	candidates := s.policy.candidateDates(due, today)
+	if len(candidates) == 0 {
+		return time.Time{}, false, nil
+	}
+	calendar, err := loadRouteCalendar(ctx, s.capacityStore, nroRto, candidates[0], candidates[len(candidates)-1])
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	for _, fecha := range candidates {
+		if calendar.full(fecha, models.Service) != nil {
+			continue
+		}
+		if s.policy.RouteDailyCapacity == 0 {
+			return fecha, true, nil
+		}
+		count, err := s.store.CountOpenOnRoute(ctx, nroRto, fecha)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		if count < int64(s.policy.RouteDailyCapacity) {
+			return fecha, true, nil
+		}
+	}
+	return time.Time{}, false, nil
+}
+
+// planDispensers devuelve los dispensers que cubre el plan entre los instalados en la cuenta
+func planDispensers(plan *models.MaintenancePlan, installed []models.Dispenser) []models.Dispenser {
+	if plan.Serial == "" {
+		return installed
+	}
+	for _, dispenser := range installed {
+		if dispenser.Serial == plan.Serial {
+			return []models.Dispenser{dispenser}
+		}
+	}
+	return nil
+}
+
+// buildMaintenanceDelivery arma el Service Pendiente con los datos del cliente de la última entrega
+// de la cuenta y un ítem por tipo de los dispensers a atender
+func buildMaintenanceDelivery(latest *models.Delivery, dispensers []models.Dispenser, fecha time.Time) *models.Delivery {
+	counts := make(map[models.TipoDispenser]uint)
+	var order []models.TipoDispenser
+	for _, dispenser := range dispensers {
+		tipo := dispenser.Tipo
+		if tipo == "" {
+			tipo = models.TipoDispenserPie
+		}
+		if _, ok := counts[tipo]; !ok {
+			order = append(order, tipo)
+		}
+		counts[tipo]++
+	}
+
+	delivery := &models.Delivery{
+		NroCta:           latest.NroCta,
+		Name:             latest.Name,
+		Email:            latest.Email,
+		Address:          latest.Address,
+		Locality:         latest.Locality,
+		AddressLatitude:  latest.AddressLatitude,
+		AddressLongitude: latest.AddressLongitude,
+		NroRto:           latest.NroRto,
+		ItemDispensers:   make([]models.ItemDispenser, 0, len(order)),
+		Estado:           models.Pendiente,
+		TipoEntrega:      models.Service,
+		EntregadoPor:     models.Tecnico,
+		FechaAccion:      models.CustomDate{Time: fecha},
+	}
+	for _, tipo := range order {
+		delivery.ItemDispensers = append(delivery.ItemDispensers, models.ItemDispenser{Tipo: tipo, Cantidad: counts[tipo]})
+		delivery.Cantidad += counts[tipo]
+	}
+	return delivery
+}
+
+func (s *maintenanceService) sendMaintenanceEmail(ctx context.Context, delivery *models.Delivery) {
+	if s.emailService == nil || delivery.Email == "" {
+		return
+	}
+	subject := fmt.Sprintf("Service preventivo programado - %s", delivery.FechaAccion.Format("02/01/2006"))
+
+	htmlBody := fmt.Sprintf(`
+		<html>
+		<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333;">
+			<div style="max-width: 600px; margin: 0 auto; padding: 20px;">
+				<h2 style="color: #2c3e50; border-bottom: 2px solid #3498db; padding-bottom: 10px;">
+					🧼 Service preventivo programado
+				</h2>
+
+				<p>Estimado cliente,</p>
+
+				<p>Programamos la sanitización periódica de sus dispensers. Un técnico lo visitará en la fecha indicada:</p>
+
+				<div style="background-color: #f8f9fa; padding: 15px; border-radius: 5px; margin: 20px 0;">
+					<p style="margin: 5px 0;"><strong>📅 Fecha:</strong> <span style="font-size: 20px; color: #27ae60; font-weight: bold;">%s</span></p>
+					<p style="margin: 5px 0;"><strong>🔑 Token de Validación:</strong> <span style="font-size: 24px; color: #e74c3c; font-weight: bold;">%s</span></p>
+					<p style="margin: 5px 0;"><strong>📦 Cuenta:</strong> %s</p>
+					<p style="margin: 5px 0;"><strong>📊 Cantidad de Dispensers:</strong> %d</p>
+				</div>
+
+				<div style="background-color: #fff3cd; padding: 15px; border-left: 4px solid #ffc107; margin: 20px 0;">
+					<p style="margin: 0;"><strong>⚠️ Importante:</strong> Tenga el token a mano cuando llegue el técnico. Si la fecha no le resulta conveniente puede reprogramarla con nuestro centro de atención.</p>
+				</div>
+
+				<p style="color: #7f8c8d; font-size: 12px; margin-top: 30px; border-top: 1px solid #ecf0f1; padding-top: 15px;">
+					Este es un email automático. Por favor no responda a este mensaje.<br>
+					<strong>El Jumillano - Sistema de Gestión de Entregas</strong>
+				</p>
+			</div>
+		</body>
+		</html>
+	`,
+		delivery.FechaAccion.Format("02/01/2006"),
+		delivery.Token,
+		delivery.NroCta,
+		delivery.Cantidad,
+	)
+
+	err := s.emailService.SendHTMLEmail(ctx, delivery.Email, subject, htmlBody)
+	if err != nil {
+		metrics.EmailSent("maintenance", false)
+		log.Error().
+			Err(err).
+			Int("delivery_id", delivery.ID).
+			Str("email", delivery.Email).
+			Msg("Error enviando email de service preventivo")
+		return
+	}
+	metrics.EmailSent("maintenance", true)
+	log.Info().
+		Int("delivery_id", delivery.ID).
+		Str("email", delivery.Email).
+		Msg("Email de service preventivo enviado exitosamente")
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"GoFrioCalor/internal/models"
+	"testing"
+	"time"
+)
+
+func TestMaintenancePolicySchedule(t *testing.T) {
+	policy := MaintenancePolicy{LeadDays: 7, SearchDays: 3, SkipWeekends: true}
+	lastService := time.Date(2026, 3, 2, 14, 30, 0, 0, time.UTC)
+
+	due := policy.dueDate(lastService, 90)
+	if want := time.Date(2026, 5, 31, 0, 0, 0, 0, time.UTC); !due.Equal(want) {
+		t.Fatalf("dueDate() = %v, se esperaba %v", due, want)
+	}
+	if policy.shouldSchedule(due, time.Date(2026, 5, 23, 0, 0, 0, 0, time.UTC)) {
+		t.Error("no se debe programar 8 días antes del vencimiento")
+	}
+	if !policy.shouldSchedule(due, time.Date(2026, 5, 24, 0, 0, 0, 0, time.UTC)) {
+		t.Error("se debe programar 7 días antes del vencimiento")
+	}
+
+	// El 31/05/2026 es domingo: se salta al lunes
+	dates := policy.candidateDates(due, time.Date(2026, 5, 24, 0, 0, 0, 0, time.UTC))
+	want := []time.Time{
+		time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 6, 2, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 6, 3, 0, 0, 0, 0, time.UTC),
+	}
+	if len(dates) != len(want) {
+		t.Fatalf("candidateDates() = %v, se esperaba %v", dates, want)
+	}
+	for i := range want {
+		if !dates[i].Equal(want[i]) {
+			t.Errorf("fecha %d = %v, se esperaba %v", i, dates[i], want[i])
+		}
+	}
+
+	// Vencido: se empieza a buscar desde mañana
+	overdue := policy.candidateDates(due, time.Date(2026, 6, 9, 0, 0, 0, 0, time.UTC))
+	if !overdue[0].Equal(time.Date(2026, 6, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("primera fecha de un plan vencido = %v", overdue[0])
+	}
+}
+
+func TestMaintenancePolicyValidateRejectsInvalidValues(t *testing.T) {
+	if err := (MaintenancePolicy{LeadDays: 7, SearchDays: 0}).Validate(); err == nil {
+		t.Error("se esperaba error con SearchDays = 0")
+	}
+	if err := (MaintenancePolicy{LeadDays: 7, SearchDays: 5, RouteDailyCapacity: -1}).Validate(); err == nil {
+		t.Error("se esperaba error con capacidad negativa")
+	}
+}
+
+func TestBuildMaintenanceDelivery(t *testing.T) {
+	installed := []models.Dispenser{
+		{Serial: "DSP-100001", Tipo: models.TipoDispenserPie},
+		{Serial: "DSP-100002", Tipo: models.TipoDispenserMesada},
+		{Serial: "DSP-100003", Tipo: models.TipoDispenserPie},
+	}
+	latest := &models.Delivery{NroCta: "100", NroRto: "R1", Email: "cliente@example.com", Address: "Calle 123", TipoEntrega: models.Instalacion}
+	fecha := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	accountPlan := &models.MaintenancePlan{NroCta: "100"}
+	delivery := buildMaintenanceDelivery(latest, planDispensers(accountPlan, installed), fecha)
+	if delivery.TipoEntrega != models.Service || delivery.Estado != models.Pendiente || delivery.EntregadoPor != models.Tecnico {
+		t.Errorf("entrega = %+v, se esperaba Service Pendiente para técnico", delivery)
+	}
+	if delivery.NroRto != "R1" || delivery.Email != "cliente@example.com" || !delivery.FechaAccion.Time.Equal(fecha) {
+		t.Errorf("datos copiados = %+v", delivery)
+	}
+	if delivery.Cantidad != 3 || len(delivery.ItemDispensers) != 2 || delivery.ItemDispensers[0].Cantidad != 2 {
+		t.Errorf("ítems = %+v, cantidad = %d", delivery.ItemDispensers, delivery.Cantidad)
+	}
+
+	serialPlan := &models.MaintenancePlan{NroCta: "100", Serial: "DSP-100002"}
+	delivery = buildMaintenanceDelivery(latest, planDispensers(serialPlan, installed), fecha)
+	if delivery.Cantidad != 1 || delivery.ItemDispensers[0].Tipo != models.TipoDispenserMesada {
+		t.Errorf("plan por dispenser: ítems = %+v", delivery.ItemDispensers)
+	}
+
+	if dispensers := planDispensers(&models.MaintenancePlan{NroCta: "100", Serial: "DSP-999999"}, installed); len(dispensers) != 0 {
+		t.Errorf("dispenser no instalado: %+v", dispensers)
+	}
+}
@@ -2,10 +2,12 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"GoFrioCalor/internal/constants"
 	"GoFrioCalor/internal/dto"
 	"GoFrioCalor/internal/metrics"
 	"GoFrioCalor/internal/models"
@@ -17,108 +19,251 @@ import (
 type MobileDeliveryService interface {
 	ValidateToken(ctx context.Context, req dto.ValidateTokenRequest) (*dto.ValidateTokenResponse, error)
 	CompleteDelivery(ctx context.Context, req dto.MobileCompleteDeliveryRequest) (*dto.MobileCompleteDeliveryResponse, error)
-	SearchDeliveries(ctx context.Context, fechaAccion string, nroRto string) ([]dto.MobileDeliverySearchResponse, error)
+	SearchDeliveries(ctx context.Context, fechaAccion string, nroRto string) ([]dto.MobileRouteStop, error)
+	RouteManifest(ctx context.Context, nroRto, fechaAccion string) (*dto.MobileRouteManifestResponse, error)
+	FailDelivery(ctx context.Context, deliveryID int, req dto.MobileFailDeliveryRequest) (*dto.MobileFailDeliveryResponse, error)
+	ListFailureReasons(ctx context.Context) ([]models.FailureReason, error)
+	Sync(ctx context.Context, req dto.MobileSyncRequest) (*dto.MobileSyncResponse, error)
 }
 
 type mobileDeliveryService struct {
-	deliveryStore     store.DeliveryStore
-	termsSessionStore store.TermsSessionStore
-	publisher         *RabbitMQPublisher
-	pdfService        PDFService
-	emailService      EmailService
-	clientLookup      ClientLookupService
+	deliveryStore         store.DeliveryStore
+	termsSessionStore     store.TermsSessionStore
+	failureReasonStore    store.FailureReasonStore
+	syncStore             store.MobileSyncStore
+	dispenserStore        store.DispenserStore
+	preparationStore      store.PreparationStore
+	publisher             *RabbitMQPublisher
+	pdfService            PDFService
+	emailService          EmailService
+	clientLookup          ClientLookupService
+	tokenPolicy           TokenPolicy
+	geofencePolicy        GeofencePolicy
+	serialFormats         serialFormats
+	partialFollowUpPolicy PartialFollowUpPolicy
 }
 
 func NewMobileDeliveryService(deliveryStore store.DeliveryStore, publisher *RabbitMQPublisher) MobileDeliveryService {
 	return &mobileDeliveryService{
-		deliveryStore:     deliveryStore,
-		termsSessionStore: nil,
-		publisher:         publisher,
-		pdfService:        nil,
-		emailService:      nil,
-		clientLookup:      nil,
+		deliveryStore:         deliveryStore,
+		termsSessionStore:     nil,
+		failureReasonStore:    nil,
+		syncStore:             nil,
+		dispenserStore:        nil,
+		preparationStore:      nil,
+		publisher:             publisher,
+		pdfService:            nil,
+		emailService:          nil,
+		clientLookup:          nil,
+		tokenPolicy:           DefaultTokenPolicy,
+		geofencePolicy:        DefaultGeofencePolicy,
+		serialFormats:         DefaultDispenserSerialPolicy.mustCompile(),
+		partialFollowUpPolicy: DefaultPartialFollowUpPolicy,
 	}
 }
 
-func NewMobileDeliveryServiceWithServices(deliveryStore store.DeliveryStore, termsSessionStore store.TermsSessionStore, publisher *RabbitMQPublisher, pdfService PDFService, emailService EmailService, clientLookup ClientLookupService) MobileDeliveryService {
+func NewMobileDeliveryServiceWithServices(deliveryStore store.DeliveryStore, termsSessionStore store.TermsSessionStore, failureReasonStore store.FailureReasonStore, syncStore store.MobileSyncStore, dispenserStore store.DispenserStore, preparationStore store.PreparationStore, publisher *RabbitMQPublisher, pdfService PDFService, emailService EmailService, clientLookup ClientLookupService, tokenPolicy TokenPolicy, geofencePolicy GeofencePolicy, serialPolicy DispenserSerialPolicy, partialFollowUpPolicy PartialFollowUpPolicy) MobileDeliveryService {
 	return &mobileDeliveryService{
-		deliveryStore:     deliveryStore,
-		termsSessionStore: termsSessionStore,
-		publisher:         publisher,
-		pdfService:        pdfService,
-		emailService:      emailService,
-		clientLookup:      clientLookup,
+		deliveryStore:         deliveryStore,
+		termsSessionStore:     termsSessionStore,
+		failureReasonStore:    failureReasonStore,
+		syncStore:             syncStore,
+		dispenserStore:        dispenserStore,
+		preparationStore:      preparationStore,
+		publisher:             publisher,
+		pdfService:            pdfService,
+		emailService:          emailService,
+		clientLookup:          clientLookup,
+		tokenPolicy:           tokenPolicy,
+		geofencePolicy:        geofencePolicy,
+		serialFormats:         serialPolicy.mustCompile(),
+		partialFollowUpPolicy: partialFollowUpPolicy,
 	}
 }
 
-func (s *mobileDeliveryService) SearchDeliveries(ctx context.Context, fechaAccion string, nroRto string) ([]dto.MobileDeliverySearchResponse, error) {
-	parsedDate, err := time.Parse("2006-01-02", fechaAccion)
-	if err != nil {
-		return nil, fmt.Errorf("fecha inválida. Formato esperado: YYYY-MM-DD")
+// ValidateToken verifica el token contra las entregas abiertas de la cuenta en la fecha indicada.
+// Cada token incorrecto suma un intento fallido a esas entregas; al llegar al máximo de la política
+// el token queda bloqueado hasta que contact center lo regenere.
+func (s *mobileDeliveryService) ValidateToken(ctx context.Context, req dto.ValidateTokenRequest) (*dto.ValidateTokenResponse, error) {
+	validatedAt := time.Now()
+	if req.ValidatedAt != "" {
+		var err error
+		if validatedAt, err = parseClientTimestamp("validated_at", req.ValidatedAt, validatedAt); err != nil {
+			return nil, err
+		}
 	}
-	deliveries, err := s.deliveryStore.FindByRto(ctx, nroRto, &parsedDate)
-	if err != nil {
-		return nil, fmt.Errorf("error buscando deliveries: %w", err)
-	}
-	results := make([]dto.MobileDeliverySearchResponse, 0, len(deliveries))
-	for _, d := range deliveries {
-		results = append(results, dto.MobileDeliverySearchResponse{
-			ID:          d.ID,
-			FechaAccion: d.FechaAccion.Format("2006-01-02"),
-			NroCta:      d.NroCta,
-			Token:       d.Token,
-		})
+	if (req.Latitude == nil) != (req.Longitude == nil) {
+		return nil, fmt.Errorf(constants.ErrDeviceLocationIncomplete)
 	}
-	return results, nil
-}
 
-func (s *mobileDeliveryService) ValidateToken(ctx context.Context, req dto.ValidateTokenRequest) (*dto.ValidateTokenResponse, error) {
-	foundDelivery, err := s.deliveryStore.FindByTokenAndFilters(ctx, req.Token, req.NroCta, req.FechaAccion, models.Pendiente)
+	candidates, err := s.deliveryStore.FindOpenByNroCtaAndFecha(ctx, req.NroCta, req.FechaAccion)
 	if err != nil {
 		log.Error().Err(err).Msg("Error validating token")
 		return nil, fmt.Errorf("error validando token: %w", err)
 	}
-	if foundDelivery == nil {
+	if len(candidates) == 0 {
 		return &dto.ValidateTokenResponse{
 			Valid:   false,
 			Message: "Datos de validación incorrectos o entrega ya completada",
 		}, nil
 	}
-	log.Info().
-		Int("delivery_id", foundDelivery.ID).
-		Str("token", req.Token).
-		Str("nro_cta", req.NroCta).
-		Msg("Token validated successfully")
+
+	for i := range candidates {
+		candidate := &candidates[i]
+		if candidate.Token != req.Token {
+			continue
+		}
+		if candidate.TokenLockedAt != nil {
+			return lockedTokenResponse(), nil
+		}
+		if s.tokenPolicy.expired(candidate, validatedAt) {
+			return &dto.ValidateTokenResponse{Valid: false, Expired: true, Message: constants.MsgSessionExpired}, nil
+		}
+		if candidate.TokenFailedAttempts > 0 {
+			if err := s.deliveryStore.ResetTokenAttempts(ctx, candidate.ID); err != nil {
+				log.Warn().Err(err).Int("delivery_id", candidate.ID).Msg("Error resetting token attempts")
+			}
+		}
+		if req.Latitude != nil {
+			if err := s.deliveryStore.SaveValidationLocation(ctx, candidate.ID, req.Latitude, req.Longitude, req.Accuracy); err != nil {
+				log.Warn().Err(err).Int("delivery_id", candidate.ID).Msg("Error saving validation location")
+			}
+		}
+		log.Info().
+			Int("delivery_id", candidate.ID).
+			Str("nro_cta", req.NroCta).
+			Msg("Token validated successfully")
+		return &dto.ValidateTokenResponse{
+			Valid:   true,
+			Message: "Token válido",
+		}, nil
+	}
+
+	remaining, locked, err := s.registerFailedTokenAttempt(ctx, candidates)
+	if err != nil {
+		return nil, fmt.Errorf("error validando token: %w", err)
+	}
+	if locked {
+		return lockedTokenResponse(), nil
+	}
 	return &dto.ValidateTokenResponse{
-		Valid:   true,
-		Message: "Token válido",
+		Valid:             false,
+		Message:           "Token incorrecto",
+		RemainingAttempts: &remaining,
 	}, nil
 }
 
+// registerFailedTokenAttempt imputa el intento fallido a cada entrega candidata y devuelve los
+// intentos restantes de la más comprometida
+func (s *mobileDeliveryService) registerFailedTokenAttempt(ctx context.Context, candidates []models.Delivery) (int, bool, error) {
+	remaining := s.tokenPolicy.MaxAttempts
+	locked := false
+	for _, candidate := range candidates {
+		updated, err := s.deliveryStore.RegisterFailedTokenAttempt(ctx, candidate.ID, s.tokenPolicy.MaxAttempts)
+		if err != nil {
+			return 0, false, err
+		}
+		if updated.TokenLockedAt != nil {
+			locked = true
+			log.Warn().
+				Int("delivery_id", updated.ID).
+				Int("failed_attempts", updated.TokenFailedAttempts).
+				Msg("Delivery token locked after too many failed attempts")
+			continue
+		}
+		if left := s.tokenPolicy.MaxAttempts - updated.TokenFailedAttempts; left < remaining {
+			remaining = left
+		}
+	}
+	return remaining, locked, nil
+}
+
+func lockedTokenResponse() *dto.ValidateTokenResponse {
+	return &dto.ValidateTokenResponse{Valid: false, Locked: true, Message: constants.ErrTokenLocked}
+}
+
 func (s *mobileDeliveryService) CompleteDelivery(ctx context.Context, req dto.MobileCompleteDeliveryRequest) (*dto.MobileCompleteDeliveryResponse, error) {
 	tipoEntrega := deriveTipoEntrega(req.Operations)
 	var delivery *models.Delivery
+	var serialWarnings []dto.DispenserSerialError
+	var preparationWarnings []dto.DispenserSerialError
+	var shortfalls []models.DeliveryShortfall
+	var followUp *models.Delivery
 	var err error
+
+	req.Operations = normalizeOperations(req.Operations)
+	req.OverrideReason = strings.TrimSpace(req.OverrideReason)
+	if req.OverrideSerialValidation && req.OverrideReason == "" {
+		return nil, fmt.Errorf(constants.ErrSerialOverrideReasonRequired)
+	}
+
+	// Con la app offline la entrega se cierra en el dispositivo y se sincroniza después:
+	// completed_at conserva el momento real de la entrega
+	completedAt := time.Now()
+	if req.CompletedAt != "" {
+		if completedAt, err = parseClientTimestamp("completed_at", req.CompletedAt, completedAt); err != nil {
+			return nil, err
+		}
+	}
+	if (req.Latitude == nil) != (req.Longitude == nil) {
+		return nil, fmt.Errorf(constants.ErrDeviceLocationIncomplete)
+	}
 	if req.DeliveryID > 0 {
 		delivery, err = s.deliveryStore.FindByID(ctx, req.DeliveryID)
 		if err != nil {
 			log.Error().Err(err).Int("delivery_id", req.DeliveryID).Msg("Error fetching delivery")
-			return nil, fmt.Errorf("error buscando delivery: %w", err)
+			return nil, fmt.Errorf(constants.ErrDeliveryNotFound)
 		}
 
-		if req.Token != "" && delivery.Token != req.Token {
-			log.Warn().Int("delivery_id", req.DeliveryID).Msg("Invalid token for completing delivery")
-			return nil, fmt.Errorf("token inválido")
+		if err := s.tokenPolicy.checkCompletionToken(delivery, req.Token, completedAt); err != nil {
+			if errors.Is(err, errInvalidCompletionToken) {
+				log.Warn().Int("delivery_id", req.DeliveryID).Msg("Invalid token for completing delivery")
+				if _, err := s.deliveryStore.RegisterFailedTokenAttempt(ctx, delivery.ID, s.tokenPolicy.MaxAttempts); err != nil {
+					log.Error().Err(err).Int("delivery_id", delivery.ID).Msg("Error registering failed token attempt")
+				}
+			}
+			return nil, err
 		}
 
-		if delivery.Estado != models.Pendiente {
+		if !delivery.Estado.CanTransitionTo(models.Completado) {
 			log.Warn().
 				Int("delivery_id", req.DeliveryID).
 				Str("estado", string(delivery.Estado)).
 				Msg("Delivery already processed")
 			return nil, fmt.Errorf("la entrega ya fue procesada (estado: %s)", delivery.Estado)
 		}
+
+		if geofence := s.geofencePolicy.check(delivery, req.Latitude, req.Longitude, req.Accuracy); geofence.Flagged && s.geofencePolicy.Reject {
+			if geofence.Distance == nil {
+				log.Warn().Int("delivery_id", req.DeliveryID).Msg("Delivery completion rejected without device location")
+				return nil, fmt.Errorf(constants.ErrGeofenceMissingLocation)
+			}
+			log.Warn().
+				Int("delivery_id", req.DeliveryID).
+				Float64("distance_meters", *geofence.Distance).
+				Msg("Delivery completion rejected outside geofence")
+			return nil, fmt.Errorf(constants.ErrGeofenceOutside, *geofence.Distance, s.geofencePolicy.RadiusMeters)
+		}
+
+		if serialWarnings, err = s.checkDispenserSerials(ctx, req, delivery.NroCta, delivery.ID); err != nil {
+			return nil, err
+		}
+		// Instalar un equipo distinto del que preparó el taller no bloquea el cierre: se advierte
+		preparationWarnings = s.checkPreparation(ctx, delivery.ID, req.Operations)
+
+		// Si se realizaron menos operaciones que los dispensers pedidos, el resto queda en una
+		// entrega de seguimiento
+		if shortfalls, err = computeShortfalls(delivery.ItemDispensers, req.Operations); err != nil {
+			return nil, err
+		}
+		if len(shortfalls) > 0 {
+			followUp = s.partialFollowUpPolicy.buildShortfallFollowUp(delivery, delivery.TipoEntrega, shortfalls, completedAt)
+		}
 	} else {
+		if serialWarnings, err = s.checkDispenserSerials(ctx, req, req.NroCta, 0); err != nil {
+			return nil, err
+		}
+
 		nroRto := req.NroRto
 		if nroRto == "" {
 			nroRto = req.OrderNumber
@@ -134,7 +279,7 @@ func (s *mobileDeliveryService) CompleteDelivery(ctx context.Context, req dto.Mo
 			TipoEntrega:  tipoEntrega,
 			EntregadoPor: models.Tecnico,
 			Cantidad:     uint(len(req.Operations)),
-			FechaAccion:  models.CustomDate{Time: time.Now()},
+			FechaAccion:  models.CustomDate{Time: completedAt},
 		}
 		if err = s.deliveryStore.Create(ctx, delivery); err != nil {
 			log.Error().Err(err).Msg("Error creating on-the-fly delivery")
@@ -155,6 +300,7 @@ func (s *mobileDeliveryService) CompleteDelivery(ctx context.Context, req dto.Mo
 		delivery.Locality = req.Locality
 	}
 	installed := make([]string, 0, len(req.Operations))
+	retired := make([]string, 0, len(req.Operations))
 	for _, op := range req.Operations {
 		if op.InstalledDispenserCode != "" {
 			installed = append(installed, op.InstalledDispenserCode)
@@ -162,17 +308,64 @@ func (s *mobileDeliveryService) CompleteDelivery(ctx context.Context, req dto.Mo
 		if op.ServiceDispenserCode != "" {
 			installed = append(installed, op.ServiceDispenserCode)
 		}
+		if op.RetiredDispenserCode != "" {
+			retired = append(retired, op.RetiredDispenserCode)
+		}
 	}
 	delivery.ValidatedDispensers = models.StringArray(installed)
+	delivery.RetiredDispensers = models.StringArray(retired)
+	if len(serialWarnings) > 0 {
+		delivery.SerialOverride = req.OverrideReason
+	}
 	delivery.TipoEntrega = tipoEntrega
 	delivery.OrderNumber = req.OrderNumber
-	delivery.Estado = models.Completado
-	delivery.Cantidad = uint(len(req.Operations))
-	delivery.UpdatedAt = time.Now()
+	// La cantidad pedida se conserva; el faltante queda en delivery.Shortfalls
+	if len(delivery.ItemDispensers) == 0 {
+		delivery.Cantidad = uint(len(req.Operations))
+	}
+	delivery.Shortfalls = shortfalls
+	delivery.CompletedAt = &completedAt
+	delivery.CompletedBy = req.CompletedBy
+
+	// La lectura GPS de cierre se compara con la dirección del cliente; fuera del radio o sin lectura
+	// la entrega se completa igual pero queda marcada para revisión
+	geofence := s.geofencePolicy.check(delivery, req.Latitude, req.Longitude, req.Accuracy)
+	delivery.CompletionLatitude = req.Latitude
+	delivery.CompletionLongitude = req.Longitude
+	delivery.CompletionAccuracy = req.Accuracy
+	delivery.CompletionDistance = geofence.Distance
+	delivery.GeofenceFlagged = geofence.Flagged
+	if geofence.Flagged {
+		event := log.Warn().Int("delivery_id", delivery.ID).Str("geofence_status", geofence.Status)
+		if geofence.Distance != nil {
+			event = event.Float64("distance_meters", *geofence.Distance)
+		}
+		event.Msg("Delivery completed flagged by geofence")
+	}
 
-	if err = s.deliveryStore.Update(ctx, delivery); err != nil {
-		log.Error().Err(err).Int("delivery_id", delivery.ID).Msg("Error updating delivery")
-		return nil, fmt.Errorf("error actualizando delivery: %w", err)
+	// UPDATE condicional por versión y estado: si otro repartidor completó la entrega
+	// después de la lectura anterior, esta llamada falla en lugar de pisar su cierre
+	reason := "entrega completada desde app móvil"
+	if len(shortfalls) > 0 {
+		reason = "entrega parcial completada desde app móvil"
+	}
+	var followUps []*models.Delivery
+	if followUp != nil {
+		followUps = append(followUps, followUp)
+	}
+	if err = s.tokenPolicy.saveWithUniqueTokens(ctx, s.deliveryStore, followUps, func() error {
+		return s.deliveryStore.Complete(ctx, delivery, followUp, mobileChangedBy(req.StaffLegajo), reason)
+	}); err != nil {
+		log.Warn().Err(err).Int("delivery_id", delivery.ID).Msg("Delivery completion rejected")
+		return nil, err
+	}
+	s.recordDispenserMovements(ctx, delivery, req.Operations, completedAt)
+	if followUp != nil {
+		log.Info().
+			Int("delivery_id", delivery.ID).
+			Int("follow_up_delivery_id", followUp.ID).
+			Str("fecha_accion", followUp.FechaAccion.Format("2006-01-02")).
+			Msg("Partial delivery: follow-up created for shortfall")
 	}
 
 	log.Info().
@@ -197,11 +390,12 @@ func (s *mobileDeliveryService) CompleteDelivery(ctx context.Context, req dto.Mo
 		Address:     delivery.Address,
 		Locality:    delivery.Locality,
 		NroRto:      delivery.NroRto,
-		CreatedAt:   time.Now().Format("2006-01-02"),
+		CreatedAt:   completedAt.Format("2006-01-02"),
 		TipoAccion:  string(tipoEntrega),
 		Token:       delivery.Token,
 		Operations:  opsMsg,
 		DeliveryID:  delivery.ID,
+		CompletedBy: delivery.CompletedBy,
 	}
 	workOrderQueued := false
 	if err = s.publisher.PublishWorkOrder(ctx, workOrderMsg); err != nil {
@@ -222,19 +416,150 @@ func (s *mobileDeliveryService) CompleteDelivery(ctx context.Context, req dto.Mo
 			ServiceDispenserCode:   op.ServiceDispenserCode,
 		})
 	}
-	return &dto.MobileCompleteDeliveryResponse{
-		DeliveryID:      delivery.ID,
-		NroCta:          delivery.NroCta,
-		Name:            delivery.Name,
-		Email:           delivery.Email,
-		Address:         delivery.Address,
-		Locality:        delivery.Locality,
-		NroRto:          delivery.NroRto,
-		TipoAccion:      string(tipoEntrega),
-		OrderNumber:     req.OrderNumber,
-		Operations:      opsCompleted,
-		WorkOrderQueued: workOrderQueued,
-	}, nil
+	response := &dto.MobileCompleteDeliveryResponse{
+		DeliveryID:          delivery.ID,
+		NroCta:              delivery.NroCta,
+		Name:                delivery.Name,
+		Email:               delivery.Email,
+		Address:             delivery.Address,
+		Locality:            delivery.Locality,
+		NroRto:              delivery.NroRto,
+		TipoAccion:          string(tipoEntrega),
+		OrderNumber:         req.OrderNumber,
+		Operations:          opsCompleted,
+		WorkOrderQueued:     workOrderQueued,
+		CompletedAt:         completedAt.Format(time.RFC3339),
+		CompletedBy:         delivery.CompletedBy,
+		GeofenceStatus:      geofence.Status,
+		GeofenceFlagged:     geofence.Flagged,
+		DistanceMeters:      geofence.Distance,
+		SerialWarnings:      serialWarnings,
+		Shortfalls:          shortfalls,
+		PreparationWarnings: preparationWarnings,
+	}
+	if followUp != nil {
+		response.FollowUpDeliveryID = &followUp.ID
+		response.FollowUpFechaAccion = followUp.FechaAccion.Format("2006-01-02")
+	}
+	return response, nil
+}
+
+func (s *mobileDeliveryService) ListFailureReasons(ctx context.Context) ([]models.FailureReason, error) {
+	if s.failureReasonStore == nil {
+		return []models.FailureReason{}, nil
+	}
+	return s.failureReasonStore.FindActive(ctx)
+}
+
+// mobileChangedBy identifica en el historial de estados al personal logueado en el dispositivo
+// por su legajo; sin sesión de personal queda registrada la app móvil
+func mobileChangedBy(staffLegajo string) string {
+	if staffLegajo != "" {
+		return staffLegajo
+	}
+	return string(models.ActorMobileApp)
+}
+
+// FailDelivery registra una visita fallida. La política del motivo decide si se crea
+// automáticamente una entrega de seguimiento o si se marca para contact center.
+func (s *mobileDeliveryService) FailDelivery(ctx context.Context, deliveryID int, req dto.MobileFailDeliveryRequest) (*dto.MobileFailDeliveryResponse, error) {
+	if s.failureReasonStore == nil {
+		return nil, fmt.Errorf("catálogo de motivos de visita fallida no disponible")
+	}
+	reason, err := s.failureReasonStore.FindByCode(ctx, req.ReasonCode)
+	if err != nil {
+		return nil, err
+	}
+	if reason == nil {
+		return nil, fmt.Errorf("motivo de visita fallida desconocido: %s", req.ReasonCode)
+	}
+
+	failedAt := time.Now()
+	if req.FailedAt != "" {
+		if failedAt, err = parseClientTimestamp("failed_at", req.FailedAt, failedAt); err != nil {
+			return nil, err
+		}
+	}
+
+	delivery, err := s.deliveryStore.FindByID(ctx, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf(constants.ErrDeliveryNotFound)
+	}
+
+	var followUp *models.Delivery
+	requiresFollowUp := reason.Policy == models.PolicyContactCenter
+	if reason.Policy == models.PolicyFollowUp {
+		followUp = buildFollowUpDelivery(delivery, failedAt, reason.FollowUpDays)
+	}
+
+	var updated *models.Delivery
+	var followUps []*models.Delivery
+	if followUp != nil {
+		followUps = append(followUps, followUp)
+	}
+	err = s.tokenPolicy.saveWithUniqueTokens(ctx, s.deliveryStore, followUps, func() error {
+		var err error
+		updated, err = s.deliveryStore.RecordFailedVisit(ctx, deliveryID, reason.Code, req.Notes, failedAt, requiresFollowUp, followUp, mobileChangedBy(req.StaffLegajo))
+		return err
+	})
+	if err != nil {
+		log.Warn().Err(err).Int("delivery_id", deliveryID).Msg("Error recording failed visit")
+		return nil, err
+	}
+
+	log.Info().
+		Int("delivery_id", deliveryID).
+		Str("reason", reason.Code).
+		Str("policy", string(reason.Policy)).
+		Bool("follow_up_created", followUp != nil).
+		Msg("Failed visit recorded")
+
+	response := &dto.MobileFailDeliveryResponse{
+		DeliveryID:            updated.ID,
+		Estado:                string(updated.Estado),
+		ReasonCode:            reason.Code,
+		ReasonDescription:     reason.Description,
+		FailedAt:              failedAt.Format(time.RFC3339),
+		Policy:                string(reason.Policy),
+		RequiresContactCenter: requiresFollowUp,
+	}
+	if followUp != nil {
+		response.FollowUpDeliveryID = &followUp.ID
+		response.FollowUpFechaAccion = followUp.FechaAccion.Format("2006-01-02")
+	}
+	return response, nil
+}
+
+// buildFollowUpDelivery arma una nueva entrega Pendiente con los mismos datos y dispensers
+// que la original, programada followUpDays días después de la visita fallida. El token lo asigna
+// quien la crea con TokenPolicy.saveWithUniqueTokens, una vez fijada la fecha. No hereda la sesión de términos:
+// el cliente los acepta de nuevo para la nueva visita.
+func buildFollowUpDelivery(original *models.Delivery, failedAt time.Time, followUpDays int) *models.Delivery {
+	if followUpDays < 1 {
+		followUpDays = 1
+	}
+	items := make([]models.ItemDispenser, 0, len(original.ItemDispensers))
+	for _, item := range original.ItemDispensers {
+		items = append(items, models.ItemDispenser{
+			Tipo:     item.Tipo,
+			Cantidad: item.Cantidad,
+		})
+	}
+	fecha := time.Date(failedAt.Year(), failedAt.Month(), failedAt.Day()+followUpDays, 0, 0, 0, 0, time.UTC)
+	return &models.Delivery{
+		NroCta:         original.NroCta,
+		Name:           original.Name,
+		Email:          original.Email,
+		Address:        original.Address,
+		Locality:       original.Locality,
+		NroRto:         original.NroRto,
+		ItemDispensers: items,
+		Cantidad:       original.Cantidad,
+		Estado:         models.Pendiente,
+		TipoEntrega:    original.TipoEntrega,
+		EntregadoPor:   original.EntregadoPor,
+		FechaAccion:    models.CustomDate{Time: fecha},
+	}
 }
 
 func deriveTipoEntrega(ops []dto.DispenserOperation) models.TipoEntrega {
@@ -299,6 +624,7 @@ func (s *mobileDeliveryService) sendCompletionEmailWithPDF(ctx context.Context,
 		TipoAccion:  string(delivery.TipoEntrega),
 		Token:       delivery.Token,
 		OrderNumber: delivery.OrderNumber,
+		CompletedBy: delivery.CompletedBy,
 	}
 	pdfBytes, orderNumber, err := s.pdfService.GenerateWorkOrderPDF(ctx, workOrderReq)
 	if err != nil {
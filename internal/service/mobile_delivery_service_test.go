@@ -0,0 +1,49 @@
+package service
+
+import (
+	"GoFrioCalor/internal/models"
+	"testing"
+	"time"
+)
+
+func TestBuildFollowUpDelivery(t *testing.T) {
+	termsID := int64(7)
+	original := &models.Delivery{
+		ID:             10,
+		NroCta:         "12345",
+		NroRto:         "R1",
+		Cantidad:       2,
+		Estado:         models.EnCamino,
+		TipoEntrega:    models.Instalacion,
+		EntregadoPor:   models.Repartidor,
+		TermsSessionID: &termsID,
+		Token:          "1234",
+		ItemDispensers: []models.ItemDispenser{{ID: 1, DeliveryID: 10, Tipo: models.TipoDispenserPie, Cantidad: 2}},
+	}
+	failedAt := time.Date(2026, 3, 31, 15, 0, 0, 0, time.UTC)
+
+	followUp := buildFollowUpDelivery(original, failedAt, 2)
+
+	if followUp.Estado != models.Pendiente {
+		t.Errorf("estado = %s, want %s", followUp.Estado, models.Pendiente)
+	}
+	if got := followUp.FechaAccion.Format("2006-01-02"); got != "2026-04-02" {
+		t.Errorf("fecha_accion = %s, want 2026-04-02", got)
+	}
+	if followUp.Token != "" {
+		t.Errorf("el token del seguimiento lo asigna quien lo crea, got %q", followUp.Token)
+	}
+	if len(followUp.ItemDispensers) != 1 || followUp.ItemDispensers[0].ID != 0 || followUp.ItemDispensers[0].DeliveryID != 0 {
+		t.Errorf("los dispensers deben copiarse sin IDs: %+v", followUp.ItemDispensers)
+	}
+	if followUp.NroCta != original.NroCta {
+		t.Errorf("el seguimiento debe conservar los datos del cliente")
+	}
+	if followUp.TermsSessionID != nil {
+		t.Errorf("el seguimiento no debe compartir la sesión de términos de la original")
+	}
+
+	if got := buildFollowUpDelivery(original, failedAt, 0).FechaAccion.Format("2006-01-02"); got != "2026-04-01" {
+		t.Errorf("con follow_up_days=0 fecha_accion = %s, want 2026-04-01", got)
+	}
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/dto"
+	"GoFrioCalor/internal/models"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// termsStatusNone es el terms_status de una parada cuya entrega no tiene sesión de términos
+const termsStatusNone = "NONE"
+
+// SearchDeliveries devuelve las paradas de una fecha, opcionalmente filtradas por reparto, con el
+// mismo formato que la hoja de ruta: sin el token de validación del cliente
+func (s *mobileDeliveryService) SearchDeliveries(ctx context.Context, fechaAccion string, nroRto string) ([]dto.MobileRouteStop, error) {
+	parsedDate, err := time.Parse("2006-01-02", fechaAccion)
+	if err != nil {
+		return nil, fmt.Errorf("fecha inválida. Formato esperado: YYYY-MM-DD")
+	}
+	deliveries, err := s.deliveryStore.FindByRto(ctx, nroRto, &parsedDate)
+	if err != nil {
+		return nil, fmt.Errorf("error buscando deliveries: %w", err)
+	}
+	return s.buildRouteStops(ctx, deliveries)
+}
+
+// RouteManifest arma la hoja de ruta de un reparto para un día
+func (s *mobileDeliveryService) RouteManifest(ctx context.Context, nroRto, fechaAccion string) (*dto.MobileRouteManifestResponse, error) {
+	if nroRto == "" {
+		return nil, fmt.Errorf(constants.ValidationRequired, "nro_rto")
+	}
+	stops, err := s.SearchDeliveries(ctx, fechaAccion, nroRto)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &dto.MobileRouteManifestResponse{
+		NroRto:      nroRto,
+		FechaAccion: fechaAccion,
+		TotalStops:  len(stops),
+		Stops:       stops,
+	}
+	for _, stop := range stops {
+		manifest.DispensersP += stop.DispensersP
+		manifest.DispensersM += stop.DispensersM
+	}
+	return manifest, nil
+}
+
+func (s *mobileDeliveryService) buildRouteStops(ctx context.Context, deliveries []models.Delivery) ([]dto.MobileRouteStop, error) {
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID < deliveries[j].ID })
+
+	termsStatuses := map[int64]models.TermsSessionStatus{}
+	if s.termsSessionStore != nil {
+		ids := make([]int64, 0, len(deliveries))
+		for _, d := range deliveries {
+			if d.TermsSessionID != nil {
+				ids = append(ids, *d.TermsSessionID)
+			}
+		}
+		var err error
+		if termsStatuses, err = s.termsSessionStore.FindStatuses(ctx, ids); err != nil {
+			return nil, err
+		}
+	}
+
+	stops := make([]dto.MobileRouteStop, 0, len(deliveries))
+	for _, d := range deliveries {
+		stop := dto.MobileRouteStop{
+			ID:               d.ID,
+			NroCta:           d.NroCta,
+			NroRto:           d.NroRto,
+			FechaAccion:      d.FechaAccion.Format("2006-01-02"),
+			Name:             d.Name,
+			Address:          d.Address,
+			Locality:         d.Locality,
+			AddressLatitude:  d.AddressLatitude,
+			AddressLongitude: d.AddressLongitude,
+			TipoEntrega:      d.TipoEntrega,
+			EntregadoPor:     d.EntregadoPor,
+			Estado:           d.Estado,
+			ItemDispensers:   make([]dto.ItemDispenserResponse, 0, len(d.ItemDispensers)),
+			TokenLocked:      d.TokenLockedAt != nil,
+		}
+		for _, item := range d.ItemDispensers {
+			stop.ItemDispensers = append(stop.ItemDispensers, dto.ItemDispenserResponse{Tipo: item.Tipo, Cantidad: item.Cantidad})
+			switch item.Tipo {
+			case models.TipoDispenserPie:
+				stop.DispensersP += item.Cantidad
+			case models.TipoDispenserMesada:
+				stop.DispensersM += item.Cantidad
+			}
+		}
+		if s.termsSessionStore != nil {
+			stop.TermsStatus = termsStatusNone
+			if d.TermsSessionID != nil {
+				if status, ok := termsStatuses[*d.TermsSessionID]; ok {
+					stop.TermsStatus = string(status)
+				}
+			}
+		}
+		stops = append(stops, stop)
+	}
+	return stops, nil
+}
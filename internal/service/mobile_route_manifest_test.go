@@ -0,0 +1,61 @@
+package service
+
+import (
+	"GoFrioCalor/internal/models"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildRouteStopsOmitsToken(t *testing.T) {
+	locked := time.Now()
+	deliveries := []models.Delivery{
+		{
+			ID:            12,
+			NroCta:        "200",
+			Name:          "Ana Gómez",
+			Token:         "918273",
+			TokenLockedAt: &locked,
+			Estado:        models.Programado,
+			TipoEntrega:   models.Retiro,
+			ItemDispensers: []models.ItemDispenser{
+				{Tipo: models.TipoDispenserMesada, Cantidad: 2},
+			},
+		},
+		{
+			ID:          10,
+			NroCta:      "100",
+			Name:        "Juan Pérez",
+			Token:       "564738",
+			Estado:      models.Pendiente,
+			TipoEntrega: models.Instalacion,
+			ItemDispensers: []models.ItemDispenser{
+				{Tipo: models.TipoDispenserPie, Cantidad: 1},
+				{Tipo: models.TipoDispenserMesada, Cantidad: 1},
+			},
+		},
+	}
+
+	stops, err := (&mobileDeliveryService{}).buildRouteStops(context.Background(), deliveries)
+	if err != nil {
+		t.Fatalf("buildRouteStops() error = %v", err)
+	}
+	if len(stops) != 2 || stops[0].ID != 10 || stops[1].ID != 12 {
+		t.Fatalf("paradas = %+v, se esperaban ordenadas por ID", stops)
+	}
+	if stops[0].DispensersP != 1 || stops[0].DispensersM != 1 || stops[1].DispensersM != 2 {
+		t.Errorf("conteo P/M incorrecto: %+v", stops)
+	}
+	if stops[0].TokenLocked || !stops[1].TokenLocked {
+		t.Errorf("token_locked incorrecto: %+v", stops)
+	}
+
+	body, _ := json.Marshal(stops)
+	for _, token := range []string{"918273", "564738", `"token"`} {
+		if strings.Contains(string(body), token) {
+			t.Errorf("la hoja de ruta expone el token del cliente: %s", body)
+		}
+	}
+}
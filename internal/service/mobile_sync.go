@@ -0,0 +1,307 @@
+package service
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/dto"
+	"GoFrioCalor/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog/log"
+)
+
+// syncConflictErrors son los errores que indican que la entrega cambió en el servidor mientras el
+// dispositivo estaba offline (cancelada, completada por otro repartidor, token bloqueado)
+var syncConflictErrors = []string{
+	constants.ErrInvalidStatusTransitionID,
+	constants.ErrDeliveryVersionConflict,
+	constants.ErrTokenLocked,
+	"la entrega ya fue procesada",
+}
+
+// syncRejectedErrors son errores de datos: reenviar la misma acción no cambiaría el resultado
+var syncRejectedErrors = []string{
+	constants.ErrDeliveryNotFound,
+	constants.MsgSessionExpired,
+	"inválido",
+	"es requerido",
+	"desconocido",
+	"la ubicación del dispositivo está a",
+	constants.ErrDeviceLocationIncomplete,
+}
+
+// Sync aplica en orden las acciones que la app encoló sin señal. Cada acción se procesa una sola
+// vez por client_action_id en el dispositivo: si ya se había procesado se devuelve el resultado
+// guardado. Los errores transitorios no se registran para que el dispositivo pueda reintentar
+// esas acciones.
+func (s *mobileDeliveryService) Sync(ctx context.Context, req dto.MobileSyncRequest) (*dto.MobileSyncResponse, error) {
+	if s.syncStore == nil {
+		return nil, fmt.Errorf("sincronización offline no disponible")
+	}
+	if len(req.Actions) > constants.MAX_SYNC_ACTIONS {
+		return nil, fmt.Errorf(constants.ErrSyncTooManyActions, constants.MAX_SYNC_ACTIONS)
+	}
+
+	response := &dto.MobileSyncResponse{
+		Total:   len(req.Actions),
+		Results: make([]dto.MobileSyncResult, 0, len(req.Actions)),
+	}
+	inBatch := make(map[string]dto.MobileSyncResult, len(req.Actions))
+	for _, action := range req.Actions {
+		var result dto.MobileSyncResult
+		if previous, ok := inBatch[action.ClientActionID]; ok {
+			result = duplicateSyncResult(previous)
+		} else {
+			result = s.syncAction(ctx, &req, action)
+			inBatch[action.ClientActionID] = result
+		}
+
+		switch models.SyncActionStatus(result.Status) {
+		case models.SyncApplied:
+			response.Applied++
+		case models.SyncDuplicate:
+			response.Duplicates++
+		case models.SyncConflict:
+			response.Conflicts++
+		case models.SyncRejected:
+			response.Rejected++
+		default:
+			response.Errors++
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	log.Info().
+		Str("device_id", req.DeviceID).
+		Int("total", response.Total).
+		Int("applied", response.Applied).
+		Int("duplicates", response.Duplicates).
+		Int("conflicts", response.Conflicts).
+		Int("rejected", response.Rejected).
+		Int("errors", response.Errors).
+		Msg("Mobile sync processed")
+
+	return response, nil
+}
+
+// syncAction registra la acción como pendiente y la aplica solo si esa reserva es nueva: de dos
+// sincronizaciones simultáneas con la misma acción, la segunda recibe el resultado guardado o, si
+// la primera todavía la está aplicando, un error transitorio para reintentar.
+func (s *mobileDeliveryService) syncAction(ctx context.Context, req *dto.MobileSyncRequest, action dto.MobileSyncAction) dto.MobileSyncResult {
+	result := dto.MobileSyncResult{ClientActionID: action.ClientActionID, Type: action.Type}
+
+	now := time.Now()
+	clientTimestamp, timestampErr := parseClientTimestamp("client_timestamp", action.ClientTimestamp, now)
+	if timestampErr != nil {
+		clientTimestamp = now
+	}
+	record := &models.MobileSyncAction{
+		Scope:           syncScope(req),
+		ClientActionID:  action.ClientActionID,
+		DeviceID:        req.DeviceID,
+		ActionType:      models.SyncActionType(action.Type),
+		Status:          models.SyncPending,
+		ClientTimestamp: clientTimestamp,
+	}
+	existing, reserved, err := s.syncStore.Reserve(ctx, record, now.Add(-constants.SYNC_PENDING_TIMEOUT_MINUTES*time.Minute))
+	if err != nil {
+		result.Status = string(models.SyncError)
+		result.Error = err.Error()
+		return result
+	}
+	if !reserved {
+		if existing.Status == models.SyncPending {
+			result.Status = string(models.SyncError)
+			result.Error = constants.ErrSyncActionInProgress
+			return result
+		}
+		return duplicateSyncResult(syncResultFromRecord(existing))
+	}
+
+	var payload interface{}
+	err = timestampErr
+	if err == nil {
+		payload, result.DeliveryID, err = s.applySyncAction(ctx, req, action)
+	}
+
+	result.Status = string(classifySyncError(err))
+	if err != nil {
+		result.Error = err.Error()
+		// Los errores por operación quedan en el resultado para que la app marque qué código corregir
+		var serialErr *DispenserValidationError
+		if errors.As(err, &serialErr) {
+			payload = map[string]interface{}{"operation_errors": serialErr.Errors}
+		}
+		log.Warn().
+			Err(err).
+			Str("client_action_id", action.ClientActionID).
+			Str("type", action.Type).
+			Str("status", result.Status).
+			Msg("Mobile sync action not applied")
+	}
+	if payload != nil {
+		result.Result, _ = json.Marshal(payload)
+	}
+
+	// El resultado se guarda aunque el dispositivo haya cortado la conexión
+	saveCtx := context.WithoutCancel(ctx)
+	if result.Status == string(models.SyncError) {
+		if err := s.syncStore.Release(saveCtx, record.ID); err != nil {
+			log.Error().Err(err).Str("client_action_id", action.ClientActionID).Msg("Error releasing mobile sync action")
+		}
+		return result
+	}
+
+	record.Status = models.SyncActionStatus(result.Status)
+	record.Error = result.Error
+	if result.DeliveryID > 0 {
+		record.DeliveryID = &result.DeliveryID
+	}
+	if result.Result != nil {
+		stored := string(result.Result)
+		record.Result = &stored
+	}
+	if err := s.syncStore.Complete(saveCtx, record); err != nil {
+		// La acción ya se aplicó: se informa el resultado aunque no haya quedado registrada
+		log.Error().Err(err).Str("client_action_id", action.ClientActionID).Msg("Error recording mobile sync action")
+	}
+	return result
+}
+
+// syncScope es el ámbito en que client_action_id es único: el dispositivo o, si la app no informa
+// device_id, el personal logueado
+func syncScope(req *dto.MobileSyncRequest) string {
+	if req.DeviceID != "" {
+		return "device:" + req.DeviceID
+	}
+	if req.StaffID != nil {
+		return fmt.Sprintf("staff:%d", *req.StaffID)
+	}
+	return ""
+}
+
+// applySyncAction ejecuta la acción con la misma lógica que el endpoint online equivalente,
+// usando client_timestamp como momento de la validación, entrega o visita fallida y el personal
+// logueado en el dispositivo como responsable de la entrega
+func (s *mobileDeliveryService) applySyncAction(ctx context.Context, sync *dto.MobileSyncRequest, action dto.MobileSyncAction) (interface{}, int, error) {
+	switch models.SyncActionType(action.Type) {
+	case models.SyncValidateToken:
+		var req dto.ValidateTokenRequest
+		if err := decodeSyncPayload(action.Payload, &req); err != nil {
+			return nil, 0, err
+		}
+		if req.ValidatedAt == "" {
+			req.ValidatedAt = action.ClientTimestamp
+		}
+		response, err := s.ValidateToken(ctx, req)
+		if err != nil {
+			return nil, 0, err
+		}
+		return response, 0, nil
+
+	case models.SyncCompleteDelivery:
+		var req dto.MobileCompleteDeliveryRequest
+		if err := decodeSyncPayload(action.Payload, &req); err != nil {
+			return nil, 0, err
+		}
+		if req.CompletedAt == "" {
+			req.CompletedAt = action.ClientTimestamp
+		}
+		req.CompletedBy = sync.StaffID
+		req.StaffLegajo = sync.StaffLegajo
+		response, err := s.CompleteDelivery(ctx, req)
+		if err != nil {
+			return nil, req.DeliveryID, err
+		}
+		return response, response.DeliveryID, nil
+
+	case models.SyncFailDelivery:
+		if action.DeliveryID <= 0 {
+			return nil, 0, fmt.Errorf(constants.ValidationRequired, "delivery_id")
+		}
+		var req dto.MobileFailDeliveryRequest
+		if err := decodeSyncPayload(action.Payload, &req); err != nil {
+			return nil, action.DeliveryID, err
+		}
+		if req.FailedAt == "" {
+			req.FailedAt = action.ClientTimestamp
+		}
+		req.StaffLegajo = sync.StaffLegajo
+		response, err := s.FailDelivery(ctx, action.DeliveryID, req)
+		if err != nil {
+			return nil, action.DeliveryID, err
+		}
+		return response, response.DeliveryID, nil
+
+	default:
+		return nil, 0, fmt.Errorf(constants.ErrSyncUnknownAction, action.Type)
+	}
+}
+
+// decodeSyncPayload interpreta el payload y lo valida con los mismos tags `binding` del endpoint online
+func decodeSyncPayload(payload json.RawMessage, target interface{}) error {
+	if err := json.Unmarshal(payload, target); err != nil {
+		return fmt.Errorf("payload inválido: %v", err)
+	}
+	if err := bindingValidator.Struct(target); err != nil {
+		var validationErrors validator.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			messages := make([]string, 0, len(validationErrors))
+			for _, fieldError := range validationErrors {
+				messages = append(messages, formatFieldError(fieldError))
+			}
+			return fmt.Errorf("payload inválido: %s", strings.Join(messages, "; "))
+		}
+		return fmt.Errorf("payload inválido: %v", err)
+	}
+	return nil
+}
+
+// classifySyncError traduce el error de una acción a su estado en la respuesta del lote
+func classifySyncError(err error) models.SyncActionStatus {
+	if err == nil {
+		return models.SyncApplied
+	}
+	message := err.Error()
+	for _, conflict := range syncConflictErrors {
+		if strings.Contains(message, conflict) {
+			return models.SyncConflict
+		}
+	}
+	for _, rejected := range syncRejectedErrors {
+		if strings.Contains(message, rejected) {
+			return models.SyncRejected
+		}
+	}
+	return models.SyncError
+}
+
+func syncResultFromRecord(record *models.MobileSyncAction) dto.MobileSyncResult {
+	result := dto.MobileSyncResult{
+		ClientActionID: record.ClientActionID,
+		Type:           string(record.ActionType),
+		Status:         string(record.Status),
+		Error:          record.Error,
+	}
+	if record.DeliveryID != nil {
+		result.DeliveryID = *record.DeliveryID
+	}
+	if record.Result != nil {
+		result.Result = json.RawMessage(*record.Result)
+	}
+	return result
+}
+
+func duplicateSyncResult(original dto.MobileSyncResult) dto.MobileSyncResult {
+	if original.Status == string(models.SyncDuplicate) {
+		return original
+	}
+	original.OriginalStatus = original.Status
+	original.Status = string(models.SyncDuplicate)
+	return original
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/dto"
+	"GoFrioCalor/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type memorySyncStore struct {
+	actions map[string]*models.MobileSyncAction
+	nextID  int
+}
+
+func (m *memorySyncStore) Reserve(ctx context.Context, action *models.MobileSyncAction, staleBefore time.Time) (*models.MobileSyncAction, bool, error) {
+	key := action.Scope + " " + action.ClientActionID
+	if existing, ok := m.actions[key]; ok {
+		if existing.Status != models.SyncPending || existing.CreatedAt.After(staleBefore) {
+			return existing, false, nil
+		}
+	}
+	m.nextID++
+	action.ID = m.nextID
+	action.CreatedAt = time.Now()
+	m.actions[key] = action
+	return action, true, nil
+}
+
+func (m *memorySyncStore) Complete(ctx context.Context, action *models.MobileSyncAction) error {
+	return nil
+}
+
+func (m *memorySyncStore) Release(ctx context.Context, id int) error {
+	for key, action := range m.actions {
+		if action.ID == id {
+			delete(m.actions, key)
+		}
+	}
+	return nil
+}
+
+func TestSyncIsIdempotent(t *testing.T) {
+	syncStore := &memorySyncStore{actions: map[string]*models.MobileSyncAction{}}
+	service := &mobileDeliveryService{syncStore: syncStore}
+	now := time.Now().Format(time.RFC3339)
+
+	req := dto.MobileSyncRequest{
+		DeviceID: "tablet-07",
+		Actions: []dto.MobileSyncAction{
+			// fail_delivery sin delivery_id: se rechaza sin llegar a la base de entregas
+			{ClientActionID: "6f1c2a6e-3c1e-4a53-9a55-8f4f0f1c0001", Type: "fail_delivery", ClientTimestamp: now, Payload: json.RawMessage(`{"reason_code":"CLIENTE_AUSENTE"}`)},
+			{ClientActionID: "6f1c2a6e-3c1e-4a53-9a55-8f4f0f1c0002", Type: "complete_delivery", ClientTimestamp: now, Payload: json.RawMessage(`{"nro_cta":"1001"}`)},
+			{ClientActionID: "6f1c2a6e-3c1e-4a53-9a55-8f4f0f1c0001", Type: "fail_delivery", ClientTimestamp: now, Payload: json.RawMessage(`{}`)},
+		},
+	}
+
+	first, err := service.Sync(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if first.Rejected != 2 || first.Duplicates != 1 || len(syncStore.actions) != 2 {
+		t.Fatalf("primer lote: %+v (registradas %d)", first, len(syncStore.actions))
+	}
+	if first.Results[2].OriginalStatus != string(models.SyncRejected) {
+		t.Errorf("el duplicado dentro del lote debería informar el estado original, got %+v", first.Results[2])
+	}
+
+	second, err := service.Sync(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if second.Duplicates != 3 {
+		t.Errorf("el reenvío del lote debería devolver 3 duplicados, got %+v", second)
+	}
+	if second.Results[1].Error != first.Results[1].Error {
+		t.Errorf("el duplicado debería conservar el error original: %q vs %q", second.Results[1].Error, first.Results[1].Error)
+	}
+}
+
+func TestSyncScopesClientActionID(t *testing.T) {
+	syncStore := &memorySyncStore{actions: map[string]*models.MobileSyncAction{}}
+	service := &mobileDeliveryService{syncStore: syncStore}
+	now := time.Now().Format(time.RFC3339)
+	action := dto.MobileSyncAction{ClientActionID: "6f1c2a6e-3c1e-4a53-9a55-8f4f0f1c0001", Type: "fail_delivery", ClientTimestamp: now, Payload: json.RawMessage(`{"reason_code":"CLIENTE_AUSENTE"}`)}
+
+	for _, deviceID := range []string{"tablet-07", "tablet-08"} {
+		response, err := service.Sync(context.Background(), dto.MobileSyncRequest{DeviceID: deviceID, Actions: []dto.MobileSyncAction{action}})
+		if err != nil {
+			t.Fatalf("Sync() error = %v", err)
+		}
+		if response.Rejected != 1 {
+			t.Errorf("el mismo client_action_id en %s debería procesarse, got %+v", deviceID, response)
+		}
+	}
+	if len(syncStore.actions) != 2 {
+		t.Errorf("acciones registradas = %d, want 2", len(syncStore.actions))
+	}
+}
+
+func TestSyncPendingActionIsNotAppliedTwice(t *testing.T) {
+	staffID := 7
+	pending := &models.MobileSyncAction{
+		ID:             1,
+		Scope:          "staff:7",
+		ClientActionID: "6f1c2a6e-3c1e-4a53-9a55-8f4f0f1c0001",
+		Status:         models.SyncPending,
+		CreatedAt:      time.Now(),
+	}
+	syncStore := &memorySyncStore{actions: map[string]*models.MobileSyncAction{"staff:7 " + pending.ClientActionID: pending}, nextID: 1}
+	service := &mobileDeliveryService{syncStore: syncStore}
+	req := dto.MobileSyncRequest{
+		StaffID: &staffID,
+		Actions: []dto.MobileSyncAction{{ClientActionID: pending.ClientActionID, Type: "fail_delivery", ClientTimestamp: time.Now().Format(time.RFC3339), Payload: json.RawMessage(`{"reason_code":"CLIENTE_AUSENTE"}`)}},
+	}
+
+	response, err := service.Sync(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if response.Errors != 1 || response.Results[0].Error != constants.ErrSyncActionInProgress {
+		t.Errorf("una acción en curso debería devolver un error transitorio, got %+v", response.Results[0])
+	}
+	if syncStore.actions["staff:7 "+pending.ClientActionID] != pending {
+		t.Errorf("la reserva de la otra sincronización no debería tocarse")
+	}
+
+	// Una reserva abandonada se vuelve a tomar
+	pending.CreatedAt = time.Now().Add(-(constants.SYNC_PENDING_TIMEOUT_MINUTES + 1) * time.Minute)
+	response, err = service.Sync(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if response.Rejected != 1 {
+		t.Errorf("una reserva abandonada debería volver a aplicarse, got %+v", response.Results[0])
+	}
+}
+
+func TestClassifySyncError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want models.SyncActionStatus
+	}{
+		{err: nil, want: models.SyncApplied},
+		{err: fmt.Errorf(constants.ErrInvalidStatusTransition, models.Cancelado, models.Completado), want: models.SyncConflict},
+		{err: fmt.Errorf("la entrega ya fue procesada (estado: %s)", models.Cancelado), want: models.SyncConflict},
+		{err: fmt.Errorf(constants.ErrTokenLocked), want: models.SyncConflict},
+		{err: fmt.Errorf("token inválido"), want: models.SyncRejected},
+		{err: fmt.Errorf(constants.ErrDeliveryNotFound), want: models.SyncRejected},
+		{err: fmt.Errorf("error publicando mensaje: connection reset"), want: models.SyncError},
+	}
+	for _, tt := range tests {
+		if got := classifySyncError(tt.err); got != tt.want {
+			t.Errorf("classifySyncError(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestParseClientTimestamp(t *testing.T) {
+	now := time.Date(2026, 5, 20, 12, 0, 0, 0, time.UTC)
+	if _, err := parseClientTimestamp("completed_at", "2026-05-20T08:15:00-03:00", now); err != nil {
+		t.Errorf("una hora pasada debería ser válida: %v", err)
+	}
+	if _, err := parseClientTimestamp("completed_at", "2026-05-20T12:03:00Z", now); err != nil {
+		t.Errorf("se tolera un adelanto menor a %d minutos: %v", constants.CLIENT_CLOCK_SKEW_MINUTES, err)
+	}
+	if _, err := parseClientTimestamp("completed_at", "2026-05-20T13:00:00Z", now); err == nil {
+		t.Errorf("una hora futura debería rechazarse")
+	}
+	if _, err := parseClientTimestamp("completed_at", "20/05/2026", now); err == nil {
+		t.Errorf("un formato distinto de RFC3339 debería rechazarse")
+	}
+}
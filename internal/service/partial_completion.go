@@ -0,0 +1,117 @@
+package service
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/dto"
+	"GoFrioCalor/internal/models"
+	"fmt"
+	"time"
+)
+
+// PartialFollowUpPolicy define la fecha de la entrega de seguimiento que se crea por el faltante
+// de una entrega parcial
+type PartialFollowUpPolicy struct {
+	// Days es la cantidad de días después del cierre parcial
+	Days int
+	// SkipWeekends mueve la fecha al lunes si cae sábado o domingo
+	SkipWeekends bool
+}
+
+// DefaultPartialFollowUpPolicy programa el faltante para el día hábil siguiente
+var DefaultPartialFollowUpPolicy = PartialFollowUpPolicy{
+	Days:         constants.PARTIAL_FOLLOW_UP_DEFAULT_DAYS,
+	SkipWeekends: true,
+}
+
+// Validate verifica que el seguimiento quede al menos un día después del cierre parcial
+func (p PartialFollowUpPolicy) Validate() error {
+	if p.Days < 1 {
+		return fmt.Errorf("días de seguimiento de entregas parciales inválidos: %d", p.Days)
+	}
+	return nil
+}
+
+// followUpFecha devuelve la fecha_accion de la entrega de seguimiento según la política
+func (p PartialFollowUpPolicy) followUpFecha(completedAt time.Time) time.Time {
+	fecha := time.Date(completedAt.Year(), completedAt.Month(), completedAt.Day()+p.Days, 0, 0, 0, 0, time.UTC)
+	if p.SkipWeekends {
+		switch fecha.Weekday() {
+		case time.Saturday:
+			fecha = fecha.AddDate(0, 0, 2)
+		case time.Sunday:
+			fecha = fecha.AddDate(0, 0, 1)
+		}
+	}
+	return fecha
+}
+
+// computeShortfalls compara las operaciones realizadas con los dispensers pedidos en la entrega y
+// devuelve el faltante por tipo. Cada operación cuenta como un dispenser del tipo informado; si la
+// entrega pidió un solo tipo las operaciones sin tipo se cuentan para ese tipo.
+func computeShortfalls(items []models.ItemDispenser, operations []dto.DispenserOperation) ([]models.DeliveryShortfall, error) {
+	ordered := make(map[models.TipoDispenser]uint)
+	var order []models.TipoDispenser
+	var totalOrdered uint
+	for _, item := range items {
+		if _, ok := ordered[item.Tipo]; !ok {
+			order = append(order, item.Tipo)
+		}
+		ordered[item.Tipo] += item.Cantidad
+		totalOrdered += item.Cantidad
+	}
+	if len(order) == 0 {
+		return nil, nil
+	}
+
+	delivered := make(map[models.TipoDispenser]uint)
+	untyped := 0
+	for _, op := range operations {
+		if op.Tipo == "" {
+			untyped++
+			continue
+		}
+		delivered[models.TipoDispenser(op.Tipo)]++
+	}
+	if untyped > 0 {
+		if len(order) > 1 {
+			// Sin tipo no se puede atribuir el faltante, salvo que no lo haya
+			if uint(len(operations)) >= totalOrdered {
+				return nil, nil
+			}
+			return nil, fmt.Errorf(constants.ErrOperationTipoRequired)
+		}
+		delivered[order[0]] += uint(untyped)
+	}
+
+	var shortfalls []models.DeliveryShortfall
+	for _, tipo := range order {
+		if delivered[tipo] >= ordered[tipo] {
+			continue
+		}
+		shortfalls = append(shortfalls, models.DeliveryShortfall{
+			Tipo:      tipo,
+			Ordered:   ordered[tipo],
+			Delivered: delivered[tipo],
+			Missing:   ordered[tipo] - delivered[tipo],
+		})
+	}
+	return shortfalls, nil
+}
+
+// buildShortfallFollowUp arma la entrega Pendiente por el faltante de una entrega parcial, con los
+// datos del cliente de la original y solo los dispensers que faltaron
+func (p PartialFollowUpPolicy) buildShortfallFollowUp(original *models.Delivery, tipoEntrega models.TipoEntrega, shortfalls []models.DeliveryShortfall, completedAt time.Time) *models.Delivery {
+	followUp := buildFollowUpDelivery(original, completedAt, p.Days)
+	followUp.TipoEntrega = tipoEntrega
+	followUp.FechaAccion = models.CustomDate{Time: p.followUpFecha(completedAt)}
+	followUp.ItemDispensers = make([]models.ItemDispenser, 0, len(shortfalls))
+	followUp.Cantidad = 0
+	for _, shortfall := range shortfalls {
+		followUp.ItemDispensers = append(followUp.ItemDispensers, models.ItemDispenser{
+			Tipo:     shortfall.Tipo,
+			Cantidad: shortfall.Missing,
+		})
+		followUp.Cantidad += shortfall.Missing
+	}
+	return followUp
+}
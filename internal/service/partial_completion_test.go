@@ -0,0 +1,92 @@
+package service
+
+import (
+	"GoFrioCalor/internal/dto"
+	"GoFrioCalor/internal/models"
+	"testing"
+	"time"
+)
+
+func TestComputeShortfalls(t *testing.T) {
+	mixed := []models.ItemDispenser{
+		{Tipo: models.TipoDispenserPie, Cantidad: 2},
+		{Tipo: models.TipoDispenserMesada, Cantidad: 1},
+	}
+	op := func(tipo string) dto.DispenserOperation {
+		return dto.DispenserOperation{Type: "installation", Tipo: tipo}
+	}
+
+	shortfalls, err := computeShortfalls(mixed, []dto.DispenserOperation{op("P"), op("M")})
+	if err != nil {
+		t.Fatalf("computeShortfalls() error = %v", err)
+	}
+	if len(shortfalls) != 1 || shortfalls[0].Tipo != models.TipoDispenserPie ||
+		shortfalls[0].Ordered != 2 || shortfalls[0].Delivered != 1 || shortfalls[0].Missing != 1 {
+		t.Errorf("faltantes = %+v, se esperaba 1 P", shortfalls)
+	}
+
+	if shortfalls, _ := computeShortfalls(mixed, []dto.DispenserOperation{op("P"), op("P"), op("M")}); len(shortfalls) != 0 {
+		t.Errorf("entrega completa con faltantes: %+v", shortfalls)
+	}
+	if _, err := computeShortfalls(mixed, []dto.DispenserOperation{op(""), op("")}); err == nil {
+		t.Error("se esperaba error: operaciones sin tipo en una entrega con P y M")
+	}
+	if shortfalls, err := computeShortfalls(mixed, []dto.DispenserOperation{op(""), op(""), op("")}); err != nil || len(shortfalls) != 0 {
+		t.Errorf("sin faltante no se requiere tipo: %+v, %v", shortfalls, err)
+	}
+
+	single := []models.ItemDispenser{{Tipo: models.TipoDispenserMesada, Cantidad: 3}}
+	shortfalls, err = computeShortfalls(single, []dto.DispenserOperation{op("")})
+	if err != nil || len(shortfalls) != 1 || shortfalls[0].Missing != 2 {
+		t.Errorf("con un solo tipo pedido las operaciones sin tipo cuentan para ese tipo: %+v, %v", shortfalls, err)
+	}
+
+	if shortfalls, _ := computeShortfalls(nil, []dto.DispenserOperation{op("")}); shortfalls != nil {
+		t.Errorf("entrega sin dispensers pedidos con faltantes: %+v", shortfalls)
+	}
+}
+
+func TestBuildShortfallFollowUp(t *testing.T) {
+	original := &models.Delivery{
+		ID:          10,
+		NroCta:      "100",
+		NroRto:      "R1",
+		Token:       "1234",
+		TipoEntrega: models.Instalacion,
+		ItemDispensers: []models.ItemDispenser{
+			{Tipo: models.TipoDispenserPie, Cantidad: 2},
+			{Tipo: models.TipoDispenserMesada, Cantidad: 1},
+		},
+	}
+	shortfalls := []models.DeliveryShortfall{{Tipo: models.TipoDispenserPie, Ordered: 2, Delivered: 1, Missing: 1}}
+	friday := time.Date(2026, 5, 15, 16, 0, 0, 0, time.UTC)
+
+	followUp := DefaultPartialFollowUpPolicy.buildShortfallFollowUp(original, models.Instalacion, shortfalls, friday)
+
+	if followUp.Estado != models.Pendiente || followUp.TipoEntrega != models.Instalacion {
+		t.Errorf("seguimiento = %s/%s", followUp.Estado, followUp.TipoEntrega)
+	}
+	if len(followUp.ItemDispensers) != 1 || followUp.ItemDispensers[0].Tipo != models.TipoDispenserPie ||
+		followUp.ItemDispensers[0].Cantidad != 1 || followUp.Cantidad != 1 {
+		t.Errorf("el seguimiento debe pedir solo el faltante: %+v (cantidad %d)", followUp.ItemDispensers, followUp.Cantidad)
+	}
+	if got := followUp.FechaAccion.Format("2006-01-02"); got != "2026-05-18" {
+		t.Errorf("fecha_accion = %s, se esperaba el lunes 2026-05-18", got)
+	}
+	if followUp.Token != "" {
+		t.Errorf("el token del seguimiento lo asigna quien lo crea, got %q", followUp.Token)
+	}
+}
+
+func TestPartialFollowUpFecha(t *testing.T) {
+	wednesday := time.Date(2026, 5, 13, 10, 0, 0, 0, time.UTC)
+	if got := (PartialFollowUpPolicy{Days: 3, SkipWeekends: true}).followUpFecha(wednesday).Format("2006-01-02"); got != "2026-05-18" {
+		t.Errorf("con fines de semana salteados = %s, se esperaba 2026-05-18", got)
+	}
+	if got := (PartialFollowUpPolicy{Days: 3}).followUpFecha(wednesday).Format("2006-01-02"); got != "2026-05-16" {
+		t.Errorf("sin saltear fines de semana = %s, se esperaba 2026-05-16", got)
+	}
+	if err := (PartialFollowUpPolicy{Days: 0}).Validate(); err == nil {
+		t.Error("se esperaba error con 0 días")
+	}
+}
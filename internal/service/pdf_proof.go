@@ -0,0 +1,136 @@
+package service
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/models"
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	pdfPageBottom       = 277.0 // alto A4 menos el margen inferior de salto de página
+	pdfSignatureMaxW    = 70.0
+	pdfSignatureMaxH    = 30.0
+	pdfPhotoMaxW        = 87.0
+	pdfPhotoMaxH        = 65.0
+	pdfPhotoGap         = 6.0
+	pdfPhotosPerRow     = 2
+	pdfProofSectionLeft = 15.0
+)
+
+// pdfImageTypes mapea el content type del adjunto al tipo de imagen de gofpdf
+var pdfImageTypes = map[string]string{
+	"image/png":  "PNG",
+	"image/jpeg": "JPG",
+}
+
+// addProofOfDelivery agrega al PDF la firma del cliente y las fotos de la instalación. Si un
+// adjunto no se puede leer se omite: la orden de trabajo se genera igual.
+func (s *pdfService) addProofOfDelivery(ctx context.Context, pdf *gofpdf.Fpdf, deliveryID int, colorPrimary, colorText []int) {
+	if s.attachmentStore == nil || s.blobStorage == nil {
+		return
+	}
+	attachments, err := s.attachmentStore.ListByDelivery(ctx, deliveryID)
+	if err != nil {
+		log.Error().Err(err).Int("delivery_id", deliveryID).Msg("Error loading proof of delivery for PDF")
+		return
+	}
+
+	var photos []models.DeliveryAttachment
+	for _, attachment := range attachments {
+		if attachment.Kind == models.AttachmentPhoto {
+			photos = append(photos, attachment)
+			continue
+		}
+		info, name := s.registerProofImage(ctx, pdf, attachment)
+		if info == nil {
+			continue
+		}
+		w, h := fitImage(info.Width(), info.Height(), pdfSignatureMaxW, pdfSignatureMaxH)
+		if pdf.GetY()+14+h > pdfPageBottom {
+			pdf.AddPage()
+		}
+		pdf.Ln(6)
+		addProofSectionHeader(pdf, constants.PDFSectionSignature, colorPrimary, colorText)
+		y := pdf.GetY()
+		pdf.ImageOptions(name, pdfProofSectionLeft+2, y, w, h, false, gofpdf.ImageOptions{ImageType: pdfImageTypes[attachment.ContentType]}, 0, "")
+		pdf.SetY(y + h + 2)
+	}
+
+	if len(photos) == 0 {
+		return
+	}
+	pdf.AddPage()
+	addProofSectionHeader(pdf, constants.PDFSectionPhotos, colorPrimary, colorText)
+	column := 0
+	rowY := pdf.GetY()
+	for _, photo := range photos {
+		info, name := s.registerProofImage(ctx, pdf, photo)
+		if info == nil {
+			continue
+		}
+		if column == 0 && rowY+pdfPhotoMaxH > pdfPageBottom {
+			pdf.AddPage()
+			rowY = pdf.GetY()
+		}
+		w, h := fitImage(info.Width(), info.Height(), pdfPhotoMaxW, pdfPhotoMaxH)
+		x := pdfProofSectionLeft + float64(column)*(pdfPhotoMaxW+pdfPhotoGap)
+		pdf.ImageOptions(name, x, rowY, w, h, false, gofpdf.ImageOptions{ImageType: pdfImageTypes[photo.ContentType]}, 0, "")
+
+		column++
+		if column == pdfPhotosPerRow {
+			column = 0
+			rowY += pdfPhotoMaxH + pdfPhotoGap
+		}
+	}
+	if column != 0 {
+		rowY += pdfPhotoMaxH + pdfPhotoGap
+	}
+	pdf.SetY(rowY)
+}
+
+// registerProofImage carga la imagen del adjunto en el PDF; devuelve nil si no se pudo leer
+func (s *pdfService) registerProofImage(ctx context.Context, pdf *gofpdf.Fpdf, attachment models.DeliveryAttachment) (*gofpdf.ImageInfoType, string) {
+	imageType, ok := pdfImageTypes[attachment.ContentType]
+	if !ok {
+		return nil, ""
+	}
+	data, err := s.blobStorage.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		log.Error().Err(err).Int("attachment_id", attachment.ID).Msg("Error reading proof image for PDF")
+		return nil, ""
+	}
+	name := fmt.Sprintf("attachment-%d", attachment.ID)
+	info := pdf.RegisterImageOptionsReader(name, gofpdf.ImageOptions{ImageType: imageType}, bytes.NewReader(data))
+	if pdf.Err() {
+		log.Error().Err(pdf.Error()).Int("attachment_id", attachment.ID).Msg("Invalid proof image, skipped in PDF")
+		pdf.ClearError()
+		return nil, ""
+	}
+	return info, name
+}
+
+func addProofSectionHeader(pdf *gofpdf.Fpdf, title string, colorPrimary, colorText []int) {
+	pdf.SetFont("Arial", "B", 11)
+	pdf.SetFillColor(colorPrimary[0], colorPrimary[1], colorPrimary[2])
+	pdf.SetTextColor(255, 255, 255)
+	pdf.CellFormat(0, 8, title, "", 1, "L", true, 0, "")
+	pdf.SetTextColor(colorText[0], colorText[1], colorText[2])
+	pdf.Ln(3)
+}
+
+// fitImage escala la imagen para que entre en maxW x maxH manteniendo la proporción
+func fitImage(width, height, maxW, maxH float64) (float64, float64) {
+	if width <= 0 || height <= 0 {
+		return maxW, maxH
+	}
+	scale := maxW / width
+	if height*scale > maxH {
+		scale = maxH / height
+	}
+	return width * scale, height * scale
+}
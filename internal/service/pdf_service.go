@@ -18,13 +18,24 @@ type PDFService interface {
 }
 
 type pdfService struct {
-	workOrderStore store.WorkOrderStore
+	workOrderStore  store.WorkOrderStore
+	attachmentStore store.DeliveryAttachmentStore
+	blobStorage     BlobStorage
 }
 
 func NewPDFService(workOrderStore store.WorkOrderStore) PDFService {
 	return &pdfService{workOrderStore: workOrderStore}
 }
 
+// NewPDFServiceWithAttachments incluye en la orden de trabajo la firma y las fotos de la entrega
+func NewPDFServiceWithAttachments(workOrderStore store.WorkOrderStore, attachmentStore store.DeliveryAttachmentStore, blobStorage BlobStorage) PDFService {
+	return &pdfService{
+		workOrderStore:  workOrderStore,
+		attachmentStore: attachmentStore,
+		blobStorage:     blobStorage,
+	}
+}
+
 func (s *pdfService) GenerateWorkOrderPDF(ctx context.Context, workOrder *dto.WorkOrderRequest) ([]byte, string, error) {
 	orderNumber := workOrder.OrderNumber
 	alreadyExists := false
@@ -53,6 +64,7 @@ func (s *pdfService) GenerateWorkOrderPDF(ctx context.Context, workOrder *dto.Wo
 			Address:     workOrder.Address,
 			Localidad:   workOrder.Locality,
 			TipoAccion:  workOrder.TipoAccion,
+			CompletedBy: workOrder.CompletedBy,
 		}
 		if err := s.workOrderStore.Create(ctx, woModel); err != nil {
 			return nil, "", fmt.Errorf("failed to create work order: %w", err)
@@ -328,6 +340,10 @@ func (s *pdfService) GenerateWorkOrderPDF(ctx context.Context, workOrder *dto.Wo
 	pdf.Rect(15, acceptanceStartY, 180, 22, "D")
 	pdf.SetY(acceptanceStartY + 21)
 
+	if workOrder.DeliveryID > 0 {
+		s.addProofOfDelivery(ctx, pdf, workOrder.DeliveryID, colorPrimary, colorText)
+	}
+
 	pdf.Ln(0)
 	var buf bytes.Buffer
 	if err := pdf.Output(&buf); err != nil {
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/dto"
+	"GoFrioCalor/internal/models"
+	"GoFrioCalor/internal/store"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var weekdayNames = [...]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"}
+
+// RouteCapacityError indica que el reparto no tiene lugar para la fecha pedida. TipoEntrega es el
+// tipo cuyo límite se alcanzó, vacío si es el límite del total del día. SuggestedFecha es la
+// primera fecha posterior con lugar, vacía si no hay ninguna dentro de ROUTE_CAPACITY_SUGGEST_DAYS.
+type RouteCapacityError struct {
+	NroRto         string
+	Fecha          string
+	TipoEntrega    models.TipoEntrega
+	Limit          int
+	Booked         int
+	SuggestedFecha string
+}
+
+func (e *RouteCapacityError) Error() string {
+	tipo := ""
+	if e.TipoEntrega != "" {
+		tipo = " de " + string(e.TipoEntrega)
+	}
+	message := fmt.Sprintf(constants.ErrRouteCapacityFull, e.NroRto, e.Fecha, e.Booked, e.Limit, tipo)
+	if e.SuggestedFecha != "" {
+		message += fmt.Sprintf(constants.ErrRouteCapacitySuggested, e.SuggestedFecha)
+	}
+	return message
+}
+
+type RouteCapacityService interface {
+	FindAll(ctx context.Context, nroRto string) ([]models.RouteCapacity, error)
+	Create(ctx context.Context, req dto.RouteCapacityRequest) (*models.RouteCapacity, error)
+	Update(ctx context.Context, id int, req dto.RouteCapacityRequest) (*models.RouteCapacity, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type routeCapacityService struct {
+	store store.RouteCapacityStore
+}
+
+func NewRouteCapacityService(store store.RouteCapacityStore) RouteCapacityService {
+	return &routeCapacityService{store: store}
+}
+
+func (s *routeCapacityService) FindAll(ctx context.Context, nroRto string) ([]models.RouteCapacity, error) {
+	return s.store.FindAll(ctx, strings.TrimSpace(nroRto))
+}
+
+func (s *routeCapacityService) Create(ctx context.Context, req dto.RouteCapacityRequest) (*models.RouteCapacity, error) {
+	capacity := &models.RouteCapacity{}
+	if err := s.applyRequest(ctx, capacity, req); err != nil {
+		return nil, err
+	}
+	if err := s.store.Create(ctx, capacity); err != nil {
+		return nil, err
+	}
+	return capacity, nil
+}
+
+func (s *routeCapacityService) Update(ctx context.Context, id int, req dto.RouteCapacityRequest) (*models.RouteCapacity, error) {
+	capacity, err := s.store.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyRequest(ctx, capacity, req); err != nil {
+		return nil, err
+	}
+	if err := s.store.Update(ctx, capacity); err != nil {
+		return nil, err
+	}
+	return capacity, nil
+}
+
+func (s *routeCapacityService) Delete(ctx context.Context, id int) error {
+	return s.store.Delete(ctx, id)
+}
+
+// applyRequest copia la regla verificando que no haya otra para el mismo reparto, día y tipo.
+// Cambiar un límite no afecta las entregas ya creadas.
+func (s *routeCapacityService) applyRequest(ctx context.Context, capacity *models.RouteCapacity, req dto.RouteCapacityRequest) error {
+	nroRto := strings.TrimSpace(req.NroRto)
+	weekday := time.Weekday(*req.Weekday)
+	tipo := models.TipoEntrega(req.TipoEntrega)
+
+	other, err := s.store.FindRule(ctx, nroRto, weekday, tipo)
+	if err != nil {
+		return err
+	}
+	if other != nil && other.ID != capacity.ID {
+		suffix := ""
+		if tipo != "" {
+			suffix = " para " + string(tipo)
+		}
+		return fmt.Errorf(constants.ErrRouteCapacityExists, nroRto, weekdayNames[weekday], suffix)
+	}
+
+	capacity.NroRto = nroRto
+	capacity.Weekday = weekday
+	capacity.TipoEntrega = tipo
+	capacity.MaxDeliveries = *req.MaxDeliveries
+	return nil
+}
+
+// routeCalendar reúne las reglas de capacidad de un reparto y las entregas que ocupan lugar en un
+// rango de fechas
+type routeCalendar struct {
+	nroRto   string
+	rules    []models.RouteCapacity
+	bookings []store.RouteBooking
+}
+
+// loadRouteCalendar lee las reglas del reparto y sus entregas entre from y to. Sin store el
+// calendario no tiene límites ni entregas.
+func loadRouteCalendar(ctx context.Context, capacityStore store.RouteCapacityStore, nroRto string, from, to time.Time) (*routeCalendar, error) {
+	calendar := &routeCalendar{nroRto: nroRto}
+	if capacityStore == nil {
+		return calendar, nil
+	}
+	rules, err := capacityStore.FindAll(ctx, nroRto)
+	if err != nil {
+		return nil, err
+	}
+	calendar.rules = rules
+	bookings, err := capacityStore.CountBooked(ctx, nroRto, from, to)
+	if err != nil {
+		return nil, err
+	}
+	calendar.bookings = bookings
+	return calendar, nil
+}
+
+// day calcula el lugar del día: el límite del total y los límites por tipo de las reglas de ese día
+// de la semana. Con tipo, remaining es el menor lugar entre el total y el límite de ese tipo.
+func (c *routeCalendar) day(fecha time.Time, tipo models.TipoEntrega) dto.DayAvailability {
+	key := fecha.Format("2006-01-02")
+	day := dto.DayAvailability{Fecha: key, Weekday: int(fecha.Weekday()), ByTipo: []dto.TipoAvailability{}}
+
+	booked := make(map[models.TipoEntrega]int)
+	for _, booking := range c.bookings {
+		if booking.Fecha.UTC().Format("2006-01-02") == key {
+			booked[booking.TipoEntrega] += booking.Count
+			day.Booked += booking.Count
+		}
+	}
+
+	for _, rule := range c.rules {
+		if rule.Weekday != fecha.Weekday() {
+			continue
+		}
+		if rule.TipoEntrega == "" {
+			limit, remaining := rule.MaxDeliveries, remainingCapacity(rule.MaxDeliveries, day.Booked)
+			day.Limit = &limit
+			day.Remaining = &remaining
+			continue
+		}
+		day.ByTipo = append(day.ByTipo, dto.TipoAvailability{
+			TipoEntrega: rule.TipoEntrega,
+			Limit:       rule.MaxDeliveries,
+			Booked:      booked[rule.TipoEntrega],
+			Remaining:   remainingCapacity(rule.MaxDeliveries, booked[rule.TipoEntrega]),
+		})
+	}
+
+	if tipo != "" {
+		for _, byTipo := range day.ByTipo {
+			if byTipo.TipoEntrega == tipo && (day.Remaining == nil || byTipo.Remaining < *day.Remaining) {
+				remaining := byTipo.Remaining
+				day.Remaining = &remaining
+			}
+		}
+	}
+	day.Available = day.Remaining == nil || *day.Remaining > 0
+	return day
+}
+
+// full devuelve el límite alcanzado para una entrega del tipo en la fecha, o nil si hay lugar
+func (c *routeCalendar) full(fecha time.Time, tipo models.TipoEntrega) *RouteCapacityError {
+	day := c.day(fecha, tipo)
+	if day.Available {
+		return nil
+	}
+	if day.Limit != nil && day.Booked >= *day.Limit {
+		return &RouteCapacityError{NroRto: c.nroRto, Fecha: day.Fecha, Limit: *day.Limit, Booked: day.Booked}
+	}
+	for _, byTipo := range day.ByTipo {
+		if byTipo.TipoEntrega == tipo {
+			return &RouteCapacityError{NroRto: c.nroRto, Fecha: day.Fecha, TipoEntrega: tipo, Limit: byTipo.Limit, Booked: byTipo.Booked}
+		}
+	}
+	return nil
+}
+
+func remainingCapacity(limit, booked int) int {
+	if booked >= limit {
+		return 0
+	}
+	return limit - booked
+}
+
+// checkRouteCapacity verifica que el reparto tenga lugar para una entrega más del tipo en la fecha.
+// Si no lo tiene devuelve un *RouteCapacityError con la primera fecha posterior con lugar.
+func checkRouteCapacity(ctx context.Context, capacityStore store.RouteCapacityStore, nroRto string, tipo models.TipoEntrega, fecha time.Time) error {
+	if capacityStore == nil {
+		return nil
+	}
+	day := store.StockDay(fecha.UTC())
+	calendar, err := loadRouteCalendar(ctx, capacityStore, nroRto, day, day.AddDate(0, 0, constants.ROUTE_CAPACITY_SUGGEST_DAYS))
+	if err != nil {
+		return err
+	}
+	fullErr := calendar.full(day, tipo)
+	if fullErr == nil {
+		return nil
+	}
+	for i := 1; i <= constants.ROUTE_CAPACITY_SUGGEST_DAYS; i++ {
+		if next := day.AddDate(0, 0, i); calendar.full(next, tipo) == nil {
+			fullErr.SuggestedFecha = next.Format("2006-01-02")
+			break
+		}
+	}
+	return fullErr
+}
+
+// bookingGuard arma la verificación de lugar del reparto y de stock del depósito para una entrega
+// del tipo en la fecha. El store la ejecuta bajo un lock del reparto y la fecha, y otro del
+// depósito, dentro de la transacción que crea o reprograma la entrega y leyendo sobre ella, para
+// que dos solicitudes simultáneas no tomen el mismo lugar ni el mismo stock. deliveryID es la entrega que se
+// reprograma (0 al crear).
+func (s *deliveryService) bookingGuard(ctx context.Context, nroRto string, tipo models.TipoEntrega, fecha time.Time, tipos dto.DispenserTypesQuantity, deliveryID int) (*store.BookingGuard, error) {
+	guard := &store.BookingGuard{Keys: []string{store.RouteBookingKey(nroRto, fecha)}}
+	if s.stockStore != nil && tipo.ConsumesStock() {
+		warehouse, err := s.stockStore.FindWarehouseForRoute(ctx, nroRto)
+		if err != nil {
+			return nil, err
+		}
+		if warehouse != nil {
+			guard.Keys = append(guard.Keys, store.WarehouseStockKey(warehouse.ID))
+		}
+	}
+	guard.Check = func(ctx context.Context, tx *gorm.DB) error {
+		var capacityStore store.RouteCapacityStore
+		if s.capacityStore != nil {
+			capacityStore = s.capacityStore.WithTx(tx)
+		}
+		if err := checkRouteCapacity(ctx, capacityStore, nroRto, tipo, fecha); err != nil {
+			return err
+		}
+		var stockStore store.StockStore
+		if s.stockStore != nil {
+			stockStore = s.stockStore.WithTx(tx)
+		}
+		return s.checkBookingStock(ctx, stockStore, nroRto, tipo, fecha, tipos, deliveryID)
+	}
+	return guard, nil
+}
+
+// Availability devuelve el lugar por día del reparto entre from y to inclusive, para el tipo de
+// entrega si no es vacío
+func (s *deliveryService) Availability(ctx context.Context, nroRto string, from, to time.Time, tipo models.TipoEntrega) (*dto.RouteAvailabilityResponse, error) {
+	from, to = store.StockDay(from), store.StockDay(to)
+	calendar, err := loadRouteCalendar(ctx, s.capacityStore, nroRto, from, to)
+	if err != nil {
+		return nil, err
+	}
+	response := &dto.RouteAvailabilityResponse{
+		NroRto:      nroRto,
+		TipoEntrega: tipo,
+		From:        from.Format("2006-01-02"),
+		To:          to.Format("2006-01-02"),
+		Days:        []dto.DayAvailability{},
+	}
+	for fecha := from; !fecha.After(to); fecha = fecha.AddDate(0, 0, 1) {
+		response.Days = append(response.Days, calendar.day(fecha, tipo))
+	}
+	return response, nil
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"GoFrioCalor/internal/models"
+	"GoFrioCalor/internal/store"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRouteCalendarDay(t *testing.T) {
+	monday := stockDate("2026-06-01")
+	calendar := &routeCalendar{
+		nroRto: "R1",
+		rules: []models.RouteCapacity{
+			{NroRto: "R1", Weekday: time.Monday, MaxDeliveries: 10},
+			{NroRto: "R1", Weekday: time.Monday, TipoEntrega: models.Instalacion, MaxDeliveries: 4},
+			{NroRto: "R1", Weekday: time.Tuesday, MaxDeliveries: 0},
+		},
+		bookings: []store.RouteBooking{
+			{Fecha: monday, TipoEntrega: models.Instalacion, Count: 4},
+			{Fecha: monday, TipoEntrega: models.Retiro, Count: 3},
+		},
+	}
+
+	day := calendar.day(monday, "")
+	if day.Limit == nil || *day.Limit != 10 || day.Booked != 7 || *day.Remaining != 3 || !day.Available {
+		t.Errorf("total del lunes = %+v", day)
+	}
+	if len(day.ByTipo) != 1 || day.ByTipo[0].Remaining != 0 {
+		t.Errorf("por tipo = %+v", day.ByTipo)
+	}
+
+	// Instalaciones agotadas aunque quede lugar en el total
+	if day := calendar.day(monday, models.Instalacion); day.Available || *day.Remaining != 0 {
+		t.Errorf("lunes para Instalacion = %+v", day)
+	}
+	if day := calendar.day(monday, models.Retiro); !day.Available || *day.Remaining != 3 {
+		t.Errorf("lunes para Retiro = %+v", day)
+	}
+	// Martes cerrado, miércoles sin reglas
+	if day := calendar.day(monday.AddDate(0, 0, 1), models.Retiro); day.Available {
+		t.Errorf("martes = %+v", day)
+	}
+	if day := calendar.day(monday.AddDate(0, 0, 2), models.Instalacion); !day.Available || day.Remaining != nil {
+		t.Errorf("miércoles = %+v", day)
+	}
+}
+
+func TestRouteCalendarFull(t *testing.T) {
+	monday := stockDate("2026-06-01")
+	calendar := &routeCalendar{
+		nroRto: "R1",
+		rules: []models.RouteCapacity{
+			{NroRto: "R1", Weekday: time.Monday, TipoEntrega: models.Instalacion, MaxDeliveries: 2},
+		},
+		bookings: []store.RouteBooking{{Fecha: monday, TipoEntrega: models.Instalacion, Count: 2}},
+	}
+
+	err := calendar.full(monday, models.Instalacion)
+	if err == nil || err.TipoEntrega != models.Instalacion || err.Limit != 2 || err.Booked != 2 {
+		t.Fatalf("error = %+v", err)
+	}
+	err.SuggestedFecha = "2026-06-02"
+	if msg := err.Error(); !strings.Contains(msg, "sin lugar en el reparto R1 para el 2026-06-01") || !strings.Contains(msg, "de Instalacion") || !strings.Contains(msg, "2026-06-02") {
+		t.Errorf("mensaje = %q", msg)
+	}
+	if err := calendar.full(monday, models.Service); err != nil {
+		t.Errorf("Service sin límite propio = %v", err)
+	}
+}
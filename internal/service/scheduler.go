@@ -9,17 +9,27 @@ import (
 )
 
 type Scheduler struct {
-	deliveryStore store.DeliveryStore
-	stopCh        chan struct{}
+	deliveryStore    store.DeliveryStore
+	idempotencyStore store.IdempotencyStore
+	maintenance      MaintenanceService
+	stopCh           chan struct{}
 }
 
-func NewScheduler(deliveryStore store.DeliveryStore) *Scheduler {
+func NewScheduler(deliveryStore store.DeliveryStore, idempotencyStore store.IdempotencyStore) *Scheduler {
 	return &Scheduler{
-		deliveryStore: deliveryStore,
-		stopCh:        make(chan struct{}),
+		deliveryStore:    deliveryStore,
+		idempotencyStore: idempotencyStore,
+		stopCh:           make(chan struct{}),
 	}
 }
 
+// NewSchedulerWithMaintenance agrega a la corrida diaria la generación de services preventivos
+func NewSchedulerWithMaintenance(deliveryStore store.DeliveryStore, idempotencyStore store.IdempotencyStore, maintenance MaintenanceService) *Scheduler {
+	scheduler := NewScheduler(deliveryStore, idempotencyStore)
+	scheduler.maintenance = maintenance
+	return scheduler
+}
+
 func (s *Scheduler) Start() {
 	go func() {
 
@@ -34,7 +44,7 @@ func (s *Scheduler) Start() {
 
 		select {
 		case <-time.After(untilMidnight):
-			s.cancelExpiredDeliveries()
+			s.runDaily()
 		case <-s.stopCh:
 			log.Info().Msg("Scheduler stopped before first run")
 			return
@@ -46,7 +56,7 @@ func (s *Scheduler) Start() {
 		for {
 			select {
 			case <-ticker.C:
-				s.cancelExpiredDeliveries()
+				s.runDaily()
 			case <-s.stopCh:
 				log.Info().Msg("Scheduler stopped")
 				return
@@ -59,6 +69,12 @@ func (s *Scheduler) Stop() {
 	close(s.stopCh)
 }
 
+func (s *Scheduler) runDaily() {
+	s.cancelExpiredDeliveries()
+	s.purgeExpiredIdempotencyKeys()
+	s.scheduleMaintenance()
+}
+
 func (s *Scheduler) cancelExpiredDeliveries() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -72,3 +88,37 @@ func (s *Scheduler) cancelExpiredDeliveries() {
 		Int64("cancelled", count).
 		Msg("Scheduler: expired pending deliveries cancelled")
 }
+
+func (s *Scheduler) purgeExpiredIdempotencyKeys() {
+	if s.idempotencyStore == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	count, err := s.idempotencyStore.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		log.Error().Err(err).Msg("Scheduler: error purging expired idempotency keys")
+		return
+	}
+
+	log.Info().
+		Int64("purged", count).
+		Msg("Scheduler: expired idempotency keys purged")
+}
+
+func (s *Scheduler) scheduleMaintenance() {
+	if s.maintenance == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	count, err := s.maintenance.ScheduleDue(ctx, time.Now())
+	if err != nil {
+		log.Error().Err(err).Msg("Scheduler: error scheduling preventive maintenance")
+		return
+	}
+
+	log.Info().
+		Int("created", count).
+		Msg("Scheduler: preventive maintenance deliveries scheduled")
+}
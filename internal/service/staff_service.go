@@ -0,0 +1,267 @@
+package service
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/dto"
+	"GoFrioCalor/internal/models"
+	"GoFrioCalor/internal/store"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type StaffService interface {
+	List(ctx context.Context, filter store.StaffFilter) ([]models.Staff, error)
+	FindByID(ctx context.Context, id int) (*models.Staff, error)
+	Create(ctx context.Context, req dto.StaffRequest) (*models.Staff, error)
+	Update(ctx context.Context, id int, req dto.StaffRequest) (*models.Staff, error)
+	Login(ctx context.Context, req dto.StaffLoginRequest) (*dto.StaffLoginResponse, error)
+	Logout(ctx context.Context, sessionToken string) error
+	ResolveSession(ctx context.Context, sessionToken string) (*models.Staff, error)
+	ResolveLegajo(ctx context.Context, legajo string) (*models.Staff, error)
+	DailyDeliveries(ctx context.Context, staffID int, fecha string) (*dto.StaffDeliveriesResponse, error)
+}
+
+type staffService struct {
+	store          store.StaffStore
+	sessionTTL     time.Duration
+	pinMaxAttempts int
+}
+
+func NewStaffService(store store.StaffStore, sessionTTL time.Duration, pinMaxAttempts int) StaffService {
+	if sessionTTL <= 0 {
+		sessionTTL = constants.STAFF_SESSION_DEFAULT_TTL_HOURS * time.Hour
+	}
+	if pinMaxAttempts < 1 {
+		pinMaxAttempts = constants.STAFF_PIN_DEFAULT_MAX_ATTEMPTS
+	}
+	return &staffService{store: store, sessionTTL: sessionTTL, pinMaxAttempts: pinMaxAttempts}
+}
+
+func (s *staffService) List(ctx context.Context, filter store.StaffFilter) ([]models.Staff, error) {
+	return s.store.List(ctx, filter)
+}
+
+func (s *staffService) FindByID(ctx context.Context, id int) (*models.Staff, error) {
+	return s.store.FindByID(ctx, id)
+}
+
+func (s *staffService) Create(ctx context.Context, req dto.StaffRequest) (*models.Staff, error) {
+	legajo := strings.TrimSpace(req.Legajo)
+	existing, err := s.store.FindByLegajo(ctx, legajo)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf(constants.ErrStaffLegajoInUse, legajo)
+	}
+
+	staff := &models.Staff{
+		Legajo: legajo,
+		Name:   strings.TrimSpace(req.Name),
+		Role:   req.Role,
+		NroRto: strings.TrimSpace(req.NroRto),
+		Active: true,
+	}
+	if req.Pin != "" {
+		if staff.PinHash, err = hashPin(req.Pin); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.store.Create(ctx, staff); err != nil {
+		return nil, err
+	}
+	// default:true hace que GORM ignore Active=false en el INSERT
+	if req.Active != nil && !*req.Active {
+		staff.Active = false
+		if err := s.store.Update(ctx, staff); err != nil {
+			return nil, err
+		}
+	}
+	return staff, nil
+}
+
+func (s *staffService) Update(ctx context.Context, id int, req dto.StaffRequest) (*models.Staff, error) {
+	staff, err := s.store.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	legajo := strings.TrimSpace(req.Legajo)
+	if legajo != staff.Legajo {
+		existing, err := s.store.FindByLegajo(ctx, legajo)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return nil, fmt.Errorf(constants.ErrStaffLegajoInUse, legajo)
+		}
+	}
+
+	staff.Legajo = legajo
+	staff.Name = strings.TrimSpace(req.Name)
+	staff.Role = req.Role
+	staff.NroRto = strings.TrimSpace(req.NroRto)
+	if req.Active != nil {
+		staff.Active = *req.Active
+	}
+	if req.Pin != "" {
+		if staff.PinHash, err = hashPin(req.Pin); err != nil {
+			return nil, err
+		}
+		// Un PIN nuevo levanta el bloqueo por intentos fallidos
+		staff.PinFailedAttempts = 0
+		staff.PinLockedAt = nil
+	}
+	if err := s.store.Update(ctx, staff); err != nil {
+		return nil, err
+	}
+	return staff, nil
+}
+
+// Login identifica al repartidor o técnico en el dispositivo al iniciar el turno. El token de
+// sesión se devuelve una sola vez y solo se guarda su hash. Tras pinMaxAttempts PIN incorrectos
+// seguidos el login queda bloqueado hasta que se cargue un PIN nuevo.
+func (s *staffService) Login(ctx context.Context, req dto.StaffLoginRequest) (*dto.StaffLoginResponse, error) {
+	staff, err := s.store.FindByLegajo(ctx, strings.TrimSpace(req.Legajo))
+	if err != nil {
+		return nil, err
+	}
+	if staff == nil || !staff.Active || staff.PinHash == "" {
+		log.Warn().Str("legajo", req.Legajo).Str("device_id", req.DeviceID).Msg("Staff login rejected")
+		return nil, fmt.Errorf(constants.ErrStaffLoginFailed)
+	}
+	if staff.PinLockedAt != nil {
+		log.Warn().Str("legajo", req.Legajo).Str("device_id", req.DeviceID).Msg("Staff login rejected: PIN locked")
+		return nil, fmt.Errorf(constants.ErrStaffPinLocked)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(staff.PinHash), []byte(req.Pin)) != nil {
+		updated, err := s.store.RegisterFailedPinAttempt(ctx, staff.ID, s.pinMaxAttempts)
+		if err != nil {
+			return nil, err
+		}
+		log.Warn().
+			Str("legajo", req.Legajo).
+			Str("device_id", req.DeviceID).
+			Int("failed_attempts", updated.PinFailedAttempts).
+			Msg("Staff login rejected")
+		if updated.PinLockedAt != nil {
+			return nil, fmt.Errorf(constants.ErrStaffPinLocked)
+		}
+		return nil, fmt.Errorf(constants.ErrStaffLoginFailed)
+	}
+	if staff.PinFailedAttempts > 0 {
+		if err := s.store.ResetPinAttempts(ctx, staff.ID); err != nil {
+			log.Error().Err(err).Int("staff_id", staff.ID).Msg("Error resetting staff PIN attempts")
+		}
+	}
+
+	now := time.Now()
+	if _, err := s.store.DeleteExpiredSessions(ctx, now); err != nil {
+		log.Error().Err(err).Msg("Error purging expired staff sessions")
+	}
+
+	token := newSessionToken()
+	session := &models.StaffSession{
+		TokenHash: hashSessionToken(token),
+		StaffID:   staff.ID,
+		DeviceID:  req.DeviceID,
+		ExpiresAt: now.Add(s.sessionTTL),
+	}
+	if err := s.store.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Int("staff_id", staff.ID).
+		Str("legajo", staff.Legajo).
+		Str("device_id", req.DeviceID).
+		Msg("Staff logged in")
+
+	return &dto.StaffLoginResponse{
+		SessionToken: token,
+		ExpiresAt:    session.ExpiresAt.Format(time.RFC3339),
+		Staff:        *staff,
+	}, nil
+}
+
+func (s *staffService) Logout(ctx context.Context, sessionToken string) error {
+	return s.store.DeleteSession(ctx, hashSessionToken(sessionToken))
+}
+
+// ResolveSession devuelve el personal activo dueño de la sesión del dispositivo
+func (s *staffService) ResolveSession(ctx context.Context, sessionToken string) (*models.Staff, error) {
+	session, err := s.store.FindSession(ctx, hashSessionToken(sessionToken), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, fmt.Errorf(constants.ErrStaffSessionInvalid)
+	}
+	staff, err := s.store.FindByID(ctx, session.StaffID)
+	if err != nil || !staff.Active {
+		return nil, fmt.Errorf(constants.ErrStaffSessionInvalid)
+	}
+	return staff, nil
+}
+
+// ResolveLegajo devuelve el personal activo con el legajo informado por el servicio de
+// autenticación, o nil si el legajo no corresponde a un repartidor o técnico
+func (s *staffService) ResolveLegajo(ctx context.Context, legajo string) (*models.Staff, error) {
+	staff, err := s.store.FindByLegajo(ctx, legajo)
+	if err != nil || staff == nil || !staff.Active {
+		return nil, err
+	}
+	return staff, nil
+}
+
+// DailyDeliveries devuelve las entregas completadas por la persona en el día indicado
+// (YYYY-MM-DD, hora local del servidor)
+func (s *staffService) DailyDeliveries(ctx context.Context, staffID int, fecha string) (*dto.StaffDeliveriesResponse, error) {
+	day, err := time.ParseInLocation("2006-01-02", fecha, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("formato de fecha inválido: %s (esperado YYYY-MM-DD)", fecha)
+	}
+	staff, err := s.store.FindByID(ctx, staffID)
+	if err != nil {
+		return nil, err
+	}
+	deliveries, err := s.store.FindCompletedDeliveries(ctx, staffID, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	return &dto.StaffDeliveriesResponse{
+		Staff:      *staff,
+		Fecha:      fecha,
+		Total:      len(deliveries),
+		Deliveries: dto.ToDeliveryResponseList(deliveries),
+	}, nil
+}
+
+func hashPin(pin string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("error procesando PIN: %w", err)
+	}
+	return string(hash), nil
+}
+
+// newSessionToken genera 32 bytes aleatorios en hexadecimal
+func newSessionToken() string {
+	buf := make([]byte, 32)
+	// desde Go 1.24 rand.Read no devuelve errores
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,388 @@
+package service
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/dto"
+	"GoFrioCalor/internal/models"
+	"GoFrioCalor/internal/store"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// StockPolicy define qué pasa al pedir una instalación sin stock para la fecha
+type StockPolicy struct {
+	// EnforceOnBooking rechaza la entrega si el depósito no tiene stock para la fecha; si es false
+	// la entrega se crea igual y solo queda advertida en el log
+	EnforceOnBooking bool
+	// SuggestDays es la cantidad de días, después de la fecha pedida, en los que se busca una fecha con stock
+	SuggestDays int
+}
+
+// DefaultStockPolicy rechaza las entregas sin stock y sugiere una fecha dentro de dos semanas
+var DefaultStockPolicy = StockPolicy{
+	EnforceOnBooking: true,
+	SuggestDays:      constants.STOCK_DEFAULT_SUGGEST_DAYS,
+}
+
+// Validate verifica que la ventana de búsqueda de fechas con stock no sea negativa
+func (policy StockPolicy) Validate() error {
+	if policy.SuggestDays < 0 {
+		return fmt.Errorf("días de búsqueda de stock inválidos: %d", policy.SuggestDays)
+	}
+	return nil
+}
+
+var stockTipos = []models.TipoDispenser{models.TipoDispenserPie, models.TipoDispenserMesada}
+
+// StockShortageError indica que el depósito no tiene stock para la fecha pedida. SuggestedFecha es
+// la primera fecha posterior con stock, vacía si no hay ninguna dentro de StockPolicy.SuggestDays.
+type StockShortageError struct {
+	Warehouse      string
+	Fecha          string
+	Shortages      []dto.StockShortage
+	SuggestedFecha string
+}
+
+func (e *StockShortageError) Error() string {
+	details := make([]string, 0, len(e.Shortages))
+	for _, shortage := range e.Shortages {
+		details = append(details, fmt.Sprintf("%s: se piden %d, disponibles %d", shortage.Tipo, shortage.Requested, shortage.Available))
+	}
+	message := fmt.Sprintf(constants.ErrStockInsufficient, e.Warehouse, e.Fecha, strings.Join(details, ", "))
+	if e.SuggestedFecha != "" {
+		message += fmt.Sprintf(constants.ErrStockSuggestedFecha, e.SuggestedFecha)
+	}
+	return message
+}
+
+type StockService interface {
+	ListWarehouses(ctx context.Context) ([]models.Warehouse, error)
+	CreateWarehouse(ctx context.Context, req dto.WarehouseRequest) (*models.Warehouse, error)
+	UpdateWarehouse(ctx context.Context, id int, req dto.WarehouseRequest) (*models.Warehouse, error)
+	Stock(ctx context.Context, warehouseID int) (*dto.WarehouseStockResponse, error)
+	RecordMovement(ctx context.Context, warehouseID int, req dto.StockMovementRequest, actor string) (*models.StockMovement, error)
+	Ledger(ctx context.Context, filter store.StockMovementFilter, limit, offset int) ([]models.StockMovement, int64, error)
+}
+
+type stockService struct {
+	store store.StockStore
+}
+
+func NewStockService(store store.StockStore) StockService {
+	return &stockService{store: store}
+}
+
+func (s *stockService) ListWarehouses(ctx context.Context) ([]models.Warehouse, error) {
+	return s.store.ListWarehouses(ctx)
+}
+
+func (s *stockService) CreateWarehouse(ctx context.Context, req dto.WarehouseRequest) (*models.Warehouse, error) {
+	warehouse := &models.Warehouse{Active: true}
+	if err := s.applyWarehouseRequest(ctx, warehouse, req); err != nil {
+		return nil, err
+	}
+	if err := s.store.CreateWarehouse(ctx, warehouse); err != nil {
+		return nil, err
+	}
+	return warehouse, nil
+}
+
+func (s *stockService) UpdateWarehouse(ctx context.Context, id int, req dto.WarehouseRequest) (*models.Warehouse, error) {
+	warehouse, err := s.store.FindWarehouseByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyWarehouseRequest(ctx, warehouse, req); err != nil {
+		return nil, err
+	}
+	if err := s.store.UpdateWarehouse(ctx, warehouse); err != nil {
+		return nil, err
+	}
+	return warehouse, nil
+}
+
+// applyWarehouseRequest valida que el código no esté en uso y que cada reparto lo abastezca un solo depósito
+func (s *stockService) applyWarehouseRequest(ctx context.Context, warehouse *models.Warehouse, req dto.WarehouseRequest) error {
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	existing, err := s.store.FindWarehouseByCode(ctx, code)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != warehouse.ID {
+		return fmt.Errorf(constants.ErrWarehouseCodeExists, code)
+	}
+
+	routes := make([]string, 0, len(req.Routes))
+	for _, route := range req.Routes {
+		if route = strings.TrimSpace(route); route != "" {
+			routes = append(routes, route)
+		}
+	}
+	warehouses, err := s.store.ListWarehouses(ctx)
+	if err != nil {
+		return err
+	}
+	for _, other := range warehouses {
+		if other.ID == warehouse.ID {
+			continue
+		}
+		for _, route := range routes {
+			for _, taken := range other.Routes {
+				if route == taken {
+					return fmt.Errorf(constants.ErrWarehouseRouteTaken, route, other.Code)
+				}
+			}
+		}
+	}
+
+	warehouse.Code = code
+	warehouse.Name = strings.TrimSpace(req.Name)
+	warehouse.Routes = models.StringArray(routes)
+	warehouse.IsDefault = req.IsDefault
+	if req.Active != nil {
+		warehouse.Active = *req.Active
+	}
+	return nil
+}
+
+// Stock devuelve la existencia, los ingresos programados, lo reservado y lo disponible por tipo
+func (s *stockService) Stock(ctx context.Context, warehouseID int) (*dto.WarehouseStockResponse, error) {
+	warehouse, err := s.store.FindWarehouseByID(ctx, warehouseID)
+	if err != nil {
+		return nil, err
+	}
+	supply, reserved, err := s.timeline(ctx, warehouseID)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.WarehouseStockResponse{
+		Warehouse: *warehouse,
+		Levels:    stockLevels(store.StockDay(time.Now()), supply, reserved),
+	}, nil
+}
+
+func (s *stockService) timeline(ctx context.Context, warehouseID int) ([]store.StockDelta, []store.StockDelta, error) {
+	supply, err := s.store.FindSupply(ctx, warehouseID)
+	if err != nil {
+		return nil, nil, err
+	}
+	reserved, err := s.store.FindReserved(ctx, warehouseID, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	return supply, reserved, nil
+}
+
+// RecordMovement registra un ingreso o un ajuste de inventario. Solo los ingresos pueden tener fecha
+// futura y un ajuste negativo no puede dejar la existencia por debajo de cero.
+func (s *stockService) RecordMovement(ctx context.Context, warehouseID int, req dto.StockMovementRequest, actor string) (*models.StockMovement, error) {
+	warehouse, err := s.store.FindWarehouseByID(ctx, warehouseID)
+	if err != nil {
+		return nil, err
+	}
+	kind := models.StockMovementKind(req.Kind)
+	tipo := models.TipoDispenser(req.Tipo)
+	today := store.StockDay(time.Now())
+
+	effective := today
+	if req.EffectiveDate != "" {
+		fecha, err := time.Parse("2006-01-02", req.EffectiveDate)
+		if err != nil {
+			return nil, fmt.Errorf("effective_date inválida, use YYYY-MM-DD")
+		}
+		if fecha.After(today) {
+			if kind != models.StockIngreso {
+				return nil, fmt.Errorf(constants.ErrStockAdjustFutureDate)
+			}
+			effective = fecha
+		}
+	}
+	if kind == models.StockIngreso && req.Quantity < 0 {
+		return nil, fmt.Errorf(constants.ErrStockReceiptQuantity)
+	}
+	if kind == models.StockAjuste && req.Quantity < 0 {
+		supply, err := s.store.FindSupply(ctx, warehouseID)
+		if err != nil {
+			return nil, err
+		}
+		onHand := sumUntil(supply, tipo, today)
+		if onHand+req.Quantity < 0 {
+			return nil, fmt.Errorf(constants.ErrStockAdjustBelowZero, tipo, warehouse.Code, onHand)
+		}
+	}
+
+	movement := &models.StockMovement{
+		WarehouseID:   warehouseID,
+		Tipo:          tipo,
+		Kind:          kind,
+		Quantity:      req.Quantity,
+		EffectiveDate: effective,
+		Reason:        strings.TrimSpace(req.Reason),
+		CreatedBy:     actor,
+	}
+	if err := s.store.RecordMovement(ctx, movement); err != nil {
+		return nil, err
+	}
+	log.Info().
+		Str("warehouse", warehouse.Code).
+		Str("tipo", string(tipo)).
+		Str("kind", string(kind)).
+		Int("quantity", req.Quantity).
+		Str("effective_date", effective.Format("2006-01-02")).
+		Msg("Stock: movimiento registrado")
+	return movement, nil
+}
+
+func (s *stockService) Ledger(ctx context.Context, filter store.StockMovementFilter, limit, offset int) ([]models.StockMovement, int64, error) {
+	if _, err := s.store.FindWarehouseByID(ctx, filter.WarehouseID); err != nil {
+		return nil, 0, err
+	}
+	total, err := s.store.CountMovements(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	movements, err := s.store.FindMovements(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return movements, total, nil
+}
+
+// stockLevels arma la existencia por tipo al día today
+func stockLevels(today time.Time, supply, reserved []store.StockDelta) []dto.StockLevel {
+	levels := make([]dto.StockLevel, 0, len(stockTipos))
+	for _, tipo := range stockTipos {
+		onHand := sumUntil(supply, tipo, today)
+		incoming := 0
+		for _, delta := range supply {
+			if delta.Tipo == tipo && store.StockDay(delta.Date).After(today) {
+				incoming += delta.Quantity
+			}
+		}
+		levels = append(levels, dto.StockLevel{
+			Tipo:      tipo,
+			OnHand:    onHand,
+			Incoming:  incoming,
+			Reserved:  sumUntil(reserved, tipo, time.Time{}),
+			Available: availableFrom(today, tipo, supply, reserved),
+		})
+	}
+	return levels
+}
+
+// sumUntil suma las cantidades del tipo hasta el día until inclusive; con until cero suma todas
+func sumUntil(deltas []store.StockDelta, tipo models.TipoDispenser, until time.Time) int {
+	total := 0
+	for _, delta := range deltas {
+		if delta.Tipo == tipo && (until.IsZero() || !store.StockDay(delta.Date).After(until)) {
+			total += delta.Quantity
+		}
+	}
+	return total
+}
+
+// availableFrom devuelve cuántas unidades del tipo se pueden reservar para fecha sin dejar
+// descubierta ninguna reserva: en cada día desde fecha en adelante la existencia acumulada hasta
+// ese día (incluidos los ingresos programados) tiene que cubrir todo lo reservado hasta ese día.
+// Basta con mirar fecha y los días posteriores en los que cambia la existencia o hay reservas.
+func availableFrom(fecha time.Time, tipo models.TipoDispenser, supply, reserved []store.StockDelta) int {
+	fecha = store.StockDay(fecha)
+	checkpoints := []time.Time{fecha}
+	for _, deltas := range [][]store.StockDelta{supply, reserved} {
+		for _, delta := range deltas {
+			if day := store.StockDay(delta.Date); delta.Tipo == tipo && day.After(fecha) {
+				checkpoints = append(checkpoints, day)
+			}
+		}
+	}
+
+	available := 0
+	for i, day := range checkpoints {
+		free := sumUntil(supply, tipo, day) - sumUntil(reserved, tipo, day)
+		if i == 0 || free < available {
+			available = free
+		}
+	}
+	return available
+}
+
+// stockShortages devuelve los tipos que no alcanzan para reservar requested en fecha
+func stockShortages(fecha time.Time, requested map[models.TipoDispenser]uint, supply, reserved []store.StockDelta) []dto.StockShortage {
+	var shortages []dto.StockShortage
+	for _, tipo := range stockTipos {
+		if requested[tipo] == 0 {
+			continue
+		}
+		if available := availableFrom(fecha, tipo, supply, reserved); available < int(requested[tipo]) {
+			shortages = append(shortages, dto.StockShortage{Tipo: tipo, Requested: requested[tipo], Available: available})
+		}
+	}
+	return shortages
+}
+
+// suggestStockDate busca, en los días siguientes a fecha, el primero con stock para todo lo pedido
+func suggestStockDate(fecha time.Time, days int, requested map[models.TipoDispenser]uint, supply, reserved []store.StockDelta) (time.Time, bool) {
+	for i := 1; i <= days; i++ {
+		candidate := fecha.AddDate(0, 0, i)
+		if len(stockShortages(candidate, requested, supply, reserved)) == 0 {
+			return candidate, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// checkBookingStock verifica que el depósito del reparto tenga stock para la entrega pedida. Solo
+// aplica a los tipos de entrega que instalan equipos y a repartos con depósito. Si falta stock
+// devuelve un *StockShortageError con la primera fecha alternativa, o nil si la política permite
+// crear la entrega igual. Al reprogramar, deliveryID excluye las reservas de la propia entrega.
+// Lee con stockStore, que el guard de la reserva pasa ya sobre su transacción.
+func (s *deliveryService) checkBookingStock(ctx context.Context, stockStore store.StockStore, nroRto string, tipo models.TipoEntrega, fecha time.Time, tipos dto.DispenserTypesQuantity, deliveryID int) error {
+	if stockStore == nil || !tipo.ConsumesStock() {
+		return nil
+	}
+	warehouse, err := stockStore.FindWarehouseForRoute(ctx, nroRto)
+	if err != nil || warehouse == nil {
+		return err
+	}
+	supply, err := stockStore.FindSupply(ctx, warehouse.ID)
+	if err != nil {
+		return err
+	}
+	reserved, err := stockStore.FindReserved(ctx, warehouse.ID, deliveryID)
+	if err != nil {
+		return err
+	}
+
+	requested := map[models.TipoDispenser]uint{models.TipoDispenserPie: tipos.P, models.TipoDispenserMesada: tipos.M}
+	shortages := stockShortages(fecha, requested, supply, reserved)
+	if len(shortages) == 0 {
+		return nil
+	}
+	shortageErr := &StockShortageError{Warehouse: warehouse.Code, Fecha: fecha.Format("2006-01-02"), Shortages: shortages}
+	if suggested, ok := suggestStockDate(store.StockDay(fecha), s.stockPolicy.SuggestDays, requested, supply, reserved); ok {
+		shortageErr.SuggestedFecha = suggested.Format("2006-01-02")
+	}
+	if !s.stockPolicy.EnforceOnBooking {
+		log.Warn().Err(shortageErr).Str("nro_rto", nroRto).Msg("Entrega creada sin stock suficiente en el depósito")
+		return nil
+	}
+	return shortageErr
+}
+
+// dispenserTypes suma por tipo los dispensers pedidos en la entrega
+func dispenserTypes(items []models.ItemDispenser) dto.DispenserTypesQuantity {
+	var tipos dto.DispenserTypesQuantity
+	for _, item := range items {
+		switch item.Tipo {
+		case models.TipoDispenserPie:
+			tipos.P += item.Cantidad
+		case models.TipoDispenserMesada:
+			tipos.M += item.Cantidad
+		}
+	}
+	return tipos
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"GoFrioCalor/internal/models"
+	"GoFrioCalor/internal/store"
+	"strings"
+	"testing"
+	"time"
+)
+
+func stockDate(value string) time.Time {
+	fecha, _ := time.Parse("2006-01-02", value)
+	return fecha
+}
+
+func TestAvailableFrom(t *testing.T) {
+	pie := models.TipoDispenserPie
+	supply := []store.StockDelta{
+		{Tipo: pie, Date: stockDate("2026-05-01"), Quantity: 5},
+		{Tipo: pie, Date: stockDate("2026-06-08"), Quantity: 3}, // ingreso programado
+		{Tipo: models.TipoDispenserMesada, Date: stockDate("2026-05-01"), Quantity: 10},
+	}
+	reserved := []store.StockDelta{
+		{Tipo: pie, Date: stockDate("2026-06-03"), Quantity: 5},
+	}
+
+	tests := []struct {
+		fecha string
+		want  int
+	}{
+		// La reserva del 3 necesita las 5 unidades actuales: hasta el ingreso del 8 no queda nada
+		{"2026-06-01", 0},
+		{"2026-06-08", 3},
+		{"2026-06-12", 3},
+	}
+	for _, tt := range tests {
+		if got := availableFrom(stockDate(tt.fecha), pie, supply, reserved); got != tt.want {
+			t.Errorf("availableFrom(%s) = %d, se esperaba %d", tt.fecha, got, tt.want)
+		}
+	}
+	if got := availableFrom(stockDate("2026-06-01"), models.TipoDispenserMesada, supply, reserved); got != 10 {
+		t.Errorf("availableFrom(M) = %d, se esperaba 10", got)
+	}
+}
+
+func TestSuggestStockDate(t *testing.T) {
+	pie := models.TipoDispenserPie
+	supply := []store.StockDelta{
+		{Tipo: pie, Date: stockDate("2026-05-01"), Quantity: 2},
+		{Tipo: pie, Date: stockDate("2026-06-05"), Quantity: 4},
+	}
+	reserved := []store.StockDelta{
+		{Tipo: pie, Date: stockDate("2026-06-02"), Quantity: 2},
+	}
+	requested := map[models.TipoDispenser]uint{pie: 2}
+
+	shortages := stockShortages(stockDate("2026-06-01"), requested, supply, reserved)
+	if len(shortages) != 1 || shortages[0].Available != 0 {
+		t.Fatalf("faltantes = %+v", shortages)
+	}
+	suggested, ok := suggestStockDate(stockDate("2026-06-01"), 14, requested, supply, reserved)
+	if !ok || !suggested.Equal(stockDate("2026-06-05")) {
+		t.Errorf("fecha sugerida = %v (%v), se esperaba 2026-06-05", suggested, ok)
+	}
+	if _, ok := suggestStockDate(stockDate("2026-06-01"), 2, requested, supply, reserved); ok {
+		t.Error("no debe haber fecha con stock dentro de 2 días")
+	}
+
+	err := &StockShortageError{Warehouse: "CENTRAL", Fecha: "2026-06-01", Shortages: shortages, SuggestedFecha: "2026-06-05"}
+	if !strings.Contains(err.Error(), "stock insuficiente en el depósito CENTRAL") || !strings.Contains(err.Error(), "2026-06-05") {
+		t.Errorf("mensaje = %q", err.Error())
+	}
+}
+
+func TestStockLevels(t *testing.T) {
+	pie := models.TipoDispenserPie
+	supply := []store.StockDelta{
+		{Tipo: pie, Date: stockDate("2026-05-01"), Quantity: 6},
+		{Tipo: pie, Date: stockDate("2026-05-20"), Quantity: -1}, // consumo
+		{Tipo: pie, Date: stockDate("2026-07-01"), Quantity: 4},
+	}
+	reserved := []store.StockDelta{{Tipo: pie, Date: stockDate("2026-06-03"), Quantity: 2}}
+
+	levels := stockLevels(stockDate("2026-06-01"), supply, reserved)
+	if len(levels) != 2 {
+		t.Fatalf("niveles = %+v", levels)
+	}
+	if got := levels[0]; got.OnHand != 5 || got.Incoming != 4 || got.Reserved != 2 || got.Available != 3 {
+		t.Errorf("nivel P = %+v", got)
+	}
+}
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/dto"
+	"GoFrioCalor/internal/models"
+	"GoFrioCalor/internal/store"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Código de advertencia al completar una entrega con un dispenser distinto del preparado en taller
+const SerialNotPrepared = "serial_not_prepared"
+
+type TallerService interface {
+	Prepare(ctx context.Context, deliveryID int, req dto.PrepareDeliveryRequest, staffID *int) (*models.DeliveryPreparation, error)
+	Board(ctx context.Context, fecha string) (*dto.TallerBoardResponse, error)
+}
+
+type tallerService struct {
+	store          store.PreparationStore
+	deliveryStore  store.DeliveryStore
+	dispenserStore store.DispenserStore
+	serialFormats  serialFormats
+}
+
+func NewTallerService(store store.PreparationStore, deliveryStore store.DeliveryStore, dispenserStore store.DispenserStore, serialPolicy DispenserSerialPolicy) TallerService {
+	return &tallerService{store: store, deliveryStore: deliveryStore, dispenserStore: dispenserStore, serialFormats: serialPolicy.mustCompile()}
+}
+
+// Prepare registra los números de serie apartados para la entrega y su avance (Preparado o Cargado).
+// Solo se preparan entregas abiertas.
+func (s *tallerService) Prepare(ctx context.Context, deliveryID int, req dto.PrepareDeliveryRequest, staffID *int) (*models.DeliveryPreparation, error) {
+	delivery, err := s.deliveryStore.FindByID(ctx, deliveryID)
+	if err != nil || delivery == nil {
+		return nil, fmt.Errorf(constants.ErrDeliveryNotFound)
+	}
+	if !isOpenEstado(delivery.Estado) {
+		return nil, fmt.Errorf(constants.ErrPreparationNotAllowed, delivery.Estado)
+	}
+
+	preparation, err := s.store.FindByDeliveryID(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if preparation == nil {
+		preparation = &models.DeliveryPreparation{DeliveryID: deliveryID}
+	}
+
+	serialsChanged := false
+	if req.Serials != nil {
+		serials, err := s.checkSerials(ctx, delivery, req.Serials)
+		if err != nil {
+			return nil, err
+		}
+		preparation.Serials = models.StringArray(serials)
+		serialsChanged = true
+	}
+
+	status := models.EstadoPreparacion(req.Status)
+	if status == "" {
+		status = models.PreparacionPreparado
+	}
+	now := time.Now()
+	if preparation.PreparedAt == nil || (serialsChanged && status == models.PreparacionPreparado) {
+		preparation.PreparedAt = &now
+	}
+	switch status {
+	case models.PreparacionPreparado:
+		preparation.LoadedAt = nil
+	case models.PreparacionCargado:
+		if preparation.Status != models.PreparacionCargado {
+			preparation.LoadedAt = &now
+		}
+	}
+	preparation.Status = status
+	if notes := strings.TrimSpace(req.Notes); notes != "" {
+		preparation.Notes = notes
+	}
+	if staffID != nil {
+		preparation.PreparedBy = staffID
+	}
+
+	if err := s.store.Save(ctx, preparation); err != nil {
+		return nil, err
+	}
+	log.Info().
+		Int("delivery_id", deliveryID).
+		Str("status", string(preparation.Status)).
+		Strs("serials", preparation.Serials).
+		Msg("Taller: preparación registrada")
+	return preparation, nil
+}
+
+// checkSerials normaliza los números de serie y verifica que entren en la entrega, tengan formato
+// válido y estén disponibles: ni instalados en una cuenta ni apartados para otra entrega abierta
+func (s *tallerService) checkSerials(ctx context.Context, delivery *models.Delivery, raw []string) ([]string, error) {
+	if uint(len(raw)) > delivery.Cantidad {
+		return nil, fmt.Errorf(constants.ErrPreparationTooManySerials, len(raw), delivery.Cantidad)
+	}
+	serials := make([]string, 0, len(raw))
+	seen := make(map[string]bool, len(raw))
+	for _, value := range raw {
+		serial := normalizeSerial(value)
+		// El taller carga los códigos sin tipo: se acepta el formato de cualquiera
+		if !s.serialFormats.valid(serial, "") {
+			return nil, fmt.Errorf(constants.ErrSerialFormat, serial)
+		}
+		if seen[serial] {
+			return nil, fmt.Errorf(constants.ErrPreparationDuplicatedSerial, serial)
+		}
+		seen[serial] = true
+
+		if s.dispenserStore != nil {
+			dispenser, err := s.dispenserStore.FindBySerial(ctx, serial)
+			if err != nil {
+				return nil, err
+			}
+			if dispenser != nil && dispenser.Status == models.DispenserInstalado && dispenser.LocationType == models.UbicacionCuenta {
+				return nil, fmt.Errorf(constants.ErrPreparationSerialInstalled, serial, dispenser.LocationID)
+			}
+		}
+		other, err := s.store.FindOpenBySerial(ctx, serial, delivery.ID)
+		if err != nil {
+			return nil, err
+		}
+		if other != nil {
+			return nil, fmt.Errorf(constants.ErrPreparationSerialInUse, serial, other.DeliveryID)
+		}
+		serials = append(serials, serial)
+	}
+	return serials, nil
+}
+
+// Board devuelve el tablero de preparación de las entregas abiertas de la fecha, por reparto
+func (s *tallerService) Board(ctx context.Context, fecha string) (*dto.TallerBoardResponse, error) {
+	deliveries, err := s.deliveryStore.FindByFechaAccion(ctx, fecha)
+	if err != nil {
+		return nil, err
+	}
+	open := make([]models.Delivery, 0, len(deliveries))
+	ids := make([]int, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		if isOpenEstado(delivery.Estado) {
+			open = append(open, delivery)
+			ids = append(ids, delivery.ID)
+		}
+	}
+
+	preparations, err := s.store.FindByDeliveryIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byDelivery := make(map[int]*models.DeliveryPreparation, len(preparations))
+	for i := range preparations {
+		byDelivery[preparations[i].DeliveryID] = &preparations[i]
+	}
+	board := buildTallerBoard(fecha, open, byDelivery)
+	return &board, nil
+}
+
+// buildTallerBoard agrupa las entregas por reparto (ordenados por nro_rto) y cuenta el avance
+func buildTallerBoard(fecha string, deliveries []models.Delivery, preparations map[int]*models.DeliveryPreparation) dto.TallerBoardResponse {
+	routes := make(map[string]*dto.TallerBoardRoute)
+	var order []string
+	board := dto.TallerBoardResponse{Fecha: fecha, Routes: []dto.TallerBoardRoute{}}
+
+	for _, delivery := range deliveries {
+		route, ok := routes[delivery.NroRto]
+		if !ok {
+			route = &dto.TallerBoardRoute{NroRto: delivery.NroRto, Deliveries: []dto.TallerBoardDelivery{}}
+			routes[delivery.NroRto] = route
+			order = append(order, delivery.NroRto)
+		}
+
+		item := dto.TallerBoardDelivery{
+			ID:             delivery.ID,
+			NroCta:         delivery.NroCta,
+			Name:           delivery.Name,
+			Address:        delivery.Address,
+			TipoEntrega:    delivery.TipoEntrega,
+			Estado:         delivery.Estado,
+			ItemDispensers: make([]dto.ItemDispenserResponse, 0, len(delivery.ItemDispensers)),
+			PrepStatus:     models.PreparacionPendiente,
+		}
+		for _, dispenser := range delivery.ItemDispensers {
+			item.ItemDispensers = append(item.ItemDispensers, dto.ItemDispenserResponse{Tipo: dispenser.Tipo, Cantidad: dispenser.Cantidad})
+			switch dispenser.Tipo {
+			case models.TipoDispenserPie:
+				route.TotalDispenserP += dispenser.Cantidad
+			case models.TipoDispenserMesada:
+				route.TotalDispenserM += dispenser.Cantidad
+			}
+		}
+		if preparation := preparations[delivery.ID]; preparation != nil {
+			item.PrepStatus = preparation.Status
+			item.Serials = preparation.Serials
+			item.Notes = preparation.Notes
+			item.PreparedAt = preparation.PreparedAt
+			item.LoadedAt = preparation.LoadedAt
+			route.SerialsAssigned += len(preparation.Serials)
+		}
+
+		route.TotalDeliveries++
+		board.TotalDeliveries++
+		switch item.PrepStatus {
+		case models.PreparacionPreparado:
+			route.Preparadas++
+			board.Preparadas++
+		case models.PreparacionCargado:
+			route.Cargadas++
+			board.Cargadas++
+		default:
+			route.Pendientes++
+			board.Pendientes++
+		}
+		route.Deliveries = append(route.Deliveries, item)
+	}
+
+	sort.Strings(order)
+	for _, nroRto := range order {
+		board.Routes = append(board.Routes, *routes[nroRto])
+	}
+	return board
+}
+
+// comparePreparedSerials advierte por cada dispenser instalado que no es uno de los que el taller
+// preparó para la entrega. Sin números de serie preparados no hay nada que comparar.
+func comparePreparedSerials(prepared []string, operations []dto.DispenserOperation) []dto.DispenserSerialError {
+	if len(prepared) == 0 {
+		return nil
+	}
+	expected := make(map[string]bool, len(prepared))
+	for _, serial := range prepared {
+		expected[serial] = true
+	}
+	list := strings.Join(prepared, ", ")
+
+	var warnings []dto.DispenserSerialError
+	for i, op := range operations {
+		if op.InstalledDispenserCode == "" || expected[op.InstalledDispenserCode] {
+			continue
+		}
+		warnings = append(warnings, dto.DispenserSerialError{
+			OperationIndex: i,
+			Type:           op.Type,
+			Field:          "installed_dispenser_code",
+			Serial:         op.InstalledDispenserCode,
+			Code:           SerialNotPrepared,
+			Message:        fmt.Sprintf(constants.ErrSerialNotPrepared, op.InstalledDispenserCode, list),
+		})
+	}
+	return warnings
+}
+
+// checkPreparation compara los dispensers instalados con los preparados en taller para la entrega.
+// Un error al leer la preparación no impide completar la entrega.
+func (s *mobileDeliveryService) checkPreparation(ctx context.Context, deliveryID int, operations []dto.DispenserOperation) []dto.DispenserSerialError {
+	if s.preparationStore == nil {
+		return nil
+	}
+	preparation, err := s.preparationStore.FindByDeliveryID(ctx, deliveryID)
+	if err != nil {
+		log.Error().Err(err).Int("delivery_id", deliveryID).Msg("Error fetching workshop preparation")
+		return nil
+	}
+	if preparation == nil {
+		return nil
+	}
+	warnings := comparePreparedSerials(preparation.Serials, operations)
+	if len(warnings) > 0 {
+		log.Warn().
+			Int("delivery_id", deliveryID).
+			Strs("prepared", preparation.Serials).
+			Int("mismatches", len(warnings)).
+			Msg("Installed dispensers differ from workshop preparation")
+	}
+	return warnings
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"GoFrioCalor/internal/dto"
+	"GoFrioCalor/internal/models"
+	"testing"
+)
+
+func TestBuildTallerBoard(t *testing.T) {
+	deliveries := []models.Delivery{
+		{ID: 1, NroCta: "100", NroRto: "R2", Estado: models.Pendiente, ItemDispensers: []models.ItemDispenser{{Tipo: models.TipoDispenserPie, Cantidad: 2}}},
+		{ID: 2, NroCta: "200", NroRto: "R1", Estado: models.Programado, ItemDispensers: []models.ItemDispenser{{Tipo: models.TipoDispenserMesada, Cantidad: 1}}},
+		{ID: 3, NroCta: "300", NroRto: "R2", Estado: models.Pendiente, ItemDispensers: []models.ItemDispenser{{Tipo: models.TipoDispenserMesada, Cantidad: 1}}},
+	}
+	preparations := map[int]*models.DeliveryPreparation{
+		1: {DeliveryID: 1, Status: models.PreparacionCargado, Serials: models.StringArray{"DSP-100001", "DSP-100002"}},
+		3: {DeliveryID: 3, Status: models.PreparacionPreparado, Serials: models.StringArray{"DSP-300001"}},
+	}
+
+	board := buildTallerBoard("2026-06-01", deliveries, preparations)
+	if board.TotalDeliveries != 3 || board.Pendientes != 1 || board.Preparadas != 1 || board.Cargadas != 1 {
+		t.Errorf("totales = %+v", board)
+	}
+	if len(board.Routes) != 2 || board.Routes[0].NroRto != "R1" || board.Routes[1].NroRto != "R2" {
+		t.Fatalf("repartos = %+v, se esperaban R1 y R2 en orden", board.Routes)
+	}
+	r2 := board.Routes[1]
+	if r2.TotalDeliveries != 2 || r2.TotalDispenserP != 2 || r2.TotalDispenserM != 1 || r2.SerialsAssigned != 3 {
+		t.Errorf("reparto R2 = %+v", r2)
+	}
+	if board.Routes[0].Deliveries[0].PrepStatus != models.PreparacionPendiente {
+		t.Errorf("entrega sin preparación = %+v", board.Routes[0].Deliveries[0])
+	}
+}
+
+func TestComparePreparedSerials(t *testing.T) {
+	operations := []dto.DispenserOperation{
+		{Type: "installation", InstalledDispenserCode: "DSP-100001"},
+		{Type: "replacement", RetiredDispenserCode: "DSP-900001", InstalledDispenserCode: "DSP-555555"},
+		{Type: "service", ServiceDispenserCode: "DSP-777777"},
+	}
+
+	warnings := comparePreparedSerials([]string{"DSP-100001", "DSP-100002"}, operations)
+	if len(warnings) != 1 {
+		t.Fatalf("advertencias = %+v, se esperaba 1", warnings)
+	}
+	if warnings[0].OperationIndex != 1 || warnings[0].Serial != "DSP-555555" || warnings[0].Code != SerialNotPrepared {
+		t.Errorf("advertencia = %+v", warnings[0])
+	}
+
+	if warnings := comparePreparedSerials(nil, operations); len(warnings) != 0 {
+		t.Errorf("sin preparación no debe haber advertencias: %+v", warnings)
+	}
+}
@@ -1,9 +1,15 @@
 package service
 
 import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/dto"
 	"GoFrioCalor/internal/models"
 	"GoFrioCalor/internal/store"
 	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
 )
 
 type TruckService interface {
@@ -13,6 +19,9 @@ type TruckService interface {
 	Create(ctx context.Context, truck *models.Truck) error
 	Update(ctx context.Context, truck *models.Truck) error
 	Delete(ctx context.Context, id int) error
+	Assign(ctx context.Context, truckID int, req dto.TruckAssignRequest) (*dto.TruckLoadSheet, error)
+	Unassign(ctx context.Context, truckID int, deliveryIDs []int) (int64, error)
+	LoadSheet(ctx context.Context, truckID int, fecha string) (*dto.TruckLoadSheet, error)
 }
 
 type truckService struct {
@@ -35,15 +44,149 @@ func (s *truckService) FindByNroTruck(ctx context.Context, nroTruck string) (*mo
 	return s.store.FindByNroTruck(ctx, nroTruck)
 }
 
+// Create da de alta el camión; el número de camión es único
 func (s *truckService) Create(ctx context.Context, truck *models.Truck) error {
+	truck.ID = 0
+	truck.NroTruck = strings.TrimSpace(truck.NroTruck)
+	if err := s.checkNroTruck(ctx, truck.NroTruck, 0); err != nil {
+		return err
+	}
 	return s.store.Create(ctx, truck)
 }
 
+// Update modifica número y capacidad. Bajar la capacidad no afecta las asignaciones ya hechas;
+// se controla en la próxima asignación.
 func (s *truckService) Update(ctx context.Context, truck *models.Truck) error {
-	return s.store.Update(ctx, truck)
+	existing, err := s.store.FindByID(ctx, truck.ID)
+	if err != nil {
+		return err
+	}
+	truck.NroTruck = strings.TrimSpace(truck.NroTruck)
+	if err := s.checkNroTruck(ctx, truck.NroTruck, truck.ID); err != nil {
+		return err
+	}
+	existing.NroTruck = truck.NroTruck
+	existing.Capacity = truck.Capacity
+	if err := s.store.Update(ctx, existing); err != nil {
+		return err
+	}
+	*truck = *existing
+	return nil
+}
+
+func (s *truckService) checkNroTruck(ctx context.Context, nroTruck string, truckID int) error {
+	other, err := s.store.FindByNroTruck(ctx, nroTruck)
+	if err != nil {
+		return err
+	}
+	if other != nil && other.ID != truckID {
+		return fmt.Errorf(constants.ErrTruckNroExists, nroTruck)
+	}
+	return nil
 }
 
+// Delete elimina el camión si no tiene entregas abiertas asignadas
 func (s *truckService) Delete(ctx context.Context, id int) error {
+	truck, err := s.store.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	open, err := s.store.CountOpenAssigned(ctx, id)
+	if err != nil {
+		return err
+	}
+	if open > 0 {
+		return fmt.Errorf(constants.ErrTruckHasDeliveries, truck.NroTruck, open)
+	}
 	return s.store.Delete(ctx, id)
 }
 
+// Assign asigna las entregas al camión para la fecha y devuelve la hoja de carga resultante
+func (s *truckService) Assign(ctx context.Context, truckID int, req dto.TruckAssignRequest) (*dto.TruckLoadSheet, error) {
+	if err := s.store.AssignDeliveries(ctx, truckID, req.FechaAccion, uniqueIDs(req.DeliveryIDs)); err != nil {
+		return nil, err
+	}
+	log.Info().
+		Int("truck_id", truckID).
+		Str("fecha", req.FechaAccion).
+		Ints("delivery_ids", req.DeliveryIDs).
+		Msg("Camión: entregas asignadas")
+	return s.LoadSheet(ctx, truckID, req.FechaAccion)
+}
+
+// Unassign quita las entregas del camión; devuelve cuántas estaban asignadas a él
+func (s *truckService) Unassign(ctx context.Context, truckID int, deliveryIDs []int) (int64, error) {
+	if _, err := s.store.FindByID(ctx, truckID); err != nil {
+		return 0, err
+	}
+	removed, err := s.store.UnassignDeliveries(ctx, truckID, uniqueIDs(deliveryIDs))
+	if err != nil {
+		return 0, err
+	}
+	log.Info().
+		Int("truck_id", truckID).
+		Ints("delivery_ids", deliveryIDs).
+		Int64("removed", removed).
+		Msg("Camión: entregas quitadas")
+	return removed, nil
+}
+
+// LoadSheet devuelve la hoja de carga del camión para la fecha
+func (s *truckService) LoadSheet(ctx context.Context, truckID int, fecha string) (*dto.TruckLoadSheet, error) {
+	truck, err := s.store.FindByID(ctx, truckID)
+	if err != nil {
+		return nil, err
+	}
+	deliveries, err := s.store.FindLoad(ctx, truckID, fecha)
+	if err != nil {
+		return nil, err
+	}
+	sheet := buildTruckLoadSheet(*truck, fecha, deliveries)
+	return &sheet, nil
+}
+
+// buildTruckLoadSheet arma la hoja de carga con los totales por tipo y el lugar libre del camión
+func buildTruckLoadSheet(truck models.Truck, fecha string, deliveries []models.Delivery) dto.TruckLoadSheet {
+	sheet := dto.TruckLoadSheet{Truck: truck, Fecha: fecha, Deliveries: []dto.TruckLoadDelivery{}}
+	for _, delivery := range deliveries {
+		item := dto.TruckLoadDelivery{
+			ID:             delivery.ID,
+			NroCta:         delivery.NroCta,
+			NroRto:         delivery.NroRto,
+			Name:           delivery.Name,
+			Address:        delivery.Address,
+			Locality:       delivery.Locality,
+			TipoEntrega:    delivery.TipoEntrega,
+			Estado:         delivery.Estado,
+			Cantidad:       delivery.Cantidad,
+			ItemDispensers: make([]dto.ItemDispenserResponse, 0, len(delivery.ItemDispensers)),
+		}
+		for _, dispenser := range delivery.ItemDispensers {
+			item.ItemDispensers = append(item.ItemDispensers, dto.ItemDispenserResponse{Tipo: dispenser.Tipo, Cantidad: dispenser.Cantidad})
+			switch dispenser.Tipo {
+			case models.TipoDispenserPie:
+				sheet.TotalDispenserP += dispenser.Cantidad
+			case models.TipoDispenserMesada:
+				sheet.TotalDispenserM += dispenser.Cantidad
+			}
+		}
+		sheet.TotalDispensers += delivery.Cantidad
+		sheet.TotalDeliveries++
+		sheet.Deliveries = append(sheet.Deliveries, item)
+	}
+	sheet.Remaining = truck.Capacity - int(sheet.TotalDispensers)
+	return sheet
+}
+
+// uniqueIDs quita los IDs repetidos conservando el orden
+func uniqueIDs(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	unique := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
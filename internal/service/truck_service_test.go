@@ -0,0 +1,42 @@
+package service
+
+import (
+	"GoFrioCalor/internal/models"
+	"testing"
+)
+
+func TestBuildTruckLoadSheet(t *testing.T) {
+	truck := models.Truck{ID: 7, NroTruck: "C-01", Capacity: 10}
+	deliveries := []models.Delivery{
+		{ID: 1, NroCta: "100", NroRto: "R1", Estado: models.Pendiente, Cantidad: 3, ItemDispensers: []models.ItemDispenser{
+			{Tipo: models.TipoDispenserPie, Cantidad: 2},
+			{Tipo: models.TipoDispenserMesada, Cantidad: 1},
+		}},
+		{ID: 2, NroCta: "200", NroRto: "R2", Estado: models.Completado, Cantidad: 4, ItemDispensers: []models.ItemDispenser{
+			{Tipo: models.TipoDispenserPie, Cantidad: 4},
+		}},
+	}
+
+	sheet := buildTruckLoadSheet(truck, "2026-06-01", deliveries)
+	if sheet.TotalDeliveries != 2 || sheet.TotalDispenserP != 6 || sheet.TotalDispenserM != 1 || sheet.TotalDispensers != 7 {
+		t.Errorf("totales = %+v", sheet)
+	}
+	if sheet.Remaining != 3 {
+		t.Errorf("lugar libre = %d, se esperaba 3", sheet.Remaining)
+	}
+	if len(sheet.Deliveries) != 2 || len(sheet.Deliveries[0].ItemDispensers) != 2 {
+		t.Errorf("entregas = %+v", sheet.Deliveries)
+	}
+
+	empty := buildTruckLoadSheet(truck, "2026-06-02", nil)
+	if empty.Deliveries == nil || empty.Remaining != 10 {
+		t.Errorf("hoja vacía = %+v", empty)
+	}
+}
+
+func TestUniqueIDs(t *testing.T) {
+	got := uniqueIDs([]int{3, 1, 3, 2, 1})
+	if len(got) != 3 || got[0] != 3 || got[1] != 1 || got[2] != 2 {
+		t.Errorf("uniqueIDs = %v", got)
+	}
+}
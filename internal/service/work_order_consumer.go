@@ -187,6 +187,7 @@ func (c *WorkOrderConsumer) createWorkOrder(ctx context.Context, msg dto.WorkOrd
 		Address:     msg.Address,
 		Localidad:   msg.Locality,
 		TipoAccion:  msg.TipoAccion,
+		CompletedBy: msg.CompletedBy,
 		CreatedAt:   time.Now(),
 	}
 
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BookingGuard serializa las altas y reprogramaciones que compiten por el lugar de un reparto en
+// una fecha o por el stock de un depósito. Dentro de la transacción se toman los advisory locks de
+// Keys y recién entonces se ejecuta Check sobre esa misma transacción: una solicitud con alguna de
+// las mismas claves espera a que la anterior confirme, de modo que Check ve las entregas y reservas
+// que esta dejó.
+type BookingGuard struct {
+	Keys  []string
+	Check func(ctx context.Context, tx *gorm.DB) error
+}
+
+// acquire toma los locks en orden, para que dos solicitudes con claves en común no se bloqueen
+// mutuamente, y ejecuta la verificación. Sin guard no hace nada.
+func (g *BookingGuard) acquire(ctx context.Context, tx *gorm.DB) error {
+	if g == nil {
+		return nil
+	}
+	if err := lockKeys(tx, g.Keys); err != nil {
+		return err
+	}
+	if g.Check == nil {
+		return nil
+	}
+	return g.Check(ctx, tx)
+}
+
+// lockKeys toma los advisory locks de la transacción en orden alfabético. Todas las transacciones
+// que combinan claves las toman en el mismo orden y antes de bloquear filas, para no bloquearse
+// mutuamente. Tomar de nuevo una clave ya tomada en la transacción no espera.
+func lockKeys(tx *gorm.DB, keys []string) error {
+	sorted := append([]string{}, keys...)
+	sort.Strings(sorted)
+	for _, key := range sorted {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", key).Error; err != nil {
+			return fmt.Errorf("error tomando lock de reserva %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// WarehouseStockKey es la clave de lock del stock de un depósito. Es por depósito y no por fecha:
+// una reserva consume la existencia desde su fecha en adelante.
+func WarehouseStockKey(warehouseID int) string {
+	return fmt.Sprintf("warehouse:%d", warehouseID)
+}
+
+// RouteBookingKey es la clave de lock del lugar de un reparto en un día (UTC)
+func RouteBookingKey(nroRto string, fecha time.Time) string {
+	return "route:" + nroRto + "|" + StockDay(fecha).Format("2006-01-02")
+}
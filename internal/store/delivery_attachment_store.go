@@ -0,0 +1,67 @@
+package store
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/models"
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type DeliveryAttachmentStore interface {
+	Create(ctx context.Context, attachment *models.DeliveryAttachment) error
+	FindByID(ctx context.Context, deliveryID, id int) (*models.DeliveryAttachment, error)
+	ListByDelivery(ctx context.Context, deliveryID int) ([]models.DeliveryAttachment, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type deliveryAttachmentStore struct {
+	db *gorm.DB
+}
+
+func NewDeliveryAttachmentStore(db *gorm.DB) DeliveryAttachmentStore {
+	return &deliveryAttachmentStore{db: db}
+}
+
+func (s *deliveryAttachmentStore) Create(ctx context.Context, attachment *models.DeliveryAttachment) error {
+	if err := s.db.WithContext(ctx).Create(attachment).Error; err != nil {
+		return fmt.Errorf("error registrando adjunto de la entrega %d: %w", attachment.DeliveryID, err)
+	}
+	return nil
+}
+
+// FindByID busca el adjunto verificando que pertenezca a la entrega indicada
+func (s *deliveryAttachmentStore) FindByID(ctx context.Context, deliveryID, id int) (*models.DeliveryAttachment, error) {
+	var attachment models.DeliveryAttachment
+	err := s.db.WithContext(ctx).Where("id = ? AND delivery_id = ?", id, deliveryID).First(&attachment).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf(constants.ErrAttachmentNotFound)
+		}
+		return nil, fmt.Errorf("error buscando adjunto %d: %w", id, err)
+	}
+	return &attachment, nil
+}
+
+// ListByDelivery devuelve los adjuntos de la entrega: primero la firma y luego las fotos en
+// orden de carga
+func (s *deliveryAttachmentStore) ListByDelivery(ctx context.Context, deliveryID int) ([]models.DeliveryAttachment, error) {
+	var attachments []models.DeliveryAttachment
+	err := s.db.WithContext(ctx).
+		Where("delivery_id = ?", deliveryID).
+		Order(gorm.Expr("CASE WHEN kind = ? THEN 0 ELSE 1 END", models.AttachmentSignature)).
+		Order("id").
+		Find(&attachments).Error
+	if err != nil {
+		return nil, fmt.Errorf("error listando adjuntos de la entrega %d: %w", deliveryID, err)
+	}
+	return attachments, nil
+}
+
+func (s *deliveryAttachmentStore) Delete(ctx context.Context, id int) error {
+	if err := s.db.WithContext(ctx).Delete(&models.DeliveryAttachment{}, id).Error; err != nil {
+		return fmt.Errorf("error eliminando adjunto %d: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package store
+
+import (
+	"GoFrioCalor/internal/models"
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// DispenserLocation es el último movimiento registrado de un número de serie en entregas completadas
+type DispenserLocation struct {
+	DeliveryID int
+	NroCta     string
+	// Installed es false si el último movimiento fue un retiro
+	Installed bool
+}
+
+// FindDispenserLocation busca la última entrega completada (distinta de excludeDeliveryID) que
+// instaló, atendió o retiró el dispenser. Devuelve nil si el número de serie no tiene historial.
+func (s *deliveryStore) FindDispenserLocation(ctx context.Context, serial string, excludeDeliveryID int) (*DispenserLocation, error) {
+	var row struct {
+		ID        int
+		NroCta    string
+		Installed bool
+	}
+	err := s.db.WithContext(ctx).Model(&models.Delivery{}).
+		Select("id, nro_cta, validated_dispensers @> jsonb_build_array(?::text) AS installed", serial).
+		Where("estado = ? AND id <> ?", models.Completado, excludeDeliveryID).
+		Where("validated_dispensers @> jsonb_build_array(?::text) OR retired_dispensers @> jsonb_build_array(?::text)", serial, serial).
+		Order("completed_at DESC NULLS LAST, id DESC").
+		Take(&row).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error buscando historial del dispenser %s: %w", serial, err)
+	}
+	return &DispenserLocation{DeliveryID: row.ID, NroCta: row.NroCta, Installed: row.Installed}, nil
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"GoFrioCalor/internal/models"
+	"context"
+	"fmt"
+	"time"
+)
+
+// DeliveryExportRow es una fila del reporte de entregas, con los totales de dispensers
+// por tipo ya agregados en la consulta
+type DeliveryExportRow struct {
+	ID                  int
+	NroCta              string
+	Name                string
+	Email               string
+	Address             string
+	Locality            string
+	NroRto              string
+	TipoEntrega         models.TipoEntrega
+	EntregadoPor        models.EntregadoPor
+	Estado              models.EstadoEntrega
+	FechaAccion         time.Time
+	CreatedAt           time.Time
+	Cantidad            uint
+	TotalP              uint
+	TotalM              uint
+	OrderNumber         string
+	ValidatedDispensers models.StringArray `gorm:"type:jsonb"`
+}
+
+const exportSelect = `deliveries.id, deliveries.nro_cta, deliveries.name, deliveries.email, deliveries.address,
+	deliveries.locality, deliveries.nro_rto, deliveries.tipo_entrega, deliveries.entregado_por, deliveries.estado,
+	deliveries.fecha_accion, deliveries.created_at, deliveries.cantidad, deliveries.order_number, deliveries.validated_dispensers,
+	COALESCE((SELECT SUM(i.cantidad) FROM item_dispensers i WHERE i.delivery_id = deliveries.id AND i.tipo = 'P' AND i.deleted_at IS NULL), 0) AS total_p,
+	COALESCE((SELECT SUM(i.cantidad) FROM item_dispensers i WHERE i.delivery_id = deliveries.id AND i.tipo = 'M' AND i.deleted_at IS NULL), 0) AS total_m`
+
+// StreamForExport recorre las entregas que cumplen el filtro fila por fila con un cursor,
+// sin cargar el resultado completo en memoria. Si fn devuelve error se corta la iteración.
+func (s *deliveryStore) StreamForExport(ctx context.Context, filter DeliveryFilter, fn func(row *DeliveryExportRow) error) error {
+	query := s.db.WithContext(ctx).Model(&models.Delivery{}).Select(exportSelect)
+	rows, err := applyDeliveryFilter(query, filter).Order("deliveries.fecha_accion ASC, deliveries.id ASC").Rows()
+	if err != nil {
+		return fmt.Errorf("error exportando entregas: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row DeliveryExportRow
+		if err := s.db.ScanRows(rows, &row); err != nil {
+			return fmt.Errorf("error leyendo fila de exportación: %w", err)
+		}
+		if err := fn(&row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,74 @@
+package store
+
+import (
+	"GoFrioCalor/internal/models"
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamForExportAppliesFilterAndScansTotals(t *testing.T) {
+	db, fake := newFakeDB(t)
+	s := NewDeliveryStore(db)
+	fake.once(`FROM "deliveries"`,
+		[]string{"id", "nro_cta", "name", "nro_rto", "estado", "fecha_accion", "cantidad", "validated_dispensers", "total_p", "total_m"},
+		[]driver.Value{int64(7), "1001", "Juan Pérez", "R1", string(models.Completado), time.Date(2026, 5, 20, 0, 0, 0, 0, time.UTC), int64(3), `["LM1","LM2"]`, int64(2), int64(1)},
+	)
+
+	estado := models.Completado
+	desde := time.Date(2026, 5, 1, 15, 0, 0, 0, time.UTC)
+	filter := DeliveryFilter{Estado: &estado, NroRto: []string{"R1", "R2"}, Locality: "Rosario", FechaAccionDesde: &desde}
+
+	var rows []DeliveryExportRow
+	err := s.StreamForExport(context.Background(), filter, func(row *DeliveryExportRow) error {
+		rows = append(rows, *row)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamForExport() error = %v", err)
+	}
+
+	query := fake.executed(`FROM "deliveries"`)[0]
+	for _, condition := range []string{
+		"deliveries.estado = $1",
+		"deliveries.fecha_accion >= $2",
+		"deliveries.nro_rto IN ($3,$4)",
+		"LOWER(deliveries.locality) = LOWER($5)",
+		`"deliveries"."deleted_at" IS NULL`,
+		"ORDER BY deliveries.fecha_accion ASC, deliveries.id ASC",
+	} {
+		if !strings.Contains(query.query, condition) {
+			t.Errorf("la consulta no incluye %q: %s", condition, query.query)
+		}
+	}
+	if len(query.args) != 5 || query.args[0] != estado || !sameTime(query.args[1], time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)) || query.args[4] != "Rosario" {
+		t.Errorf("argumentos = %v", query.args)
+	}
+
+	if len(rows) != 1 {
+		t.Fatalf("filas = %d, want 1", len(rows))
+	}
+	row := rows[0]
+	if row.ID != 7 || row.Name != "Juan Pérez" || row.Estado != models.Completado || row.Cantidad != 3 || row.TotalP != 2 || row.TotalM != 1 {
+		t.Errorf("fila = %+v", row)
+	}
+	if len(row.ValidatedDispensers) != 2 || row.ValidatedDispensers[1] != "LM2" {
+		t.Errorf("validated_dispensers = %v", row.ValidatedDispensers)
+	}
+}
+
+func TestStreamForExportStopsOnCallbackError(t *testing.T) {
+	db, fake := newFakeDB(t)
+	fake.once(`FROM "deliveries"`, []string{"id"}, []driver.Value{int64(1)}, []driver.Value{int64(2)})
+
+	calls := 0
+	err := NewDeliveryStore(db).StreamForExport(context.Background(), DeliveryFilter{}, func(row *DeliveryExportRow) error {
+		calls++
+		return context.Canceled
+	})
+	if err != context.Canceled || calls != 1 {
+		t.Errorf("StreamForExport() = %v después de %d filas, want cortar en la primera", err, calls)
+	}
+}
@@ -0,0 +1,38 @@
+package store
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/models"
+	"context"
+	"fmt"
+)
+
+// SaveValidationLocation guarda la lectura GPS del dispositivo al validar el token. Como el
+// contador de intentos, no incrementa la versión: no es un dato editable de la entrega.
+func (s *deliveryStore) SaveValidationLocation(ctx context.Context, id int, latitude, longitude, accuracy *float64) error {
+	err := s.db.WithContext(ctx).Model(&models.Delivery{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"validation_latitude":  latitude,
+		"validation_longitude": longitude,
+		"validation_accuracy":  accuracy,
+	}).Error
+	if err != nil {
+		return fmt.Errorf(constants.ErrUpdateDelivery, err)
+	}
+	return nil
+}
+
+// preserveDeviceLocation conserva en una actualización completa las lecturas GPS del dispositivo,
+// el resultado de la geocerca y quién cerró la entrega, datos que solo escribe la app móvil
+func preserveDeviceLocation(delivery, current *models.Delivery) {
+	delivery.ValidationLatitude = current.ValidationLatitude
+	delivery.ValidationLongitude = current.ValidationLongitude
+	delivery.ValidationAccuracy = current.ValidationAccuracy
+	delivery.CompletionLatitude = current.CompletionLatitude
+	delivery.CompletionLongitude = current.CompletionLongitude
+	delivery.CompletionAccuracy = current.CompletionAccuracy
+	delivery.CompletionDistance = current.CompletionDistance
+	delivery.GeofenceFlagged = current.GeofenceFlagged
+	delivery.CompletedBy = current.CompletedBy
+	delivery.RetiredDispensers = current.RetiredDispensers
+	delivery.SerialOverride = current.SerialOverride
+}
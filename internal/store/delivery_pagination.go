@@ -0,0 +1,151 @@
+package store
+
+import (
+	"GoFrioCalor/internal/models"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// deliverySortColumns son los campos por los que se puede ordenar el listado de entregas
+var deliverySortColumns = map[string]string{
+	"id":           "deliveries.id",
+	"fecha_accion": "deliveries.fecha_accion",
+	"created_at":   "deliveries.created_at",
+	"nro_cta":      "deliveries.nro_cta",
+}
+
+// DeliverySort indica el campo y la dirección de orden. El id se usa siempre como desempate
+// para que el orden sea total y la paginación por cursor sea estable.
+type DeliverySort struct {
+	Field string
+	Desc  bool
+}
+
+// DefaultDeliverySort mantiene el orden histórico del listado (más recientes primero)
+var DefaultDeliverySort = DeliverySort{Field: "id", Desc: true}
+
+// ParseDeliverySort interpreta valores como "fecha_accion" (ascendente) o "-fecha_accion" (descendente)
+func ParseDeliverySort(value string) (DeliverySort, error) {
+	if value == "" {
+		return DefaultDeliverySort, nil
+	}
+	sort := DeliverySort{Field: strings.TrimPrefix(value, "-"), Desc: strings.HasPrefix(value, "-")}
+	if _, ok := deliverySortColumns[sort.Field]; !ok {
+		return DeliverySort{}, fmt.Errorf("orden inválido: %s (valores permitidos: id, fecha_accion, created_at, nro_cta)", value)
+	}
+	return sort, nil
+}
+
+func (s DeliverySort) String() string {
+	if s.Desc {
+		return "-" + s.Field
+	}
+	return s.Field
+}
+
+// DeliveryCursor es la posición de la última fila entregada en una paginación por cursor
+type DeliveryCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v,omitempty"`
+	ID    int    `json:"id"`
+}
+
+// DeliveryPage combina orden y paginación. Con Cursor se usa keyset (Offset se ignora);
+// Limit <= 0 devuelve todas las filas.
+type DeliveryPage struct {
+	Sort   DeliverySort
+	Limit  int
+	Offset int
+	Cursor *DeliveryCursor
+}
+
+// EncodeDeliveryCursor genera el cursor opaco que apunta a la fila siguiente a delivery
+func EncodeDeliveryCursor(delivery *models.Delivery, sort DeliverySort) string {
+	cursor := DeliveryCursor{Sort: sort.String(), ID: delivery.ID}
+	switch sort.Field {
+	case "fecha_accion":
+		cursor.Value = delivery.FechaAccion.Time.Format(time.RFC3339Nano)
+	case "created_at":
+		cursor.Value = delivery.CreatedAt.Format(time.RFC3339Nano)
+	case "nro_cta":
+		cursor.Value = delivery.NroCta
+	}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeDeliveryCursor valida que el cursor sea legible y corresponda al orden solicitado
+func DecodeDeliveryCursor(value string, sort DeliverySort) (*DeliveryCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("cursor inválido")
+	}
+	var cursor DeliveryCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID <= 0 {
+		return nil, fmt.Errorf("cursor inválido")
+	}
+	if cursor.Sort != sort.String() {
+		return nil, fmt.Errorf("cursor inválido: fue generado con otro orden (%s)", cursor.Sort)
+	}
+	return &cursor, nil
+}
+
+// applyDeliveryPage agrega ORDER BY, la condición de keyset (si hay cursor) y LIMIT/OFFSET
+func applyDeliveryPage(query *gorm.DB, page DeliveryPage) (*gorm.DB, error) {
+	sort := page.Sort
+	if sort.Field == "" {
+		sort = DefaultDeliverySort
+	}
+	column, ok := deliverySortColumns[sort.Field]
+	if !ok {
+		return nil, fmt.Errorf("orden inválido: %s", sort.Field)
+	}
+	direction, comparator := "ASC", ">"
+	if sort.Desc {
+		direction, comparator = "DESC", "<"
+	}
+
+	if page.Cursor != nil {
+		if sort.Field == "id" {
+			query = query.Where(fmt.Sprintf("deliveries.id %s ?", comparator), page.Cursor.ID)
+		} else {
+			value, err := cursorValue(sort.Field, page.Cursor.Value)
+			if err != nil {
+				return nil, err
+			}
+			query = query.Where(fmt.Sprintf("(%s, deliveries.id) %s (?, ?)", column, comparator), value, page.Cursor.ID)
+		}
+	}
+
+	if sort.Field == "id" {
+		query = query.Order("deliveries.id " + direction)
+	} else {
+		query = query.Order(column + " " + direction).Order("deliveries.id " + direction)
+	}
+
+	if page.Limit > 0 {
+		query = query.Limit(page.Limit)
+	}
+	if page.Cursor == nil && page.Offset > 0 {
+		query = query.Offset(page.Offset)
+	}
+	return query, nil
+}
+
+func cursorValue(field, value string) (interface{}, error) {
+	switch field {
+	case "fecha_accion", "created_at":
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return nil, fmt.Errorf("cursor inválido")
+		}
+		return t, nil
+	default:
+		return value, nil
+	}
+}
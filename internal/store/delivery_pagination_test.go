@@ -0,0 +1,56 @@
+package store
+
+import (
+	"GoFrioCalor/internal/models"
+	"testing"
+	"time"
+)
+
+func TestParseDeliverySort(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    DeliverySort
+		wantErr bool
+	}{
+		{value: "", want: DefaultDeliverySort},
+		{value: "fecha_accion", want: DeliverySort{Field: "fecha_accion"}},
+		{value: "-created_at", want: DeliverySort{Field: "created_at", Desc: true}},
+		{value: "token", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDeliverySort(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDeliverySort(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseDeliverySort(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestDeliveryCursorRoundTrip(t *testing.T) {
+	fecha := time.Date(2026, 5, 20, 0, 0, 0, 0, time.UTC)
+	delivery := &models.Delivery{ID: 42, NroCta: "1001", FechaAccion: models.CustomDate{Time: fecha}}
+	sort := DeliverySort{Field: "fecha_accion", Desc: true}
+
+	encoded := EncodeDeliveryCursor(delivery, sort)
+	cursor, err := DecodeDeliveryCursor(encoded, sort)
+	if err != nil {
+		t.Fatalf("DecodeDeliveryCursor() error = %v", err)
+	}
+	if cursor.ID != 42 {
+		t.Errorf("ID = %d, want 42", cursor.ID)
+	}
+	value, err := cursorValue(sort.Field, cursor.Value)
+	if err != nil || !value.(time.Time).Equal(fecha) {
+		t.Errorf("cursorValue() = %v, %v; want %v", value, err, fecha)
+	}
+
+	if _, err := DecodeDeliveryCursor(encoded, DeliverySort{Field: "fecha_accion"}); err == nil {
+		t.Errorf("un cursor generado con otro orden debería rechazarse")
+	}
+	if _, err := DecodeDeliveryCursor("no-es-un-cursor", sort); err == nil {
+		t.Errorf("un cursor ilegible debería rechazarse")
+	}
+}
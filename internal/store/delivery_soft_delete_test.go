@@ -0,0 +1,156 @@
+package store
+
+import (
+	"GoFrioCalor/internal/models"
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+)
+
+var deliveryColumns = []string{"id", "nro_rto", "estado", "tipo_entrega", "fecha_accion", "version", "deleted_at"}
+
+func deliveryRow(deletedAt interface{}) []driver.Value {
+	return []driver.Value{int64(1), "R1", string(models.Programado), string(models.Instalacion), time.Date(2026, 5, 20, 0, 0, 0, 0, time.UTC), int64(3), deletedAt}
+}
+
+var reservationColumns = []string{"id", "delivery_id", "tipo", "warehouse_id", "cantidad", "fecha_accion", "status"}
+
+func reservationRow(status models.EstadoReserva) []driver.Value {
+	return []driver.Value{int64(9), int64(1), string(models.TipoDispenserPie), int64(3), int64(1), time.Date(2026, 5, 20, 0, 0, 0, 0, time.UTC), string(status)}
+}
+
+// statusUpdated indica si alguna sentencia guardó la reserva con el estado
+func statusUpdated(statements []fakeStatement, status models.EstadoReserva) bool {
+	for _, statement := range statements {
+		for _, arg := range statement.args {
+			if arg == status {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func sameTime(value driver.Value, want time.Time) bool {
+	got, ok := value.(time.Time)
+	return ok && got.Equal(want)
+}
+
+func TestDeletedDeliveriesAreHidden(t *testing.T) {
+	db, fake := newFakeDB(t)
+	s := NewDeliveryStore(db)
+
+	if _, err := s.FindByID(context.Background(), 1); err == nil {
+		t.Errorf("FindByID() de una entrega eliminada debería fallar")
+	}
+	if _, err := s.FindAll(context.Background(), 10, 0); err != nil {
+		t.Fatalf("FindAll() error = %v", err)
+	}
+	queries := fake.executed(`FROM "deliveries"`)
+	if len(queries) != 2 {
+		t.Fatalf("consultas = %d, want 2", len(queries))
+	}
+	for _, query := range queries {
+		if !strings.Contains(query.query, `"deliveries"."deleted_at" IS NULL`) {
+			t.Errorf("la consulta no excluye las entregas eliminadas: %s", query.query)
+		}
+	}
+}
+
+func TestFindDeletedIncludesDeletedItems(t *testing.T) {
+	db, fake := newFakeDB(t)
+	s := NewDeliveryStore(db)
+	deletedAt := time.Date(2026, 5, 18, 10, 0, 0, 0, time.UTC)
+	fake.once(`FROM "deliveries"`, deliveryColumns, deliveryRow(deletedAt))
+	fake.once(`FROM "item_dispensers"`, []string{"id", "delivery_id", "tipo", "cantidad", "deleted_at"},
+		[]driver.Value{int64(5), int64(1), string(models.TipoDispenserPie), int64(1), deletedAt})
+
+	deliveries, err := s.FindDeleted(context.Background(), 10, 0)
+	if err != nil {
+		t.Fatalf("FindDeleted() error = %v", err)
+	}
+	if len(deliveries) != 1 || len(deliveries[0].ItemDispensers) != 1 {
+		t.Fatalf("FindDeleted() = %+v, want la entrega con su item dispenser", deliveries)
+	}
+	if query := fake.executed(`FROM "deliveries"`)[0].query; !strings.Contains(query, "deleted_at IS NOT NULL") {
+		t.Errorf("FindDeleted() debería listar solo las eliminadas: %s", query)
+	}
+	if query := fake.executed(`FROM "item_dispensers"`)[0].query; strings.Contains(query, "deleted_at") {
+		t.Errorf("los item dispensers eliminados con la entrega deberían incluirse: %s", query)
+	}
+}
+
+func TestDeleteReleasesStockReservations(t *testing.T) {
+	db, fake := newFakeDB(t)
+	s := NewDeliveryStore(db)
+	fake.once(`FROM "deliveries"`, deliveryColumns, deliveryRow(nil))
+	// syncStockReservations relee la entrega ya eliminada
+	fake.once(`FROM "deliveries"`, deliveryColumns, deliveryRow(time.Now()))
+	fake.once(`FROM "stock_reservations"`, reservationColumns, reservationRow(models.ReservaActiva))
+
+	if err := s.Delete(context.Background(), 1); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	items := fake.executed(`UPDATE "item_dispensers" SET "deleted_at"`)
+	if len(items) != 1 || !strings.Contains(items[0].query, "WHERE delivery_id = $3") {
+		t.Errorf("los item dispensers deberían eliminarse con la entrega: %+v", items)
+	}
+	deliveries := fake.executed(`UPDATE "deliveries" SET "deleted_at"`)
+	if len(deliveries) != 1 || !strings.Contains(deliveries[0].query, "version + 1") {
+		t.Errorf("la entrega debería eliminarse incrementando la versión: %+v", deliveries)
+	}
+	if len(fake.executed(`INSERT INTO "stock_movements"`)) != 1 || !statusUpdated(fake.executed(`"stock_reservations"`), models.ReservaLiberada) {
+		t.Errorf("la reserva activa debería liberarse con su movimiento de stock")
+	}
+}
+
+func TestRestoreBringsBackItemsAndReservations(t *testing.T) {
+	db, fake := newFakeDB(t)
+	s := NewDeliveryStore(db)
+	deletedAt := time.Date(2026, 5, 18, 10, 0, 0, 0, time.UTC)
+	fake.once(`FROM "deliveries"`, deliveryColumns, deliveryRow(deletedAt))
+	fake.once(`FROM "deliveries"`, deliveryColumns, deliveryRow(nil))
+	fake.once(`FROM "stock_reservations"`, reservationColumns, reservationRow(models.ReservaLiberada))
+	fake.once(`FROM "item_dispensers"`, []string{"id", "delivery_id", "tipo", "cantidad"},
+		[]driver.Value{int64(5), int64(1), string(models.TipoDispenserPie), int64(1)})
+	fake.once(`FROM "warehouses"`, []string{"id", "code", "active"}, []driver.Value{int64(3), "DEP-1", true})
+	// FindByID final con sus item dispensers
+	fake.once(`FROM "deliveries"`, deliveryColumns, deliveryRow(nil))
+	fake.once(`FROM "item_dispensers"`, []string{"id", "delivery_id", "tipo", "cantidad"},
+		[]driver.Value{int64(5), int64(1), string(models.TipoDispenserPie), int64(1)})
+
+	delivery, err := s.Restore(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if delivery.DeletedAt.Valid || len(delivery.ItemDispensers) != 1 {
+		t.Errorf("Restore() = %+v, want la entrega activa con su item dispenser", delivery)
+	}
+
+	items := fake.executed(`UPDATE "item_dispensers" SET "deleted_at"`)
+	if len(items) != 1 || len(items[0].args) != 4 || items[0].args[0] != nil || !sameTime(items[0].args[3], deletedAt) {
+		t.Errorf("deberían restaurarse solo los item dispensers eliminados con la entrega: %+v", items)
+	}
+	deliveries := fake.executed(`UPDATE "deliveries" SET "deleted_at"`)
+	if len(deliveries) != 1 || deliveries[0].args[0] != nil {
+		t.Errorf("la entrega debería restaurarse: %+v", deliveries)
+	}
+	if len(fake.executed(`INSERT INTO "stock_movements"`)) != 1 || !statusUpdated(fake.executed(`"stock_reservations"`), models.ReservaActiva) {
+		t.Errorf("la reserva liberada debería volver a tomarse con su movimiento de stock")
+	}
+}
+
+func TestRestoreRejectsActiveDelivery(t *testing.T) {
+	db, fake := newFakeDB(t)
+	fake.once(`FROM "deliveries"`, deliveryColumns, deliveryRow(nil))
+
+	if _, err := NewDeliveryStore(db).Restore(context.Background(), 1); err == nil {
+		t.Errorf("Restore() de una entrega activa debería fallar")
+	}
+	if len(fake.executed(`UPDATE "`)) != 0 {
+		t.Errorf("no debería modificar nada")
+	}
+}
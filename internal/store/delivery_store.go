@@ -6,9 +6,11 @@ import (
 	"GoFrioCalor/internal/models"
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type DeliveryStore interface {
@@ -16,18 +18,38 @@ type DeliveryStore interface {
 	CountAll(ctx context.Context) (int64, error)
 	FindByID(ctx context.Context, id int) (*models.Delivery, error)
 	FindByConversationID(ctx context.Context, conversationID string) (*models.Delivery, error)
-	FindByTokenAndFilters(ctx context.Context, token, nroCta, fechaAccion string, estado models.EstadoEntrega) (*models.Delivery, error)
-	FindByFilters(ctx context.Context, nroCta string, fechaAccion, fechaCreacion *time.Time, estado *models.EstadoEntrega, limit, offset int) ([]models.Delivery, error)
-	CountByFilters(ctx context.Context, nroCta string, fechaAccion, fechaCreacion *time.Time, estado *models.EstadoEntrega) (int64, error)
+	FindByFilters(ctx context.Context, filter DeliveryFilter, page DeliveryPage) ([]models.Delivery, error)
+	CountByFilters(ctx context.Context, filter DeliveryFilter) (int64, error)
 	FindByRto(ctx context.Context, nroRto string, fechaAccion *time.Time) ([]models.Delivery, error)
 	FindByFechaAccion(ctx context.Context, fecha string) ([]models.Delivery, error)
 	FindByFechaAndNroCta(ctx context.Context, fechaAccion, nroCta string) (*models.Delivery, error)
 	Create(ctx context.Context, delivery *models.Delivery) error
-	Update(ctx context.Context, delivery *models.Delivery) error
+	CreateGuarded(ctx context.Context, delivery *models.Delivery, guard *BookingGuard) error
+	CreateBatch(ctx context.Context, deliveries []*models.Delivery) error
+	Update(ctx context.Context, delivery *models.Delivery, changedBy string) error
+	Patch(ctx context.Context, delivery *models.Delivery, changedBy string) error
+	Complete(ctx context.Context, delivery *models.Delivery, followUp *models.Delivery, changedBy, reason string) error
 	Delete(ctx context.Context, id int) error
-	CancelDelivery(ctx context.Context, id int) (*models.Delivery, error)
+	Restore(ctx context.Context, id int) (*models.Delivery, error)
+	FindDeleted(ctx context.Context, limit, offset int) ([]models.Delivery, error)
+	CountDeleted(ctx context.Context) (int64, error)
+	CancelDelivery(ctx context.Context, id, expectedVersion int, changedBy, reason string) (*models.Delivery, error)
 	CancelExpiredPending(ctx context.Context) (int64, error)
 	FindPendingByNroCta(ctx context.Context, nroCta string) ([]models.Delivery, error)
+	TransitionStatus(ctx context.Context, id int, to models.EstadoEntrega, expectedVersion int, changedBy, reason string) (*models.Delivery, error)
+	FindStatusHistory(ctx context.Context, deliveryID int) ([]models.DeliveryStatusHistory, error)
+	Reschedule(ctx context.Context, id int, newFecha time.Time, newToken string, expectedVersion int, changedBy, reason string, guard *BookingGuard) (*models.Delivery, error)
+	FindReschedules(ctx context.Context, deliveryID int) ([]models.DeliveryReschedule, error)
+	RecordFailedVisit(ctx context.Context, id int, reasonCode, notes string, failedAt time.Time, requiresFollowUp bool, followUp *models.Delivery, changedBy string) (*models.Delivery, error)
+	FindRequiringFollowUp(ctx context.Context) ([]models.Delivery, error)
+	StreamForExport(ctx context.Context, filter DeliveryFilter, fn func(row *DeliveryExportRow) error) error
+	TokenInUse(ctx context.Context, token, nroRto string, fechaAccion time.Time, excludeID int) (bool, error)
+	FindOpenByNroCtaAndFecha(ctx context.Context, nroCta, fechaAccion string) ([]models.Delivery, error)
+	RegisterFailedTokenAttempt(ctx context.Context, id, maxAttempts int) (*models.Delivery, error)
+	ResetTokenAttempts(ctx context.Context, id int) error
+	RegenerateToken(ctx context.Context, id int, newToken string, expectedVersion int) (*models.Delivery, error)
+	SaveValidationLocation(ctx context.Context, id int, latitude, longitude, accuracy *float64) error
+	FindDispenserLocation(ctx context.Context, serial string, excludeDeliveryID int) (*DispenserLocation, error)
 }
 
 type deliveryStore struct {
@@ -56,7 +78,7 @@ func (s *deliveryStore) CountAll(ctx context.Context) (int64, error) {
 }
 func (s *deliveryStore) FindByID(ctx context.Context, id int) (*models.Delivery, error) {
 	var delivery models.Delivery
-	if err := s.db.WithContext(ctx).Preload("ItemDispensers").First(&delivery, id).Error; err != nil {
+	if err := s.db.WithContext(ctx).Preload("ItemDispensers").Preload("Shortfalls").First(&delivery, id).Error; err != nil {
 		return nil, fmt.Errorf(constants.ErrFindDeliveryByID, id, err)
 	}
 	return &delivery, nil
@@ -73,74 +95,117 @@ func (s *deliveryStore) FindByConversationID(ctx context.Context, conversationID
 	return &delivery, nil
 }
 
-// FindByTokenAndFilters busca un delivery por token, nro_cta, fecha y estado (optimizado para validación móvil)
-func (s *deliveryStore) FindByTokenAndFilters(ctx context.Context, token, nroCta, fechaAccion string, estado models.EstadoEntrega) (*models.Delivery, error) {
-	var delivery models.Delivery
+// DeliveryFilter agrupa los filtros del listado de entregas. Las fechas puntuales filtran
+// el día completo; los rangos Desde/Hasta son inclusivos y pueden usarse por separado.
+// Los filtros multi-valor (NroRto, TiposEntrega) se combinan con IN.
+type DeliveryFilter struct {
+	NroCta             string
+	Estado             *models.EstadoEntrega
+	FechaAccion        *time.Time
+	FechaCreacion      *time.Time
+	FechaAccionDesde   *time.Time
+	FechaAccionHasta   *time.Time
+	FechaCreacionDesde *time.Time
+	FechaCreacionHasta *time.Time
+	NroRto             []string
+	TiposEntrega       []models.TipoEntrega
+	EntregadoPor       *models.EntregadoPor
+	Locality           string
+	OrderNumber        string
+	HasTermsSession    *bool
+	GeofenceFlagged    *bool
+	Search             string
+}
 
-	query := s.db.WithContext(ctx).
-		Preload("ItemDispensers").
-		Where("token = ? AND nro_cta = ? AND estado = ?", token, nroCta, estado)
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
 
-	if fechaAccion != "" {
-		// Parsear fecha y buscar en el rango del día
-		parsedDate, err := time.Parse("2006-01-02", fechaAccion)
-		if err == nil {
-			startOfDay := time.Date(parsedDate.Year(), parsedDate.Month(), parsedDate.Day(), 0, 0, 0, 0, parsedDate.Location())
-			endOfDay := startOfDay.Add(24 * time.Hour)
-			query = query.Where("fecha_accion >= ? AND fecha_accion < ?", startOfDay, endOfDay)
-		}
-	}
+// likeEscaper escapa los comodines de LIKE para que la búsqueda parcial sea literal
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
 
-	if err := query.First(&delivery).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, nil // No encontrado, no es un error
-		}
-		return nil, fmt.Errorf("error buscando delivery por token y filtros: %w", err)
+// applyDeliveryFilter agrega al query las condiciones del filtro sobre la tabla deliveries
+func applyDeliveryFilter(query *gorm.DB, filter DeliveryFilter) *gorm.DB {
+	if filter.NroCta != "" {
+		query = query.Where("deliveries.nro_cta = ?", filter.NroCta)
 	}
-
-	return &delivery, nil
-}
-
-func (s *deliveryStore) buildFiltersQuery(ctx context.Context, nroCta string, fechaAccion, fechaCreacion *time.Time, estado *models.EstadoEntrega) *gorm.DB {
-	query := s.db.WithContext(ctx).Model(&models.Delivery{})
-	if nroCta != "" {
-		query = query.Where("nro_cta = ?", nroCta)
+	if filter.Estado != nil {
+		query = query.Where("deliveries.estado = ?", *filter.Estado)
 	}
-	if fechaAccion != nil {
-		startOfDay := time.Date(fechaAccion.Year(), fechaAccion.Month(), fechaAccion.Day(), 0, 0, 0, 0, fechaAccion.Location())
-		endOfDay := startOfDay.Add(24 * time.Hour)
-		query = query.Where("fecha_accion >= ? AND fecha_accion < ?", startOfDay, endOfDay)
+	if filter.FechaAccion != nil {
+		day := startOfDay(*filter.FechaAccion)
+		query = query.Where("deliveries.fecha_accion >= ? AND deliveries.fecha_accion < ?", day, day.Add(24*time.Hour))
 	}
-	if fechaCreacion != nil {
-		startOfDay := time.Date(fechaCreacion.Year(), fechaCreacion.Month(), fechaCreacion.Day(), 0, 0, 0, 0, fechaCreacion.Location())
-		endOfDay := startOfDay.Add(24 * time.Hour)
-		query = query.Where("created_at >= ? AND created_at < ?", startOfDay, endOfDay)
+	if filter.FechaCreacion != nil {
+		day := startOfDay(*filter.FechaCreacion)
+		query = query.Where("deliveries.created_at >= ? AND deliveries.created_at < ?", day, day.Add(24*time.Hour))
+	}
+	if filter.FechaAccionDesde != nil {
+		query = query.Where("deliveries.fecha_accion >= ?", startOfDay(*filter.FechaAccionDesde))
+	}
+	if filter.FechaAccionHasta != nil {
+		query = query.Where("deliveries.fecha_accion < ?", startOfDay(*filter.FechaAccionHasta).Add(24*time.Hour))
+	}
+	if filter.FechaCreacionDesde != nil {
+		query = query.Where("deliveries.created_at >= ?", startOfDay(*filter.FechaCreacionDesde))
+	}
+	if filter.FechaCreacionHasta != nil {
+		query = query.Where("deliveries.created_at < ?", startOfDay(*filter.FechaCreacionHasta).Add(24*time.Hour))
+	}
+	if len(filter.NroRto) > 0 {
+		query = query.Where("deliveries.nro_rto IN ?", filter.NroRto)
+	}
+	if len(filter.TiposEntrega) > 0 {
+		query = query.Where("deliveries.tipo_entrega IN ?", filter.TiposEntrega)
+	}
+	if filter.EntregadoPor != nil {
+		query = query.Where("deliveries.entregado_por = ?", *filter.EntregadoPor)
+	}
+	if filter.Locality != "" {
+		query = query.Where("LOWER(deliveries.locality) = LOWER(?)", filter.Locality)
 	}
-	if estado != nil {
-		query = query.Where("estado = ?", *estado)
+	if filter.OrderNumber != "" {
+		query = query.Where("deliveries.order_number = ?", filter.OrderNumber)
+	}
+	if filter.HasTermsSession != nil {
+		if *filter.HasTermsSession {
+			query = query.Where("deliveries.terms_session_id IS NOT NULL")
+		} else {
+			query = query.Where("deliveries.terms_session_id IS NULL")
+		}
+	}
+	if filter.GeofenceFlagged != nil {
+		query = query.Where("deliveries.geofence_flagged = ?", *filter.GeofenceFlagged)
+	}
+	if filter.Search != "" {
+		pattern := "%" + likeEscaper.Replace(filter.Search) + "%"
+		query = query.Where("(deliveries.name ILIKE ? OR deliveries.address ILIKE ?)", pattern, pattern)
 	}
 	return query
 }
 
-func (s *deliveryStore) FindByFilters(ctx context.Context, nroCta string, fechaAccion, fechaCreacion *time.Time, estado *models.EstadoEntrega, limit, offset int) ([]models.Delivery, error) {
+func (s *deliveryStore) FindByFilters(ctx context.Context, filter DeliveryFilter, page DeliveryPage) ([]models.Delivery, error) {
 	var deliveries []models.Delivery
-	query := s.buildFiltersQuery(ctx, nroCta, fechaAccion, fechaCreacion, estado).
-		Preload("ItemDispensers").
-		Order("id DESC").
-		Offset(offset).Limit(limit)
-	if err := query.Find(&deliveries).Error; err != nil {
+	query := applyDeliveryFilter(s.db.WithContext(ctx).Model(&models.Delivery{}), filter)
+	query, err := applyDeliveryPage(query, page)
+	if err != nil {
+		return nil, err
+	}
+	if err := query.Preload("ItemDispensers").Find(&deliveries).Error; err != nil {
 		return nil, fmt.Errorf(constants.ErrFindDeliveriesFilters, err)
 	}
 	return deliveries, nil
 }
 
-func (s *deliveryStore) CountByFilters(ctx context.Context, nroCta string, fechaAccion, fechaCreacion *time.Time, estado *models.EstadoEntrega) (int64, error) {
+// CountByFilters cuenta con exactamente los mismos filtros que FindByFilters (sin paginación)
+func (s *deliveryStore) CountByFilters(ctx context.Context, filter DeliveryFilter) (int64, error) {
 	var count int64
-	if err := s.buildFiltersQuery(ctx, nroCta, fechaAccion, fechaCreacion, estado).Count(&count).Error; err != nil {
+	if err := applyDeliveryFilter(s.db.WithContext(ctx).Model(&models.Delivery{}), filter).Count(&count).Error; err != nil {
 		return 0, fmt.Errorf("error contando deliveries con filtros: %w", err)
 	}
 	return count, nil
 }
+
 func (s *deliveryStore) FindByRto(ctx context.Context, nroRto string, fechaAccion *time.Time) ([]models.Delivery, error) {
 	var deliveries []models.Delivery
 	query := s.db.WithContext(ctx).Preload("ItemDispensers")
@@ -185,74 +250,673 @@ func (s *deliveryStore) FindByFechaAndNroCta(ctx context.Context, fechaAccion, n
 	return &delivery, nil
 }
 
+// Create inserta la entrega y, si corresponde, reserva su stock en la misma transacción
 func (s *deliveryStore) Create(ctx context.Context, delivery *models.Delivery) error {
-	if err := s.db.WithContext(ctx).Create(delivery).Error; err != nil {
-		return fmt.Errorf(constants.ErrCreateDelivery, err)
+	return s.CreateGuarded(ctx, delivery, nil)
+}
+
+// CreateGuarded crea la entrega después de tomar los locks del guard y pasar su verificación, en la
+// misma transacción (ver BookingGuard)
+func (s *deliveryStore) CreateGuarded(ctx context.Context, delivery *models.Delivery, guard *BookingGuard) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := guard.acquire(ctx, tx); err != nil {
+			return err
+		}
+		if err := lockTokens(tx, delivery); err != nil {
+			return err
+		}
+		if err := tx.Create(delivery).Error; err != nil {
+			return fmt.Errorf(constants.ErrCreateDelivery, err)
+		}
+		return syncStockReservations(tx, delivery.ID, "")
+	})
+	if err != nil {
+		return err
 	}
 	metrics.DeliveryCreated(string(delivery.TipoEntrega))
 	return nil
 }
 
-func (s *deliveryStore) Update(ctx context.Context, delivery *models.Delivery) error {
-	if err := s.db.WithContext(ctx).Save(delivery).Error; err != nil {
-		return fmt.Errorf(constants.ErrUpdateDelivery, err)
+// CreateBatch inserta todas las entregas (con sus item dispensers) en una única transacción:
+// si alguna falla no se crea ninguna
+func (s *deliveryStore) CreateBatch(ctx context.Context, deliveries []*models.Delivery) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockTokens(tx, deliveries...); err != nil {
+			return err
+		}
+		for _, delivery := range deliveries {
+			if err := tx.Create(delivery).Error; err != nil {
+				return fmt.Errorf(constants.ErrCreateDelivery, err)
+			}
+			if err := syncStockReservations(tx, delivery.ID, ""); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, delivery := range deliveries {
+		metrics.DeliveryCreated(string(delivery.TipoEntrega))
 	}
 	return nil
 }
 
-func (s *deliveryStore) Delete(ctx context.Context, id int) error {
-	if err := s.db.WithContext(ctx).Delete(&models.Delivery{}, id).Error; err != nil {
-		return fmt.Errorf(constants.ErrDeleteDelivery, id, err)
+// AnyVersion desactiva el control de concurrencia optimista en las operaciones que reciben
+// expectedVersion (procesos internos que no parten de una lectura previa del cliente)
+const AnyVersion = 0
+
+func checkVersion(delivery *models.Delivery, expectedVersion int) error {
+	if expectedVersion != AnyVersion && delivery.Version != expectedVersion {
+		return fmt.Errorf(constants.ErrVersionConflictDetail, expectedVersion, delivery.Version)
 	}
 	return nil
 }
 
-// FindPendingByNroCta busca deliveries en estado Pendiente para un número de cuenta
+// Update guarda la entrega completa solo si delivery.Version coincide con la versión en la base
+// e incrementa la versión. Si el estado cambia, la transición se valida y queda en el historial
+// dentro de la misma transacción.
+func (s *deliveryStore) Update(ctx context.Context, delivery *models.Delivery, changedBy string) error {
+	return s.update(ctx, delivery, changedBy, preserveServerFields)
+}
+
+// Patch guarda como Update una entrega modificada por merge patch, salvo failure_notes, que el
+// patch puede editar en una entrega Fallido y se guarda tal como viene
+func (s *deliveryStore) Patch(ctx context.Context, delivery *models.Delivery, changedBy string) error {
+	return s.update(ctx, delivery, changedBy, func(delivery, current *models.Delivery) {
+		notes := delivery.FailureNotes
+		preserveServerFields(delivery, current)
+		delivery.FailureNotes = notes
+	})
+}
+
+func (s *deliveryStore) update(ctx context.Context, delivery *models.Delivery, changedBy string, preserve func(delivery, current *models.Delivery)) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current models.Delivery
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, delivery.ID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf(constants.ErrDeliveryNotFound)
+			}
+			return fmt.Errorf(constants.ErrFindDeliveryByID, delivery.ID, err)
+		}
+		if err := checkVersion(&current, delivery.Version); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if current.Estado != delivery.Estado {
+			if !current.Estado.CanTransitionTo(delivery.Estado) {
+				return fmt.Errorf(constants.ErrInvalidStatusTransition, current.Estado, delivery.Estado)
+			}
+			if err := tx.Create(&models.DeliveryStatusHistory{
+				DeliveryID: delivery.ID,
+				FromEstado: current.Estado,
+				ToEstado:   delivery.Estado,
+				ChangedBy:  changedBy,
+				Reason:     "actualización de entrega",
+				ChangedAt:  now,
+			}).Error; err != nil {
+				return fmt.Errorf(constants.ErrDeliveryStatusHistory, delivery.ID, err)
+			}
+		}
+
+		// Las lecturas GPS del dispositivo son evidencia del cierre: no se editan por API
+		preserveDeviceLocation(delivery, &current)
+		preserve(delivery, &current)
+		// El camión se asigna solo con POST /trucks/:id/assign y vale para la fecha asignada
+		delivery.TruckID = current.TruckID
+		if delivery.FechaAccion.UTC().Format("2006-01-02") != current.FechaAccion.UTC().Format("2006-01-02") {
+			delivery.TruckID = nil
+		}
+		delivery.Version = current.Version + 1
+		delivery.CreatedAt = current.CreatedAt
+		delivery.UpdatedAt = now
+		// Los faltantes se registran solo al completar la entrega
+		if err := tx.Omit("Shortfalls").Save(delivery).Error; err != nil {
+			return fmt.Errorf(constants.ErrUpdateDelivery, err)
+		}
+		return syncStockReservations(tx, delivery.ID, changedBy)
+	})
+}
+
+// preserveServerFields conserva en una actualización completa los datos que solo escribe el
+// servidor. El token cambia únicamente con POST /deliveries/:id/token/regenerate o al reprogramar,
+// que verifican que no lo use otra entrega de la ruta; el bloqueo se levanta al regenerarlo.
+func preserveServerFields(delivery, current *models.Delivery) {
+	delivery.Token = current.Token
+	delivery.TokenFailedAttempts = current.TokenFailedAttempts
+	delivery.TokenLockedAt = current.TokenLockedAt
+	// Las reprogramaciones se cuentan solo en PATCH /deliveries/:id/reschedule
+	delivery.RescheduleCount = current.RescheduleCount
+	// El motivo de la visita fallida y el seguimiento los registra la app al marcarla fallida
+	delivery.FailureReason = current.FailureReason
+	delivery.FailureNotes = current.FailureNotes
+	delivery.FailedAt = current.FailedAt
+	delivery.RequiresFollowUp = current.RequiresFollowUp
+	delivery.FollowUpOfID = current.FollowUpOfID
+	// La baja y la restauración tienen sus propios endpoints
+	delivery.DeletedAt = current.DeletedAt
+	// El momento de la entrega lo fija Complete
+	delivery.CompletedAt = current.CompletedAt
+}
+
+// Complete marca la entrega como Completado con un UPDATE condicional sobre id, versión y estado:
+// si dos repartidores completan la misma entrega, solo el primero afecta la fila y el segundo
+// recibe un conflicto. Persiste además los datos de cierre cargados en delivery; si
+// delivery.CompletedAt es nil se toma la hora del servidor como momento de la entrega.
+// En una entrega parcial se registran los faltantes de delivery.Shortfalls y, si followUp no es
+// nil, se crea la entrega de seguimiento vinculada; todo en la misma transacción, junto con el
+// consumo del stock reservado (ver syncStockReservations).
+func (s *deliveryStore) Complete(ctx context.Context, delivery *models.Delivery, followUp *models.Delivery, changedBy, reason string) error {
+	from := delivery.Estado
+	completables := make([]models.EstadoEntrega, 0, len(models.EstadosAbiertos))
+	for _, estado := range models.EstadosAbiertos {
+		if estado.CanTransitionTo(models.Completado) {
+			completables = append(completables, estado)
+		}
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockTokens(tx, followUp); err != nil {
+			return err
+		}
+		now := time.Now()
+		completedAt := now
+		if delivery.CompletedAt != nil {
+			completedAt = *delivery.CompletedAt
+		}
+		result := tx.Model(&models.Delivery{}).
+			Where("id = ? AND version = ? AND estado IN ?", delivery.ID, delivery.Version, completables).
+			Updates(map[string]interface{}{
+				"estado":               models.Completado,
+				"completed_at":         completedAt,
+				"completed_by":         delivery.CompletedBy,
+				"name":                 delivery.Name,
+				"email":                delivery.Email,
+				"address":              delivery.Address,
+				"locality":             delivery.Locality,
+				"validated_dispensers": delivery.ValidatedDispensers,
+				"retired_dispensers":   delivery.RetiredDispensers,
+				"serial_override":      delivery.SerialOverride,
+				"tipo_entrega":         delivery.TipoEntrega,
+				"order_number":         delivery.OrderNumber,
+				"cantidad":             delivery.Cantidad,
+				"completion_latitude":  delivery.CompletionLatitude,
+				"completion_longitude": delivery.CompletionLongitude,
+				"completion_accuracy":  delivery.CompletionAccuracy,
+				"completion_distance":  delivery.CompletionDistance,
+				"geofence_flagged":     delivery.GeofenceFlagged,
+				"version":              gorm.Expr("version + 1"),
+				"updated_at":           now,
+			})
+		if result.Error != nil {
+			return fmt.Errorf(constants.ErrUpdateDelivery, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			var current models.Delivery
+			if err := tx.Select("id", "estado", "version").First(&current, delivery.ID).Error; err != nil {
+				return fmt.Errorf(constants.ErrDeliveryNotFound)
+			}
+			if !current.Estado.CanTransitionTo(models.Completado) {
+				return fmt.Errorf(constants.ErrInvalidStatusTransition, current.Estado, models.Completado)
+			}
+			return fmt.Errorf(constants.ErrVersionConflictDetail, delivery.Version, current.Version)
+		}
+
+		if err := tx.Create(&models.DeliveryStatusHistory{
+			DeliveryID: delivery.ID,
+			FromEstado: from,
+			ToEstado:   models.Completado,
+			ChangedBy:  changedBy,
+			Reason:     reason,
+			ChangedAt:  completedAt,
+		}).Error; err != nil {
+			return fmt.Errorf(constants.ErrDeliveryStatusHistory, delivery.ID, err)
+		}
+
+		if followUp != nil {
+			followUp.FollowUpOfID = &delivery.ID
+			if err := tx.Create(followUp).Error; err != nil {
+				return fmt.Errorf(constants.ErrCreateDelivery, err)
+			}
+		}
+		for i := range delivery.Shortfalls {
+			delivery.Shortfalls[i].DeliveryID = delivery.ID
+			if followUp != nil {
+				delivery.Shortfalls[i].FollowUpDeliveryID = &followUp.ID
+			}
+		}
+		if len(delivery.Shortfalls) > 0 {
+			if err := tx.Create(&delivery.Shortfalls).Error; err != nil {
+				return fmt.Errorf("error registrando faltantes de la entrega %d: %w", delivery.ID, err)
+			}
+		}
+		if err := syncStockReservations(tx, delivery.ID, changedBy); err != nil {
+			return err
+		}
+		if followUp != nil {
+			if err := syncStockReservations(tx, followUp.ID, changedBy); err != nil {
+				return err
+			}
+		}
+
+		delivery.Estado = models.Completado
+		delivery.CompletedAt = &completedAt
+		delivery.Version++
+		delivery.UpdatedAt = now
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if followUp != nil {
+		metrics.DeliveryCreated(string(followUp.TipoEntrega))
+	}
+	return nil
+}
+
+// Delete hace un borrado lógico de la entrega y de sus item dispensers en la misma transacción.
+// Ambos comparten el mismo deleted_at para que Restore pueda recuperar exactamente esos items.
+func (s *deliveryStore) Delete(ctx context.Context, id int) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var delivery models.Delivery
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&delivery, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf(constants.ErrDeliveryNotFound)
+			}
+			return fmt.Errorf(constants.ErrDeleteDelivery, id, err)
+		}
+
+		deletedAt := time.Now()
+		if err := tx.Model(&models.ItemDispenser{}).
+			Where("delivery_id = ?", id).
+			Update("deleted_at", deletedAt).Error; err != nil {
+			return fmt.Errorf(constants.ErrDeleteDelivery, id, err)
+		}
+		if err := tx.Model(&delivery).Updates(map[string]interface{}{
+			"deleted_at": deletedAt,
+			"version":    gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			return fmt.Errorf(constants.ErrDeleteDelivery, id, err)
+		}
+		return syncStockReservations(tx, id, "")
+	})
+}
+
+// Restore revierte el borrado lógico de una entrega junto con los item dispensers eliminados con ella
+func (s *deliveryStore) Restore(ctx context.Context, id int) (*models.Delivery, error) {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var delivery models.Delivery
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).First(&delivery, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf(constants.ErrDeliveryNotFound)
+			}
+			return fmt.Errorf(constants.ErrRestoreDelivery, id, err)
+		}
+		if !delivery.DeletedAt.Valid {
+			return fmt.Errorf(constants.ErrDeliveryNotDeleted)
+		}
+
+		if err := tx.Unscoped().Model(&models.ItemDispenser{}).
+			Where("delivery_id = ? AND deleted_at = ?", id, delivery.DeletedAt.Time).
+			Update("deleted_at", nil).Error; err != nil {
+			return fmt.Errorf(constants.ErrRestoreDelivery, id, err)
+		}
+		if err := tx.Unscoped().Model(&delivery).Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"version":    gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			return fmt.Errorf(constants.ErrRestoreDelivery, id, err)
+		}
+		return syncStockReservations(tx, id, "")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.FindByID(ctx, id)
+}
+
+// FindDeleted lista las entregas con borrado lógico, con sus item dispensers eliminados
+func (s *deliveryStore) FindDeleted(ctx context.Context, limit, offset int) ([]models.Delivery, error) {
+	var deliveries []models.Delivery
+	if err := s.db.WithContext(ctx).Unscoped().
+		Preload("ItemDispensers", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").
+		Offset(offset).Limit(limit).
+		Find(&deliveries).Error; err != nil {
+		return nil, fmt.Errorf("error buscando entregas eliminadas: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (s *deliveryStore) CountDeleted(ctx context.Context) (int64, error) {
+	var count int64
+	if err := s.db.WithContext(ctx).Unscoped().Model(&models.Delivery{}).
+		Where("deleted_at IS NOT NULL").
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("error contando entregas eliminadas: %w", err)
+	}
+	return count, nil
+}
+
+// FindPendingByNroCta busca deliveries todavía abiertas (Pendiente, Programado, etc.) para un número de cuenta
 func (s *deliveryStore) FindPendingByNroCta(ctx context.Context, nroCta string) ([]models.Delivery, error) {
 	var deliveries []models.Delivery
 	if err := s.db.WithContext(ctx).
 		Preload("ItemDispensers").
-		Where("nro_cta = ? AND estado = ?", nroCta, models.Pendiente).
+		Where("nro_cta = ? AND estado IN ?", nroCta, models.EstadosAbiertos).
 		Find(&deliveries).Error; err != nil {
 		return nil, fmt.Errorf("error buscando deliveries pendientes por nro_cta: %w", err)
 	}
 	return deliveries, nil
 }
 
-// CancelDelivery cancela un delivery específico por ID si no está ya cancelado
-func (s *deliveryStore) CancelDelivery(ctx context.Context, id int) (*models.Delivery, error) {
-	delivery, err := s.FindByID(ctx, id)
+// CancelDelivery cancela un delivery específico por ID pasando por la tabla de transiciones
+func (s *deliveryStore) CancelDelivery(ctx context.Context, id, expectedVersion int, changedBy, reason string) (*models.Delivery, error) {
+	return s.TransitionStatus(ctx, id, models.Cancelado, expectedVersion, changedBy, reason)
+}
+
+// CancelExpiredPending cancela todos los deliveries abiertos cuya fecha_accion ya pasó,
+// registrando una entrada de historial por cada entrega cancelada
+func (s *deliveryStore) CancelExpiredPending(ctx context.Context) (int64, error) {
+	today := time.Now().Truncate(24 * time.Hour)
+	var cancelled int64
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var expired []models.Delivery
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "estado").
+			Where("estado IN ? AND fecha_accion < ?", models.EstadosAbiertos, today).
+			Find(&expired).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		history := make([]models.DeliveryStatusHistory, 0, len(expired))
+		ids := make([]int, 0, len(expired))
+		for _, d := range expired {
+			if !d.Estado.CanTransitionTo(models.Cancelado) {
+				continue
+			}
+			ids = append(ids, d.ID)
+			history = append(history, models.DeliveryStatusHistory{
+				DeliveryID: d.ID,
+				FromEstado: d.Estado,
+				ToEstado:   models.Cancelado,
+				ChangedBy:  "scheduler",
+				Reason:     "fecha_accion vencida",
+				ChangedAt:  now,
+			})
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		result := tx.Model(&models.Delivery{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"estado":     models.Cancelado,
+				"version":    gorm.Expr("version + 1"),
+				"updated_at": now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if err := tx.Create(&history).Error; err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := syncStockReservations(tx, id, "scheduler"); err != nil {
+				return err
+			}
+		}
+		cancelled = result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error cancelando deliveries expirados: %w", err)
+	}
+	return cancelled, nil
+}
+
+// TransitionStatus cambia el estado de una entrega validando la tabla de transiciones
+// y registra el cambio en delivery_status_history dentro de la misma transacción
+func (s *deliveryStore) TransitionStatus(ctx context.Context, id int, to models.EstadoEntrega, expectedVersion int, changedBy, reason string) (*models.Delivery, error) {
+	var delivery models.Delivery
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&delivery, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf(constants.ErrDeliveryNotFound)
+			}
+			return fmt.Errorf(constants.ErrFindDeliveryByID, id, err)
+		}
+
+		if err := checkVersion(&delivery, expectedVersion); err != nil {
+			return err
+		}
+
+		from := delivery.Estado
+		if !from.CanTransitionTo(to) {
+			return fmt.Errorf(constants.ErrInvalidStatusTransition, from, to)
+		}
+
+		now := time.Now()
+		if err := tx.Model(&delivery).Updates(map[string]interface{}{
+			"estado":     to,
+			"version":    gorm.Expr("version + 1"),
+			"updated_at": now,
+		}).Error; err != nil {
+			return fmt.Errorf(constants.ErrUpdateDelivery, err)
+		}
+		delivery.Estado = to
+		delivery.Version++
+		delivery.UpdatedAt = now
+
+		history := &models.DeliveryStatusHistory{
+			DeliveryID: id,
+			FromEstado: from,
+			ToEstado:   to,
+			ChangedBy:  changedBy,
+			Reason:     reason,
+			ChangedAt:  now,
+		}
+		if err := tx.Create(history).Error; err != nil {
+			return fmt.Errorf(constants.ErrDeliveryStatusHistory, id, err)
+		}
+		return syncStockReservations(tx, id, changedBy)
+	})
 	if err != nil {
 		return nil, err
 	}
-	if delivery == nil {
-		return nil, fmt.Errorf("delivery con id %d no encontrado", id)
+
+	if err := s.db.WithContext(ctx).Model(&delivery).Association("ItemDispensers").Find(&delivery.ItemDispensers); err != nil {
+		return nil, fmt.Errorf(constants.ErrFindDeliveryByID, id, err)
 	}
-	result := s.db.WithContext(ctx).
-		Model(delivery).
-		Updates(map[string]interface{}{
-			"estado":     models.Cancelado,
-			"updated_at": time.Now(),
-		})
-	if result.Error != nil {
-		return nil, fmt.Errorf("error cancelando delivery: %w", result.Error)
+	return &delivery, nil
+}
+
+// FindStatusHistory devuelve las transiciones de estado de una entrega, de la más antigua a la más reciente
+func (s *deliveryStore) FindStatusHistory(ctx context.Context, deliveryID int) ([]models.DeliveryStatusHistory, error) {
+	var history []models.DeliveryStatusHistory
+	if err := s.db.WithContext(ctx).
+		Where("delivery_id = ?", deliveryID).
+		Order("changed_at ASC, id ASC").
+		Find(&history).Error; err != nil {
+		return nil, fmt.Errorf("error buscando historial de estados de la entrega %d: %w", deliveryID, err)
 	}
-	delivery.Estado = models.Cancelado
-	return delivery, nil
+	return history, nil
 }
 
-// CancelExpiredPending cancela todos los deliveries en estado Pendiente cuya fecha_accion ya pasó
-func (s *deliveryStore) CancelExpiredPending(ctx context.Context) (int64, error) {
-	today := time.Now().Truncate(24 * time.Hour)
-	result := s.db.WithContext(ctx).
-		Model(&models.Delivery{}).
-		Where("estado = ? AND fecha_accion < ?", models.Pendiente, today).
-		Updates(map[string]interface{}{
-			"estado":     models.Cancelado,
-			"updated_at": time.Now(),
-		})
-	if result.Error != nil {
-		return 0, fmt.Errorf("error cancelando deliveries expirados: %w", result.Error)
-	}
-	return result.RowsAffected, nil
+// Reschedule cambia la fecha_accion de una entrega, la pasa a Reprogramado e incrementa el contador.
+// Si newToken no está vacío reemplaza el token de validación. Todo ocurre en una única transacción
+// junto con el historial de estados y el registro de reprogramación, después de tomar los locks del
+// guard y pasar su verificación (ver BookingGuard). Si el token que queda ya lo usa otra entrega
+// abierta en la nueva fecha devuelve ErrTokenInUse.
+func (s *deliveryStore) Reschedule(ctx context.Context, id int, newFecha time.Time, newToken string, expectedVersion int, changedBy, reason string, guard *BookingGuard) (*models.Delivery, error) {
+	var delivery models.Delivery
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := guard.acquire(ctx, tx); err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&delivery, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf(constants.ErrDeliveryNotFound)
+			}
+			return fmt.Errorf(constants.ErrFindDeliveryByID, id, err)
+		}
+
+		if err := checkVersion(&delivery, expectedVersion); err != nil {
+			return err
+		}
+
+		from := delivery.Estado
+		if !from.CanTransitionTo(models.Reprogramado) {
+			return fmt.Errorf(constants.ErrInvalidStatusTransition, from, models.Reprogramado)
+		}
+
+		// El token que queda (el actual o newToken) tiene que estar libre en la nueva fecha
+		moved := models.Delivery{ID: id, NroRto: delivery.NroRto, Token: delivery.Token, FechaAccion: models.CustomDate{Time: newFecha}}
+		if newToken != "" {
+			moved.Token = newToken
+		}
+		if err := lockTokens(tx, &moved); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		previousFecha := delivery.FechaAccion.Time
+		updates := map[string]interface{}{
+			"estado":           models.Reprogramado,
+			"fecha_accion":     newFecha,
+			"reschedule_count": gorm.Expr("reschedule_count + 1"),
+			"truck_id":         nil, // la asignación de camión es por fecha
+			"version":          gorm.Expr("version + 1"),
+			"updated_at":       now,
+		}
+		if newToken != "" {
+			updates["token"] = newToken
+			updates["token_failed_attempts"] = 0
+			updates["token_locked_at"] = nil
+		}
+		if err := tx.Model(&delivery).Updates(updates).Error; err != nil {
+			return fmt.Errorf(constants.ErrUpdateDelivery, err)
+		}
+
+		if err := tx.Create(&models.DeliveryStatusHistory{
+			DeliveryID: id,
+			FromEstado: from,
+			ToEstado:   models.Reprogramado,
+			ChangedBy:  changedBy,
+			Reason:     reason,
+			ChangedAt:  now,
+		}).Error; err != nil {
+			return fmt.Errorf(constants.ErrDeliveryStatusHistory, id, err)
+		}
+
+		if err := tx.Create(&models.DeliveryReschedule{
+			DeliveryID:       id,
+			PreviousFecha:    previousFecha,
+			NewFecha:         newFecha,
+			Reason:           reason,
+			RescheduledBy:    changedBy,
+			TokenRegenerated: newToken != "",
+		}).Error; err != nil {
+			return fmt.Errorf("error registrando reprogramación de la entrega %d: %w", id, err)
+		}
+		return syncStockReservations(tx, id, changedBy)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.FindByID(ctx, id)
+}
+
+// FindReschedules devuelve las reprogramaciones de una entrega, de la más antigua a la más reciente
+func (s *deliveryStore) FindReschedules(ctx context.Context, deliveryID int) ([]models.DeliveryReschedule, error) {
+	var reschedules []models.DeliveryReschedule
+	if err := s.db.WithContext(ctx).
+		Where("delivery_id = ?", deliveryID).
+		Order("created_at ASC, id ASC").
+		Find(&reschedules).Error; err != nil {
+		return nil, fmt.Errorf("error buscando reprogramaciones de la entrega %d: %w", deliveryID, err)
+	}
+	return reschedules, nil
+}
+
+// RecordFailedVisit pasa la entrega a Fallido guardando motivo, notas y horario de la visita.
+// Si followUp no es nil se crea en la misma transacción, vinculada a la entrega original.
+func (s *deliveryStore) RecordFailedVisit(ctx context.Context, id int, reasonCode, notes string, failedAt time.Time, requiresFollowUp bool, followUp *models.Delivery, changedBy string) (*models.Delivery, error) {
+	var delivery models.Delivery
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockTokens(tx, followUp); err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&delivery, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf(constants.ErrDeliveryNotFound)
+			}
+			return fmt.Errorf(constants.ErrFindDeliveryByID, id, err)
+		}
+
+		from := delivery.Estado
+		if !from.CanTransitionTo(models.Fallido) {
+			return fmt.Errorf(constants.ErrInvalidStatusTransition, from, models.Fallido)
+		}
+
+		now := time.Now()
+		if err := tx.Model(&delivery).Updates(map[string]interface{}{
+			"estado":             models.Fallido,
+			"failure_reason":     reasonCode,
+			"failure_notes":      notes,
+			"failed_at":          failedAt,
+			"requires_follow_up": requiresFollowUp,
+			"version":            gorm.Expr("version + 1"),
+			"updated_at":         now,
+		}).Error; err != nil {
+			return fmt.Errorf(constants.ErrUpdateDelivery, err)
+		}
+
+		if err := tx.Create(&models.DeliveryStatusHistory{
+			DeliveryID: id,
+			FromEstado: from,
+			ToEstado:   models.Fallido,
+			ChangedBy:  changedBy,
+			Reason:     reasonCode,
+			ChangedAt:  now,
+		}).Error; err != nil {
+			return fmt.Errorf(constants.ErrDeliveryStatusHistory, id, err)
+		}
+
+		if followUp != nil {
+			followUp.FollowUpOfID = &delivery.ID
+			if err := tx.Create(followUp).Error; err != nil {
+				return fmt.Errorf(constants.ErrCreateDelivery, err)
+			}
+			if err := syncStockReservations(tx, followUp.ID, changedBy); err != nil {
+				return err
+			}
+		}
+		return syncStockReservations(tx, id, changedBy)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if followUp != nil {
+		metrics.DeliveryCreated(string(followUp.TipoEntrega))
+	}
+	return s.FindByID(ctx, id)
+}
+
+// FindRequiringFollowUp devuelve las entregas fallidas marcadas para seguimiento de contact center
+func (s *deliveryStore) FindRequiringFollowUp(ctx context.Context) ([]models.Delivery, error) {
+	var deliveries []models.Delivery
+	if err := s.db.WithContext(ctx).
+		Preload("ItemDispensers").
+		Where("requires_follow_up = ? AND estado = ?", true, models.Fallido).
+		Order("failed_at ASC").
+		Find(&deliveries).Error; err != nil {
+		return nil, fmt.Errorf("error buscando entregas para seguimiento: %w", err)
+	}
+	return deliveries, nil
 }
@@ -0,0 +1,187 @@
+package store
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TokenInUse indica si otra entrega abierta de la misma ruta y fecha ya usa el token.
+// excludeID permite ignorar la propia entrega al regenerar o reprogramar.
+func (s *deliveryStore) TokenInUse(ctx context.Context, token, nroRto string, fechaAccion time.Time, excludeID int) (bool, error) {
+	return tokenTaken(s.db.WithContext(ctx), token, nroRto, fechaAccion, excludeID)
+}
+
+// ErrTokenInUse indica que, ya con el lock de la ruta y fecha tomado, otra entrega abierta usa el
+// token elegido. El servicio elige otro y reintenta.
+var ErrTokenInUse = errors.New("el token ya lo usa otra entrega abierta de la ruta y fecha")
+
+// lockTokens toma el lock de ruta y fecha (RouteBookingKey) de cada entrega con token y verifica,
+// dentro de la transacción, que ninguna otra entrega abierta lo use. Toda escritura de un token
+// pasa por acá: la verificación y la escritura quedan serializadas por ruta y fecha.
+func lockTokens(tx *gorm.DB, deliveries ...*models.Delivery) error {
+	keys := make([]string, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		if delivery != nil && delivery.Token != "" {
+			keys = append(keys, RouteBookingKey(delivery.NroRto, delivery.FechaAccion.Time))
+		}
+	}
+	if err := lockKeys(tx, keys); err != nil {
+		return err
+	}
+	for _, delivery := range deliveries {
+		if delivery == nil || delivery.Token == "" {
+			continue
+		}
+		taken, err := tokenTaken(tx, delivery.Token, delivery.NroRto, delivery.FechaAccion.Time, delivery.ID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return ErrTokenInUse
+		}
+	}
+	return nil
+}
+
+func tokenTaken(db *gorm.DB, token, nroRto string, fechaAccion time.Time, excludeID int) (bool, error) {
+	day := startOfDay(fechaAccion)
+	var count int64
+	err := db.Model(&models.Delivery{}).
+		Where("token = ? AND nro_rto = ? AND estado IN ?", token, nroRto, models.EstadosAbiertos).
+		Where("fecha_accion >= ? AND fecha_accion < ?", day, day.AddDate(0, 0, 1)).
+		Where("id <> ?", excludeID).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("error verificando unicidad del token: %w", err)
+	}
+	return count > 0, nil
+}
+
+// FindOpenByNroCtaAndFecha devuelve las entregas abiertas de la cuenta para el día indicado
+// (YYYY-MM-DD). Se usa para imputar los intentos fallidos de validación de token.
+func (s *deliveryStore) FindOpenByNroCtaAndFecha(ctx context.Context, nroCta, fechaAccion string) ([]models.Delivery, error) {
+	parsedDate, err := time.Parse("2006-01-02", fechaAccion)
+	if err != nil {
+		return nil, fmt.Errorf("formato de fecha inválido, use YYYY-MM-DD")
+	}
+	var deliveries []models.Delivery
+	err = s.db.WithContext(ctx).
+		Where("nro_cta = ? AND estado IN ?", nroCta, models.EstadosAbiertos).
+		Where("fecha_accion >= ? AND fecha_accion < ?", parsedDate, parsedDate.AddDate(0, 0, 1)).
+		Order("id").
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, fmt.Errorf("error buscando entregas abiertas de la cuenta %s: %w", nroCta, err)
+	}
+	return deliveries, nil
+}
+
+// RegisterFailedTokenAttempt suma un intento fallido de forma atómica y bloquea el token al
+// llegar a maxAttempts. No incrementa la versión: el contador no es un dato editable de la
+// entrega y no debe invalidar el ETag que tenga abierto contact center.
+func (s *deliveryStore) RegisterFailedTokenAttempt(ctx context.Context, id, maxAttempts int) (*models.Delivery, error) {
+	var delivery models.Delivery
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&delivery, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf(constants.ErrDeliveryNotFound)
+			}
+			return fmt.Errorf(constants.ErrFindDeliveryByID, id, err)
+		}
+		if delivery.TokenLockedAt != nil {
+			return nil
+		}
+
+		delivery.TokenFailedAttempts++
+		if delivery.TokenFailedAttempts >= maxAttempts {
+			now := time.Now()
+			delivery.TokenLockedAt = &now
+		}
+		if err := tx.Model(&delivery).UpdateColumns(map[string]interface{}{
+			"token_failed_attempts": delivery.TokenFailedAttempts,
+			"token_locked_at":       delivery.TokenLockedAt,
+		}).Error; err != nil {
+			return fmt.Errorf(constants.ErrUpdateDelivery, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ResetTokenAttempts vuelve a cero el contador de intentos luego de una validación exitosa
+func (s *deliveryStore) ResetTokenAttempts(ctx context.Context, id int) error {
+	err := s.db.WithContext(ctx).Model(&models.Delivery{}).
+		Where("id = ? AND token_locked_at IS NULL", id).
+		UpdateColumn("token_failed_attempts", 0).Error
+	if err != nil {
+		return fmt.Errorf(constants.ErrUpdateDelivery, err)
+	}
+	return nil
+}
+
+// RegenerateToken reemplaza el token de una entrega abierta, levanta el bloqueo por intentos
+// fallidos e incrementa la versión
+func (s *deliveryStore) RegenerateToken(ctx context.Context, id int, newToken string, expectedVersion int) (*models.Delivery, error) {
+	var delivery models.Delivery
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// El lock de ruta y fecha se toma antes que el de la fila, como en el resto de las escrituras
+		var scope models.Delivery
+		if err := tx.Select("id", "nro_rto", "fecha_accion").First(&scope, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf(constants.ErrDeliveryNotFound)
+			}
+			return fmt.Errorf(constants.ErrFindDeliveryByID, id, err)
+		}
+		scope.Token = newToken
+		if err := lockTokens(tx, &scope); err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&delivery, id).Error; err != nil {
+			return fmt.Errorf(constants.ErrFindDeliveryByID, id, err)
+		}
+		if err := checkVersion(&delivery, expectedVersion); err != nil {
+			return err
+		}
+		if delivery.NroRto != scope.NroRto || !delivery.FechaAccion.Equal(scope.FechaAccion.Time) {
+			// Se reprogramó entre la lectura y el lock: el token se vuelve a elegir para la fecha nueva
+			return ErrTokenInUse
+		}
+		if !isOpenEstado(delivery.Estado) {
+			return fmt.Errorf(constants.ErrTokenRegenerateNotOpen, delivery.Estado)
+		}
+
+		if err := tx.Model(&delivery).Updates(map[string]interface{}{
+			"token":                 newToken,
+			"token_failed_attempts": 0,
+			"token_locked_at":       nil,
+			"version":               gorm.Expr("version + 1"),
+			"updated_at":            time.Now(),
+		}).Error; err != nil {
+			return fmt.Errorf(constants.ErrUpdateDelivery, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.FindByID(ctx, id)
+}
+
+func isOpenEstado(estado models.EstadoEntrega) bool {
+	for _, open := range models.EstadosAbiertos {
+		if estado == open {
+			return true
+		}
+	}
+	return false
+}
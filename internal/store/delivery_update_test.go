@@ -0,0 +1,60 @@
+package store
+
+import (
+	"GoFrioCalor/internal/models"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestPreserveServerFields(t *testing.T) {
+	lockedAt := time.Date(2024, 5, 10, 9, 30, 0, 0, time.UTC)
+	originalID := 7
+	current := &models.Delivery{
+		ID:                  1,
+		Token:               "482913",
+		TokenFailedAttempts: 5,
+		TokenLockedAt:       &lockedAt,
+		RescheduleCount:     2,
+		FailureReason:       "cliente_ausente",
+		FailureNotes:        "No atiende el timbre",
+		FailedAt:            &lockedAt,
+		RequiresFollowUp:    true,
+		FollowUpOfID:        &originalID,
+		CompletedAt:         &lockedAt,
+	}
+	delivery := &models.Delivery{
+		ID:            1,
+		Name:          "Nuevo nombre",
+		Token:         "000000",
+		FailureReason: "otro",
+		FailureNotes:  "",
+		DeletedAt:     gorm.DeletedAt{Time: lockedAt, Valid: true},
+	}
+
+	preserveServerFields(delivery, current)
+
+	if delivery.Token != "482913" {
+		t.Errorf("Token = %q, want the persisted token", delivery.Token)
+	}
+	if delivery.TokenFailedAttempts != 5 || delivery.TokenLockedAt == nil || !delivery.TokenLockedAt.Equal(lockedAt) {
+		t.Errorf("token lockout = %d, %v, want 5, %v", delivery.TokenFailedAttempts, delivery.TokenLockedAt, lockedAt)
+	}
+	if delivery.RescheduleCount != 2 {
+		t.Errorf("RescheduleCount = %d, want 2", delivery.RescheduleCount)
+	}
+	if delivery.FailureReason != "cliente_ausente" || delivery.FailureNotes != "No atiende el timbre" || delivery.FailedAt == nil || !delivery.RequiresFollowUp ||
+		delivery.FollowUpOfID == nil || *delivery.FollowUpOfID != originalID {
+		t.Errorf("failure fields were not preserved: %+v", delivery)
+	}
+	if delivery.DeletedAt.Valid {
+		t.Errorf("DeletedAt = %v, want the persisted (null) value", delivery.DeletedAt)
+	}
+	if delivery.CompletedAt == nil || !delivery.CompletedAt.Equal(lockedAt) {
+		t.Errorf("CompletedAt = %v, want %v", delivery.CompletedAt, lockedAt)
+	}
+	if delivery.Name != "Nuevo nombre" {
+		t.Errorf("Name = %q, want the edited value", delivery.Name)
+	}
+}
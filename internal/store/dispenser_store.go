@@ -0,0 +1,138 @@
+package store
+
+import (
+	"GoFrioCalor/internal/models"
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DispenserMove es un movimiento a registrar. Si el dispenser no existe se da de alta con Tipo.
+type DispenserMove struct {
+	Tipo     models.TipoDispenser
+	Movement models.DispenserMovement
+}
+
+type DispenserStore interface {
+	FindBySerial(ctx context.Context, serial string) (*models.Dispenser, error)
+	FindMovements(ctx context.Context, dispenserID int) ([]models.DispenserMovement, error)
+	FindInstalledAtAccount(ctx context.Context, nroCta string) ([]models.Dispenser, error)
+	CountInstalledAtAccount(ctx context.Context, nroCta string) (int64, error)
+	FindAccountMovements(ctx context.Context, nroCta string) ([]models.DispenserMovement, error)
+	ApplyMovements(ctx context.Context, moves []DispenserMove) error
+}
+
+type dispenserStore struct {
+	db *gorm.DB
+}
+
+func NewDispenserStore(db *gorm.DB) DispenserStore {
+	return &dispenserStore{db: db}
+}
+
+// FindBySerial devuelve nil sin error si el número de serie no está registrado
+func (s *dispenserStore) FindBySerial(ctx context.Context, serial string) (*models.Dispenser, error) {
+	var dispenser models.Dispenser
+	if err := s.db.WithContext(ctx).Where("serial = ?", serial).First(&dispenser).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error buscando dispenser %s: %w", serial, err)
+	}
+	return &dispenser, nil
+}
+
+// FindMovements devuelve los movimientos del dispenser, del más antiguo al más reciente
+func (s *dispenserStore) FindMovements(ctx context.Context, dispenserID int) ([]models.DispenserMovement, error) {
+	var movements []models.DispenserMovement
+	if err := s.db.WithContext(ctx).
+		Where("dispenser_id = ?", dispenserID).
+		Order("occurred_at ASC, id ASC").
+		Find(&movements).Error; err != nil {
+		return nil, fmt.Errorf("error buscando movimientos del dispenser %d: %w", dispenserID, err)
+	}
+	return movements, nil
+}
+
+// FindInstalledAtAccount devuelve los dispensers instalados actualmente en la cuenta
+func (s *dispenserStore) FindInstalledAtAccount(ctx context.Context, nroCta string) ([]models.Dispenser, error) {
+	var dispensers []models.Dispenser
+	if err := s.installedAtAccount(ctx, nroCta).Order("serial ASC").Find(&dispensers).Error; err != nil {
+		return nil, fmt.Errorf("error buscando dispensers instalados en la cuenta %s: %w", nroCta, err)
+	}
+	return dispensers, nil
+}
+
+func (s *dispenserStore) CountInstalledAtAccount(ctx context.Context, nroCta string) (int64, error) {
+	var count int64
+	if err := s.installedAtAccount(ctx, nroCta).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("error contando dispensers instalados en la cuenta %s: %w", nroCta, err)
+	}
+	return count, nil
+}
+
+func (s *dispenserStore) installedAtAccount(ctx context.Context, nroCta string) *gorm.DB {
+	return s.db.WithContext(ctx).Model(&models.Dispenser{}).
+		Where("status = ? AND location_type = ? AND location_id = ?", models.DispenserInstalado, models.UbicacionCuenta, nroCta)
+}
+
+// FindAccountMovements devuelve los movimientos con destino en la cuenta, del más antiguo al más reciente
+func (s *dispenserStore) FindAccountMovements(ctx context.Context, nroCta string) ([]models.DispenserMovement, error) {
+	var movements []models.DispenserMovement
+	if err := s.db.WithContext(ctx).
+		Where("to_location_type = ? AND to_location_id = ?", models.UbicacionCuenta, nroCta).
+		Order("occurred_at ASC, id ASC").
+		Find(&movements).Error; err != nil {
+		return nil, fmt.Errorf("error buscando movimientos de la cuenta %s: %w", nroCta, err)
+	}
+	return movements, nil
+}
+
+// ApplyMovements registra los movimientos en orden y actualiza estado y ubicación de cada
+// dispenser en una única transacción. La ubicación de origen se toma del registro.
+func (s *dispenserStore) ApplyMovements(ctx context.Context, moves []DispenserMove) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, move := range moves {
+			movement := move.Movement
+			var dispenser models.Dispenser
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("serial = ?", movement.Serial).First(&dispenser).Error
+			switch {
+			case err == gorm.ErrRecordNotFound:
+				dispenser = models.Dispenser{
+					Serial:       movement.Serial,
+					Tipo:         move.Tipo,
+					Status:       movement.Status,
+					LocationType: movement.ToLocationType,
+					LocationID:   movement.ToLocationID,
+				}
+				if err := tx.Create(&dispenser).Error; err != nil {
+					return fmt.Errorf("error registrando dispenser %s: %w", movement.Serial, err)
+				}
+			case err != nil:
+				return fmt.Errorf("error buscando dispenser %s: %w", movement.Serial, err)
+			default:
+				movement.FromLocationType = dispenser.LocationType
+				movement.FromLocationID = dispenser.LocationID
+				updates := map[string]interface{}{
+					"status":        movement.Status,
+					"location_type": movement.ToLocationType,
+					"location_id":   movement.ToLocationID,
+				}
+				if dispenser.Tipo == "" && move.Tipo != "" {
+					updates["tipo"] = move.Tipo
+				}
+				if err := tx.Model(&dispenser).Updates(updates).Error; err != nil {
+					return fmt.Errorf("error actualizando dispenser %s: %w", movement.Serial, err)
+				}
+			}
+
+			movement.DispenserID = dispenser.ID
+			if err := tx.Create(&movement).Error; err != nil {
+				return fmt.Errorf("error registrando movimiento del dispenser %s: %w", movement.Serial, err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,42 @@
+package store
+
+import (
+	"GoFrioCalor/internal/models"
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type FailureReasonStore interface {
+	FindActive(ctx context.Context) ([]models.FailureReason, error)
+	FindByCode(ctx context.Context, code string) (*models.FailureReason, error)
+}
+
+type failureReasonStore struct {
+	db *gorm.DB
+}
+
+func NewFailureReasonStore(db *gorm.DB) FailureReasonStore {
+	return &failureReasonStore{db: db}
+}
+
+func (s *failureReasonStore) FindActive(ctx context.Context) ([]models.FailureReason, error) {
+	var reasons []models.FailureReason
+	if err := s.db.WithContext(ctx).Where("active = ?", true).Order("code ASC").Find(&reasons).Error; err != nil {
+		return nil, fmt.Errorf("error buscando motivos de visita fallida: %w", err)
+	}
+	return reasons, nil
+}
+
+// FindByCode devuelve el motivo activo con ese código, o nil si no existe
+func (s *failureReasonStore) FindByCode(ctx context.Context, code string) (*models.FailureReason, error) {
+	var reason models.FailureReason
+	if err := s.db.WithContext(ctx).Where("code = ? AND active = ?", code, true).First(&reason).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error buscando motivo de visita fallida: %w", err)
+	}
+	return &reason, nil
+}
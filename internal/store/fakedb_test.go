@@ -0,0 +1,166 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakeDB reemplaza a Postgres a nivel database/sql para probar el SQL que arma el store: cada
+// consulta se responde con la primera respuesta cargada cuyo fragmento aparece en el SQL (vacía si
+// no hay ninguna) y todas las sentencias quedan registradas para verificar lo que se ejecutó.
+type fakeDB struct {
+	mu         sync.Mutex
+	responses  []*fakeResponse
+	statements []fakeStatement
+}
+
+type fakeResponse struct {
+	match   string
+	columns []string
+	rows    [][]driver.Value
+	once    bool
+}
+
+type fakeStatement struct {
+	query string
+	args  []driver.Value
+}
+
+// once responde con rows la próxima consulta que contenga match
+func (f *fakeDB) once(match string, columns []string, rows ...[]driver.Value) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses = append(f.responses, &fakeResponse{match: match, columns: columns, rows: rows, once: true})
+}
+
+// executed devuelve las sentencias que contienen match, en orden
+func (f *fakeDB) executed(match string) []fakeStatement {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var found []fakeStatement
+	for _, statement := range f.statements {
+		if strings.Contains(statement.query, match) {
+			found = append(found, statement)
+		}
+	}
+	return found
+}
+
+func (f *fakeDB) record(query string, args []driver.NamedValue) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	f.statements = append(f.statements, fakeStatement{query: query, args: values})
+}
+
+func (f *fakeDB) query(query string, args []driver.NamedValue) *fakeRows {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record(query, args)
+	for i, response := range f.responses {
+		if strings.Contains(query, response.match) {
+			if response.once {
+				f.responses = append(f.responses[:i], f.responses[i+1:]...)
+			}
+			return &fakeRows{columns: response.columns, rows: response.rows}
+		}
+	}
+	return &fakeRows{}
+}
+
+func (f *fakeDB) exec(query string, args []driver.NamedValue) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record(query, args)
+}
+
+var (
+	fakeDBs      sync.Map
+	registerOnce sync.Once
+)
+
+// newFakeDB abre un *gorm.DB con el dialecto de Postgres sobre un fakeDB nuevo
+func newFakeDB(t *testing.T) (*gorm.DB, *fakeDB) {
+	t.Helper()
+	registerOnce.Do(func() { sql.Register("store_fakedb", fakeDriver{}) })
+	fake := &fakeDB{}
+	fakeDBs.Store(t.Name(), fake)
+	t.Cleanup(func() { fakeDBs.Delete(t.Name()) })
+
+	conn, err := sql.Open("store_fakedb", t.Name())
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	return db, fake
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fake, ok := fakeDBs.Load(name)
+	if !ok {
+		return nil, fmt.Errorf("fakedb %s no registrada", name)
+	}
+	return &fakeConn{db: fake.(*fakeDB)}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("fakedb: Prepare no soportado")
+}
+
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+// CheckNamedValue acepta los argumentos tal como los pasa gorm, para poder verificarlos
+func (c *fakeConn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.db.query(query, args), nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.exec(query, args)
+	return driver.RowsAffected(1), nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+	next    int
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
@@ -0,0 +1,84 @@
+package store
+
+import (
+	"GoFrioCalor/internal/models"
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyStore interface {
+	Reserve(ctx context.Context, record *models.IdempotencyKey) (*models.IdempotencyKey, bool, error)
+	Complete(ctx context.Context, id, statusCode int, contentType string, body []byte) error
+	Release(ctx context.Context, id int) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+type idempotencyStore struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyStore(db *gorm.DB) IdempotencyStore {
+	return &idempotencyStore{db: db}
+}
+
+// Reserve registra la clave como en curso. Si ya existía una clave vigente para la misma ruta
+// devuelve ese registro y false; una clave vencida se descarta y se vuelve a reservar.
+func (s *idempotencyStore) Reserve(ctx context.Context, record *models.IdempotencyKey) (*models.IdempotencyKey, bool, error) {
+	db := s.db.WithContext(ctx)
+	if err := db.Where("key = ? AND route = ? AND expires_at <= ?", record.Key, record.Route, time.Now()).
+		Delete(&models.IdempotencyKey{}).Error; err != nil {
+		return nil, false, fmt.Errorf("error liberando clave de idempotencia vencida: %w", err)
+	}
+
+	result := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}, {Name: "route"}},
+		DoNothing: true,
+	}).Create(record)
+	if result.Error != nil {
+		return nil, false, fmt.Errorf("error reservando clave de idempotencia: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		return record, true, nil
+	}
+
+	var existing models.IdempotencyKey
+	if err := db.Where("key = ? AND route = ?", record.Key, record.Route).First(&existing).Error; err != nil {
+		return nil, false, fmt.Errorf("error buscando clave de idempotencia: %w", err)
+	}
+	return &existing, false, nil
+}
+
+// Complete guarda la respuesta que se reproducirá en los reintentos
+func (s *idempotencyStore) Complete(ctx context.Context, id, statusCode int, contentType string, body []byte) error {
+	err := s.db.WithContext(ctx).Model(&models.IdempotencyKey{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":        models.IdempotencyCompleted,
+		"status_code":   statusCode,
+		"content_type":  contentType,
+		"response_body": body,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("error guardando respuesta idempotente: %w", err)
+	}
+	return nil
+}
+
+// Release elimina la reserva para que el cliente pueda reintentar con la misma clave
+func (s *idempotencyStore) Release(ctx context.Context, id int) error {
+	if err := s.db.WithContext(ctx).Delete(&models.IdempotencyKey{}, id).Error; err != nil {
+		return fmt.Errorf("error liberando clave de idempotencia: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired purga las claves vencidas
+func (s *idempotencyStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&models.IdempotencyKey{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("error purgando claves de idempotencia vencidas: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
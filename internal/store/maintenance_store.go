@@ -0,0 +1,171 @@
+package store
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MaintenancePlanFilter filtra el listado de planes de mantenimiento
+type MaintenancePlanFilter struct {
+	NroCta     *string
+	ActiveOnly bool
+}
+
+type MaintenanceStore interface {
+	List(ctx context.Context, filter MaintenancePlanFilter) ([]models.MaintenancePlan, error)
+	FindByID(ctx context.Context, id int) (*models.MaintenancePlan, error)
+	FindByTarget(ctx context.Context, nroCta, serial string) (*models.MaintenancePlan, error)
+	Create(ctx context.Context, plan *models.MaintenancePlan) error
+	Update(ctx context.Context, plan *models.MaintenancePlan) error
+	Delete(ctx context.Context, id int) error
+	LastServiceAt(ctx context.Context, plan *models.MaintenancePlan) (*time.Time, error)
+	HasOpenService(ctx context.Context, nroCta string) (bool, error)
+	FindLatestDelivery(ctx context.Context, nroCta string) (*models.Delivery, error)
+	CountOpenOnRoute(ctx context.Context, nroRto string, fecha time.Time) (int64, error)
+}
+
+type maintenanceStore struct {
+	db *gorm.DB
+}
+
+func NewMaintenanceStore(db *gorm.DB) MaintenanceStore {
+	return &maintenanceStore{db: db}
+}
+
+func (s *maintenanceStore) List(ctx context.Context, filter MaintenancePlanFilter) ([]models.MaintenancePlan, error) {
+	query := s.db.WithContext(ctx).Model(&models.MaintenancePlan{})
+	if filter.NroCta != nil {
+		query = query.Where("nro_cta = ?", *filter.NroCta)
+	}
+	if filter.ActiveOnly {
+		query = query.Where("active = ?", true)
+	}
+
+	var plans []models.MaintenancePlan
+	if err := query.Order("nro_cta ASC, serial ASC").Find(&plans).Error; err != nil {
+		return nil, fmt.Errorf("error listando planes de mantenimiento: %w", err)
+	}
+	return plans, nil
+}
+
+func (s *maintenanceStore) FindByID(ctx context.Context, id int) (*models.MaintenancePlan, error) {
+	var plan models.MaintenancePlan
+	if err := s.db.WithContext(ctx).First(&plan, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf(constants.ErrMaintenancePlanNotFound)
+		}
+		return nil, fmt.Errorf("error buscando plan de mantenimiento %d: %w", id, err)
+	}
+	return &plan, nil
+}
+
+// FindByTarget devuelve nil sin error si la cuenta (o el dispenser) no tiene plan
+func (s *maintenanceStore) FindByTarget(ctx context.Context, nroCta, serial string) (*models.MaintenancePlan, error) {
+	var plan models.MaintenancePlan
+	if err := s.db.WithContext(ctx).Where("nro_cta = ? AND serial = ?", nroCta, serial).First(&plan).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error buscando plan de mantenimiento de la cuenta %s: %w", nroCta, err)
+	}
+	return &plan, nil
+}
+
+func (s *maintenanceStore) Create(ctx context.Context, plan *models.MaintenancePlan) error {
+	if err := s.db.WithContext(ctx).Create(plan).Error; err != nil {
+		return fmt.Errorf("error creando plan de mantenimiento: %w", err)
+	}
+	return nil
+}
+
+func (s *maintenanceStore) Update(ctx context.Context, plan *models.MaintenancePlan) error {
+	if err := s.db.WithContext(ctx).Save(plan).Error; err != nil {
+		return fmt.Errorf("error actualizando plan de mantenimiento %d: %w", plan.ID, err)
+	}
+	return nil
+}
+
+func (s *maintenanceStore) Delete(ctx context.Context, id int) error {
+	result := s.db.WithContext(ctx).Delete(&models.MaintenancePlan{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("error eliminando plan de mantenimiento %d: %w", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf(constants.ErrMaintenancePlanNotFound)
+	}
+	return nil
+}
+
+// LastServiceAt devuelve la fecha del último service o instalación completados. Para un plan de
+// cuenta se toman las entregas completadas; para un dispenser, los movimientos del registro que lo
+// dejaron instalado o atendido en la cuenta (que también salen de entregas completadas). Devuelve
+// nil si no hay ninguno.
+func (s *maintenanceStore) LastServiceAt(ctx context.Context, plan *models.MaintenancePlan) (*time.Time, error) {
+	var last sql.NullTime
+	var err error
+	if plan.Serial == "" {
+		err = s.db.WithContext(ctx).Model(&models.Delivery{}).
+			Select("MAX(completed_at)").
+			Where("nro_cta = ? AND estado = ? AND tipo_entrega IN ?", plan.NroCta, models.Completado,
+				[]models.TipoEntrega{models.Service, models.Instalacion}).
+			Scan(&last).Error
+	} else {
+		err = s.db.WithContext(ctx).Model(&models.DispenserMovement{}).
+			Select("MAX(occurred_at)").
+			Where("serial = ? AND operation IN ? AND to_location_type = ? AND to_location_id = ?",
+				plan.Serial, []string{"installation", "service"}, models.UbicacionCuenta, plan.NroCta).
+			Scan(&last).Error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error buscando último service de la cuenta %s: %w", plan.NroCta, err)
+	}
+	if !last.Valid {
+		return nil, nil
+	}
+	return &last.Time, nil
+}
+
+// HasOpenService indica si la cuenta ya tiene una entrega de Service sin cerrar
+func (s *maintenanceStore) HasOpenService(ctx context.Context, nroCta string) (bool, error) {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.Delivery{}).
+		Where("nro_cta = ? AND tipo_entrega = ? AND estado IN ?", nroCta, models.Service, models.EstadosAbiertos).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("error buscando services abiertos de la cuenta %s: %w", nroCta, err)
+	}
+	return count > 0, nil
+}
+
+// FindLatestDelivery devuelve la entrega más reciente de la cuenta, de la que se copian los datos
+// del cliente y el reparto. Devuelve nil sin error si la cuenta no tiene entregas.
+func (s *maintenanceStore) FindLatestDelivery(ctx context.Context, nroCta string) (*models.Delivery, error) {
+	var delivery models.Delivery
+	if err := s.db.WithContext(ctx).
+		Where("nro_cta = ?", nroCta).
+		Order("fecha_accion DESC, id DESC").
+		First(&delivery).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error buscando última entrega de la cuenta %s: %w", nroCta, err)
+	}
+	return &delivery, nil
+}
+
+// CountOpenOnRoute cuenta las entregas abiertas del reparto para el día
+func (s *maintenanceStore) CountOpenOnRoute(ctx context.Context, nroRto string, fecha time.Time) (int64, error) {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.Delivery{}).
+		Where("nro_rto = ? AND estado IN ?", nroRto, models.EstadosAbiertos).
+		Where("(fecha_accion AT TIME ZONE 'UTC')::date = ?::date", fecha.Format("2006-01-02")).
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("error contando entregas del reparto %s: %w", nroRto, err)
+	}
+	return count, nil
+}
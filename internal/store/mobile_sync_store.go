@@ -0,0 +1,76 @@
+package store
+
+import (
+	"GoFrioCalor/internal/models"
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MobileSyncStore interface {
+	Reserve(ctx context.Context, action *models.MobileSyncAction, staleBefore time.Time) (*models.MobileSyncAction, bool, error)
+	Complete(ctx context.Context, action *models.MobileSyncAction) error
+	Release(ctx context.Context, id int) error
+}
+
+type mobileSyncStore struct {
+	db *gorm.DB
+}
+
+func NewMobileSyncStore(db *gorm.DB) MobileSyncStore {
+	return &mobileSyncStore{db: db}
+}
+
+// Reserve registra la acción como pendiente antes de aplicarla. Si el ámbito (dispositivo o
+// personal) ya tiene registrado el mismo client_action_id devuelve ese registro y false. Una
+// reserva pendiente desde antes de staleBefore (la sincronización que la tomó no terminó) se
+// descarta y se vuelve a reservar.
+func (s *mobileSyncStore) Reserve(ctx context.Context, action *models.MobileSyncAction, staleBefore time.Time) (*models.MobileSyncAction, bool, error) {
+	db := s.db.WithContext(ctx)
+	if err := db.Where("scope = ? AND client_action_id = ? AND status = ? AND created_at <= ?", action.Scope, action.ClientActionID, models.SyncPending, staleBefore).
+		Delete(&models.MobileSyncAction{}).Error; err != nil {
+		return nil, false, fmt.Errorf("error liberando acción de sincronización pendiente %s: %w", action.ClientActionID, err)
+	}
+
+	result := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "scope"}, {Name: "client_action_id"}},
+		DoNothing: true,
+	}).Create(action)
+	if result.Error != nil {
+		return nil, false, fmt.Errorf("error registrando acción de sincronización %s: %w", action.ClientActionID, result.Error)
+	}
+	if result.RowsAffected > 0 {
+		return action, true, nil
+	}
+
+	var existing models.MobileSyncAction
+	if err := db.Where("scope = ? AND client_action_id = ?", action.Scope, action.ClientActionID).First(&existing).Error; err != nil {
+		return nil, false, fmt.Errorf("error buscando acción de sincronización %s: %w", action.ClientActionID, err)
+	}
+	return &existing, false, nil
+}
+
+// Complete guarda el resultado de la acción reservada, que se devuelve en los reenvíos
+func (s *mobileSyncStore) Complete(ctx context.Context, action *models.MobileSyncAction) error {
+	err := s.db.WithContext(ctx).Model(&models.MobileSyncAction{}).Where("id = ?", action.ID).Updates(map[string]interface{}{
+		"status":      action.Status,
+		"error":       action.Error,
+		"delivery_id": action.DeliveryID,
+		"result":      action.Result,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("error registrando resultado de la acción de sincronización %s: %w", action.ClientActionID, err)
+	}
+	return nil
+}
+
+// Release elimina la reserva para que el dispositivo pueda reintentar la acción
+func (s *mobileSyncStore) Release(ctx context.Context, id int) error {
+	if err := s.db.WithContext(ctx).Delete(&models.MobileSyncAction{}, id).Error; err != nil {
+		return fmt.Errorf("error liberando acción de sincronización: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package store
+
+import (
+	"GoFrioCalor/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type PreparationStore interface {
+	FindByDeliveryID(ctx context.Context, deliveryID int) (*models.DeliveryPreparation, error)
+	FindByDeliveryIDs(ctx context.Context, deliveryIDs []int) ([]models.DeliveryPreparation, error)
+	FindOpenBySerial(ctx context.Context, serial string, excludeDeliveryID int) (*models.DeliveryPreparation, error)
+	Save(ctx context.Context, preparation *models.DeliveryPreparation) error
+}
+
+type preparationStore struct {
+	db *gorm.DB
+}
+
+func NewPreparationStore(db *gorm.DB) PreparationStore {
+	return &preparationStore{db: db}
+}
+
+// FindByDeliveryID devuelve nil sin error si la entrega todavía no tiene preparación
+func (s *preparationStore) FindByDeliveryID(ctx context.Context, deliveryID int) (*models.DeliveryPreparation, error) {
+	var preparation models.DeliveryPreparation
+	if err := s.db.WithContext(ctx).Where("delivery_id = ?", deliveryID).First(&preparation).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error buscando preparación de la entrega %d: %w", deliveryID, err)
+	}
+	return &preparation, nil
+}
+
+func (s *preparationStore) FindByDeliveryIDs(ctx context.Context, deliveryIDs []int) ([]models.DeliveryPreparation, error) {
+	var preparations []models.DeliveryPreparation
+	if len(deliveryIDs) == 0 {
+		return preparations, nil
+	}
+	if err := s.db.WithContext(ctx).Where("delivery_id IN ?", deliveryIDs).Find(&preparations).Error; err != nil {
+		return nil, fmt.Errorf("error buscando preparaciones de entregas: %w", err)
+	}
+	return preparations, nil
+}
+
+// FindOpenBySerial busca otra entrega abierta que ya tenga apartado el número de serie. Devuelve nil
+// sin error si no hay ninguna.
+func (s *preparationStore) FindOpenBySerial(ctx context.Context, serial string, excludeDeliveryID int) (*models.DeliveryPreparation, error) {
+	contains, err := json.Marshal([]string{serial})
+	if err != nil {
+		return nil, err
+	}
+	var preparation models.DeliveryPreparation
+	err = s.db.WithContext(ctx).
+		Joins("JOIN deliveries ON deliveries.id = delivery_preparations.delivery_id AND deliveries.deleted_at IS NULL").
+		Where("deliveries.estado IN ? AND delivery_preparations.delivery_id <> ?", models.EstadosAbiertos, excludeDeliveryID).
+		Where("delivery_preparations.serials @> ?::jsonb", string(contains)).
+		First(&preparation).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error buscando preparación del dispenser %s: %w", serial, err)
+	}
+	return &preparation, nil
+}
+
+func (s *preparationStore) Save(ctx context.Context, preparation *models.DeliveryPreparation) error {
+	if err := s.db.WithContext(ctx).Save(preparation).Error; err != nil {
+		return fmt.Errorf("error guardando preparación de la entrega %d: %w", preparation.DeliveryID, err)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package store
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/models"
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RouteBooking es la cantidad de entregas de un tipo que ocupan un día del reparto
+type RouteBooking struct {
+	Fecha       time.Time
+	TipoEntrega models.TipoEntrega
+	Count       int
+}
+
+type RouteCapacityStore interface {
+	FindAll(ctx context.Context, nroRto string) ([]models.RouteCapacity, error)
+	FindByID(ctx context.Context, id int) (*models.RouteCapacity, error)
+	FindRule(ctx context.Context, nroRto string, weekday time.Weekday, tipo models.TipoEntrega) (*models.RouteCapacity, error)
+	Create(ctx context.Context, capacity *models.RouteCapacity) error
+	Update(ctx context.Context, capacity *models.RouteCapacity) error
+	Delete(ctx context.Context, id int) error
+	CountBooked(ctx context.Context, nroRto string, from, to time.Time) ([]RouteBooking, error)
+	WithTx(tx *gorm.DB) RouteCapacityStore
+}
+
+type routeCapacityStore struct {
+	db *gorm.DB
+}
+
+func NewRouteCapacityStore(db *gorm.DB) RouteCapacityStore {
+	return &routeCapacityStore{db: db}
+}
+
+// WithTx devuelve el store sobre la transacción tx, para leer dentro de un BookingGuard
+func (s *routeCapacityStore) WithTx(tx *gorm.DB) RouteCapacityStore {
+	return &routeCapacityStore{db: tx}
+}
+
+// FindAll devuelve las reglas de capacidad, de un reparto si nroRto no es vacío, ordenadas por
+// reparto, día de la semana y tipo
+func (s *routeCapacityStore) FindAll(ctx context.Context, nroRto string) ([]models.RouteCapacity, error) {
+	var capacities []models.RouteCapacity
+	query := s.db.WithContext(ctx).Order("nro_rto ASC, weekday ASC, tipo_entrega ASC")
+	if nroRto != "" {
+		query = query.Where("nro_rto = ?", nroRto)
+	}
+	if err := query.Find(&capacities).Error; err != nil {
+		return nil, fmt.Errorf("error listando capacidades de reparto: %w", err)
+	}
+	return capacities, nil
+}
+
+func (s *routeCapacityStore) FindByID(ctx context.Context, id int) (*models.RouteCapacity, error) {
+	var capacity models.RouteCapacity
+	if err := s.db.WithContext(ctx).First(&capacity, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf(constants.ErrRouteCapacityNotFound)
+		}
+		return nil, fmt.Errorf("error buscando capacidad de reparto %d: %w", id, err)
+	}
+	return &capacity, nil
+}
+
+// FindRule devuelve nil sin error si el reparto no tiene regla para ese día y tipo
+func (s *routeCapacityStore) FindRule(ctx context.Context, nroRto string, weekday time.Weekday, tipo models.TipoEntrega) (*models.RouteCapacity, error) {
+	var capacity models.RouteCapacity
+	if err := s.db.WithContext(ctx).
+		Where("nro_rto = ? AND weekday = ? AND tipo_entrega = ?", nroRto, weekday, tipo).
+		First(&capacity).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error buscando capacidad del reparto %s: %w", nroRto, err)
+	}
+	return &capacity, nil
+}
+
+func (s *routeCapacityStore) Create(ctx context.Context, capacity *models.RouteCapacity) error {
+	if err := s.db.WithContext(ctx).Create(capacity).Error; err != nil {
+		return fmt.Errorf("error creando capacidad de reparto: %w", err)
+	}
+	return nil
+}
+
+func (s *routeCapacityStore) Update(ctx context.Context, capacity *models.RouteCapacity) error {
+	if err := s.db.WithContext(ctx).Save(capacity).Error; err != nil {
+		return fmt.Errorf("error actualizando capacidad de reparto %d: %w", capacity.ID, err)
+	}
+	return nil
+}
+
+func (s *routeCapacityStore) Delete(ctx context.Context, id int) error {
+	result := s.db.WithContext(ctx).Delete(&models.RouteCapacity{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("error eliminando capacidad de reparto %d: %w", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf(constants.ErrRouteCapacityNotFound)
+	}
+	return nil
+}
+
+// CountBooked cuenta, por día (UTC) y tipo de entrega, las entregas del reparto que ocupan lugar
+// entre from y to inclusive (ver models.EstadosOcupanCapacidad)
+func (s *routeCapacityStore) CountBooked(ctx context.Context, nroRto string, from, to time.Time) ([]RouteBooking, error) {
+	var bookings []RouteBooking
+	if err := s.db.WithContext(ctx).Model(&models.Delivery{}).
+		Select("(fecha_accion AT TIME ZONE 'UTC')::date AS fecha, tipo_entrega, COUNT(*) AS count").
+		Where("nro_rto = ? AND estado IN ?", nroRto, models.EstadosOcupanCapacidad).
+		Where("(fecha_accion AT TIME ZONE 'UTC')::date BETWEEN ?::date AND ?::date", from.Format("2006-01-02"), to.Format("2006-01-02")).
+		Group("1, 2").
+		Scan(&bookings).Error; err != nil {
+		return nil, fmt.Errorf("error contando entregas del reparto %s: %w", nroRto, err)
+	}
+	return bookings, nil
+}
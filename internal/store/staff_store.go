@@ -0,0 +1,195 @@
+package store
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/models"
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StaffFilter filtra el listado de repartidores y técnicos
+type StaffFilter struct {
+	Role       *models.EntregadoPor
+	NroRto     *string
+	ActiveOnly bool
+}
+
+type StaffStore interface {
+	List(ctx context.Context, filter StaffFilter) ([]models.Staff, error)
+	FindByID(ctx context.Context, id int) (*models.Staff, error)
+	FindByLegajo(ctx context.Context, legajo string) (*models.Staff, error)
+	Create(ctx context.Context, staff *models.Staff) error
+	Update(ctx context.Context, staff *models.Staff) error
+	RegisterFailedPinAttempt(ctx context.Context, id, maxAttempts int) (*models.Staff, error)
+	ResetPinAttempts(ctx context.Context, id int) error
+	CreateSession(ctx context.Context, session *models.StaffSession) error
+	FindSession(ctx context.Context, tokenHash string, now time.Time) (*models.StaffSession, error)
+	DeleteSession(ctx context.Context, tokenHash string) error
+	DeleteExpiredSessions(ctx context.Context, now time.Time) (int64, error)
+	FindCompletedDeliveries(ctx context.Context, staffID int, from, to time.Time) ([]models.Delivery, error)
+}
+
+type staffStore struct {
+	db *gorm.DB
+}
+
+func NewStaffStore(db *gorm.DB) StaffStore {
+	return &staffStore{db: db}
+}
+
+func (s *staffStore) List(ctx context.Context, filter StaffFilter) ([]models.Staff, error) {
+	query := s.db.WithContext(ctx).Model(&models.Staff{})
+	if filter.Role != nil {
+		query = query.Where("role = ?", *filter.Role)
+	}
+	if filter.NroRto != nil {
+		query = query.Where("nro_rto = ?", *filter.NroRto)
+	}
+	if filter.ActiveOnly {
+		query = query.Where("active = ?", true)
+	}
+
+	var staff []models.Staff
+	if err := query.Order("name ASC").Find(&staff).Error; err != nil {
+		return nil, fmt.Errorf("error listando personal: %w", err)
+	}
+	return staff, nil
+}
+
+func (s *staffStore) FindByID(ctx context.Context, id int) (*models.Staff, error) {
+	var staff models.Staff
+	if err := s.db.WithContext(ctx).First(&staff, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf(constants.ErrStaffNotFound)
+		}
+		return nil, fmt.Errorf("error buscando personal %d: %w", id, err)
+	}
+	return &staff, nil
+}
+
+// FindByLegajo devuelve nil sin error si no existe personal con ese legajo
+func (s *staffStore) FindByLegajo(ctx context.Context, legajo string) (*models.Staff, error) {
+	var staff models.Staff
+	if err := s.db.WithContext(ctx).Where("legajo = ?", legajo).First(&staff).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error buscando personal con legajo %s: %w", legajo, err)
+	}
+	return &staff, nil
+}
+
+func (s *staffStore) Create(ctx context.Context, staff *models.Staff) error {
+	if err := s.db.WithContext(ctx).Create(staff).Error; err != nil {
+		return fmt.Errorf("error creando personal: %w", err)
+	}
+	return nil
+}
+
+func (s *staffStore) Update(ctx context.Context, staff *models.Staff) error {
+	if err := s.db.WithContext(ctx).Save(staff).Error; err != nil {
+		return fmt.Errorf("error actualizando personal %d: %w", staff.ID, err)
+	}
+	return nil
+}
+
+// RegisterFailedPinAttempt suma un PIN incorrecto de forma atómica y bloquea el login al llegar a
+// maxAttempts
+func (s *staffStore) RegisterFailedPinAttempt(ctx context.Context, id, maxAttempts int) (*models.Staff, error) {
+	var staff models.Staff
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&staff, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf(constants.ErrStaffNotFound)
+			}
+			return fmt.Errorf("error buscando personal %d: %w", id, err)
+		}
+		if staff.PinLockedAt != nil {
+			return nil
+		}
+
+		staff.PinFailedAttempts++
+		if staff.PinFailedAttempts >= maxAttempts {
+			now := time.Now()
+			staff.PinLockedAt = &now
+		}
+		if err := tx.Model(&staff).UpdateColumns(map[string]interface{}{
+			"pin_failed_attempts": staff.PinFailedAttempts,
+			"pin_locked_at":       staff.PinLockedAt,
+		}).Error; err != nil {
+			return fmt.Errorf("error actualizando personal %d: %w", id, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &staff, nil
+}
+
+// ResetPinAttempts vuelve a cero el contador de PIN incorrectos luego de un login exitoso
+func (s *staffStore) ResetPinAttempts(ctx context.Context, id int) error {
+	err := s.db.WithContext(ctx).Model(&models.Staff{}).
+		Where("id = ? AND pin_locked_at IS NULL", id).
+		UpdateColumn("pin_failed_attempts", 0).Error
+	if err != nil {
+		return fmt.Errorf("error actualizando personal %d: %w", id, err)
+	}
+	return nil
+}
+
+func (s *staffStore) CreateSession(ctx context.Context, session *models.StaffSession) error {
+	if err := s.db.WithContext(ctx).Create(session).Error; err != nil {
+		return fmt.Errorf("error creando sesión de personal: %w", err)
+	}
+	return nil
+}
+
+// FindSession devuelve la sesión vigente con ese hash de token, o nil si no existe o venció
+func (s *staffStore) FindSession(ctx context.Context, tokenHash string, now time.Time) (*models.StaffSession, error) {
+	var session models.StaffSession
+	err := s.db.WithContext(ctx).
+		Where("token_hash = ? AND expires_at > ?", tokenHash, now).
+		First(&session).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error buscando sesión de personal: %w", err)
+	}
+	return &session, nil
+}
+
+func (s *staffStore) DeleteSession(ctx context.Context, tokenHash string) error {
+	if err := s.db.WithContext(ctx).Where("token_hash = ?", tokenHash).Delete(&models.StaffSession{}).Error; err != nil {
+		return fmt.Errorf("error cerrando sesión de personal: %w", err)
+	}
+	return nil
+}
+
+func (s *staffStore) DeleteExpiredSessions(ctx context.Context, now time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&models.StaffSession{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("error eliminando sesiones de personal vencidas: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// FindCompletedDeliveries devuelve las entregas que el repartidor o técnico completó en el
+// intervalo [from, to), ordenadas por hora de cierre
+func (s *staffStore) FindCompletedDeliveries(ctx context.Context, staffID int, from, to time.Time) ([]models.Delivery, error) {
+	var deliveries []models.Delivery
+	err := s.db.WithContext(ctx).
+		Preload("ItemDispensers").
+		Where("completed_by = ? AND completed_at >= ? AND completed_at < ?", staffID, from, to).
+		Order("completed_at ASC").
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, fmt.Errorf("error buscando entregas del personal %d: %w", staffID, err)
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,486 @@
+package store
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/models"
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StockMovementFilter filtra el libro de stock de un depósito; los campos nil no filtran
+type StockMovementFilter struct {
+	WarehouseID int
+	Tipo        *models.TipoDispenser
+	Kind        *models.StockMovementKind
+	DeliveryID  *int
+	From        *time.Time
+	To          *time.Time
+}
+
+// StockDelta es la suma de cantidades de un tipo de dispenser en un día
+type StockDelta struct {
+	Tipo     models.TipoDispenser
+	Date     time.Time
+	Quantity int
+}
+
+type StockStore interface {
+	ListWarehouses(ctx context.Context) ([]models.Warehouse, error)
+	FindWarehouseByID(ctx context.Context, id int) (*models.Warehouse, error)
+	FindWarehouseByCode(ctx context.Context, code string) (*models.Warehouse, error)
+	FindWarehouseForRoute(ctx context.Context, nroRto string) (*models.Warehouse, error)
+	CreateWarehouse(ctx context.Context, warehouse *models.Warehouse) error
+	UpdateWarehouse(ctx context.Context, warehouse *models.Warehouse) error
+	RecordMovement(ctx context.Context, movement *models.StockMovement) error
+	FindMovements(ctx context.Context, filter StockMovementFilter, limit, offset int) ([]models.StockMovement, error)
+	CountMovements(ctx context.Context, filter StockMovementFilter) (int64, error)
+	FindSupply(ctx context.Context, warehouseID int) ([]StockDelta, error)
+	FindReserved(ctx context.Context, warehouseID, excludeDeliveryID int) ([]StockDelta, error)
+	FindReservations(ctx context.Context, deliveryID int) ([]models.StockReservation, error)
+	WithTx(tx *gorm.DB) StockStore
+}
+
+type stockStore struct {
+	db *gorm.DB
+}
+
+func NewStockStore(db *gorm.DB) StockStore {
+	return &stockStore{db: db}
+}
+
+// WithTx devuelve el store sobre la transacción tx, para leer dentro de un BookingGuard
+func (s *stockStore) WithTx(tx *gorm.DB) StockStore {
+	return &stockStore{db: tx}
+}
+
+func (s *stockStore) ListWarehouses(ctx context.Context) ([]models.Warehouse, error) {
+	var warehouses []models.Warehouse
+	if err := s.db.WithContext(ctx).Order("code ASC").Find(&warehouses).Error; err != nil {
+		return nil, fmt.Errorf("error listando depósitos: %w", err)
+	}
+	return warehouses, nil
+}
+
+func (s *stockStore) FindWarehouseByID(ctx context.Context, id int) (*models.Warehouse, error) {
+	var warehouse models.Warehouse
+	if err := s.db.WithContext(ctx).First(&warehouse, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf(constants.ErrWarehouseNotFound)
+		}
+		return nil, fmt.Errorf("error buscando depósito %d: %w", id, err)
+	}
+	return &warehouse, nil
+}
+
+// FindWarehouseByCode devuelve nil sin error si no hay un depósito con ese código
+func (s *stockStore) FindWarehouseByCode(ctx context.Context, code string) (*models.Warehouse, error) {
+	var warehouse models.Warehouse
+	if err := s.db.WithContext(ctx).Where("code = ?", code).First(&warehouse).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error buscando depósito %s: %w", code, err)
+	}
+	return &warehouse, nil
+}
+
+// FindWarehouseForRoute devuelve el depósito activo que abastece al reparto o, si ninguno lo tiene
+// asignado, el depósito por defecto. Devuelve nil sin error si no hay depósito: el reparto no
+// tiene control de stock.
+func (s *stockStore) FindWarehouseForRoute(ctx context.Context, nroRto string) (*models.Warehouse, error) {
+	return warehouseForRoute(s.db.WithContext(ctx), nroRto)
+}
+
+func warehouseForRoute(db *gorm.DB, nroRto string) (*models.Warehouse, error) {
+	var warehouse models.Warehouse
+	err := db.
+		Where("active = ? AND (routes @> jsonb_build_array(?::text) OR is_default = ?)", true, nroRto, true).
+		Order("is_default ASC, id ASC").
+		Take(&warehouse).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error buscando depósito del reparto %s: %w", nroRto, err)
+	}
+	return &warehouse, nil
+}
+
+// CreateWarehouse da de alta el depósito; si es el depósito por defecto deja de serlo el anterior
+func (s *stockStore) CreateWarehouse(ctx context.Context, warehouse *models.Warehouse) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := clearDefaultWarehouse(tx, warehouse); err != nil {
+			return err
+		}
+		if err := tx.Create(warehouse).Error; err != nil {
+			return fmt.Errorf("error creando depósito: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *stockStore) UpdateWarehouse(ctx context.Context, warehouse *models.Warehouse) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := clearDefaultWarehouse(tx, warehouse); err != nil {
+			return err
+		}
+		if err := tx.Save(warehouse).Error; err != nil {
+			return fmt.Errorf("error actualizando depósito %d: %w", warehouse.ID, err)
+		}
+		return nil
+	})
+}
+
+func clearDefaultWarehouse(tx *gorm.DB, warehouse *models.Warehouse) error {
+	if !warehouse.IsDefault {
+		return nil
+	}
+	if err := tx.Model(&models.Warehouse{}).
+		Where("is_default = ? AND id <> ?", true, warehouse.ID).
+		Update("is_default", false).Error; err != nil {
+		return fmt.Errorf("error actualizando depósito por defecto: %w", err)
+	}
+	return nil
+}
+
+func (s *stockStore) RecordMovement(ctx context.Context, movement *models.StockMovement) error {
+	if err := s.db.WithContext(ctx).Create(movement).Error; err != nil {
+		return fmt.Errorf("error registrando movimiento de stock: %w", err)
+	}
+	return nil
+}
+
+// FindMovements devuelve el libro de stock del más reciente al más antiguo
+func (s *stockStore) FindMovements(ctx context.Context, filter StockMovementFilter, limit, offset int) ([]models.StockMovement, error) {
+	var movements []models.StockMovement
+	if err := s.movements(ctx, filter).
+		Order("created_at DESC, id DESC").
+		Offset(offset).Limit(limit).
+		Find(&movements).Error; err != nil {
+		return nil, fmt.Errorf("error buscando movimientos de stock del depósito %d: %w", filter.WarehouseID, err)
+	}
+	return movements, nil
+}
+
+func (s *stockStore) CountMovements(ctx context.Context, filter StockMovementFilter) (int64, error) {
+	var count int64
+	if err := s.movements(ctx, filter).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("error contando movimientos de stock del depósito %d: %w", filter.WarehouseID, err)
+	}
+	return count, nil
+}
+
+func (s *stockStore) movements(ctx context.Context, filter StockMovementFilter) *gorm.DB {
+	query := s.db.WithContext(ctx).Model(&models.StockMovement{}).Where("warehouse_id = ?", filter.WarehouseID)
+	if filter.Tipo != nil {
+		query = query.Where("tipo = ?", *filter.Tipo)
+	}
+	if filter.Kind != nil {
+		query = query.Where("kind = ?", *filter.Kind)
+	}
+	if filter.DeliveryID != nil {
+		query = query.Where("delivery_id = ?", *filter.DeliveryID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	return query
+}
+
+// FindSupply suma por tipo y día los movimientos que cambian la existencia del depósito, incluidos
+// los ingresos programados con fecha futura
+func (s *stockStore) FindSupply(ctx context.Context, warehouseID int) ([]StockDelta, error) {
+	var deltas []StockDelta
+	if err := s.db.WithContext(ctx).Model(&models.StockMovement{}).
+		Select("tipo, effective_date AS date, SUM(quantity) AS quantity").
+		Where("warehouse_id = ? AND kind IN ?", warehouseID, models.OnHandKinds).
+		Group("tipo, effective_date").
+		Order("effective_date ASC").
+		Scan(&deltas).Error; err != nil {
+		return nil, fmt.Errorf("error calculando existencia del depósito %d: %w", warehouseID, err)
+	}
+	return deltas, nil
+}
+
+// FindReserved suma por tipo y fecha_accion las reservas activas del depósito, salvo las de la
+// entrega excludeDeliveryID (0: ninguna), que se está reprogramando
+func (s *stockStore) FindReserved(ctx context.Context, warehouseID, excludeDeliveryID int) ([]StockDelta, error) {
+	var deltas []StockDelta
+	query := s.db.WithContext(ctx).Model(&models.StockReservation{}).
+		Select("tipo, fecha_accion AS date, SUM(cantidad) AS quantity").
+		Where("warehouse_id = ? AND status = ?", warehouseID, models.ReservaActiva)
+	if excludeDeliveryID > 0 {
+		query = query.Where("delivery_id <> ?", excludeDeliveryID)
+	}
+	if err := query.
+		Group("tipo, fecha_accion").
+		Order("fecha_accion ASC").
+		Scan(&deltas).Error; err != nil {
+		return nil, fmt.Errorf("error calculando reservas del depósito %d: %w", warehouseID, err)
+	}
+	return deltas, nil
+}
+
+func (s *stockStore) FindReservations(ctx context.Context, deliveryID int) ([]models.StockReservation, error) {
+	var reservations []models.StockReservation
+	if err := s.db.WithContext(ctx).Where("delivery_id = ?", deliveryID).Order("tipo ASC").Find(&reservations).Error; err != nil {
+		return nil, fmt.Errorf("error buscando reservas de stock de la entrega %d: %w", deliveryID, err)
+	}
+	return reservations, nil
+}
+
+// StockDay normaliza t al día (UTC) con el que se registran movimientos y reservas
+func StockDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// syncStockReservations ajusta, dentro de tx, las reservas de stock de la entrega a su estado actual:
+// una entrega abierta que instala equipos reserva sus items en el depósito del reparto; al dejar
+// los estados abiertos (cancelada, fallida, eliminada) la reserva se libera y al completarse se
+// consume lo entregado. Cada cambio queda en el libro de stock. Si el reparto no tiene depósito
+// no hay control de stock.
+func syncStockReservations(tx *gorm.DB, deliveryID int, actor string) error {
+	var delivery models.Delivery
+	if err := tx.Unscoped().First(&delivery, deliveryID).Error; err != nil {
+		return fmt.Errorf(constants.ErrStockSync, deliveryID, err)
+	}
+	var reservations []models.StockReservation
+	if err := tx.Where("delivery_id = ?", deliveryID).Order("tipo ASC").Find(&reservations).Error; err != nil {
+		return fmt.Errorf(constants.ErrStockSync, deliveryID, err)
+	}
+	ledger := &stockLedger{tx: tx, deliveryID: deliveryID, actor: actor, today: StockDay(time.Now())}
+
+	open := false
+	for _, estado := range models.EstadosAbiertos {
+		if delivery.Estado == estado {
+			open = true
+		}
+	}
+	switch {
+	case delivery.DeletedAt.Valid:
+		return ledger.releaseAll(reservations, "entrega eliminada")
+	case delivery.Estado == models.Completado:
+		return ledger.consume(&delivery, reservations)
+	case !open:
+		return ledger.releaseAll(reservations, fmt.Sprintf("entrega %s", delivery.Estado))
+	case !delivery.TipoEntrega.ConsumesStock():
+		return ledger.releaseAll(reservations, fmt.Sprintf("entrega de tipo %s", delivery.TipoEntrega))
+	}
+
+	items, err := deliveryItemsByTipo(tx, deliveryID)
+	if err != nil {
+		return err
+	}
+	warehouse, err := warehouseForRoute(tx, delivery.NroRto)
+	if err != nil {
+		return err
+	}
+	fecha := StockDay(delivery.FechaAccion.Time)
+
+	byTipo := make(map[models.TipoDispenser]*models.StockReservation, len(reservations))
+	for i := range reservations {
+		reservation := &reservations[i]
+		byTipo[reservation.Tipo] = reservation
+		if reservation.Status != models.ReservaActiva {
+			continue
+		}
+		if warehouse == nil || reservation.WarehouseID != warehouse.ID || items[reservation.Tipo] == 0 {
+			if err := ledger.release(reservation, "cambio de reparto o de dispensers"); err != nil {
+				return err
+			}
+		}
+	}
+	if warehouse == nil {
+		return nil
+	}
+
+	for _, tipo := range []models.TipoDispenser{models.TipoDispenserPie, models.TipoDispenserMesada} {
+		cantidad := items[tipo]
+		if cantidad == 0 {
+			continue
+		}
+		reservation := byTipo[tipo]
+		switch {
+		case reservation == nil:
+			reservation = &models.StockReservation{DeliveryID: deliveryID, Tipo: tipo}
+			fallthrough
+		case reservation.Status != models.ReservaActiva:
+			reservation.WarehouseID = warehouse.ID
+			reservation.Cantidad = cantidad
+			reservation.Status = models.ReservaActiva
+			if err := ledger.record(warehouse.ID, tipo, models.StockReserva, int(cantidad), "reserva para la entrega"); err != nil {
+				return err
+			}
+		case reservation.Cantidad != cantidad:
+			delta := int(cantidad) - int(reservation.Cantidad)
+			kind := models.StockReserva
+			if delta < 0 {
+				kind = models.StockLiberacion
+			}
+			if err := ledger.record(warehouse.ID, tipo, kind, delta, "cambio de cantidad de la entrega"); err != nil {
+				return err
+			}
+			reservation.Cantidad = cantidad
+		case reservation.FechaAccion.Equal(fecha):
+			continue
+		}
+		reservation.FechaAccion = fecha
+		if err := tx.Save(reservation).Error; err != nil {
+			return fmt.Errorf(constants.ErrStockSync, deliveryID, err)
+		}
+	}
+	return nil
+}
+
+// deliveryItemsByTipo suma los item dispensers vigentes de la entrega por tipo
+func deliveryItemsByTipo(tx *gorm.DB, deliveryID int) (map[models.TipoDispenser]uint, error) {
+	var items []models.ItemDispenser
+	if err := tx.Where("delivery_id = ?", deliveryID).Find(&items).Error; err != nil {
+		return nil, fmt.Errorf(constants.ErrStockSync, deliveryID, err)
+	}
+	byTipo := make(map[models.TipoDispenser]uint, 2)
+	for _, item := range items {
+		byTipo[item.Tipo] += item.Cantidad
+	}
+	return byTipo, nil
+}
+
+// stockLedger registra los movimientos de stock de una entrega dentro de la transacción
+type stockLedger struct {
+	tx         *gorm.DB
+	deliveryID int
+	actor      string
+	today      time.Time
+}
+
+func (l *stockLedger) record(warehouseID int, tipo models.TipoDispenser, kind models.StockMovementKind, quantity int, reason string) error {
+	deliveryID := l.deliveryID
+	movement := &models.StockMovement{
+		WarehouseID:   warehouseID,
+		Tipo:          tipo,
+		Kind:          kind,
+		Quantity:      quantity,
+		EffectiveDate: l.today,
+		DeliveryID:    &deliveryID,
+		Reason:        reason,
+		CreatedBy:     l.actor,
+	}
+	if err := l.tx.Create(movement).Error; err != nil {
+		return fmt.Errorf(constants.ErrStockSync, l.deliveryID, err)
+	}
+	return nil
+}
+
+func (l *stockLedger) release(reservation *models.StockReservation, reason string) error {
+	if err := l.record(reservation.WarehouseID, reservation.Tipo, models.StockLiberacion, -int(reservation.Cantidad), reason); err != nil {
+		return err
+	}
+	reservation.Status = models.ReservaLiberada
+	if err := l.tx.Save(reservation).Error; err != nil {
+		return fmt.Errorf(constants.ErrStockSync, l.deliveryID, err)
+	}
+	return nil
+}
+
+func (l *stockLedger) releaseAll(reservations []models.StockReservation, reason string) error {
+	for i := range reservations {
+		if reservations[i].Status != models.ReservaActiva {
+			continue
+		}
+		if err := l.release(&reservations[i], reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// consume descuenta de la existencia lo entregado (items menos faltantes) y cierra las reservas.
+// Lo reservado que no se entregó vuelve al disponible: el faltante tiene su propia entrega de
+// seguimiento con su reserva. Una entrega ya consumida no se vuelve a descontar.
+func (l *stockLedger) consume(delivery *models.Delivery, reservations []models.StockReservation) error {
+	var consumed int64
+	if err := l.tx.Model(&models.StockMovement{}).
+		Where("delivery_id = ? AND kind = ?", delivery.ID, models.StockConsumo).
+		Count(&consumed).Error; err != nil {
+		return fmt.Errorf(constants.ErrStockSync, delivery.ID, err)
+	}
+	if consumed > 0 {
+		return l.releaseAll(reservations, "entrega completada")
+	}
+	if !delivery.TipoEntrega.ConsumesStock() {
+		return l.releaseAll(reservations, fmt.Sprintf("entrega de tipo %s", delivery.TipoEntrega))
+	}
+
+	delivered, err := deliveryItemsByTipo(l.tx, delivery.ID)
+	if err != nil {
+		return err
+	}
+	var shortfalls []models.DeliveryShortfall
+	if err := l.tx.Where("delivery_id = ?", delivery.ID).Find(&shortfalls).Error; err != nil {
+		return fmt.Errorf(constants.ErrStockSync, delivery.ID, err)
+	}
+	for _, shortfall := range shortfalls {
+		if delivered[shortfall.Tipo] < shortfall.Missing {
+			delivered[shortfall.Tipo] = 0
+			continue
+		}
+		delivered[shortfall.Tipo] -= shortfall.Missing
+	}
+
+	active := make(map[models.TipoDispenser]*models.StockReservation, len(reservations))
+	for i := range reservations {
+		if reservations[i].Status == models.ReservaActiva {
+			active[reservations[i].Tipo] = &reservations[i]
+		}
+	}
+	var warehouse *models.Warehouse
+	for _, tipo := range []models.TipoDispenser{models.TipoDispenserPie, models.TipoDispenserMesada} {
+		cantidad := delivered[tipo]
+		reservation := active[tipo]
+		warehouseID := 0
+		if reservation != nil {
+			warehouseID = reservation.WarehouseID
+		} else if cantidad > 0 {
+			// Entrega sin reserva (creada antes de tener depósito): igual se descuenta lo instalado
+			if warehouse == nil {
+				if warehouse, err = warehouseForRoute(l.tx, delivery.NroRto); err != nil {
+					return err
+				}
+			}
+			if warehouse == nil {
+				continue
+			}
+			warehouseID = warehouse.ID
+		}
+		if cantidad > 0 {
+			if err := l.record(warehouseID, tipo, models.StockConsumo, -int(cantidad), "entrega completada"); err != nil {
+				return err
+			}
+		}
+		if reservation == nil {
+			continue
+		}
+		if reservation.Cantidad > cantidad {
+			if err := l.record(warehouseID, tipo, models.StockLiberacion, -int(reservation.Cantidad-cantidad), "faltante de la entrega"); err != nil {
+				return err
+			}
+		}
+		reservation.Status = models.ReservaConsumida
+		if err := l.tx.Save(reservation).Error; err != nil {
+			return fmt.Errorf(constants.ErrStockSync, delivery.ID, err)
+		}
+		delete(active, tipo)
+	}
+	for _, reservation := range active {
+		if err := l.release(reservation, "entrega completada"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -12,6 +12,7 @@ import (
 type TermsSessionStore interface {
 	Create(ctx context.Context, session *models.TermsSession) error
 	GetByID(ctx context.Context, id int64) (*models.TermsSession, error)
+	FindStatuses(ctx context.Context, ids []int64) (map[int64]models.TermsSessionStatus, error)
 	FindByToken(ctx context.Context, token string) (*models.TermsSession, error)
 	FindBySessionID(ctx context.Context, sessionID string) (*models.TermsSession, error)
 	FindByConversationID(ctx context.Context, conversationID string) (*models.TermsSession, error)
@@ -47,6 +48,22 @@ func (s *termsSessionStore) GetByID(ctx context.Context, id int64) (*models.Term
 	return &session, nil
 }
 
+// FindStatuses devuelve el estado de las sesiones indicadas, por ID (hoja de ruta del repartidor)
+func (s *termsSessionStore) FindStatuses(ctx context.Context, ids []int64) (map[int64]models.TermsSessionStatus, error) {
+	statuses := make(map[int64]models.TermsSessionStatus, len(ids))
+	if len(ids) == 0 {
+		return statuses, nil
+	}
+	var sessions []models.TermsSession
+	if err := s.db.WithContext(ctx).Select("id", "status").Where("id IN ?", ids).Find(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("error buscando estado de sesiones de términos: %w", err)
+	}
+	for _, session := range sessions {
+		statuses[session.ID] = session.Status
+	}
+	return statuses, nil
+}
+
 func (s *termsSessionStore) FindByToken(ctx context.Context, token string) (*models.TermsSession, error) {
 	var session models.TermsSession
 	if err := s.db.WithContext(ctx).Where("token = ?", token).First(&session).Error; err != nil {
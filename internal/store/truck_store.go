@@ -1,10 +1,14 @@
 package store
 
 import (
+	"GoFrioCalor/internal/constants"
 	"GoFrioCalor/internal/models"
 	"context"
+	"fmt"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TruckStore interface {
@@ -14,6 +18,10 @@ type TruckStore interface {
 	Create(ctx context.Context, truck *models.Truck) error
 	Update(ctx context.Context, truck *models.Truck) error
 	Delete(ctx context.Context, id int) error
+	CountOpenAssigned(ctx context.Context, truckID int) (int64, error)
+	FindLoad(ctx context.Context, truckID int, fecha string) ([]models.Delivery, error)
+	AssignDeliveries(ctx context.Context, truckID int, fecha string, deliveryIDs []int) error
+	UnassignDeliveries(ctx context.Context, truckID int, deliveryIDs []int) (int64, error)
 }
 
 type truckStore struct {
@@ -26,8 +34,8 @@ func NewTruckStore(db *gorm.DB) TruckStore {
 
 func (s *truckStore) FindAll(ctx context.Context) ([]models.Truck, error) {
 	var trucks []models.Truck
-	if err := s.db.WithContext(ctx).Find(&trucks).Error; err != nil {
-		return nil, err
+	if err := s.db.WithContext(ctx).Order("nro_truck ASC").Find(&trucks).Error; err != nil {
+		return nil, fmt.Errorf("error listando camiones: %w", err)
 	}
 	return trucks, nil
 }
@@ -35,36 +43,177 @@ func (s *truckStore) FindAll(ctx context.Context) ([]models.Truck, error) {
 func (s *truckStore) FindByID(ctx context.Context, id int) (*models.Truck, error) {
 	var truck models.Truck
 	if err := s.db.WithContext(ctx).First(&truck, id).Error; err != nil {
-		return nil, err
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf(constants.ErrTruckNotFound)
+		}
+		return nil, fmt.Errorf("error buscando camión %d: %w", id, err)
 	}
 	return &truck, nil
 }
 
+// FindByNroTruck devuelve nil sin error si no hay un camión con ese número
 func (s *truckStore) FindByNroTruck(ctx context.Context, nroTruck string) (*models.Truck, error) {
 	var truck models.Truck
 	if err := s.db.WithContext(ctx).Where("nro_truck = ?", nroTruck).First(&truck).Error; err != nil {
-		return nil, err
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error buscando camión %s: %w", nroTruck, err)
 	}
 	return &truck, nil
 }
 
 func (s *truckStore) Create(ctx context.Context, truck *models.Truck) error {
 	if err := s.db.WithContext(ctx).Create(truck).Error; err != nil {
-		return err
+		return fmt.Errorf("error creando camión: %w", err)
 	}
 	return nil
 }
 
 func (s *truckStore) Update(ctx context.Context, truck *models.Truck) error {
 	if err := s.db.WithContext(ctx).Save(truck).Error; err != nil {
-		return err
+		return fmt.Errorf("error actualizando camión %d: %w", truck.ID, err)
 	}
 	return nil
 }
 
+// Delete elimina el camión y quita su asignación de las entregas ya cerradas que lo referencian
 func (s *truckStore) Delete(ctx context.Context, id int) error {
-	if err := s.db.WithContext(ctx).Delete(&models.Truck{}, id).Error; err != nil {
-		return err
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.Delivery{}).
+			Where("truck_id = ?", id).
+			Update("truck_id", nil).Error; err != nil {
+			return fmt.Errorf("error eliminando camión %d: %w", id, err)
+		}
+		result := tx.Delete(&models.Truck{}, id)
+		if result.Error != nil {
+			return fmt.Errorf("error eliminando camión %d: %w", id, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf(constants.ErrTruckNotFound)
+		}
+		return nil
+	})
+}
+
+// CountOpenAssigned cuenta las entregas abiertas asignadas al camión, de cualquier fecha
+func (s *truckStore) CountOpenAssigned(ctx context.Context, truckID int) (int64, error) {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.Delivery{}).
+		Where("truck_id = ? AND estado IN ?", truckID, models.EstadosAbiertos).
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("error contando entregas del camión %d: %w", truckID, err)
+	}
+	return count, nil
+}
+
+// FindLoad devuelve las entregas asignadas al camión para la fecha, salvo las canceladas,
+// ordenadas por reparto
+func (s *truckStore) FindLoad(ctx context.Context, truckID int, fecha string) ([]models.Delivery, error) {
+	var deliveries []models.Delivery
+	if err := truckLoad(s.db.WithContext(ctx), truckID, fecha).
+		Preload("ItemDispensers").
+		Order("nro_rto ASC, id ASC").
+		Find(&deliveries).Error; err != nil {
+		return nil, fmt.Errorf("error buscando la carga del camión %d para el %s: %w", truckID, fecha, err)
+	}
+	return deliveries, nil
+}
+
+func truckLoad(db *gorm.DB, truckID int, fecha string) *gorm.DB {
+	return db.Model(&models.Delivery{}).
+		Where("truck_id = ? AND (fecha_accion AT TIME ZONE 'UTC')::date = ?::date AND estado <> ?", truckID, fecha, models.Cancelado)
+}
+
+// AssignDeliveries asigna las entregas al camión para la fecha. Bloquea el camión para que dos
+// asignaciones simultáneas no superen juntas la capacidad: solo se asignan entregas abiertas de esa
+// fecha y la suma de dispensers del día, contando las ya asignadas, no puede superar Capacity.
+// Una entrega asignada a otro camión pasa a este.
+func (s *truckStore) AssignDeliveries(ctx context.Context, truckID int, fecha string, deliveryIDs []int) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var truck models.Truck
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&truck, truckID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf(constants.ErrTruckNotFound)
+			}
+			return fmt.Errorf("error buscando camión %d: %w", truckID, err)
+		}
+
+		var deliveries []models.Delivery
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", deliveryIDs).
+			Find(&deliveries).Error; err != nil {
+			return fmt.Errorf("error buscando entregas a asignar: %w", err)
+		}
+		byID := make(map[int]*models.Delivery, len(deliveries))
+		for i := range deliveries {
+			byID[deliveries[i].ID] = &deliveries[i]
+		}
+
+		var requested uint
+		for _, id := range deliveryIDs {
+			delivery, ok := byID[id]
+			if !ok {
+				return fmt.Errorf(constants.ErrTruckAssignNotFound, id)
+			}
+			if err := checkTruckAssignable(delivery, fecha); err != nil {
+				return err
+			}
+			requested += delivery.Cantidad
+		}
+
+		var assigned struct{ Total uint }
+		if err := truckLoad(tx, truck.ID, fecha).
+			Where("id NOT IN ?", deliveryIDs).
+			Select("COALESCE(SUM(cantidad), 0) AS total").
+			Scan(&assigned).Error; err != nil {
+			return fmt.Errorf("error calculando la carga del camión %d: %w", truck.ID, err)
+		}
+		if total := assigned.Total + requested; int(total) > truck.Capacity {
+			return fmt.Errorf(constants.ErrTruckCapacityExceeded, truck.NroTruck, fecha, total, truck.Capacity)
+		}
+
+		if err := tx.Model(&models.Delivery{}).
+			Where("id IN ?", deliveryIDs).
+			Updates(map[string]interface{}{
+				"truck_id":   truck.ID,
+				"version":    gorm.Expr("version + 1"),
+				"updated_at": time.Now(),
+			}).Error; err != nil {
+			return fmt.Errorf("error asignando entregas al camión %d: %w", truck.ID, err)
+		}
+		return nil
+	})
+}
+
+// checkTruckAssignable verifica que la entrega esté abierta y sea de la fecha de la carga
+func checkTruckAssignable(delivery *models.Delivery, fecha string) error {
+	open := false
+	for _, estado := range models.EstadosAbiertos {
+		if delivery.Estado == estado {
+			open = true
+		}
+	}
+	if !open {
+		return fmt.Errorf(constants.ErrTruckAssignState, delivery.ID, delivery.Estado)
+	}
+	if deliveryFecha := delivery.FechaAccion.UTC().Format("2006-01-02"); deliveryFecha != fecha {
+		return fmt.Errorf(constants.ErrTruckAssignDate, delivery.ID, deliveryFecha, fecha)
 	}
 	return nil
 }
+
+// UnassignDeliveries quita del camión las entregas indicadas; devuelve cuántas estaban asignadas a él
+func (s *truckStore) UnassignDeliveries(ctx context.Context, truckID int, deliveryIDs []int) (int64, error) {
+	result := s.db.WithContext(ctx).Model(&models.Delivery{}).
+		Where("truck_id = ? AND id IN ?", truckID, deliveryIDs).
+		Updates(map[string]interface{}{
+			"truck_id":   nil,
+			"version":    gorm.Expr("version + 1"),
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("error quitando entregas del camión %d: %w", truckID, result.Error)
+	}
+	return result.RowsAffected, nil
+}
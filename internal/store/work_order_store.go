@@ -13,6 +13,7 @@ type WorkOrderStore interface {
 	Create(ctx context.Context, workOrder *models.WorkOrder) error
 	GetNextOrderNumber(ctx context.Context) (string, error)
 	FindByDeliveryID(ctx context.Context, deliveryID int) (*models.WorkOrder, error)
+	FindByDeliveryIDs(ctx context.Context, deliveryIDs []int) ([]models.WorkOrder, error)
 }
 
 type workOrderStore struct {
@@ -51,3 +52,14 @@ func (s *workOrderStore) FindByDeliveryID(ctx context.Context, deliveryID int) (
 	}
 	return &workOrder, nil
 }
+
+func (s *workOrderStore) FindByDeliveryIDs(ctx context.Context, deliveryIDs []int) ([]models.WorkOrder, error) {
+	var workOrders []models.WorkOrder
+	if len(deliveryIDs) == 0 {
+		return workOrders, nil
+	}
+	if err := s.db.WithContext(ctx).Where("delivery_id IN ?", deliveryIDs).Find(&workOrders).Error; err != nil {
+		return nil, fmt.Errorf("error buscando órdenes de trabajo por entrega: %w", err)
+	}
+	return workOrders, nil
+}
@@ -5,11 +5,17 @@ import (
 	"GoFrioCalor/internal/dto"
 	"GoFrioCalor/internal/models"
 	"GoFrioCalor/internal/service"
+	"GoFrioCalor/internal/store"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
 )
 
 type DeliveryHandler struct {
@@ -24,12 +30,22 @@ func NewDeliveryHandler(service service.DeliveryService, auditService *service.A
 	}
 }
 
+// GetAllDeliveries lista entregas con filtros, orden y paginación.
+// Por defecto pagina con page/page_size; si se envía el parámetro cursor (vacío para la
+// primera página) usa paginación por cursor (keyset) y devuelve next_cursor.
 func (h *DeliveryHandler) GetAllDeliveries(c *gin.Context) {
 	ctx := c.Request.Context()
-	nroCta := c.Query("nro_cta")
-	fechaStr := c.Query("fecha_accion")
-	fechaCreacionStr := c.Query("fecha_creacion")
-	estadoStr := c.Query("estado")
+
+	filter, err := parseDeliveryFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sort, err := store.ParseDeliverySort(c.Query("sort"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// Paginación
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
@@ -40,58 +56,48 @@ func (h *DeliveryHandler) GetAllDeliveries(c *gin.Context) {
 	if pageSize < 1 || pageSize > 100 {
 		pageSize = 20
 	}
-	offset := (page - 1) * pageSize
 
-	var estado *models.EstadoEntrega
-	if estadoStr != "" {
-		switch models.EstadoEntrega(estadoStr) {
-		case models.Pendiente, models.Completado, models.Cancelado:
-			e := models.EstadoEntrega(estadoStr)
-			estado = &e
-		default:
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Estado inválido. Valores aceptados: Pendiente, Completado, Cancelado"})
-			return
-		}
-	}
-
-	var deliveries []models.Delivery
-	var total int64
-	var err error
-
-	if nroCta != "" || fechaStr != "" || fechaCreacionStr != "" || estado != nil {
-		var fechaAccion *time.Time
-		if fechaStr != "" {
-			parsed, parseErr := time.Parse("2006-01-02", fechaStr)
-			if parseErr != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha inválida. Formato esperado: YYYY-MM-DD"})
-				return
-			}
-			fechaAccion = &parsed
-		}
-		var fechaCreacion *time.Time
-		if fechaCreacionStr != "" {
-			parsed, parseErr := time.Parse("2006-01-02", fechaCreacionStr)
-			if parseErr != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha de creación inválida. Formato esperado: YYYY-MM-DD"})
+	if cursorParam, keyset := c.GetQuery("cursor"); keyset {
+		var cursor *store.DeliveryCursor
+		if cursorParam != "" {
+			cursor, err = store.DecodeDeliveryCursor(cursorParam, sort)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 				return
 			}
-			fechaCreacion = &parsed
 		}
-		total, err = h.service.CountByFilters(ctx, nroCta, fechaAccion, fechaCreacion, estado)
+
+		// Se pide una fila extra para saber si hay una página siguiente
+		deliveries, err := h.service.FindByFilters(ctx, filter, store.DeliveryPage{Sort: sort, Limit: pageSize + 1, Cursor: cursor})
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		deliveries, err = h.service.FindByFilters(ctx, nroCta, fechaAccion, fechaCreacion, estado, pageSize, offset)
-	} else {
-		total, err = h.service.CountAll(ctx)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+		hasMore := len(deliveries) > pageSize
+		var nextCursor string
+		if hasMore {
+			deliveries = deliveries[:pageSize]
+			nextCursor = store.EncodeDeliveryCursor(&deliveries[len(deliveries)-1], sort)
 		}
-		deliveries, err = h.service.FindAll(ctx, pageSize, offset)
+
+		c.JSON(http.StatusOK, gin.H{
+			"data": dto.ToDeliveryResponseList(deliveries),
+			"pagination": gin.H{
+				"page_size":   pageSize,
+				"sort":        sort.String(),
+				"has_more":    hasMore,
+				"next_cursor": nextCursor,
+			},
+		})
+		return
 	}
 
+	total, err := h.service.CountByFilters(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	deliveries, err := h.service.FindByFilters(ctx, filter, store.DeliveryPage{Sort: sort, Limit: pageSize, Offset: (page - 1) * pageSize})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -109,6 +115,7 @@ func (h *DeliveryHandler) GetAllDeliveries(c *gin.Context) {
 			"page_size":   pageSize,
 			"total":       total,
 			"total_pages": totalPages,
+			"sort":        sort.String(),
 		},
 	})
 }
@@ -129,6 +136,7 @@ func (h *DeliveryHandler) GetDeliveryByID(c *gin.Context) {
 		return
 	}
 	response := dto.ToDeliveryResponse(delivery)
+	setETag(c, delivery.Version)
 	c.JSON(http.StatusOK, response)
 }
 
@@ -156,7 +164,7 @@ func (h *DeliveryHandler) CreateDelivery(c *gin.Context) {
 			ctx,
 			delivery.ID,
 			"api_client",
-			"postman",
+			requestActor(c),
 			&delivery,
 			map[string]interface{}{
 				"nro_cta":      delivery.NroCta,
@@ -180,6 +188,11 @@ func (h *DeliveryHandler) UpdateDelivery(c *gin.Context) {
 		return
 	}
 
+	expectedVersion, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
 	var delivery models.Delivery
 	if err := c.ShouldBindJSON(&delivery); err != nil {
 		if validationErrors := FormatValidationError(err); len(validationErrors) > 0 {
@@ -191,9 +204,11 @@ func (h *DeliveryHandler) UpdateDelivery(c *gin.Context) {
 	}
 
 	delivery.ID = id
+	delivery.Version = expectedVersion
 
-	if err := h.service.Update(ctx, &delivery); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	actor := requestActor(c)
+	if err := h.service.Update(ctx, &delivery, actor); err != nil {
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -203,7 +218,7 @@ func (h *DeliveryHandler) UpdateDelivery(c *gin.Context) {
 			ctx,
 			delivery.ID,
 			"api_client",
-			"postman",
+			actor,
 			nil, // before state opcional
 			&delivery,
 			map[string]interface{}{
@@ -212,7 +227,55 @@ func (h *DeliveryHandler) UpdateDelivery(c *gin.Context) {
 		)
 	}
 
-	c.JSON(http.StatusOK, delivery)
+	setETag(c, delivery.Version)
+	c.JSON(http.StatusOK, dto.ToDeliveryResponse(&delivery))
+}
+
+// PatchDelivery aplica un JSON merge patch (RFC 7396) con los campos editables en el estado actual
+func (h *DeliveryHandler) PatchDelivery(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
+		return
+	}
+
+	expectedVersion, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": err.Error()})
+		return
+	}
+
+	actor := requestActor(c)
+	result, err := h.service.PatchDelivery(ctx, id, patch, expectedVersion, actor)
+	if err != nil {
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if h.auditService != nil && len(result.ChangedFields) > 0 {
+		h.auditService.LogDeliveryUpdated(
+			ctx,
+			id,
+			models.ActorAPIClient,
+			actor,
+			result.Before,
+			result.After,
+			map[string]interface{}{
+				"updated_fields": result.ChangedFields,
+				"content_type":   c.ContentType(),
+			},
+		)
+	}
+
+	setETag(c, result.After.Version)
+	c.JSON(http.StatusOK, dto.ToDeliveryResponse(result.After))
 }
 
 func (h *DeliveryHandler) DeleteDelivery(c *gin.Context) {
@@ -226,7 +289,7 @@ func (h *DeliveryHandler) DeleteDelivery(c *gin.Context) {
 	}
 
 	if err := h.service.Delete(ctx, id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -236,7 +299,7 @@ func (h *DeliveryHandler) DeleteDelivery(c *gin.Context) {
 			ctx,
 			id,
 			"api_client",
-			"postman",
+			requestActor(c),
 			nil,
 			nil,
 			map[string]interface{}{
@@ -248,6 +311,135 @@ func (h *DeliveryHandler) DeleteDelivery(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": constants.MsgDeliveryDeleted})
 }
 
+// ImportDeliveries carga entregas en forma masiva desde un archivo CSV o XLSX (campo multipart "file").
+// Con dry_run=true solo valida y devuelve el reporte por fila; sin dry_run inserta las filas válidas.
+// POST /deliveries/import
+func (h *DeliveryHandler) ImportDeliveries(c *gin.Context) {
+	ctx := c.Request.Context()
+	dryRun, _ := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, constants.MAX_IMPORT_FILE_SIZE)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": "debe adjuntar el archivo en el campo 'file' (máximo 10 MB)"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	report, err := h.service.ImportDeliveries(ctx, fileHeader.Filename, file, dryRun)
+	if err != nil {
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if h.auditService != nil && !dryRun {
+		actor := requestActor(c)
+		for _, created := range report.Created {
+			h.auditService.LogDeliveryCreated(
+				ctx,
+				created.DeliveryID,
+				models.ActorAPIClient,
+				actor,
+				created,
+				map[string]interface{}{
+					"source":   "bulk_import",
+					"filename": fileHeader.Filename,
+					"row":      created.Row,
+				},
+			)
+		}
+	}
+
+	status := http.StatusOK
+	if !dryRun && len(report.Created) > 0 {
+		status = http.StatusCreated
+	}
+	c.JSON(status, report)
+}
+
+// RestoreDelivery revierte el borrado lógico de una entrega y sus item dispensers
+// POST /deliveries/:id/restore
+func (h *DeliveryHandler) RestoreDelivery(c *gin.Context) {
+	ctx := c.Request.Context()
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
+		return
+	}
+
+	delivery, err := h.service.Restore(ctx, id)
+	if err != nil {
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if h.auditService != nil {
+		h.auditService.LogDeliveryUpdated(
+			ctx,
+			id,
+			"api_client",
+			requestActor(c),
+			nil,
+			delivery,
+			map[string]interface{}{
+				"action": "restored",
+			},
+		)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  constants.MsgDeliveryRestored,
+		"delivery": dto.ToDeliveryResponse(delivery),
+	})
+}
+
+// GetDeletedDeliveries lista las entregas eliminadas lógicamente (uso administrativo)
+// GET /deliveries/deleted
+func (h *DeliveryHandler) GetDeletedDeliveries(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	offset := (page - 1) * pageSize
+
+	total, err := h.service.CountDeleted(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	deliveries, err := h.service.FindDeleted(ctx, pageSize, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	totalPages := int(total) / pageSize
+	if int(total)%pageSize != 0 {
+		totalPages++
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": dto.ToDeliveryResponseList(deliveries),
+		"pagination": gin.H{
+			"page":        page,
+			"page_size":   pageSize,
+			"total":       total,
+			"total_pages": totalPages,
+		},
+	})
+}
+
 func (h *DeliveryHandler) CancelDelivery(c *gin.Context) {
 	ctx := c.Request.Context()
 	idParam := c.Param("id")
@@ -258,17 +450,31 @@ func (h *DeliveryHandler) CancelDelivery(c *gin.Context) {
 		return
 	}
 
-	delivery, err := h.service.CancelDelivery(ctx, id)
+	expectedVersion, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	var req dto.CancelDeliveryRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": err.Error()})
+			return
+		}
+	}
+
+	actor := requestActor(c)
+	delivery, err := h.service.CancelDelivery(ctx, id, expectedVersion, actor, req.Reason)
 	if err != nil {
 		if err.Error() == "la entrega ya se encuentra cancelada" {
 			c.JSON(http.StatusConflict, gin.H{"error": constants.MsgDeliveryAlreadyCancelled})
 			return
 		}
-		if err.Error() == "entrega no encontrada" {
+		if err.Error() == constants.ErrDeliveryNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": constants.MsgDeliveryNotFound})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -277,21 +483,393 @@ func (h *DeliveryHandler) CancelDelivery(c *gin.Context) {
 			ctx,
 			id,
 			"api_client",
-			"postman",
+			actor,
 			nil,
 			delivery,
 			map[string]interface{}{
 				"action": "cancelled",
+				"reason": req.Reason,
 			},
 		)
 	}
 
+	setETag(c, delivery.Version)
 	c.JSON(http.StatusOK, gin.H{
 		"message":  constants.MsgDeliveryCancelled,
 		"delivery": dto.ToDeliveryResponse(delivery),
 	})
 }
 
+// ChangeDeliveryStatus aplica una transición de estado validada contra la tabla de reglas
+// PATCH /deliveries/:id/status
+func (h *DeliveryHandler) ChangeDeliveryStatus(c *gin.Context) {
+	ctx := c.Request.Context()
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
+		return
+	}
+
+	expectedVersion, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	var req dto.ChangeDeliveryStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := FormatValidationError(err); len(validationErrors) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": err.Error()})
+		return
+	}
+
+	before, err := h.service.FindByID(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": constants.MsgDeliveryNotFound})
+		return
+	}
+	actor := requestActor(c)
+	delivery, err := h.service.ChangeStatus(ctx, id, req.Estado, expectedVersion, actor, req.Reason)
+	if err != nil {
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if h.auditService != nil {
+		h.auditService.LogDeliveryUpdated(
+			ctx,
+			id,
+			models.ActorAPIClient,
+			actor,
+			before,
+			delivery,
+			map[string]interface{}{
+				"action":      "status_changed",
+				"from_estado": before.Estado,
+				"to_estado":   delivery.Estado,
+				"reason":      req.Reason,
+			},
+		)
+	}
+
+	setETag(c, delivery.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"message":  constants.MsgDeliveryStatusChanged,
+		"delivery": dto.ToDeliveryResponse(delivery),
+	})
+}
+
+// GetDeliveryStatusHistory devuelve el historial de transiciones de estado de una entrega
+// GET /deliveries/:id/status-history
+func (h *DeliveryHandler) GetDeliveryStatusHistory(c *gin.Context) {
+	ctx := c.Request.Context()
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
+		return
+	}
+
+	history, err := h.service.FindStatusHistory(ctx, id)
+	if err != nil {
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"delivery_id": id,
+		"total":       len(history),
+		"history":     history,
+	})
+}
+
+// RescheduleDelivery cambia la fecha_accion de una entrega sin perder conversation_id ni token
+// PATCH /deliveries/:id/reschedule
+func (h *DeliveryHandler) RescheduleDelivery(c *gin.Context) {
+	ctx := c.Request.Context()
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
+		return
+	}
+
+	expectedVersion, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	var req dto.RescheduleDeliveryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := FormatValidationError(err); len(validationErrors) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": err.Error()})
+		return
+	}
+
+	before, err := h.service.FindByID(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": constants.MsgDeliveryNotFound})
+		return
+	}
+
+	actor := requestActor(c)
+	delivery, err := h.service.Reschedule(ctx, id, req, expectedVersion, actor)
+	if err != nil {
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if h.auditService != nil {
+		h.auditService.LogDeliveryUpdated(
+			ctx,
+			id,
+			models.ActorAPIClient,
+			actor,
+			before,
+			delivery,
+			map[string]interface{}{
+				"action":            "rescheduled",
+				"previous_fecha":    before.FechaAccion.Format("2006-01-02"),
+				"new_fecha":         delivery.FechaAccion.Format("2006-01-02"),
+				"reason":            req.Reason,
+				"token_regenerated": delivery.Token != before.Token,
+				"reschedule_count":  delivery.RescheduleCount,
+				"ip_address":        c.ClientIP(),
+			},
+		)
+	}
+
+	setETag(c, delivery.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"message":  constants.MsgDeliveryRescheduled,
+		"delivery": dto.ToDeliveryResponse(delivery),
+	})
+}
+
+// RegenerateDeliveryToken genera un token nuevo para la entrega, levanta el bloqueo por intentos
+// fallidos y reenvía el token al cliente. La respuesta no incluye el token.
+// POST /deliveries/:id/token/regenerate
+func (h *DeliveryHandler) RegenerateDeliveryToken(c *gin.Context) {
+	ctx := c.Request.Context()
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
+		return
+	}
+
+	expectedVersion, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	before, err := h.service.FindByID(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": constants.MsgDeliveryNotFound})
+		return
+	}
+
+	actor := requestActor(c)
+	delivery, notified, err := h.service.RegenerateToken(ctx, id, expectedVersion, actor)
+	if err != nil {
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if h.auditService != nil {
+		h.auditService.LogDeliveryUpdated(
+			ctx,
+			id,
+			models.ActorAPIClient,
+			actor,
+			before,
+			delivery,
+			map[string]interface{}{
+				"action":                   "token_regenerated",
+				"previous_failed_attempts": before.TokenFailedAttempts,
+				"was_locked":               before.TokenLockedAt != nil,
+				"customer_notified":        notified,
+				"ip_address":               c.ClientIP(),
+			},
+		)
+	}
+
+	// El token nuevo solo lo recibe el cliente por email
+	response := dto.ToDeliveryResponse(delivery)
+	response.Token = ""
+
+	setETag(c, delivery.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"message":           constants.MsgTokenRegenerated,
+		"customer_notified": notified,
+		"delivery":          response,
+	})
+}
+
+// GetDeliveryReschedules devuelve el historial de reprogramaciones de una entrega
+// GET /deliveries/:id/reschedules
+func (h *DeliveryHandler) GetDeliveryReschedules(c *gin.Context) {
+	ctx := c.Request.Context()
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
+		return
+	}
+
+	reschedules, err := h.service.FindReschedules(ctx, id)
+	if err != nil {
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"delivery_id": id,
+		"total":       len(reschedules),
+		"reschedules": reschedules,
+	})
+}
+
+// GetFollowUpDeliveries lista las visitas fallidas que requieren gestión de contact center
+// GET /deliveries/follow-up
+func (h *DeliveryHandler) GetFollowUpDeliveries(c *gin.Context) {
+	deliveries, err := h.service.FindRequiringFollowUp(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":      len(deliveries),
+		"deliveries": dto.ToDeliveryResponseList(deliveries),
+	})
+}
+
+// ExportDeliveries descarga las entregas que cumplen los filtros del listado en CSV o XLSX.
+// Además de nro_cta, estado, fecha_accion y fecha_creacion acepta los rangos
+// fecha_accion_desde/hasta y fecha_creacion_desde/hasta (YYYY-MM-DD, inclusivos).
+// GET /deliveries/export?format=csv|xlsx
+func (h *DeliveryHandler) ExportDeliveries(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", service.ExportFormatCSV))
+	var contentType string
+	switch format {
+	case service.ExportFormatCSV:
+		contentType = "text/csv; charset=utf-8"
+	case service.ExportFormatXLSX:
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Formato inválido. Valores permitidos: csv, xlsx"})
+		return
+	}
+
+	filter, err := parseDeliveryFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("entregas_%s.%s", time.Now().Format("20060102_150405"), format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	// Una vez enviados los headers ya no se puede cambiar el status: los errores solo se registran
+	if err := h.service.ExportDeliveries(c.Request.Context(), filter, format, c.Writer); err != nil {
+		log.Error().Err(err).Str("format", format).Msg("Error exportando entregas")
+	}
+}
+
+// parseDeliveryFilter arma el filtro de entregas a partir de los query params del listado.
+// nro_rto y tipo_entrega aceptan varios valores, repitiendo el parámetro o separados por coma.
+func parseDeliveryFilter(c *gin.Context) (store.DeliveryFilter, error) {
+	filter := store.DeliveryFilter{
+		NroCta:      c.Query("nro_cta"),
+		NroRto:      queryList(c, "nro_rto"),
+		Locality:    strings.TrimSpace(c.Query("locality")),
+		OrderNumber: strings.TrimSpace(c.Query("order_number")),
+		Search:      strings.TrimSpace(c.Query("q")),
+	}
+
+	for _, tipo := range queryList(c, "tipo_entrega") {
+		switch t := models.TipoEntrega(tipo); t {
+		case models.Instalacion, models.Retiro, models.Service, models.Recambio, models.Mixto:
+			filter.TiposEntrega = append(filter.TiposEntrega, t)
+		default:
+			return filter, fmt.Errorf("tipo_entrega inválido: %s", tipo)
+		}
+	}
+
+	if entregadoPorStr := c.Query("entregado_por"); entregadoPorStr != "" {
+		entregadoPor := models.EntregadoPor(entregadoPorStr)
+		if entregadoPor != models.Repartidor && entregadoPor != models.Tecnico {
+			return filter, fmt.Errorf("entregado_por inválido: %s (valores permitidos: Repartidor, Tecnico)", entregadoPorStr)
+		}
+		filter.EntregadoPor = &entregadoPor
+	}
+
+	if hasTermsStr := c.Query("has_terms_session"); hasTermsStr != "" {
+		hasTerms, err := strconv.ParseBool(hasTermsStr)
+		if err != nil {
+			return filter, fmt.Errorf("has_terms_session inválido: use true o false")
+		}
+		filter.HasTermsSession = &hasTerms
+	}
+
+	if flaggedStr := c.Query("geofence_flagged"); flaggedStr != "" {
+		flagged, err := strconv.ParseBool(flaggedStr)
+		if err != nil {
+			return filter, fmt.Errorf("geofence_flagged inválido: use true o false")
+		}
+		filter.GeofenceFlagged = &flagged
+	}
+
+	if estadoStr := c.Query("estado"); estadoStr != "" {
+		estado := models.EstadoEntrega(estadoStr)
+		if !estado.IsValid() {
+			return filter, fmt.Errorf(constants.MsgInvalidEstado)
+		}
+		filter.Estado = &estado
+	}
+
+	dates := []struct {
+		param  string
+		target **time.Time
+	}{
+		{"fecha_accion", &filter.FechaAccion},
+		{"fecha_creacion", &filter.FechaCreacion},
+		{"fecha_accion_desde", &filter.FechaAccionDesde},
+		{"fecha_accion_hasta", &filter.FechaAccionHasta},
+		{"fecha_creacion_desde", &filter.FechaCreacionDesde},
+		{"fecha_creacion_hasta", &filter.FechaCreacionHasta},
+	}
+	for _, d := range dates {
+		value := c.Query(d.param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return filter, fmt.Errorf("%s inválida. Formato esperado: YYYY-MM-DD", d.param)
+		}
+		*d.target = &parsed
+	}
+	return filter, nil
+}
+
+// queryList devuelve los valores de un parámetro que puede repetirse o venir separado por comas
+func queryList(c *gin.Context, key string) []string {
+	var values []string
+	for _, raw := range c.QueryArray(key) {
+		for _, value := range strings.Split(raw, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
 func (h *DeliveryHandler) GetDeliveriesByRto(c *gin.Context) {
 	ctx := c.Request.Context()
 	nroRto := c.Query("nro_rto")
@@ -332,7 +910,7 @@ func (h *DeliveryHandler) GetDeliveriesByNroCta(c *gin.Context) {
 		fechaAccion = &parsed
 	}
 
-	deliveries, err := h.service.FindByFilters(ctx, nroCta, fechaAccion, nil, nil, -1, 0)
+	deliveries, err := h.service.FindByFilters(ctx, store.DeliveryFilter{NroCta: nroCta, FechaAccion: fechaAccion}, store.DeliveryPage{Sort: store.DefaultDeliverySort})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -439,6 +1017,9 @@ func (h *DeliveryHandler) CreateDeliveryFromInfobip(c *gin.Context) {
 
 	delivery, idempotent, err := h.service.CreateFromInfobip(ctx, req)
 	if err != nil {
+		if respondBookingError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   constants.MsgServerError,
 			"message": err.Error(),
@@ -510,6 +1091,9 @@ func (h *DeliveryHandler) CreateDeliveryFromContactCenter(c *gin.Context) {
 
 	delivery, idempotent, err := h.service.CreateFromInfobip(ctx, req)
 	if err != nil {
+		if respondBookingError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   constants.MsgServerError,
 			"message": err.Error(),
@@ -548,6 +1132,98 @@ func (h *DeliveryHandler) CreateDeliveryFromContactCenter(c *gin.Context) {
 	c.JSON(http.StatusCreated, response)
 }
 
+// respondBookingError responde los errores de reserva de una entrega nueva (capacidad del reparto,
+// stock del depósito o cuenta sin equipos). Devuelve false si err no es uno de ellos.
+func respondBookingError(c *gin.Context, err error) bool {
+	// Reparto sin lugar para la fecha: se informa la primera fecha con lugar
+	var capacityErr *service.RouteCapacityError
+	if errors.As(err, &capacityErr) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":           constants.MsgValidationFailed,
+			"message":         err.Error(),
+			"nro_rto":         capacityErr.NroRto,
+			"fecha":           capacityErr.Fecha,
+			"limit":           capacityErr.Limit,
+			"booked":          capacityErr.Booked,
+			"suggested_fecha": capacityErr.SuggestedFecha,
+		})
+		return true
+	}
+	// Sin stock en el depósito del reparto para la fecha: se informa la primera fecha con stock
+	var stockErr *service.StockShortageError
+	if errors.As(err, &stockErr) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":           constants.MsgValidationFailed,
+			"message":         err.Error(),
+			"shortages":       stockErr.Shortages,
+			"suggested_fecha": stockErr.SuggestedFecha,
+		})
+		return true
+	}
+	// Retiro o Service para una cuenta sin equipos instalados
+	if strings.Contains(err.Error(), constants.ErrAccountWithoutEquipmentID) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   constants.MsgValidationFailed,
+			"message": err.Error(),
+		})
+		return true
+	}
+	return false
+}
+
+// GetAvailability devuelve el lugar que queda por día en un reparto según su capacidad configurada
+// GET /api/v1/deliveries/availability
+func (h *DeliveryHandler) GetAvailability(c *gin.Context) {
+	nroRto := strings.TrimSpace(c.Query("nro_rto"))
+	if nroRto == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "nro_rto es requerido"})
+		return
+	}
+	tipo := models.TipoEntrega(c.Query("tipo_entrega"))
+	switch tipo {
+	case "", models.Instalacion, models.Retiro, models.Recambio, models.Service, models.Mixto:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": "tipo_entrega debe ser Instalacion, Retiro, Recambio, Service o Mixto"})
+		return
+	}
+
+	today := time.Now().UTC()
+	from := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha inválida. Formato esperado: YYYY-MM-DD"})
+			return
+		}
+		from = parsed
+	}
+	to := from.AddDate(0, 0, constants.ROUTE_CAPACITY_SUGGEST_DAYS-1)
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha inválida. Formato esperado: YYYY-MM-DD"})
+			return
+		}
+		to = parsed
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": "to no puede ser anterior a from"})
+		return
+	}
+	if to.Sub(from) >= time.Duration(constants.ROUTE_AVAILABILITY_MAX_DAYS)*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": fmt.Sprintf(constants.ErrAvailabilityRange, constants.ROUTE_AVAILABILITY_MAX_DAYS)})
+		return
+	}
+
+	availability, err := h.service.Availability(c.Request.Context(), nroRto, from, to, tipo)
+	if err != nil {
+		log.Error().Err(err).Str("nro_rto", nroRto).Msg("Error fetching route availability")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": constants.MsgInternalServerError})
+		return
+	}
+	c.JSON(http.StatusOK, availability)
+}
+
 // GetPendingByNroCta busca entregas pendientes por número de cuenta (para Infobip)
 func (h *DeliveryHandler) GetPendingByNroCta(c *gin.Context) {
 	nroCta := c.Query("nro_cta")
@@ -0,0 +1,174 @@
+package transport
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/models"
+	"GoFrioCalor/internal/service"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+type DeliveryProofHandler struct {
+	service      service.DeliveryProofService
+	auditService *service.AuditService
+}
+
+func NewDeliveryProofHandler(service service.DeliveryProofService, auditService *service.AuditService) *DeliveryProofHandler {
+	return &DeliveryProofHandler{
+		service:      service,
+		auditService: auditService,
+	}
+}
+
+// UploadProof godoc
+// @Summary Subir prueba de entrega
+// @Description Recibe en multipart la firma del cliente (PNG, campo signature) y fotos de la instalación (JPEG/PNG, campo photos)
+// @Tags Mobile
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "ID de la entrega"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/mobile/deliveries/{id}/proof [post]
+func (h *DeliveryProofHandler) UploadProof(c *gin.Context) {
+	ctx := c.Request.Context()
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, constants.MAX_PROOF_UPLOAD_SIZE)
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": fmt.Sprintf("el body debe ser multipart/form-data de hasta %d MB", constants.MAX_PROOF_UPLOAD_SIZE>>20)})
+		return
+	}
+
+	var signature *service.ProofFile
+	if headers := form.File["signature"]; len(headers) > 0 {
+		file, err := readProofFile(headers[0])
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": err.Error()})
+			return
+		}
+		signature = &file
+	}
+	photos := make([]service.ProofFile, 0, len(form.File["photos"]))
+	for _, header := range form.File["photos"] {
+		file, err := readProofFile(header)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": err.Error()})
+			return
+		}
+		photos = append(photos, file)
+	}
+
+	uploadedBy := mobileActorID(c, string(models.ActorMobileApp))
+	attachments, err := h.service.UploadProof(ctx, id, signature, photos, uploadedBy)
+	if err != nil {
+		log.Warn().Err(err).Int("delivery_id", id).Msg("Proof of delivery rejected")
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if h.auditService != nil {
+		h.auditService.LogDeliveryUpdated(
+			ctx,
+			id,
+			models.ActorMobileApp,
+			mobileActorID(c, c.ClientIP()),
+			nil,
+			attachments,
+			map[string]interface{}{
+				"action":    "proof_uploaded",
+				"signature": signature != nil,
+				"photos":    len(photos),
+			},
+		)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     constants.MsgProofUploaded,
+		"attachments": attachments,
+	})
+}
+
+// ListAttachments godoc
+// @Summary Listar prueba de entrega
+// @Description Devuelve los metadatos de la firma y las fotos de la entrega
+// @Tags Deliveries
+// @Produce json
+// @Param id path int true "ID de la entrega"
+// @Success 200 {array} models.DeliveryAttachment
+// @Failure 404 {object} ErrorResponse
+// @Router /deliveries/{id}/attachments [get]
+func (h *DeliveryProofHandler) ListAttachments(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
+		return
+	}
+
+	attachments, err := h.service.ListAttachments(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, attachments)
+}
+
+// GetAttachment godoc
+// @Summary Descargar archivo de prueba de entrega
+// @Tags Deliveries
+// @Produce image/png,image/jpeg
+// @Param id path int true "ID de la entrega"
+// @Param attachmentId path int true "ID del adjunto"
+// @Success 200 {file} binary
+// @Failure 404 {object} ErrorResponse
+// @Router /deliveries/{id}/attachments/{attachmentId} [get]
+func (h *DeliveryProofHandler) GetAttachment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
+		return
+	}
+	attachmentID, err := strconv.Atoi(c.Param("attachmentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
+		return
+	}
+
+	attachment, data, err := h.service.GetAttachment(c.Request.Context(), id, attachmentID)
+	if err != nil {
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", attachment.FileName))
+	c.Header("Cache-Control", "private, max-age=3600")
+	c.Data(http.StatusOK, attachment.ContentType, data)
+}
+
+// readProofFile lee un archivo del multipart cortando en MAX_PROOF_FILE_SIZE + 1 byte para que
+// el servicio detecte el exceso sin cargar archivos arbitrariamente grandes
+func readProofFile(header *multipart.FileHeader) (service.ProofFile, error) {
+	file, err := header.Open()
+	if err != nil {
+		return service.ProofFile{}, fmt.Errorf("error leyendo archivo %s: %w", header.Filename, err)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, constants.MAX_PROOF_FILE_SIZE+1))
+	if err != nil {
+		return service.ProofFile{}, fmt.Errorf("error leyendo archivo %s: %w", header.Filename, err)
+	}
+	return service.ProofFile{FileName: header.Filename, Data: data}, nil
+}
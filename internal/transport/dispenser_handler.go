@@ -0,0 +1,73 @@
+package transport
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+type DispenserHandler struct {
+	service service.DispenserService
+}
+
+func NewDispenserHandler(service service.DispenserService) *DispenserHandler {
+	return &DispenserHandler{service: service}
+}
+
+// GetDispenser godoc
+// @Summary Ubicación actual de un dispenser
+// @Description Estado y ubicación (cuenta, depósito, camión o taller) del dispenser por número de serie
+// @Tags Dispensers
+// @Produce json
+// @Param serial path string true "Número de serie"
+// @Success 200 {object} models.Dispenser
+// @Failure 404 {object} ErrorResponse
+// @Router /dispensers/{serial} [get]
+func (h *DispenserHandler) GetDispenser(c *gin.Context) {
+	dispenser, err := h.service.FindBySerial(c.Request.Context(), c.Param("serial"))
+	if err != nil {
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, dispenser)
+}
+
+// GetDispenserHistory godoc
+// @Summary Historial de movimientos de un dispenser
+// @Description Instalaciones, retiros, recambios y servicios registrados para el número de serie
+// @Tags Dispensers
+// @Produce json
+// @Param serial path string true "Número de serie"
+// @Success 200 {object} dto.DispenserHistoryResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /dispensers/{serial}/history [get]
+func (h *DispenserHandler) GetDispenserHistory(c *gin.Context) {
+	history, err := h.service.History(c.Request.Context(), c.Param("serial"))
+	if err != nil {
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+// GetAccountEquipment godoc
+// @Summary Equipos instalados en una cuenta
+// @Description Dispensers instalados actualmente con fecha de instalación, último servicio y órdenes de trabajo de origen
+// @Tags Dispensers
+// @Produce json
+// @Param nro_cta path string true "Número de cuenta"
+// @Success 200 {object} dto.AccountEquipmentResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /accounts/{nro_cta}/equipment [get]
+func (h *DispenserHandler) GetAccountEquipment(c *gin.Context) {
+	response, err := h.service.AccountEquipment(c.Request.Context(), c.Param("nro_cta"))
+	if err != nil {
+		log.Error().Err(err).Str("nro_cta", c.Param("nro_cta")).Msg("Error fetching account equipment")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": constants.MsgInternalServerError})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,33 @@
+package transport
+
+import (
+	"GoFrioCalor/internal/constants"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setETag publica la versión de la entrega como ETag para que el cliente la devuelva en If-Match
+func setETag(c *gin.Context, version int) {
+	c.Header("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+// requireIfMatch obtiene la versión esperada del header If-Match ("3", W/"3" o 3).
+// Si falta responde 428 y si no es una versión válida responde 400; en ambos casos devuelve false.
+func requireIfMatch(c *gin.Context) (int, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": constants.MsgIfMatchRequired})
+		return 0, false
+	}
+
+	value := strings.Trim(strings.TrimPrefix(header, "W/"), `"`)
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidIfMatch})
+		return 0, false
+	}
+	return version, true
+}
@@ -0,0 +1,46 @@
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireIfMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name        string
+		header      string
+		wantVersion int
+		wantOK      bool
+		wantStatus  int
+	}{
+		{name: "ETag fuerte", header: `"3"`, wantVersion: 3, wantOK: true},
+		{name: "ETag débil", header: `W/"7"`, wantVersion: 7, wantOK: true},
+		{name: "Sin comillas", header: "12", wantVersion: 12, wantOK: true},
+		{name: "Header ausente", header: "", wantStatus: http.StatusPreconditionRequired},
+		{name: "Comodín no soportado", header: "*", wantStatus: http.StatusBadRequest},
+		{name: "Versión cero", header: `"0"`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPut, "/deliveries/1", nil)
+			if tt.header != "" {
+				c.Request.Header.Set("If-Match", tt.header)
+			}
+
+			version, ok := requireIfMatch(c)
+			if ok != tt.wantOK || version != tt.wantVersion {
+				t.Errorf("requireIfMatch() = (%d, %v), want (%d, %v)", version, ok, tt.wantVersion, tt.wantOK)
+			}
+			if !tt.wantOK && w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package transport
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/dto"
+	"GoFrioCalor/internal/service"
+	"GoFrioCalor/internal/store"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+type MaintenanceHandler struct {
+	service service.MaintenanceService
+}
+
+func NewMaintenanceHandler(service service.MaintenanceService) *MaintenanceHandler {
+	return &MaintenanceHandler{service: service}
+}
+
+// ListMaintenancePlans godoc
+// @Summary Listar planes de mantenimiento preventivo
+// @Tags Maintenance
+// @Produce json
+// @Param nro_cta query string false "Número de cuenta"
+// @Param active query bool false "Solo planes activos"
+// @Success 200 {array} models.MaintenancePlan
+// @Failure 400 {object} ErrorResponse
+// @Router /maintenance-plans [get]
+func (h *MaintenanceHandler) ListMaintenancePlans(c *gin.Context) {
+	var filter store.MaintenancePlanFilter
+	if nroCta := c.Query("nro_cta"); nroCta != "" {
+		filter.NroCta = &nroCta
+	}
+	if active := c.Query("active"); active != "" {
+		activeOnly, err := strconv.ParseBool(active)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": "active debe ser true o false"})
+			return
+		}
+		filter.ActiveOnly = activeOnly
+	}
+
+	plans, err := h.service.List(c.Request.Context(), filter)
+	if err != nil {
+		log.Error().Err(err).Msg("Error listing maintenance plans")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": constants.MsgInternalServerError})
+		return
+	}
+	c.JSON(http.StatusOK, plans)
+}
+
+// GetMaintenancePlan godoc
+// @Summary Obtener plan de mantenimiento preventivo
+// @Tags Maintenance
+// @Produce json
+// @Param id path int true "ID del plan"
+// @Success 200 {object} models.MaintenancePlan
+// @Failure 404 {object} ErrorResponse
+// @Router /maintenance-plans/{id} [get]
+func (h *MaintenanceHandler) GetMaintenancePlan(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
+		return
+	}
+	plan, err := h.service.FindByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+// CreateMaintenancePlan godoc
+// @Summary Alta de plan de mantenimiento preventivo
+// @Description Sin serial el plan cubre todos los dispensers instalados en la cuenta; con serial, solo ese dispenser
+// @Tags Maintenance
+// @Accept json
+// @Produce json
+// @Param request body dto.MaintenancePlanRequest true "Cuenta, dispenser opcional e intervalo en días"
+// @Success 201 {object} models.MaintenancePlan
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /maintenance-plans [post]
+func (h *MaintenanceHandler) CreateMaintenancePlan(c *gin.Context) {
+	var req dto.MaintenancePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := FormatValidationError(err); len(validationErrors) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": err.Error()})
+		return
+	}
+	plan, err := h.service.Create(c.Request.Context(), req)
+	if err != nil {
+		log.Warn().Err(err).Str("nro_cta", req.NroCta).Msg("Error creating maintenance plan")
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, plan)
+}
+
+// UpdateMaintenancePlan godoc
+// @Summary Modificar plan de mantenimiento preventivo
+// @Tags Maintenance
+// @Accept json
+// @Produce json
+// @Param id path int true "ID del plan"
+// @Param request body dto.MaintenancePlanRequest true "Cuenta, dispenser opcional e intervalo en días"
+// @Success 200 {object} models.MaintenancePlan
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /maintenance-plans/{id} [put]
+func (h *MaintenanceHandler) UpdateMaintenancePlan(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
+		return
+	}
+	var req dto.MaintenancePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := FormatValidationError(err); len(validationErrors) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": err.Error()})
+		return
+	}
+	plan, err := h.service.Update(c.Request.Context(), id, req)
+	if err != nil {
+		log.Warn().Err(err).Int("plan_id", id).Msg("Error updating maintenance plan")
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+// DeleteMaintenancePlan godoc
+// @Summary Eliminar plan de mantenimiento preventivo
+// @Description Las entregas de Service ya generadas no se modifican
+// @Tags Maintenance
+// @Produce json
+// @Param id path int true "ID del plan"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} ErrorResponse
+// @Router /maintenance-plans/{id} [delete]
+func (h *MaintenanceHandler) DeleteMaintenancePlan(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
+		return
+	}
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": constants.MsgMaintenancePlanDeleted})
+}
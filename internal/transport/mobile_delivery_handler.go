@@ -1,10 +1,15 @@
 package transport
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"GoFrioCalor/internal/constants"
 	"GoFrioCalor/internal/dto"
+	"GoFrioCalor/internal/middleware"
+	"GoFrioCalor/internal/models"
 	"GoFrioCalor/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -50,6 +55,10 @@ func (h *MobileDeliveryHandler) ValidateToken(c *gin.Context) {
 	response, err := h.service.ValidateToken(c.Request.Context(), req)
 	if err != nil {
 		log.Error().Err(err).Msg("Error validating token")
+		if status := GetHTTPStatusFromError(err); status != http.StatusInternalServerError {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al validar el token"})
 		return
 	}
@@ -96,9 +105,16 @@ func (h *MobileDeliveryHandler) CompleteDelivery(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": err.Error()})
 		return
 	}
+	req.CompletedBy = currentStaffID(c)
+	req.StaffLegajo = currentStaffLegajo(c)
 
 	response, err := h.service.CompleteDelivery(c.Request.Context(), req)
 	if err != nil {
+		var serialErr *service.DispenserValidationError
+		if errors.As(err, &serialErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "operation_errors": serialErr.Errors})
+			return
+		}
 		log.Error().Err(err).Msg("Error completing delivery")
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -110,12 +126,28 @@ func (h *MobileDeliveryHandler) CompleteDelivery(c *gin.Context) {
 			"operations":        req.Operations,
 			"operations_count":  len(req.Operations),
 			"work_order_queued": response.WorkOrderQueued,
+			"geofence_status":   response.GeofenceStatus,
+			"geofence_flagged":  response.GeofenceFlagged,
+		}
+		if response.DistanceMeters != nil {
+			metadata["distance_meters"] = *response.DistanceMeters
+		}
+		if len(response.Shortfalls) > 0 {
+			metadata["shortfalls"] = response.Shortfalls
+			metadata["follow_up_delivery"] = response.FollowUpDeliveryID
+		}
+		if len(response.SerialWarnings) > 0 {
+			metadata["serial_override"] = req.OverrideReason
+			metadata["serial_warnings"] = response.SerialWarnings
+		}
+		if len(response.PreparationWarnings) > 0 {
+			metadata["preparation_warnings"] = response.PreparationWarnings
 		}
 		h.auditService.LogDeliveryUpdated(
 			c.Request.Context(),
 			response.DeliveryID,
-			"mobile_app",
-			req.Token,
+			models.ActorMobileApp,
+			mobileActorID(c, req.Token),
 			nil, // before state (opcional)
 			response,
 			metadata,
@@ -127,13 +159,13 @@ func (h *MobileDeliveryHandler) CompleteDelivery(c *gin.Context) {
 
 // SearchDeliveries godoc
 // @Summary Buscar deliveries por fecha y reparto
-// @Description Búsqueda de deliveries con filtros de fecha (obligatorio) y nro_rto (opcional)
+// @Description Búsqueda de deliveries con filtros de fecha (obligatorio) y nro_rto (opcional). Devuelve las paradas con el formato de la hoja de ruta, sin el token del cliente
 // @Tags Mobile
 // @Accept json
 // @Produce json
 // @Param fecha_accion query string true "Fecha de acción (YYYY-MM-DD)"
 // @Param nro_rto query string false "Número de reparto"
-// @Success 200 {array} dto.MobileDeliverySearchResponse
+// @Success 200 {array} dto.MobileRouteStop
 // @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/mobile/deliveries/search [get]
@@ -155,3 +187,217 @@ func (h *MobileDeliveryHandler) SearchDeliveries(c *gin.Context) {
 
 	c.JSON(http.StatusOK, results)
 }
+
+// RouteManifest godoc
+// @Summary Hoja de ruta del repartidor
+// @Description Paradas de un reparto para un día con cliente, dirección, dispensers P/M, tipo de entrega, estado de términos y estado de la entrega. No incluye el token del cliente
+// @Tags Mobile
+// @Produce json
+// @Param nro_rto query string true "Número de reparto"
+// @Param fecha_accion query string true "Fecha de acción (YYYY-MM-DD)"
+// @Success 200 {object} dto.MobileRouteManifestResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/mobile/route-manifest [get]
+func (h *MobileDeliveryHandler) RouteManifest(c *gin.Context) {
+	nroRto := c.Query("nro_rto")
+	fechaAccion := c.Query("fecha_accion")
+	if nroRto == "" || fechaAccion == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Los parámetros nro_rto y fecha_accion son obligatorios"})
+		return
+	}
+
+	manifest, err := h.service.RouteManifest(c.Request.Context(), nroRto, fechaAccion)
+	if err != nil {
+		log.Error().Err(err).Str("nro_rto", nroRto).Msg("Error building route manifest")
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "fecha inválida") {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, manifest)
+}
+
+// FailDelivery godoc
+// @Summary Registrar visita fallida
+// @Description Registra una visita fallida con un motivo del catálogo. Según la política del motivo se crea una entrega de seguimiento o se deriva a contact center
+// @Tags Mobile
+// @Accept json
+// @Produce json
+// @Param id path int true "ID de la entrega"
+// @Param request body dto.MobileFailDeliveryRequest true "Motivo de la visita fallida"
+// @Success 200 {object} dto.MobileFailDeliveryResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/mobile/deliveries/{id}/fail [post]
+func (h *MobileDeliveryHandler) FailDelivery(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
+		return
+	}
+
+	var req dto.MobileFailDeliveryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid fail delivery request")
+		if validationErrors := FormatValidationError(err); len(validationErrors) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": err.Error()})
+		return
+	}
+
+	req.StaffLegajo = currentStaffLegajo(c)
+
+	response, err := h.service.FailDelivery(c.Request.Context(), id, req)
+	if err != nil {
+		log.Error().Err(err).Int("delivery_id", id).Msg("Error recording failed visit")
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	// Auditar visita fallida
+	if h.auditService != nil {
+		metadata := map[string]interface{}{
+			"reason_code":             response.ReasonCode,
+			"notes":                   req.Notes,
+			"policy":                  response.Policy,
+			"follow_up_delivery":      response.FollowUpDeliveryID,
+			"requires_contact_center": response.RequiresContactCenter,
+		}
+		h.auditService.LogDeliveryUpdated(
+			c.Request.Context(),
+			response.DeliveryID,
+			models.ActorMobileApp,
+			mobileActorID(c, ""),
+			nil,
+			response,
+			metadata,
+		)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Sync godoc
+// @Summary Sincronizar acciones offline
+// @Description Aplica en orden un lote de acciones encoladas sin señal (validaciones, entregas y visitas fallidas). Cada acción se identifica con un UUID del dispositivo y se procesa una sola vez
+// @Tags Mobile
+// @Accept json
+// @Produce json
+// @Param request body dto.MobileSyncRequest true "Lote de acciones"
+// @Success 200 {object} dto.MobileSyncResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/mobile/sync [post]
+func (h *MobileDeliveryHandler) Sync(c *gin.Context) {
+	var req dto.MobileSyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid mobile sync request")
+		if validationErrors := FormatValidationError(err); len(validationErrors) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": err.Error()})
+		return
+	}
+	req.StaffID = currentStaffID(c)
+	req.StaffLegajo = currentStaffLegajo(c)
+
+	response, err := h.service.Sync(c.Request.Context(), req)
+	if err != nil {
+		log.Error().Err(err).Msg("Error processing mobile sync")
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "el lote supera el máximo") {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Auditar las acciones aplicadas en este lote (los duplicados ya se auditaron al aplicarse)
+	if h.auditService != nil {
+		for _, result := range response.Results {
+			if result.Status != string(models.SyncApplied) || result.DeliveryID == 0 {
+				continue
+			}
+			h.auditService.LogDeliveryUpdated(
+				c.Request.Context(),
+				result.DeliveryID,
+				models.ActorMobileApp,
+				mobileActorID(c, req.DeviceID),
+				nil,
+				result.Result,
+				map[string]interface{}{
+					"source":           "mobile_sync",
+					"action":           result.Type,
+					"client_action_id": result.ClientActionID,
+				},
+			)
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ListFailureReasons godoc
+// @Summary Listar motivos de visita fallida
+// @Description Devuelve el catálogo de motivos activos para registrar visitas fallidas
+// @Tags Mobile
+// @Produce json
+// @Success 200 {array} models.FailureReason
+// @Failure 500 {object} ErrorResponse
+// @Router /api/mobile/failure-reasons [get]
+func (h *MobileDeliveryHandler) ListFailureReasons(c *gin.Context) {
+	reasons, err := h.service.ListFailureReasons(c.Request.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("Error listing failure reasons")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, reasons)
+}
+
+// currentStaffID devuelve el ID del repartidor o técnico logueado en el dispositivo, si lo hay
+func currentStaffID(c *gin.Context) *int {
+	if staff := middleware.CurrentStaff(c); staff != nil {
+		return &staff.ID
+	}
+	return nil
+}
+
+// currentStaffLegajo devuelve el legajo del repartidor o técnico logueado en el dispositivo, o vacío
+func currentStaffLegajo(c *gin.Context) string {
+	if staff := middleware.CurrentStaff(c); staff != nil {
+		return staff.Legajo
+	}
+	return ""
+}
+
+// requestActor identifica a quien hace una solicitud autenticada: el legajo del personal
+// identificado por StaffIdentity o, si no, el que informó el servicio de autenticación. Sin
+// ninguno se usa el cliente de API genérico.
+func requestActor(c *gin.Context) string {
+	if staff := middleware.CurrentStaff(c); staff != nil {
+		return staff.Legajo
+	}
+	if legajo := c.GetString(middleware.ContextKeyAuthLegajo); legajo != "" {
+		return legajo
+	}
+	return string(models.ActorAPIClient)
+}
+
+// mobileActorID identifica al actor en la auditoría por el legajo del personal logueado; sin
+// sesión de personal se usa el identificador alternativo (token de la entrega o dispositivo)
+func mobileActorID(c *gin.Context, fallback string) string {
+	if staff := middleware.CurrentStaff(c); staff != nil {
+		return staff.Legajo
+	}
+	return fallback
+}
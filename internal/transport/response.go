@@ -28,9 +28,58 @@ func GetHTTPStatusFromError(err error) int {
 	// Errores 404 - Not Found
 	if strings.Contains(errMsg, constants.ErrTermsSessionNotFound) ||
 		strings.Contains(errMsg, "sesión de términos no encontrada") ||
-		strings.Contains(errMsg, "sesión no encontrada") {
+		strings.Contains(errMsg, "sesión no encontrada") ||
+		strings.Contains(errMsg, constants.ErrDeliveryNotFound) ||
+		strings.Contains(errMsg, constants.ErrAttachmentNotFound) ||
+		strings.Contains(errMsg, constants.ErrStaffNotFound) ||
+		strings.Contains(errMsg, constants.ErrDispenserNotFound) ||
+		strings.Contains(errMsg, constants.ErrMaintenancePlanNotFound) ||
+		strings.Contains(errMsg, constants.ErrWarehouseNotFound) ||
+		strings.Contains(errMsg, constants.ErrTruckNotFound) ||
+		strings.Contains(errMsg, constants.ErrRouteCapacityNotFound) {
 		return http.StatusNotFound
 	}
+	// Errores 401 - Unauthorized (login de personal rechazado)
+	if strings.Contains(errMsg, constants.ErrStaffLoginFailed) ||
+		strings.Contains(errMsg, constants.ErrStaffSessionInvalid) {
+		return http.StatusUnauthorized
+	}
+	// Errores 409 - Conflict (transición de estado no permitida, restauración de entrega activa,
+	// campo no editable en el estado actual o en PUT, prueba de entrega sobre una entrega cerrada, legajo duplicado,
+	// plan de mantenimiento duplicado, dispenser no disponible para preparar en taller, depósito
+	// duplicado o reparto ya asignado, entrega sin stock en el depósito, camión duplicado, con entregas
+	// abiertas o sin capacidad, entrega que no se puede asignar al camión, regla de capacidad duplicada
+	// o reparto sin lugar para la fecha)
+	if strings.Contains(errMsg, constants.ErrInvalidStatusTransitionID) ||
+		strings.Contains(errMsg, constants.ErrDeliveryNotDeleted) ||
+		strings.Contains(errMsg, "no se puede regenerar el token") ||
+		strings.Contains(errMsg, "campo no editable en estado") ||
+		strings.Contains(errMsg, constants.ErrUpdateBookingFieldsID) ||
+		strings.Contains(errMsg, "no se puede adjuntar prueba de entrega") ||
+		strings.Contains(errMsg, "ya existe personal con el legajo") ||
+		strings.Contains(errMsg, "ya existe un plan de mantenimiento") ||
+		strings.Contains(errMsg, "no se puede preparar") ||
+		strings.Contains(errMsg, "ya está preparado para la entrega") ||
+		strings.Contains(errMsg, "ya existe un depósito con el código") ||
+		strings.Contains(errMsg, "ya es abastecido por el depósito") ||
+		strings.Contains(errMsg, constants.ErrStockInsufficientID) ||
+		strings.Contains(errMsg, "ya existe un camión con el número") ||
+		strings.Contains(errMsg, "entregas abiertas asignadas") ||
+		strings.Contains(errMsg, "y su capacidad es") ||
+		strings.Contains(errMsg, "no se puede asignar la entrega") ||
+		strings.Contains(errMsg, "ya existe una capacidad para el reparto") ||
+		strings.Contains(errMsg, constants.ErrRouteCapacityFullID) {
+		return http.StatusConflict
+	}
+	// Errores 412 - Precondition Failed (If-Match con una versión desactualizada)
+	if strings.Contains(errMsg, constants.ErrDeliveryVersionConflict) {
+		return http.StatusPreconditionFailed
+	}
+	// Errores 423 - Locked (token o PIN bloqueado por exceso de intentos fallidos)
+	if strings.Contains(errMsg, constants.ErrTokenLocked) ||
+		strings.Contains(errMsg, constants.ErrStaffPinLocked) {
+		return http.StatusLocked
+	}
 	// Errores 410 - Gone (recurso expirado)
 	if strings.Contains(errMsg, constants.MsgSessionExpired) ||
 		strings.Contains(errMsg, "el token ha expirado") {
@@ -40,6 +89,38 @@ func GetHTTPStatusFromError(err error) int {
 	if strings.Contains(errMsg, constants.ErrTermsNotAcceptedPending) ||
 		strings.Contains(errMsg, constants.ErrTermsNotAcceptedRejected) ||
 		strings.Contains(errMsg, constants.ErrTermsSessionExpired) ||
+		strings.Contains(errMsg, constants.ErrRescheduleDateInPast) ||
+		strings.Contains(errMsg, constants.ErrRescheduleSameDate) ||
+		strings.Contains(errMsg, "formato de fecha inválido") ||
+		strings.Contains(errMsg, "motivo de visita fallida desconocido") ||
+		strings.Contains(errMsg, constants.ErrImportUnsupportedFormat) ||
+		strings.Contains(errMsg, constants.ErrImportEmptyFile) ||
+		strings.Contains(errMsg, "faltan columnas obligatorias") ||
+		strings.Contains(errMsg, "el archivo supera el máximo") ||
+		strings.Contains(errMsg, "error leyendo archivo") ||
+		strings.Contains(errMsg, "failed_at inválido") ||
+		strings.Contains(errMsg, "completed_at inválido") ||
+		strings.Contains(errMsg, "validated_at inválido") ||
+		strings.Contains(errMsg, constants.ErrPatchInvalidBody) ||
+		strings.Contains(errMsg, constants.ErrPatchEmpty) ||
+		strings.Contains(errMsg, "campo desconocido en el merge patch") ||
+		strings.Contains(errMsg, "valor inválido para") ||
+		strings.Contains(errMsg, constants.ErrProofEmpty) ||
+		strings.Contains(errMsg, constants.ErrProofSignatureFormat) ||
+		strings.Contains(errMsg, "formato de foto inválido") ||
+		strings.Contains(errMsg, "supera el máximo de") ||
+		strings.Contains(errMsg, "la entrega admite como máximo") ||
+		strings.Contains(errMsg, constants.ErrDeviceLocationIncomplete) ||
+		strings.Contains(errMsg, "la ubicación del dispositivo está a") ||
+		strings.Contains(errMsg, constants.ErrGeofenceMissingLocation) ||
+		strings.Contains(errMsg, "no figura instalado en la cuenta") ||
+		strings.Contains(errMsg, "no tiene un formato válido") ||
+		strings.Contains(errMsg, "números de serie pero la entrega tiene") ||
+		strings.Contains(errMsg, "está repetido") ||
+		strings.Contains(errMsg, "deja negativa la existencia") ||
+		strings.Contains(errMsg, constants.ErrStockAdjustFutureDate) ||
+		strings.Contains(errMsg, constants.ErrStockReceiptQuantity) ||
+		strings.Contains(errMsg, "effective_date inválida") ||
 		strings.Contains(errMsg, "el token") {
 		return http.StatusBadRequest
 	}
@@ -0,0 +1,127 @@
+package transport
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/dto"
+	"GoFrioCalor/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+type RouteCapacityHandler struct {
+	service service.RouteCapacityService
+}
+
+func NewRouteCapacityHandler(service service.RouteCapacityService) *RouteCapacityHandler {
+	return &RouteCapacityHandler{service: service}
+}
+
+// ListRouteCapacities godoc
+// @Summary Listar capacidades de reparto
+// @Tags Capacidad de repartos
+// @Produce json
+// @Param nro_rto query string false "Número de reparto"
+// @Success 200 {array} models.RouteCapacity
+// @Router /route-capacities [get]
+func (h *RouteCapacityHandler) ListRouteCapacities(c *gin.Context) {
+	capacities, err := h.service.FindAll(c.Request.Context(), c.Query("nro_rto"))
+	if err != nil {
+		log.Error().Err(err).Msg("Error listing route capacities")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": constants.MsgInternalServerError})
+		return
+	}
+	c.JSON(http.StatusOK, capacities)
+}
+
+// CreateRouteCapacity godoc
+// @Summary Crear capacidad de reparto
+// @Description Máximo de entregas por día de la semana para un reparto, del total o de un tipo de entrega
+// @Tags Capacidad de repartos
+// @Accept json
+// @Produce json
+// @Param request body dto.RouteCapacityRequest true "Regla de capacidad"
+// @Success 201 {object} models.RouteCapacity
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /route-capacities [post]
+func (h *RouteCapacityHandler) CreateRouteCapacity(c *gin.Context) {
+	var req dto.RouteCapacityRequest
+	if !bindRouteCapacityRequest(c, &req) {
+		return
+	}
+	capacity, err := h.service.Create(c.Request.Context(), req)
+	if err != nil {
+		log.Warn().Err(err).Str("nro_rto", req.NroRto).Msg("Error creating route capacity")
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, capacity)
+}
+
+// UpdateRouteCapacity godoc
+// @Summary Modificar capacidad de reparto
+// @Tags Capacidad de repartos
+// @Accept json
+// @Produce json
+// @Param id path int true "ID de la regla"
+// @Param request body dto.RouteCapacityRequest true "Regla de capacidad"
+// @Success 200 {object} models.RouteCapacity
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /route-capacities/{id} [put]
+func (h *RouteCapacityHandler) UpdateRouteCapacity(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
+		return
+	}
+	var req dto.RouteCapacityRequest
+	if !bindRouteCapacityRequest(c, &req) {
+		return
+	}
+	capacity, err := h.service.Update(c.Request.Context(), id, req)
+	if err != nil {
+		log.Warn().Err(err).Int("route_capacity_id", id).Msg("Error updating route capacity")
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, capacity)
+}
+
+// DeleteRouteCapacity godoc
+// @Summary Eliminar capacidad de reparto
+// @Description Sin reglas para el día el reparto no tiene límite
+// @Tags Capacidad de repartos
+// @Param id path int true "ID de la regla"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /route-capacities/{id} [delete]
+func (h *RouteCapacityHandler) DeleteRouteCapacity(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
+		return
+	}
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		log.Warn().Err(err).Int("route_capacity_id", id).Msg("Error deleting route capacity")
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func bindRouteCapacityRequest(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		if validationErrors := FormatValidationError(err); len(validationErrors) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": validationErrors})
+			return false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": err.Error()})
+		return false
+	}
+	return true
+}
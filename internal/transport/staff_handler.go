@@ -0,0 +1,228 @@
+package transport
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/dto"
+	"GoFrioCalor/internal/middleware"
+	"GoFrioCalor/internal/models"
+	"GoFrioCalor/internal/service"
+	"GoFrioCalor/internal/store"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+type StaffHandler struct {
+	service service.StaffService
+}
+
+func NewStaffHandler(service service.StaffService) *StaffHandler {
+	return &StaffHandler{service: service}
+}
+
+// ListStaff godoc
+// @Summary Listar repartidores y técnicos
+// @Tags Staff
+// @Produce json
+// @Param role query string false "Repartidor o Tecnico"
+// @Param nro_rto query string false "Reparto asignado"
+// @Param active query bool false "Solo personal activo"
+// @Success 200 {array} models.Staff
+// @Failure 400 {object} ErrorResponse
+// @Router /staff [get]
+func (h *StaffHandler) ListStaff(c *gin.Context) {
+	var filter store.StaffFilter
+	if role := c.Query("role"); role != "" {
+		entregadoPor := models.EntregadoPor(role)
+		if entregadoPor != models.Repartidor && entregadoPor != models.Tecnico {
+			c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": "role debe ser Repartidor o Tecnico"})
+			return
+		}
+		filter.Role = &entregadoPor
+	}
+	if nroRto := c.Query("nro_rto"); nroRto != "" {
+		filter.NroRto = &nroRto
+	}
+	if active := c.Query("active"); active != "" {
+		activeOnly, err := strconv.ParseBool(active)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": "active debe ser true o false"})
+			return
+		}
+		filter.ActiveOnly = activeOnly
+	}
+
+	staff, err := h.service.List(c.Request.Context(), filter)
+	if err != nil {
+		log.Error().Err(err).Msg("Error listing staff")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": constants.MsgInternalServerError})
+		return
+	}
+	c.JSON(http.StatusOK, staff)
+}
+
+// GetStaff godoc
+// @Summary Obtener repartidor o técnico
+// @Tags Staff
+// @Produce json
+// @Param id path int true "ID del personal"
+// @Success 200 {object} models.Staff
+// @Failure 404 {object} ErrorResponse
+// @Router /staff/{id} [get]
+func (h *StaffHandler) GetStaff(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
+		return
+	}
+	staff, err := h.service.FindByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, staff)
+}
+
+// CreateStaff godoc
+// @Summary Alta de repartidor o técnico
+// @Description El PIN (4 a 8 dígitos) es el que se usa para iniciar sesión en el dispositivo
+// @Tags Staff
+// @Accept json
+// @Produce json
+// @Param request body dto.StaffRequest true "Datos del personal"
+// @Success 201 {object} models.Staff
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /staff [post]
+func (h *StaffHandler) CreateStaff(c *gin.Context) {
+	var req dto.StaffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := FormatValidationError(err); len(validationErrors) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": err.Error()})
+		return
+	}
+	staff, err := h.service.Create(c.Request.Context(), req)
+	if err != nil {
+		log.Warn().Err(err).Str("legajo", req.Legajo).Msg("Error creating staff")
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, staff)
+}
+
+// UpdateStaff godoc
+// @Summary Modificar repartidor o técnico
+// @Description Si se omite el PIN se conserva el anterior
+// @Tags Staff
+// @Accept json
+// @Produce json
+// @Param id path int true "ID del personal"
+// @Param request body dto.StaffRequest true "Datos del personal"
+// @Success 200 {object} models.Staff
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /staff/{id} [put]
+func (h *StaffHandler) UpdateStaff(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
+		return
+	}
+	var req dto.StaffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := FormatValidationError(err); len(validationErrors) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": err.Error()})
+		return
+	}
+	staff, err := h.service.Update(c.Request.Context(), id, req)
+	if err != nil {
+		log.Warn().Err(err).Int("staff_id", id).Msg("Error updating staff")
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, staff)
+}
+
+// GetStaffDeliveries godoc
+// @Summary Historial diario de entregas de un repartidor o técnico
+// @Description Entregas completadas por la persona en el día indicado (por defecto hoy)
+// @Tags Staff
+// @Produce json
+// @Param id path int true "ID del personal"
+// @Param fecha query string false "Fecha (YYYY-MM-DD)"
+// @Success 200 {object} dto.StaffDeliveriesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /staff/{id}/deliveries [get]
+func (h *StaffHandler) GetStaffDeliveries(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
+		return
+	}
+	fecha := c.DefaultQuery("fecha", time.Now().Format("2006-01-02"))
+
+	response, err := h.service.DailyDeliveries(c.Request.Context(), id, fecha)
+	if err != nil {
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// Login godoc
+// @Summary Iniciar sesión de personal en el dispositivo
+// @Description El repartidor o técnico se identifica con legajo y PIN al iniciar el turno. El session_token devuelto se envía en el header X-Staff-Session de las llamadas móviles
+// @Tags Mobile
+// @Accept json
+// @Produce json
+// @Param request body dto.StaffLoginRequest true "Legajo, PIN y dispositivo"
+// @Success 200 {object} dto.StaffLoginResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /api/mobile/login [post]
+func (h *StaffHandler) Login(c *gin.Context) {
+	var req dto.StaffLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := FormatValidationError(err); len(validationErrors) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": err.Error()})
+		return
+	}
+	response, err := h.service.Login(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// Logout godoc
+// @Summary Cerrar sesión de personal en el dispositivo
+// @Tags Mobile
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /api/mobile/logout [post]
+func (h *StaffHandler) Logout(c *gin.Context) {
+	if sessionToken := strings.TrimSpace(c.GetHeader(middleware.StaffSessionHeader)); sessionToken != "" {
+		if err := h.service.Logout(c.Request.Context(), sessionToken); err != nil {
+			log.Error().Err(err).Msg("Error closing staff session")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": constants.MsgInternalServerError})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": constants.MsgStaffLoggedOut})
+}
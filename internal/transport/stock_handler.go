@@ -0,0 +1,252 @@
+package transport
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/dto"
+	"GoFrioCalor/internal/models"
+	"GoFrioCalor/internal/service"
+	"GoFrioCalor/internal/store"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+type StockHandler struct {
+	service service.StockService
+}
+
+func NewStockHandler(service service.StockService) *StockHandler {
+	return &StockHandler{service: service}
+}
+
+// ListWarehouses godoc
+// @Summary Listar depósitos
+// @Tags Stock
+// @Produce json
+// @Success 200 {array} models.Warehouse
+// @Router /warehouses [get]
+func (h *StockHandler) ListWarehouses(c *gin.Context) {
+	warehouses, err := h.service.ListWarehouses(c.Request.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("Error listing warehouses")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": constants.MsgInternalServerError})
+		return
+	}
+	c.JSON(http.StatusOK, warehouses)
+}
+
+// CreateWarehouse godoc
+// @Summary Crear depósito
+// @Description Da de alta un depósito con los repartos que abastece. Un reparto solo puede pertenecer a un depósito
+// @Tags Stock
+// @Accept json
+// @Produce json
+// @Param request body dto.WarehouseRequest true "Depósito"
+// @Success 201 {object} models.Warehouse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /warehouses [post]
+func (h *StockHandler) CreateWarehouse(c *gin.Context) {
+	var req dto.WarehouseRequest
+	if !bindStockRequest(c, &req) {
+		return
+	}
+	warehouse, err := h.service.CreateWarehouse(c.Request.Context(), req)
+	if err != nil {
+		log.Warn().Err(err).Str("code", req.Code).Msg("Error creating warehouse")
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, warehouse)
+}
+
+// UpdateWarehouse godoc
+// @Summary Modificar depósito
+// @Tags Stock
+// @Accept json
+// @Produce json
+// @Param id path int true "ID del depósito"
+// @Param request body dto.WarehouseRequest true "Depósito"
+// @Success 200 {object} models.Warehouse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /warehouses/{id} [put]
+func (h *StockHandler) UpdateWarehouse(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
+		return
+	}
+	var req dto.WarehouseRequest
+	if !bindStockRequest(c, &req) {
+		return
+	}
+	warehouse, err := h.service.UpdateWarehouse(c.Request.Context(), id, req)
+	if err != nil {
+		log.Warn().Err(err).Int("warehouse_id", id).Msg("Error updating warehouse")
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, warehouse)
+}
+
+// GetStock godoc
+// @Summary Stock de un depósito
+// @Description Existencia, ingresos programados, reservado y disponible por tipo de dispenser (P y M)
+// @Tags Stock
+// @Produce json
+// @Param id path int true "ID del depósito"
+// @Success 200 {object} dto.WarehouseStockResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /warehouses/{id}/stock [get]
+func (h *StockHandler) GetStock(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
+		return
+	}
+	stock, err := h.service.Stock(c.Request.Context(), id)
+	if err != nil {
+		log.Warn().Err(err).Int("warehouse_id", id).Msg("Error fetching warehouse stock")
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, stock)
+}
+
+// RecordStockMovement godoc
+// @Summary Registrar ingreso o ajuste de stock
+// @Description Ingreso de equipos (con effective_date futura para una recepción programada) o ajuste de inventario
+// @Tags Stock
+// @Accept json
+// @Produce json
+// @Param id path int true "ID del depósito"
+// @Param request body dto.StockMovementRequest true "Movimiento"
+// @Success 201 {object} models.StockMovement
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /warehouses/{id}/movements [post]
+func (h *StockHandler) RecordStockMovement(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
+		return
+	}
+	var req dto.StockMovementRequest
+	if !bindStockRequest(c, &req) {
+		return
+	}
+	movement, err := h.service.RecordMovement(c.Request.Context(), id, req, mobileActorID(c, string(models.ActorAPIClient)))
+	if err != nil {
+		log.Warn().Err(err).Int("warehouse_id", id).Msg("Error recording stock movement")
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, movement)
+}
+
+// GetStockLedger godoc
+// @Summary Libro de stock de un depósito
+// @Description Movimientos del depósito, del más reciente al más antiguo: ingresos, ajustes, reservas, liberaciones y consumos
+// @Tags Stock
+// @Produce json
+// @Param id path int true "ID del depósito"
+// @Param tipo query string false "Tipo de dispenser (P o M)"
+// @Param kind query string false "Ingreso, Ajuste, Reserva, Liberacion o Consumo"
+// @Param delivery_id query int false "ID de la entrega"
+// @Param from query string false "Desde (YYYY-MM-DD)"
+// @Param to query string false "Hasta, inclusive (YYYY-MM-DD)"
+// @Param page query int false "Página" default(1)
+// @Param page_size query int false "Tamaño de página" default(50)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /warehouses/{id}/movements [get]
+func (h *StockHandler) GetStockLedger(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
+		return
+	}
+	filter := store.StockMovementFilter{WarehouseID: id}
+	if tipo := c.Query("tipo"); tipo != "" {
+		if tipo != string(models.TipoDispenserPie) && tipo != string(models.TipoDispenserMesada) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": "tipo debe ser P o M"})
+			return
+		}
+		value := models.TipoDispenser(tipo)
+		filter.Tipo = &value
+	}
+	if kind := c.Query("kind"); kind != "" {
+		value := models.StockMovementKind(kind)
+		filter.Kind = &value
+	}
+	if deliveryID := c.Query("delivery_id"); deliveryID != "" {
+		value, err := strconv.Atoi(deliveryID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": "delivery_id inválido"})
+			return
+		}
+		filter.DeliveryID = &value
+	}
+	for param, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		fecha, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha inválida. Formato esperado: YYYY-MM-DD"})
+			return
+		}
+		if param == "to" {
+			fecha = fecha.AddDate(0, 0, 1)
+		}
+		*target = &fecha
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 200 {
+		pageSize = 50
+	}
+
+	movements, total, err := h.service.Ledger(c.Request.Context(), filter, pageSize, (page-1)*pageSize)
+	if err != nil {
+		log.Warn().Err(err).Int("warehouse_id", id).Msg("Error fetching stock ledger")
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+	totalPages := int(total) / pageSize
+	if int(total)%pageSize != 0 {
+		totalPages++
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": movements,
+		"pagination": gin.H{
+			"page":        page,
+			"page_size":   pageSize,
+			"total":       total,
+			"total_pages": totalPages,
+		},
+	})
+}
+
+func bindStockRequest(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		if validationErrors := FormatValidationError(err); len(validationErrors) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": validationErrors})
+			return false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": err.Error()})
+		return false
+	}
+	return true
+}
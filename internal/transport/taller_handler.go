@@ -0,0 +1,93 @@
+package transport
+
+import (
+	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/dto"
+	"GoFrioCalor/internal/middleware"
+	"GoFrioCalor/internal/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+type TallerHandler struct {
+	service service.TallerService
+}
+
+func NewTallerHandler(service service.TallerService) *TallerHandler {
+	return &TallerHandler{service: service}
+}
+
+// PrepareDelivery godoc
+// @Summary Registrar la preparación en taller de una entrega
+// @Description Asigna los números de serie apartados para la entrega y marca el avance (Preparado o Cargado). Sin serials se conservan los ya asignados
+// @Tags Taller
+// @Accept json
+// @Produce json
+// @Param id path int true "ID de la entrega"
+// @Param request body dto.PrepareDeliveryRequest true "Números de serie y estado"
+// @Success 200 {object} models.DeliveryPreparation
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /taller/deliveries/{id}/prepare [patch]
+func (h *TallerHandler) PrepareDelivery(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
+		return
+	}
+	var req dto.PrepareDeliveryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := FormatValidationError(err); len(validationErrors) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": err.Error()})
+		return
+	}
+
+	var staffID *int
+	if staff := middleware.CurrentStaff(c); staff != nil {
+		staffID = &staff.ID
+	}
+	preparation, err := h.service.Prepare(c.Request.Context(), id, req, staffID)
+	if err != nil {
+		log.Warn().Err(err).Int("delivery_id", id).Msg("Error preparing delivery")
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, preparation)
+}
+
+// GetBoard godoc
+// @Summary Tablero de preparación del taller
+// @Description Entregas abiertas de la fecha agrupadas por reparto, con los números de serie asignados y el avance (Pendiente, Preparado, Cargado)
+// @Tags Taller
+// @Produce json
+// @Param fecha_accion query string true "Fecha (YYYY-MM-DD)"
+// @Success 200 {object} dto.TallerBoardResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /taller/board [get]
+func (h *TallerHandler) GetBoard(c *gin.Context) {
+	fecha := c.Query("fecha_accion")
+	if fecha == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parámetro 'fecha_accion' requerido. Formato: YYYY-MM-DD"})
+		return
+	}
+	if _, err := time.Parse("2006-01-02", fecha); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha inválida. Formato esperado: YYYY-MM-DD"})
+		return
+	}
+
+	board, err := h.service.Board(c.Request.Context(), fecha)
+	if err != nil {
+		log.Error().Err(err).Str("fecha_accion", fecha).Msg("Error fetching workshop board")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": constants.MsgInternalServerError})
+		return
+	}
+	c.JSON(http.StatusOK, board)
+}
@@ -2,12 +2,15 @@ package transport
 
 import (
 	"GoFrioCalor/internal/constants"
+	"GoFrioCalor/internal/dto"
 	"GoFrioCalor/internal/models"
 	"GoFrioCalor/internal/service"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
 )
 
 type TruckHandler struct {
@@ -18,80 +21,229 @@ func NewTruckHandler(service service.TruckService) *TruckHandler {
 	return &TruckHandler{service: service}
 }
 
+// GetAllTrucks godoc
+// @Summary Listar camiones
+// @Tags Camiones
+// @Produce json
+// @Success 200 {array} models.Truck
+// @Router /trucks [get]
 func (h *TruckHandler) GetAllTrucks(c *gin.Context) {
-	ctx := c.Request.Context()
-	trucks, err := h.service.FindAll(ctx)
+	trucks, err := h.service.FindAll(c.Request.Context())
 	if err != nil {
+		log.Error().Err(err).Msg("Error listing trucks")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": constants.MsgInternalServerError})
 		return
 	}
 	c.JSON(http.StatusOK, trucks)
 }
 
+// GetTruckByID godoc
+// @Summary Obtener camión
+// @Tags Camiones
+// @Produce json
+// @Param id path int true "ID del camión"
+// @Success 200 {object} models.Truck
+// @Failure 404 {object} ErrorResponse
+// @Router /trucks/{id} [get]
 func (h *TruckHandler) GetTruckByID(c *gin.Context) {
-	ctx := c.Request.Context()
-	idParam := c.Param("id")
-	id, err := strconv.Atoi(idParam)
+	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
 		return
 	}
-	truck, err := h.service.FindByID(ctx, id)
+	truck, err := h.service.FindByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": constants.MsgInternalServerError})
+		log.Warn().Err(err).Int("truck_id", id).Msg("Error fetching truck")
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, truck)
 }
 
+// CreateTruck godoc
+// @Summary Crear camión
+// @Description Da de alta un camión con su capacidad diaria en dispensers
+// @Tags Camiones
+// @Accept json
+// @Produce json
+// @Param request body models.Truck true "Camión"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /trucks [post]
 func (h *TruckHandler) CreateTruck(c *gin.Context) {
-	ctx := c.Request.Context()
 	var truck models.Truck
-	if err := c.ShouldBindJSON(&truck); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"errors": FormatValidationError(err)})
+	if !bindTruckRequest(c, &truck) {
 		return
 	}
-	err := h.service.Create(ctx, &truck)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": constants.MsgInternalServerError})
+	if err := h.service.Create(c.Request.Context(), &truck); err != nil {
+		log.Warn().Err(err).Str("nro_truck", truck.NroTruck).Msg("Error creating truck")
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusCreated, gin.H{"message": "Camión creado exitosamente", "data": truck})
 }
 
+// UpdateTruck godoc
+// @Summary Modificar camión
+// @Tags Camiones
+// @Accept json
+// @Produce json
+// @Param id path int true "ID del camión"
+// @Param request body models.Truck true "Camión"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /trucks/{id} [put]
 func (h *TruckHandler) UpdateTruck(c *gin.Context) {
-	ctx := c.Request.Context()
-	idParam := c.Param("id")
-	id, err := strconv.Atoi(idParam)
+	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
 		return
 	}
 	var truck models.Truck
-	if err := c.ShouldBindJSON(&truck); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"errors": FormatValidationError(err)})
+	if !bindTruckRequest(c, &truck) {
 		return
 	}
 	truck.ID = id
-	err = h.service.Update(ctx, &truck)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": constants.MsgInternalServerError})
+	if err := h.service.Update(c.Request.Context(), &truck); err != nil {
+		log.Warn().Err(err).Int("truck_id", id).Msg("Error updating truck")
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Camión actualizado exitosamente", "data": truck})
 }
 
+// DeleteTruck godoc
+// @Summary Eliminar camión
+// @Description Solo se puede eliminar un camión sin entregas abiertas asignadas
+// @Tags Camiones
+// @Param id path int true "ID del camión"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /trucks/{id} [delete]
 func (h *TruckHandler) DeleteTruck(c *gin.Context) {
-	ctx := c.Request.Context()
-	idParam := c.Param("id")
-	id, err := strconv.Atoi(idParam)
+	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
 		return
 	}
-	if err := h.service.Delete(ctx, id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": constants.MsgInternalServerError})
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		log.Warn().Err(err).Int("truck_id", id).Msg("Error deleting truck")
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
+
+// AssignDeliveries godoc
+// @Summary Asignar entregas a un camión
+// @Description Asigna entregas abiertas de la fecha al camión. Se rechaza si la suma de dispensers del día supera la capacidad del camión
+// @Tags Camiones
+// @Accept json
+// @Produce json
+// @Param id path int true "ID del camión"
+// @Param request body dto.TruckAssignRequest true "Fecha y entregas"
+// @Success 200 {object} dto.TruckLoadSheet
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /trucks/{id}/assign [post]
+func (h *TruckHandler) AssignDeliveries(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
+		return
+	}
+	var req dto.TruckAssignRequest
+	if !bindTruckRequest(c, &req) {
+		return
+	}
+	sheet, err := h.service.Assign(c.Request.Context(), id, req)
+	if err != nil {
+		log.Warn().Err(err).Int("truck_id", id).Str("fecha_accion", req.FechaAccion).Msg("Error assigning deliveries to truck")
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, sheet)
+}
+
+// UnassignDeliveries godoc
+// @Summary Quitar entregas de un camión
+// @Tags Camiones
+// @Accept json
+// @Produce json
+// @Param id path int true "ID del camión"
+// @Param request body dto.TruckUnassignRequest true "Entregas"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /trucks/{id}/unassign [post]
+func (h *TruckHandler) UnassignDeliveries(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
+		return
+	}
+	var req dto.TruckUnassignRequest
+	if !bindTruckRequest(c, &req) {
+		return
+	}
+	removed, err := h.service.Unassign(c.Request.Context(), id, req.DeliveryIDs)
+	if err != nil {
+		log.Warn().Err(err).Int("truck_id", id).Msg("Error unassigning deliveries from truck")
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"removed": removed})
+}
+
+// GetLoadSheet godoc
+// @Summary Hoja de carga de un camión
+// @Description Entregas asignadas al camión para la fecha, dispensers por tipo y lugar libre según su capacidad
+// @Tags Camiones
+// @Produce json
+// @Param id path int true "ID del camión"
+// @Param fecha_accion query string true "Fecha (YYYY-MM-DD)"
+// @Success 200 {object} dto.TruckLoadSheet
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /trucks/{id}/load-sheet [get]
+func (h *TruckHandler) GetLoadSheet(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidID})
+		return
+	}
+	fecha := c.Query("fecha_accion")
+	if fecha == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parámetro 'fecha_accion' requerido. Formato: YYYY-MM-DD"})
+		return
+	}
+	if _, err := time.Parse("2006-01-02", fecha); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha inválida. Formato esperado: YYYY-MM-DD"})
+		return
+	}
+
+	sheet, err := h.service.LoadSheet(c.Request.Context(), id, fecha)
+	if err != nil {
+		log.Warn().Err(err).Int("truck_id", id).Str("fecha_accion", fecha).Msg("Error fetching truck load sheet")
+		c.JSON(GetHTTPStatusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, sheet)
+}
+
+func bindTruckRequest(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		if validationErrors := FormatValidationError(err); len(validationErrors) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": validationErrors})
+			return false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": constants.MsgInvalidInput, "details": err.Error()})
+		return false
+	}
+	return true
+}
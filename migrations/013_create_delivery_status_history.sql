@@ -0,0 +1,18 @@
+-- Migración 013: Ciclo de vida de entregas con historial de estados
+-- Nuevos estados: Programado, EnCamino, Reprogramado, Fallido
+-- Cada transición queda registrada en delivery_status_history
+
+CREATE TABLE IF NOT EXISTS delivery_status_history (
+    id SERIAL PRIMARY KEY,
+    delivery_id INTEGER NOT NULL REFERENCES deliveries(id) ON DELETE CASCADE,
+    from_estado VARCHAR(20) NOT NULL,
+    to_estado VARCHAR(20) NOT NULL,
+    changed_by VARCHAR(100) NOT NULL,
+    reason TEXT,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_delivery_status_history_delivery_id ON delivery_status_history (delivery_id);
+CREATE INDEX IF NOT EXISTS idx_delivery_status_history_changed_at ON delivery_status_history (changed_at);
+
+COMMENT ON TABLE delivery_status_history IS 'Historial de transiciones de estado de cada entrega (quién, cuándo y por qué)';
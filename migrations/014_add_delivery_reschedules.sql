@@ -0,0 +1,19 @@
+-- Migración 014: Reprogramación de entregas
+-- Permite cambiar fecha_accion sin cancelar la entrega (se conserva conversation_id y token)
+
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS reschedule_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS delivery_reschedules (
+    id SERIAL PRIMARY KEY,
+    delivery_id INTEGER NOT NULL REFERENCES deliveries(id) ON DELETE CASCADE,
+    previous_fecha TIMESTAMPTZ NOT NULL,
+    new_fecha TIMESTAMPTZ NOT NULL,
+    reason TEXT,
+    rescheduled_by VARCHAR(100) NOT NULL,
+    token_regenerated BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_delivery_reschedules_delivery_id ON delivery_reschedules (delivery_id);
+
+COMMENT ON TABLE delivery_reschedules IS 'Historial de cambios de fecha_accion de cada entrega';
@@ -0,0 +1,29 @@
+-- Migración 015: Visitas fallidas desde la app móvil
+-- Catálogo configurable de motivos y política por motivo:
+--   FOLLOW_UP      → se crea automáticamente una nueva entrega Pendiente a follow_up_days
+--   CONTACT_CENTER → la entrega queda marcada (requires_follow_up) para que contact center contacte al cliente
+
+CREATE TABLE IF NOT EXISTS failure_reasons (
+    code VARCHAR(50) PRIMARY KEY,
+    description VARCHAR(200) NOT NULL,
+    policy VARCHAR(20) NOT NULL CHECK (policy IN ('FOLLOW_UP', 'CONTACT_CENTER')),
+    follow_up_days INTEGER NOT NULL DEFAULT 1,
+    active BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+INSERT INTO failure_reasons (code, description, policy, follow_up_days) VALUES
+    ('CLIENTE_AUSENTE', 'Cliente ausente', 'FOLLOW_UP', 1),
+    ('SIN_ACCESO', 'Sin acceso al domicilio', 'FOLLOW_UP', 2),
+    ('DIRECCION_INCORRECTA', 'Dirección incorrecta o inexistente', 'CONTACT_CENTER', 0),
+    ('CLIENTE_RECHAZA', 'El cliente rechaza la entrega', 'CONTACT_CENTER', 0),
+    ('OTRO', 'Otro motivo (ver notas)', 'CONTACT_CENTER', 0)
+ON CONFLICT (code) DO NOTHING;
+
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS failure_reason VARCHAR(50);
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS failure_notes TEXT;
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS failed_at TIMESTAMPTZ;
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS requires_follow_up BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS follow_up_of_id INTEGER REFERENCES deliveries(id);
+
+CREATE INDEX IF NOT EXISTS idx_deliveries_requires_follow_up ON deliveries (requires_follow_up) WHERE requires_follow_up = TRUE;
+CREATE INDEX IF NOT EXISTS idx_deliveries_follow_up_of_id ON deliveries (follow_up_of_id);
@@ -0,0 +1,11 @@
+-- Migración 016: Borrado lógico de entregas
+-- DELETE /deliveries/:id marca deleted_at en la entrega y en sus item_dispensers en la misma transacción.
+-- Las entregas eliminadas se consultan con GET /deliveries/deleted y se recuperan con POST /deliveries/:id/restore
+
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE item_dispensers ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_deliveries_deleted_at ON deliveries (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_item_dispensers_deleted_at ON item_dispensers (deleted_at);
+
+COMMENT ON COLUMN deliveries.deleted_at IS 'Fecha de borrado lógico; NULL si la entrega está activa';
@@ -0,0 +1,11 @@
+-- Migración 017: Índices para los filtros y la paginación por cursor de GET /deliveries
+-- La paginación keyset compara (columna_de_orden, id), por eso los índices son compuestos con id
+
+CREATE INDEX IF NOT EXISTS idx_deliveries_fecha_accion_id ON deliveries (fecha_accion, id);
+CREATE INDEX IF NOT EXISTS idx_deliveries_created_at_id ON deliveries (created_at, id);
+CREATE INDEX IF NOT EXISTS idx_deliveries_nro_cta_id ON deliveries (nro_cta, id);
+
+CREATE INDEX IF NOT EXISTS idx_deliveries_nro_rto ON deliveries (nro_rto);
+CREATE INDEX IF NOT EXISTS idx_deliveries_tipo_entrega ON deliveries (tipo_entrega);
+CREATE INDEX IF NOT EXISTS idx_deliveries_locality_lower ON deliveries (LOWER(locality));
+CREATE INDEX IF NOT EXISTS idx_deliveries_order_number ON deliveries (order_number);
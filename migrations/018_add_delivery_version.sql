@@ -0,0 +1,6 @@
+-- Migración 018: Control de concurrencia optimista en entregas
+-- Cada escritura incrementa version; la API la expone como ETag y exige If-Match en PUT/PATCH/cancel
+
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+COMMENT ON COLUMN deliveries.version IS 'Versión de la fila para control de concurrencia optimista (ETag / If-Match)';
@@ -0,0 +1,14 @@
+-- Migración 019: Endurecimiento de tokens de validación de entregas
+-- Cuenta los intentos fallidos de validación y bloquea el token al superar el máximo configurado.
+-- El bloqueo se levanta regenerando el token desde contact center.
+
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS token_failed_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS token_locked_at TIMESTAMPTZ;
+
+-- Búsqueda de colisiones de token dentro de la misma ruta y fecha (solo entregas abiertas)
+CREATE INDEX IF NOT EXISTS idx_deliveries_open_rto_fecha_token
+ON deliveries (nro_rto, fecha_accion, token)
+WHERE estado IN ('Pendiente', 'Programado', 'EnCamino', 'Reprogramado') AND deleted_at IS NULL;
+
+COMMENT ON COLUMN deliveries.token_failed_attempts IS 'Intentos fallidos de validación del token desde la app móvil';
+COMMENT ON COLUMN deliveries.token_locked_at IS 'Momento en que el token se bloqueó por exceso de intentos; NULL si está habilitado';
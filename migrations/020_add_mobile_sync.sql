@@ -0,0 +1,24 @@
+-- Migración 020: Sincronización offline de la app móvil
+-- completed_at guarda el momento real de la entrega informado por el dispositivo (puede ser anterior
+-- a la sincronización). mobile_sync_actions hace idempotente el reenvío de lotes por client_action_id.
+
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS completed_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS mobile_sync_actions (
+    id SERIAL PRIMARY KEY,
+    client_action_id VARCHAR(36) NOT NULL,
+    device_id VARCHAR(100),
+    action_type VARCHAR(30) NOT NULL,
+    delivery_id INTEGER,
+    status VARCHAR(20) NOT NULL,
+    error TEXT,
+    result JSONB,
+    client_timestamp TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_mobile_sync_actions_client_action_id ON mobile_sync_actions (client_action_id);
+CREATE INDEX IF NOT EXISTS idx_mobile_sync_actions_delivery_id ON mobile_sync_actions (delivery_id);
+
+COMMENT ON COLUMN deliveries.completed_at IS 'Momento real de la entrega (hora del dispositivo si se completó offline)';
+COMMENT ON TABLE mobile_sync_actions IS 'Acciones offline procesadas por POST /mobile/sync (idempotencia por client_action_id)';
@@ -0,0 +1,21 @@
+-- Migración 021: Idempotency-Key en endpoints POST/PUT/PATCH
+-- Guarda la respuesta de cada solicitud enviada con el header Idempotency-Key para reproducirla
+-- en los reintentos de la app móvil y del frontend de contact center.
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id SERIAL PRIMARY KEY,
+    key VARCHAR(255) NOT NULL,
+    route VARCHAR(255) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    status_code INTEGER,
+    content_type VARCHAR(100),
+    response_body BYTEA,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_idempotency_keys_key_route ON idempotency_keys (key, route);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
+
+COMMENT ON TABLE idempotency_keys IS 'Respuestas guardadas por Idempotency-Key (clave + ruta + hash del body)';
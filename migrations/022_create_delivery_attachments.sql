@@ -0,0 +1,20 @@
+-- Migración 022: Prueba de entrega (firma del cliente y fotos)
+-- Los archivos se guardan en el blob storage configurado (BLOB_STORAGE_DIR); la tabla guarda
+-- los metadatos y el vínculo con la entrega.
+
+CREATE TABLE IF NOT EXISTS delivery_attachments (
+    id SERIAL PRIMARY KEY,
+    delivery_id INTEGER NOT NULL REFERENCES deliveries(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    storage_key VARCHAR(255) NOT NULL,
+    file_name VARCHAR(255),
+    content_type VARCHAR(50) NOT NULL,
+    size BIGINT NOT NULL,
+    uploaded_by VARCHAR(100),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_delivery_attachments_delivery_id ON delivery_attachments (delivery_id);
+
+COMMENT ON TABLE delivery_attachments IS 'Firma del cliente y fotos de la instalación asociadas a cada entrega';
+COMMENT ON COLUMN delivery_attachments.kind IS 'signature o photo';
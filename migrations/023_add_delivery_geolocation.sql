@@ -0,0 +1,21 @@
+-- Migración 023: Geolocalización de entregas y geocerca
+-- address_latitude/longitude: coordenadas de la dirección del cliente (cargadas por contact center o importación).
+-- validation_* y completion_*: lectura GPS del dispositivo al validar el token y al completar la entrega.
+-- completion_distance: distancia en metros entre la lectura de cierre y la dirección del cliente.
+-- geofence_flagged: la entrega se completó fuera del radio configurado (GEOFENCE_RADIUS_METERS).
+
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS address_latitude DOUBLE PRECISION;
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS address_longitude DOUBLE PRECISION;
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS validation_latitude DOUBLE PRECISION;
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS validation_longitude DOUBLE PRECISION;
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS validation_accuracy DOUBLE PRECISION;
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS completion_latitude DOUBLE PRECISION;
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS completion_longitude DOUBLE PRECISION;
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS completion_accuracy DOUBLE PRECISION;
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS completion_distance DOUBLE PRECISION;
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS geofence_flagged BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_deliveries_geofence_flagged ON deliveries (geofence_flagged) WHERE geofence_flagged;
+
+COMMENT ON COLUMN deliveries.completion_distance IS 'Metros entre la lectura GPS de cierre y la dirección del cliente';
+COMMENT ON COLUMN deliveries.geofence_flagged IS 'Entrega completada fuera del radio de geocerca configurado';
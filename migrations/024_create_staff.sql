@@ -0,0 +1,43 @@
+-- Migración 024: Personal (repartidores y técnicos) y quién completó cada entrega
+-- staff: legajo, rol y reparto asignado. El PIN para iniciar sesión en el dispositivo se guarda
+-- hasheado con bcrypt.
+-- staff_sessions: login del personal en el dispositivo; solo se guarda el SHA-256 del token.
+-- completed_by: personal que cerró la entrega / realizó el trabajo de la orden.
+
+CREATE TABLE IF NOT EXISTS staff (
+    id SERIAL PRIMARY KEY,
+    legajo VARCHAR(20) NOT NULL,
+    name VARCHAR(200) NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    nro_rto VARCHAR(50),
+    pin_hash VARCHAR(100),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_staff_legajo ON staff (legajo);
+CREATE INDEX IF NOT EXISTS idx_staff_nro_rto ON staff (nro_rto);
+
+CREATE TABLE IF NOT EXISTS staff_sessions (
+    id SERIAL PRIMARY KEY,
+    token_hash VARCHAR(64) NOT NULL,
+    staff_id INTEGER NOT NULL REFERENCES staff(id) ON DELETE CASCADE,
+    device_id VARCHAR(100),
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_staff_sessions_token_hash ON staff_sessions (token_hash);
+CREATE INDEX IF NOT EXISTS idx_staff_sessions_staff_id ON staff_sessions (staff_id);
+CREATE INDEX IF NOT EXISTS idx_staff_sessions_expires_at ON staff_sessions (expires_at);
+
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS completed_by INTEGER REFERENCES staff(id);
+ALTER TABLE work_orders ADD COLUMN IF NOT EXISTS completed_by INTEGER REFERENCES staff(id);
+
+CREATE INDEX IF NOT EXISTS idx_deliveries_completed_by ON deliveries (completed_by, completed_at);
+CREATE INDEX IF NOT EXISTS idx_work_orders_completed_by ON work_orders (completed_by);
+
+COMMENT ON TABLE staff IS 'Repartidores y técnicos que operan la app móvil';
+COMMENT ON COLUMN deliveries.completed_by IS 'Personal que completó la entrega (sesión del dispositivo o legajo del token)';
+COMMENT ON COLUMN work_orders.completed_by IS 'Personal que realizó el trabajo';
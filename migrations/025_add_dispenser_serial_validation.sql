@@ -0,0 +1,13 @@
+-- Migración 025: Validación de números de serie al completar entregas
+-- retired_dispensers: códigos de los dispensers retirados (retiros y recambios).
+-- serial_override: motivo informado por el técnico al aceptar códigos que no pasaron la validación.
+-- La ubicación de un dispenser se deduce de la última entrega completada que lo instaló o retiró
+-- (validated_dispensers ya tiene índice GIN desde la migración 009).
+
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS retired_dispensers JSONB;
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS serial_override VARCHAR(500);
+
+CREATE INDEX IF NOT EXISTS idx_deliveries_retired_dispensers ON deliveries USING GIN (retired_dispensers);
+
+COMMENT ON COLUMN deliveries.retired_dispensers IS 'Códigos de los dispensers retirados en la entrega (e.g., ["ABC123"])';
+COMMENT ON COLUMN deliveries.serial_override IS 'Motivo del override de validación de números de serie';
@@ -0,0 +1,20 @@
+-- Migración 026: Entregas parciales
+-- Cuando el repartidor realiza menos operaciones que los dispensers pedidos, el faltante se registra
+-- por tipo y se crea una entrega Pendiente por el resto (follow_up_of_id apunta a la original).
+-- La fecha de la entrega de seguimiento sale de PARTIAL_FOLLOW_UP_DAYS / PARTIAL_FOLLOW_UP_SKIP_WEEKENDS.
+
+CREATE TABLE IF NOT EXISTS delivery_shortfalls (
+    id SERIAL PRIMARY KEY,
+    delivery_id INTEGER NOT NULL REFERENCES deliveries(id),
+    tipo VARCHAR(1) NOT NULL,
+    ordered INTEGER NOT NULL,
+    delivered INTEGER NOT NULL,
+    missing INTEGER NOT NULL,
+    follow_up_delivery_id INTEGER REFERENCES deliveries(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_delivery_shortfalls_delivery_id ON delivery_shortfalls (delivery_id);
+CREATE INDEX IF NOT EXISTS idx_delivery_shortfalls_follow_up_delivery_id ON delivery_shortfalls (follow_up_delivery_id);
+
+COMMENT ON TABLE delivery_shortfalls IS 'Faltante por tipo de dispenser de las entregas completadas parcialmente';
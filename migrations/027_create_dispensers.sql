@@ -0,0 +1,72 @@
+-- Migración 027: Registro de dispensers por número de serie
+-- dispensers: estado y ubicación actual de cada equipo. location_type/location_id:
+--   Cuenta → nro_cta, Deposito → depósito, Camion → reparto, Taller → taller.
+-- dispenser_movements: cada operación procesada al completar una entrega (instalación, retiro,
+-- recambio o servicio) registra un movimiento con la ubicación de origen y destino.
+
+CREATE TABLE IF NOT EXISTS dispensers (
+    id SERIAL PRIMARY KEY,
+    serial VARCHAR(30) NOT NULL,
+    tipo VARCHAR(1),
+    model VARCHAR(100),
+    status VARCHAR(20) NOT NULL,
+    location_type VARCHAR(20) NOT NULL,
+    location_id VARCHAR(50) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_dispensers_serial ON dispensers (serial);
+CREATE INDEX IF NOT EXISTS idx_dispensers_status ON dispensers (status);
+CREATE INDEX IF NOT EXISTS idx_dispensers_location ON dispensers (location_type, location_id);
+
+CREATE TABLE IF NOT EXISTS dispenser_movements (
+    id SERIAL PRIMARY KEY,
+    dispenser_id INTEGER NOT NULL REFERENCES dispensers(id),
+    serial VARCHAR(30) NOT NULL,
+    operation VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    from_location_type VARCHAR(20),
+    from_location_id VARCHAR(50),
+    to_location_type VARCHAR(20) NOT NULL,
+    to_location_id VARCHAR(50) NOT NULL,
+    delivery_id INTEGER REFERENCES deliveries(id),
+    staff_id INTEGER,
+    occurred_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_dispenser_movements_dispenser_id ON dispenser_movements (dispenser_id);
+CREATE INDEX IF NOT EXISTS idx_dispenser_movements_serial ON dispenser_movements (serial);
+CREATE INDEX IF NOT EXISTS idx_dispenser_movements_delivery_id ON dispenser_movements (delivery_id);
+
+-- Carga inicial a partir de las entregas completadas: cada código instalado o retirado es un
+-- movimiento y la ubicación actual es la del último
+DROP TABLE IF EXISTS dispenser_events;
+CREATE TEMP TABLE dispenser_events AS
+SELECT d.id AS delivery_id, d.nro_cta, d.nro_rto, d.completed_by, COALESCE(d.completed_at, d.updated_at) AS occurred_at,
+       s.serial, 'installation' AS operation, 'Instalado' AS status, 'Cuenta' AS location_type, d.nro_cta AS location_id
+FROM deliveries d, jsonb_array_elements_text(d.validated_dispensers) AS s(serial)
+WHERE d.estado = 'Completado' AND d.deleted_at IS NULL AND jsonb_typeof(d.validated_dispensers) = 'array'
+UNION ALL
+SELECT d.id, d.nro_cta, d.nro_rto, d.completed_by, COALESCE(d.completed_at, d.updated_at),
+       s.serial, 'retirement', 'Retirado', 'Camion', d.nro_rto
+FROM deliveries d, jsonb_array_elements_text(d.retired_dispensers) AS s(serial)
+WHERE d.estado = 'Completado' AND d.deleted_at IS NULL AND jsonb_typeof(d.retired_dispensers) = 'array';
+
+INSERT INTO dispensers (serial, status, location_type, location_id)
+SELECT DISTINCT ON (serial) serial, status, location_type, location_id
+FROM dispenser_events
+ORDER BY serial, occurred_at DESC, delivery_id DESC
+ON CONFLICT (serial) DO NOTHING;
+
+INSERT INTO dispenser_movements (dispenser_id, serial, operation, status, to_location_type, to_location_id, delivery_id, staff_id, occurred_at)
+SELECT ds.id, e.serial, e.operation, e.status, e.location_type, e.location_id, e.delivery_id, e.completed_by, e.occurred_at
+FROM dispenser_events e
+JOIN dispensers ds ON ds.serial = e.serial
+WHERE NOT EXISTS (SELECT 1 FROM dispenser_movements m WHERE m.dispenser_id = ds.id);
+
+DROP TABLE dispenser_events;
+
+COMMENT ON TABLE dispensers IS 'Registro de dispensers por número de serie con su ubicación actual';
+COMMENT ON TABLE dispenser_movements IS 'Historial de movimientos de cada dispenser';
@@ -0,0 +1,22 @@
+-- Migración 028: Mantenimiento preventivo
+-- Plan de service periódico (sanitización) por cuenta o por dispenser instalado. serial vacío = toda
+-- la cuenta. El scheduler diario crea una entrega Pendiente de tipo Service MAINTENANCE_LEAD_DAYS
+-- antes del vencimiento (último Service/Instalacion completado + interval_days).
+
+CREATE TABLE IF NOT EXISTS maintenance_plans (
+    id SERIAL PRIMARY KEY,
+    nro_cta VARCHAR(50) NOT NULL,
+    serial VARCHAR(100) NOT NULL DEFAULT '',
+    interval_days INTEGER NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    last_service_at TIMESTAMPTZ,
+    next_due_at TIMESTAMPTZ,
+    last_delivery_id INTEGER REFERENCES deliveries(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_maintenance_plan_target ON maintenance_plans (nro_cta, serial);
+
+COMMENT ON TABLE maintenance_plans IS 'Planes de mantenimiento preventivo por cuenta o dispenser';
+COMMENT ON COLUMN maintenance_plans.serial IS 'Vacío: el plan cubre todos los dispensers instalados en la cuenta';
@@ -0,0 +1,22 @@
+-- Migración 029: Preparación en taller
+-- Por entrega: números de serie apartados por el taller para instalar y avance de la preparación
+-- (Preparado / Cargado). Sin fila la entrega está Pendiente de preparar. Al completar la entrega la
+-- app advierte si se instaló un dispenser distinto de los preparados.
+
+CREATE TABLE IF NOT EXISTS delivery_preparations (
+    id SERIAL PRIMARY KEY,
+    delivery_id INTEGER NOT NULL REFERENCES deliveries(id),
+    status VARCHAR(20) NOT NULL,
+    serials JSONB,
+    notes VARCHAR(500),
+    prepared_by INTEGER REFERENCES staff(id),
+    prepared_at TIMESTAMPTZ,
+    loaded_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_delivery_preparations_delivery_id ON delivery_preparations (delivery_id);
+CREATE INDEX IF NOT EXISTS idx_delivery_preparations_serials ON delivery_preparations USING GIN (serials);
+
+COMMENT ON TABLE delivery_preparations IS 'Preparación en taller de las entregas: números de serie apartados y carga';
@@ -0,0 +1,56 @@
+-- Migración 030: Stock por depósito
+-- Depósitos con los repartos que abastecen, libro de movimientos de stock por tipo de dispenser
+-- (P / M) y reservas por entrega. Una entrega abierta de Instalacion o Recambio reserva sus
+-- dispensers en el depósito del reparto; al cancelarse, fallar o eliminarse la reserva se libera y
+-- al completarse se consume lo entregado. La existencia es la suma de Ingreso, Ajuste y Consumo
+-- con effective_date hasta el día (los ingresos pueden programarse con fecha futura).
+
+CREATE TABLE IF NOT EXISTS warehouses (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(20) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    routes JSONB,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_warehouses_code ON warehouses (code);
+CREATE INDEX IF NOT EXISTS idx_warehouses_routes ON warehouses USING GIN (routes);
+
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id SERIAL PRIMARY KEY,
+    warehouse_id INTEGER NOT NULL REFERENCES warehouses(id),
+    tipo VARCHAR(1) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    quantity INTEGER NOT NULL,
+    effective_date DATE NOT NULL,
+    delivery_id INTEGER REFERENCES deliveries(id),
+    reason VARCHAR(255),
+    created_by VARCHAR(100),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_warehouse_id ON stock_movements (warehouse_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_delivery_id ON stock_movements (delivery_id);
+
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id SERIAL PRIMARY KEY,
+    delivery_id INTEGER NOT NULL REFERENCES deliveries(id),
+    tipo VARCHAR(1) NOT NULL,
+    warehouse_id INTEGER NOT NULL REFERENCES warehouses(id),
+    cantidad INTEGER NOT NULL,
+    fecha_accion DATE NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_reservation_delivery_tipo ON stock_reservations (delivery_id, tipo);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_warehouse_id ON stock_reservations (warehouse_id);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_status ON stock_reservations (status);
+
+COMMENT ON TABLE warehouses IS 'Depósitos de dispensers y repartos que abastece cada uno';
+COMMENT ON TABLE stock_movements IS 'Libro de stock por depósito: ingresos, ajustes, reservas, liberaciones y consumos';
+COMMENT ON TABLE stock_reservations IS 'Dispensers reservados en un depósito para una entrega abierta';
@@ -0,0 +1,19 @@
+-- Migración 031: Camiones y asignación de entregas
+-- Cada camión tiene una capacidad diaria en dispensers. Las entregas abiertas se asignan a un camión
+-- para su fecha_accion (POST /trucks/:id/assign); la suma de dispensers del día no puede superar la
+-- capacidad. Reprogramar o cambiar la fecha de una entrega quita la asignación.
+
+CREATE TABLE IF NOT EXISTS trucks (
+    id SERIAL PRIMARY KEY,
+    nro_truck VARCHAR(20) NOT NULL,
+    capacity INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_trucks_nro_truck ON trucks (nro_truck);
+
+ALTER TABLE deliveries
+ADD COLUMN IF NOT EXISTS truck_id INTEGER REFERENCES trucks(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_deliveries_truck_id ON deliveries (truck_id);
@@ -0,0 +1,18 @@
+-- Migración 032: Capacidad diaria por reparto
+-- Máximo de entregas por reparto y día de la semana (0 = domingo ... 6 = sábado). Con tipo_entrega
+-- vacío el límite es para el total del día; con un tipo, solo para las entregas de ese tipo. Ocupan
+-- lugar las entregas abiertas y las completadas. Infobip y contact center rechazan una entrega sin
+-- lugar, y el mantenimiento preventivo busca un día con lugar para el Service.
+
+CREATE TABLE IF NOT EXISTS route_capacities (
+    id SERIAL PRIMARY KEY,
+    nro_rto VARCHAR(50) NOT NULL,
+    weekday INTEGER NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+    tipo_entrega VARCHAR(20) NOT NULL DEFAULT '',
+    max_deliveries INTEGER NOT NULL CHECK (max_deliveries >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_route_capacity_rule ON route_capacities (nro_rto, weekday, tipo_entrega);
+CREATE INDEX IF NOT EXISTS idx_deliveries_nro_rto_fecha_accion ON deliveries (nro_rto, ((fecha_accion AT TIME ZONE 'UTC')::date));
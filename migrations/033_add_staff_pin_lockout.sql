@@ -0,0 +1,7 @@
+-- Migración 033: Bloqueo del PIN de personal
+-- Cada PIN incorrecto en POST /mobile/login suma un intento fallido; al llegar a
+-- STAFF_PIN_MAX_ATTEMPTS el login queda bloqueado hasta que se cargue un PIN nuevo.
+
+ALTER TABLE staff
+ADD COLUMN IF NOT EXISTS pin_failed_attempts INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS pin_locked_at TIMESTAMPTZ;
@@ -0,0 +1,14 @@
+-- Migración 034: client_action_id único por dispositivo o personal
+-- Cada acción offline se registra como pendiente antes de aplicarse, y el UUID generado en el
+-- dispositivo deja de ser único en toda la tabla: lo es dentro de scope ('device:<device_id>' o,
+-- sin device_id, 'staff:<id>').
+
+ALTER TABLE mobile_sync_actions ADD COLUMN IF NOT EXISTS scope VARCHAR(120) NOT NULL DEFAULT '';
+
+UPDATE mobile_sync_actions SET scope = 'device:' || device_id WHERE scope = '' AND COALESCE(device_id, '') <> '';
+
+DROP INDEX IF EXISTS idx_mobile_sync_actions_client_action_id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_mobile_sync_actions_scope_action ON mobile_sync_actions (scope, client_action_id);
+
+COMMENT ON COLUMN mobile_sync_actions.scope IS 'Ámbito del client_action_id: device:<device_id> o staff:<id>';
+COMMENT ON TABLE mobile_sync_actions IS 'Acciones offline procesadas por POST /mobile/sync (idempotencia por scope y client_action_id)';